@@ -48,6 +48,7 @@ func main() {
 		appDeps.db,
 		appDeps.redisClient,
 		appDeps.asynqClient,
+		appDeps.asynqInspector,
 		logger,
 	)
 	if err != nil {
@@ -15,7 +15,9 @@ type AppDeps struct {
 	db          *sqlx.DB
 	redisClient *redis.Client
 	asynqClient *asynq.Client
-	logger      *slog.Logger
+	// asynqInspector backs the admin job queue endpoints
+	asynqInspector *asynq.Inspector
+	logger         *slog.Logger
 }
 
 func InitDeps(logger *slog.Logger) (*AppDeps, error) {
@@ -37,11 +39,16 @@ func InitDeps(logger *slog.Logger) (*AppDeps, error) {
 		Addr: cfg.RedisAddr,
 	})
 
+	asynqInspector := asynq.NewInspector(asynq.RedisClientOpt{
+		Addr: cfg.RedisAddr,
+	})
+
 	return &AppDeps{
-		cfg:         cfg,
-		db:          database,
-		redisClient: redisClient,
-		asynqClient: asynqClient,
-		logger:      logger,
+		cfg:            cfg,
+		db:             database,
+		redisClient:    redisClient,
+		asynqClient:    asynqClient,
+		asynqInspector: asynqInspector,
+		logger:         logger,
 	}, nil
 }
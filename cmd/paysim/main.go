@@ -0,0 +1,145 @@
+// Command paysim plays the payment provider's side of a deposit so the whole
+// flow runs offline: it can create a deposit for a user and then sends the
+// signed webhook that confirms or fails it.
+//
+//	go run ./cmd/paysim -token "$JWT" -amount 25
+//	go run ./cmd/paysim -ref fake_pi_... -amount 25 -status failed -reason "card declined"
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/payments"
+	"github.com/google/uuid"
+)
+
+func main() {
+	api := flag.String("api", "http://localhost:8080", "API base URL")
+	secret := flag.String("secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "webhook secret shared with the API")
+	token := flag.String("token", "", "JWT of the depositing user; creates a new deposit when -ref is empty")
+	ref := flag.String("ref", "", "provider reference of an existing deposit")
+	amount := flag.Float64("amount", 0, "amount paid (and deposited when a new deposit is created)")
+	status := flag.String("status", "succeeded", "payment outcome: succeeded or failed")
+	reason := flag.String("reason", "", "failure reason for -status failed")
+	eventID := flag.String("event-id", "", "webhook event ID, random when empty")
+	repeat := flag.Int("repeat", 1, "send the same event this many times to exercise idempotency")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("webhook secret required: pass -secret or set PAYMENT_WEBHOOK_SECRET")
+	}
+	if *amount <= 0 {
+		log.Fatal("-amount must be greater than zero")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var idempotencyKey string
+	if *ref == "" {
+		if *token == "" {
+			log.Fatal("pass -ref of an existing deposit or -token to create one")
+		}
+		intent, err := createDeposit(client, *api, *token, *amount)
+		if err != nil {
+			log.Fatalf("create deposit: %v", err)
+		}
+		if intent.ProviderReference == nil || intent.CheckoutURL == nil {
+			log.Fatalf("deposit %s has no provider reference", intent.ID)
+		}
+		fmt.Printf("created deposit %s (%s), checkout at %s\n", intent.ID, *intent.ProviderReference, *intent.CheckoutURL)
+		*ref = *intent.ProviderReference
+		idempotencyKey = intent.ID.String()
+	}
+
+	event := payments.WebhookEvent{
+		ID:             *eventID,
+		Reference:      *ref,
+		IdempotencyKey: idempotencyKey,
+		Amount:         *amount,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if event.ID == "" {
+		event.ID = "evt_" + uuid.NewString()
+	}
+	switch *status {
+	case "succeeded":
+		event.Type = payments.EventPaymentSucceeded
+	case "failed":
+		event.Type = payments.EventPaymentFailed
+		event.FailureReason = *reason
+	default:
+		log.Fatalf("unknown -status %q, expected succeeded or failed", *status)
+	}
+
+	for i := 0; i < *repeat; i++ {
+		code, body, err := sendWebhook(client, *api, []byte(*secret), event)
+		if err != nil {
+			log.Fatalf("send webhook: %v", err)
+		}
+		fmt.Printf("webhook %s %s -> %d %s\n", event.ID, event.Type, code, strings.TrimSpace(body))
+	}
+}
+
+func createDeposit(client *http.Client, api, token string, amount float64) (*models.PaymentIntent, error) {
+	body, err := json.Marshal(models.DepositRequest{Amount: amount})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, api+"/transactions/deposit", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	intent := &models.PaymentIntent{}
+	if err := json.NewDecoder(resp.Body).Decode(intent); err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+func sendWebhook(client *http.Client, api string, secret []byte, event payments.WebhookEvent) (int, string, error) {
+	payload, signature, err := payments.NewSignedWebhook(secret, event, time.Now())
+	if err != nil {
+		return 0, "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, api+"/webhooks/payments", bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payments.SignatureHeader, signature)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
@@ -2,13 +2,37 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
+	"github.com/Uranury/RBK_finalProject/internal/limits"
+	"github.com/Uranury/RBK_finalProject/internal/metrics"
+	"github.com/Uranury/RBK_finalProject/internal/payments"
 	"github.com/Uranury/RBK_finalProject/internal/queue/handlers"
 	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/hold"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/invoice"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/limit"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/marketdata"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/order"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/reconciliation"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/risk"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/skin"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/washtrade"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/withdrawal"
+	"github.com/Uranury/RBK_finalProject/internal/scheduler"
 	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/internal/storage"
 	"github.com/hibiken/asynq"
 	"github.com/mailgun/mailgun-go/v4"
 )
@@ -29,20 +53,112 @@ func main() {
 
 	// Initialize services used by worker handlers
 	ordRepo := order.NewRepository(deps.DB)
-	invoiceService := services.NewInvoiceService(ordRepo, deps.Logger)
+	userRepo := user.NewRepository(deps.DB)
+	blobs, err := storage.NewLocalStore(deps.Cfg.BlobDir)
+	if err != nil {
+		logger.Error("failed to open blob storage", "err", err)
+		os.Exit(1)
+	}
+	invoiceService := services.NewInvoiceService(invoice.NewRepository(deps.DB), ordRepo, userRepo, blobs, deps.Client, deps.Inspector, deps.Cfg.Company, deps.Cfg.Invoice, deps.DB, deps.Logger)
+	marketDataService := services.NewMarketDataService(marketdata.NewRepository(deps.DB), deps.DB, deps.Logger)
+	transactionRepo := transaction.NewRepository(deps.DB)
+	publisher := realtime.NewRedisPublisher(deps.RedisClient)
+	holdService := services.NewHoldService(hold.NewRepository(deps.DB), userRepo, transactionRepo, publisher, deps.DB, deps.Logger)
+	riskEngine, err := fraud.NewEngine(deps.Cfg.RiskRules)
+	if err != nil {
+		logger.Error("invalid risk rules", "err", err)
+		os.Exit(1)
+	}
+	riskService := services.NewRiskService(risk.NewRepository(deps.DB), riskEngine, deps.DB, deps.Logger)
+	limitService := services.NewLimitService(limit.NewRepository(deps.DB), userRepo, limits.NewPolicy(deps.Cfg.Limits), deps.DB, deps.Logger)
+	withdrawalService := services.NewWithdrawalService(withdrawal.NewRepository(deps.DB), userRepo, transactionRepo, limitService, riskService,
+		payments.NewFakeProvider(deps.Cfg.PaymentWebhookSecret, deps.Logger), deps.Client, deps.Inspector, publisher, deps.Cfg.WithdrawalReviewThreshold, deps.DB, deps.Logger)
+	washTradeService := services.NewWashTradeService(washtrade.NewRepository(deps.DB), marketDataService, deps.Cfg.WashTrade, deps.DB, deps.Logger)
 
 	mg := mailgun.NewMailgun(deps.Cfg.MailgunDomain, deps.Cfg.MailgunAPIKey)
 	emailService := services.NewEmailService(mg, deps.Cfg.MailgunDomain, deps.Logger)
+	reconciliationService := services.NewReconciliationService(reconciliation.NewRepository(deps.DB), deps.Client, emailService, deps.DB, deps.Logger)
 
-	workerHandler := handlers.NewWorkerHandler(emailService, invoiceService, deps.Logger)
+	transactionService := services.NewTransactionService(transactionRepo, deps.Client, deps.Logger)
+	statementService := services.NewStatementService(transactionRepo, deps.Logger)
+	listingService := services.NewListingService(skin.NewRepository(deps.DB), userRepo, emailService, publisher, deps.DB, deps.Logger)
 
-	mux.HandleFunc(jobs.SendInvoice, func(ctx context.Context, t *asynq.Task) error {
-		logger.Info("processing send-invoice task", "task_id", t.ResultWriter().TaskID())
-		return workerHandler.HandleSendInvoiceTask(ctx, t)
-	})
+	workerHandler := handlers.NewWorkerHandler(emailService, invoiceService, marketDataService, holdService, withdrawalService, washTradeService, reconciliationService, transactionService, statementService, listingService, deps.Logger)
 
-	if err := deps.Server.Run(mux); err != nil {
-		logger.Error("could not run asynq server", "err", err)
+	// Logging sees the error after permanent failures were marked as such
+	mux.Use(handlers.Logging(logger), handlers.Metrics, handlers.SkipPermanentFailures)
+
+	mux.HandleFunc(jobs.SendInvoice, workerHandler.HandleSendInvoiceTask)
+	mux.HandleFunc(jobs.SendCreditNote, workerHandler.HandleSendCreditNoteTask)
+	mux.HandleFunc(jobs.RecordMarketSales, workerHandler.HandleRecordMarketSalesTask)
+	mux.HandleFunc(jobs.BackfillMarketData, workerHandler.HandleBackfillMarketDataTask)
+	mux.HandleFunc(jobs.ReleaseHolds, workerHandler.HandleReleaseHoldsTask)
+	mux.HandleFunc(jobs.ExpireListings, workerHandler.HandleExpireListingsTask)
+	mux.HandleFunc(jobs.ProcessPayout, workerHandler.HandleProcessPayoutTask)
+	mux.HandleFunc(jobs.RequeueStalePayouts, workerHandler.HandleRequeueStalePayoutsTask)
+	mux.HandleFunc(jobs.QueueMissingCreditNotes, workerHandler.HandleQueueMissingCreditNotesTask)
+	mux.HandleFunc(jobs.DetectWashTrading, workerHandler.HandleDetectWashTradingTask)
+	mux.HandleFunc(jobs.ReconcileBalances, workerHandler.HandleReconcileBalancesTask)
+	mux.HandleFunc(jobs.QueueTransactionExports, workerHandler.HandleQueueTransactionExportsTask)
+	mux.HandleFunc(jobs.SendTransactionExport, workerHandler.HandleSendTransactionExportTask)
+
+	// Catch up on sales that were never aggregated (e.g. made before the aggregator existed)
+	if _, err := deps.Client.Enqueue(jobs.NewBackfillMarketDataTask(), asynq.Unique(10*time.Minute)); err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+		logger.Warn("failed to enqueue market data backfill", "err", err)
+	}
+
+	// Periodic jobs are queued by whichever worker instance holds the scheduler lock
+	schedules := scheduler.Registry(deps.Cfg)
+	if err := scheduler.Validate(schedules, deps.Cfg.JobSchedules); err != nil {
+		logger.Error("invalid job schedules", "err", err)
 		os.Exit(1)
 	}
+	hostname, _ := os.Hostname()
+	runner := scheduler.NewRunner(schedules, asynq.RedisClientOpt{Addr: deps.Cfg.RedisAddr}, deps.RedisClient, fmt.Sprintf("%s-%d", hostname, os.Getpid()), logger)
+
+	// Task, queue and connection pool metrics for Prometheus on their own listener
+	if err := metrics.RegisterDB(deps.DB); err != nil {
+		logger.Error("failed to register database metrics", "err", err)
+		os.Exit(1)
+	}
+	if err := metrics.RegisterQueues(deps.Inspector); err != nil {
+		logger.Error("failed to register queue metrics", "err", err)
+		os.Exit(1)
+	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", metrics.Handler())
+	metricsServer := &http.Server{Addr: deps.Cfg.WorkerMetricsAddr, Handler: metricsMux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		logger.Info("serving metrics", "address", deps.Cfg.WorkerMetricsAddr)
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("metrics listener failed", "err", err)
+		}
+	}()
+
+	if err := deps.Server.Start(mux); err != nil {
+		logger.Error("could not start asynq server", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	schedulerDone := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(schedulerDone)
+	}()
+	<-ctx.Done()
+
+	// Stop queueing scheduled work first, then let running tasks finish; the
+	// ones still running after WORKER_SHUTDOWN_TIMEOUT go back to their queue
+	logger.Info("shutting down worker", "timeout", deps.Cfg.WorkerShutdownTimeout)
+	<-schedulerDone
+	deps.Server.Shutdown()
+	metricsCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := metricsServer.Shutdown(metricsCtx); err != nil {
+		logger.Warn("failed to stop metrics listener", "err", err)
+	}
+	cancel()
+	deps.Close()
+	logger.Info("worker stopped")
 }
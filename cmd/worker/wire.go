@@ -3,18 +3,23 @@ package main
 import (
 	"log/slog"
 
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"github.com/Uranury/RBK_finalProject/pkg/config"
 	"github.com/Uranury/RBK_finalProject/pkg/db"
 	"github.com/hibiken/asynq"
 	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 )
 
 type WorkerDeps struct {
-	Cfg    *config.Config
-	Server *asynq.Server
-	DB     *sqlx.DB
-	Logger *slog.Logger
+	Cfg         *config.Config
+	Server      *asynq.Server
+	Client      *asynq.Client
+	Inspector   *asynq.Inspector
+	RedisClient *redis.Client
+	DB          *sqlx.DB
+	Logger      *slog.Logger
 }
 
 func InitWorkerDeps(logger *slog.Logger) (*WorkerDeps, error) {
@@ -32,18 +37,48 @@ func InitWorkerDeps(logger *slog.Logger) (*WorkerDeps, error) {
 				"default":  3,
 				"low":      1,
 			},
+			// Retries back off by the policy of each task type
+			RetryDelayFunc:  jobs.RetryDelay,
+			ShutdownTimeout: cfg.WorkerShutdownTimeout,
 		},
 	)
 
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: cfg.RedisAddr})
+	// Reads the queue depth for the metrics
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: cfg.RedisAddr})
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: cfg.RedisAddr,
+	})
+
 	database, err := db.InitDBWithoutMigrations("postgres", cfg.DbURL, logger)
 	if err != nil {
 		return nil, apperrors.NewInternalError("couldn't init database", err)
 	}
 
 	return &WorkerDeps{
-		Cfg:    cfg,
-		Server: server,
-		DB:     database,
-		Logger: logger,
+		Cfg:         cfg,
+		Server:      server,
+		Client:      client,
+		Inspector:   inspector,
+		RedisClient: redisClient,
+		DB:          database,
+		Logger:      logger,
 	}, nil
 }
+
+// Close releases the connections once the server and scheduler have stopped
+func (d *WorkerDeps) Close() {
+	if err := d.Client.Close(); err != nil {
+		d.Logger.Error("failed to close asynq client", "err", err)
+	}
+	if err := d.Inspector.Close(); err != nil {
+		d.Logger.Error("failed to close asynq inspector", "err", err)
+	}
+	if err := d.RedisClient.Close(); err != nil {
+		d.Logger.Error("failed to close redis client", "err", err)
+	}
+	if err := d.DB.Close(); err != nil {
+		d.Logger.Error("failed to close database", "err", err)
+	}
+}
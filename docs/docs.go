@@ -24,77 +24,53 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/guns": {
+        "/admin/disputes": {
             "get": {
-                "description": "Get a list of all available guns in the system",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "skins"
-                ],
-                "summary": "Get all available guns",
-                "responses": {
-                    "200": {
-                        "description": "List of available guns",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Gun"
-                            }
-                        }
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                }
-            }
-        },
-        "/login": {
-            "post": {
-                "description": "Login with email and password to receive JWT token",
-                "consumes": [
-                    "application/json"
                 ],
+                "description": "List disputes by status, open ones by default (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Authenticate user",
+                "summary": "List disputes",
                 "parameters": [
                     {
-                        "description": "Login credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UserLoginRequest"
-                        }
+                        "type": "string",
+                        "description": "open, refunded, partially_refunded or rejected (default open)",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Login successful",
+                        "description": "Disputes",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Dispute"
                             }
                         }
                     },
                     "400": {
-                        "description": "Validation error",
+                        "description": "Invalid status",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Invalid credentials",
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -108,40 +84,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/marketplace/orders/{order_id}": {
-            "get": {
+        "/admin/disputes/{dispute_id}/resolve": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get details of a specific order by ID (only if owned by the user)",
+                "description": "Release the frozen proceeds and refund the order, refund part of it or reject the dispute (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "marketplace"
+                    "admin"
                 ],
-                "summary": "Get order details",
+                "summary": "Resolve a dispute",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "Order ID",
-                        "name": "order_id",
+                        "description": "Dispute ID",
+                        "name": "dispute_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Resolution",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResolveDisputeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order details",
+                        "description": "Resolved dispute",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Dispute"
                         }
                     },
                     "400": {
-                        "description": "Invalid order ID",
+                        "description": "Invalid request or dispute already resolved",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -153,13 +141,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - order not owned by user",
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Order not found",
+                        "description": "Dispute not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -173,46 +161,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/marketplace/purchase": {
-            "post": {
+        "/admin/jobs/queues": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Purchase a skin from the marketplace using user's balance",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List every background job queue with the number of jobs in each state and whether it is paused (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "marketplace"
-                ],
-                "summary": "Purchase a skin",
-                "parameters": [
-                    {
-                        "description": "Purchase request",
-                        "name": "purchase",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.purchaseRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "List background job queues",
                 "responses": {
-                    "201": {
-                        "description": "Purchase successful",
-                        "schema": {
-                            "$ref": "#/definitions/models.Order"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
+                    "200": {
+                        "description": "Queues",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.QueueStats"
+                            }
                         }
                     },
                     "401": {
@@ -221,14 +192,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Skin not available",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
-                    },
-                    "422": {
-                        "description": "Insufficient funds",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -242,44 +207,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/marketplace/sell": {
+        "/admin/jobs/queues/{queue}/pause": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Sell a skin that you own. Price must be \u003e 0 and \u003c= 1,000,000.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stop the workers from processing a queue; new jobs are still queued and run once it is resumed (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "marketplace"
+                    "admin"
                 ],
-                "summary": "Sell a skin",
+                "summary": "Pause a queue",
                 "parameters": [
                     {
-                        "description": "Sell request",
-                        "name": "sell",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.sellRequest"
-                        }
+                        "type": "string",
+                        "description": "Queue name",
+                        "name": "queue",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "UUID of listed skin",
+                    "200": {
+                        "description": "Queue paused",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request (e.g., invalid skinID, invalid price, skin already listed)",
+                        "description": "Queue is already paused",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -291,13 +254,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden: skin ownership required",
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Skin not found",
+                        "description": "Queue not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -311,32 +274,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/marketplace/skins": {
-            "get": {
-                "description": "Get all skins available for purchase in the marketplace",
+        "/admin/jobs/queues/{queue}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Let the workers process a paused queue again (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "marketplace"
+                    "admin"
+                ],
+                "summary": "Resume a queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Queue name",
+                        "name": "queue",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List available skins",
                 "responses": {
                     "200": {
-                        "description": "List of available skins",
+                        "description": "Queue resumed",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Skin"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Queue is not paused",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Queue not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -346,37 +341,82 @@ const docTemplate = `{
                 }
             }
         },
-        "/marketplace/skins/mine": {
+        "/admin/jobs/queues/{queue}/tasks": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all skins owned by the authenticated user",
+                "description": "List the jobs of a queue in one state with their payloads and last errors; archived jobs are the ones that ran out of retries (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "marketplace"
+                    "admin"
+                ],
+                "summary": "List a queue's jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Queue name",
+                        "name": "queue",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, active, scheduled, retry, archived or completed (default archived)",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page, starting at 1 (default 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Jobs per page, at most 100 (default 20)",
+                        "name": "page_size",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List user's skins",
                 "responses": {
                     "200": {
-                        "description": "List of user's skins",
+                        "description": "Jobs",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Skin"
+                                "$ref": "#/definitions/models.JobInfo"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid state or paging",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Queue not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -386,39 +426,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/marketplace/skins/{skin_id}": {
+        "/admin/jobs/queues/{queue}/tasks/{task_id}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a user's skin from listing",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Drop a job that ran out of retries for good (admin only)",
                 "tags": [
-                    "marketplace"
+                    "admin"
                 ],
-                "summary": "Remove a skin",
+                "summary": "Delete an archived job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Skin ID to remove from listing",
-                        "name": "skin_id",
+                        "description": "Queue name",
+                        "name": "queue",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "task_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Skin ID removed successfully",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "204": {
+                        "description": "Job deleted"
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Job is not archived",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -429,8 +470,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Skin not available",
+                        "description": "Queue or job not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -444,36 +491,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/profile": {
-            "get": {
+        "/admin/jobs/queues/{queue}/tasks/{task_id}/retry": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve the authenticated user's profile information (name, email, balance)",
+                "description": "Run a job that ran out of retries again right away (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get user profile",
-                "responses": {
-                    "200": {
-                        "description": "User profile data",
-                        "schema": {
-                            "$ref": "#/definitions/models.UserProfile"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                "summary": "Retry an archived job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Queue name",
+                        "name": "queue",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job queued",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Job is not archived",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Queue or job not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -487,48 +565,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/signup": {
-            "post": {
-                "description": "Create a new user account with email, password, and name",
-                "consumes": [
-                    "application/json"
+        "/admin/jobs/schedules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "List the worker's periodic jobs with their schedules, when they were last queued and when they run next, and the worker instance queueing them (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
-                ],
-                "summary": "Register a new user",
-                "parameters": [
-                    {
-                        "description": "User registration data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UserSignupRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "List periodic jobs",
                 "responses": {
-                    "201": {
-                        "description": "User created successfully",
+                    "200": {
+                        "description": "Schedules",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Schedules"
                         }
                     },
-                    "400": {
-                        "description": "Validation error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "User already exists",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -542,44 +608,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/skins": {
-            "post": {
+        "/admin/orders/{order_id}/history": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new skin and add it to the marketplace. Wear is automatically calculated based on condition.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List every status change of an order, oldest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "skins"
+                    "admin"
                 ],
-                "summary": "Create a new skin",
+                "summary": "Get order status history",
                 "parameters": [
                     {
-                        "description": "Skin creation data",
-                        "name": "skin",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.createSkinRequest"
-                        }
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Skin created successfully",
+                    "200": {
+                        "description": "Status history",
                         "schema": {
-                            "$ref": "#/definitions/models.Skin"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderStatusChange"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Validation error",
+                        "description": "Invalid order ID",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -590,6 +655,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -599,44 +676,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/deposit": {
+        "/admin/orders/{order_id}/invoice/resend": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deposit a specified amount to the user's balance",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Queue the invoice email to the order's buyer again (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "admin"
                 ],
-                "summary": "Deposit money to balance",
+                "summary": "Re-send an order's invoice",
                 "parameters": [
                     {
-                        "description": "Deposit request",
-                        "name": "deposit",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.DepositRequest"
-                        }
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Deposit successful",
+                    "202": {
+                        "description": "Invoice email queued",
                         "schema": {
-                            "$ref": "#/definitions/models.Transaction"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Validation error",
+                        "description": "Invalid order ID or order not paid",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -647,6 +723,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Invoice email already queued",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -656,37 +750,59 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/history": {
+        "/admin/orders/{order_id}/jobs": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the transaction history for the authenticated user",
+                "description": "List the background jobs of an order, such as its invoice, credit notes and market data, with their states (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "admin"
+                ],
+                "summary": "List an order's jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get transaction history",
                 "responses": {
                     "200": {
-                        "description": "Transaction history",
+                        "description": "Jobs",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Transaction"
+                                "$ref": "#/definitions/models.JobInfo"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -696,14 +812,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/withdraw": {
+        "/admin/orders/{order_id}/refund": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Withdraw a specified amount from the user's balance",
+                "description": "Undo a purchase: refund the buyer, debit the seller's proceeds, reverse the platform fee and return the skin to the seller (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -711,29 +827,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "admin"
                 ],
-                "summary": "Withdraw money from balance",
+                "summary": "Refund an order",
                 "parameters": [
                     {
-                        "description": "Withdrawal request",
-                        "name": "withdrawal",
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund reason",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.WithdrawRequest"
+                            "$ref": "#/definitions/models.RefundOrderRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Withdrawal successful",
+                        "description": "Refunded order",
                         "schema": {
-                            "$ref": "#/definitions/models.Transaction"
+                            "$ref": "#/definitions/models.Order"
                         }
                     },
                     "400": {
-                        "description": "Validation error",
+                        "description": "Invalid request or order cannot be refunded",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -744,8 +868,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "422": {
-                        "description": "Insufficient funds",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -759,249 +889,4902 @@ const docTemplate = `{
                 }
             }
         },
-        "/wears": {
+        "/admin/reconciliation/reports": {
             "get": {
-                "description": "Get a list of all available wear levels in the system",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the most recent runs of the balance reconciliation job, without their discrepancies (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "skins"
+                    "admin"
+                ],
+                "summary": "List reconciliation reports",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of reports, at most 100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all available wear levels",
                 "responses": {
                     "200": {
-                        "description": "List of available wear levels",
+                        "description": "Reports",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Wear"
+                                "$ref": "#/definitions/models.ReconciliationReport"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "details": {
-                    "description": "Field-specific validation errors",
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+        },
+        "/admin/reconciliation/reports/{report_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
+                ],
+                "description": "Get a reconciliation run with the discrepancies it found (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a reconciliation report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Report ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only discrepancies of this kind: balance_mismatch, pending_mismatch, frozen_mismatch, reserved_mismatch, chain_gap, broken_row or orphan_order",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReconciliationReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or kind",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reconciliation/run": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queue the balance reconciliation job instead of waiting for its schedule; the report shows up once the worker is done (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run a reconciliation now",
+                "responses": {
+                    "202": {
+                        "description": "Reconciliation queued",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A reconciliation is already queued",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/fees": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Aggregate the marketplace fees earned by the platform per day, week or month (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get fee revenue report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Aggregation period: day, week or month (default day)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339), defaults to 30 days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Fee revenue report",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevenueReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/tax": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sum the VAT charged on purchases per day, week or month, buyer country and rate, with reverse-charged sales listed separately. Refunds count in the period they happened (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get VAT report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Aggregation period: day, week or month (default day)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339), defaults to 30 days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "VAT report",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/flags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List deposits, withdrawals, purchases and listings flagged by the risk rules, the open ones by default (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List risk flags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "open, cleared or confirmed (default open)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Flags",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RiskFlag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/flags/{flag_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a flag with the rule hits that raised it (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a risk flag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Flag ID",
+                        "name": "flag_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Flag",
+                        "schema": {
+                            "$ref": "#/definitions/models.RiskFlag"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Flag not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/flags/{flag_id}/review": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear a flag as legitimate or confirm it as fraud. Clearing a held purchase lets the seller's proceeds be released (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Review a risk flag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Flag ID",
+                        "name": "flag_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewRiskFlagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reviewed flag",
+                        "schema": {
+                            "$ref": "#/definitions/models.RiskFlag"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or flag already reviewed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Flag not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/replay": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Run the configured rules, or the rules in the body, against past deposits, withdrawals, purchases and sales and report what they would have done. Nothing is recorded (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Replay risk rules against history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start time (default 7 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 end time (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Rules to try instead of the configured ones",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RiskReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Replay report",
+                        "schema": {
+                            "$ref": "#/definitions/models.RiskReplayReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid range or rules",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/risk/users/{user_id}/hits": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Audit trail of the risk rules a user's money movements matched, including allow-only rules (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List a user's risk rule hits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start time (default 30 days ago)",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rule hits",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RiskRuleHit"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{user_id}/limits": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Effective limits of a user and the overrides set for them (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a user's limits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Limits",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserLimits"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{user_id}/limits/{kind}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the user's tier limits for one kind. Omitted fields keep the tier's value, 0 lifts the limit (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Override a user's limits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "deposit, withdraw or purchase",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetLimitOverrideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Override",
+                        "schema": {
+                            "$ref": "#/definitions/models.LimitOverride"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Put the user back on their tier's limits for one kind (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Remove a user's limit override",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "deposit, withdraw or purchase",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Override removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Override not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{user_id}/tier": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the seller tier that selects the user's marketplace fee rule (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update a user's seller tier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New tier",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateTierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tier updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wash-trading/clusters": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List suspicious clusters by status, the open ones by default, most suspicious first (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List wash-trading clusters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "open, cleared or confirmed (default open)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Clusters",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WashTradeCluster"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wash-trading/clusters/{cluster_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a cluster with its accounts and the sales that made it suspicious (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a wash-trading cluster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cluster",
+                        "schema": {
+                            "$ref": "#/definitions/models.WashTradeCluster"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Cluster not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wash-trading/clusters/{cluster_id}/review": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear a cluster as legitimate trading, which returns its sales to the price statistics, or confirm it as wash trading (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Review a wash-trading cluster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewWashTradeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reviewed cluster",
+                        "schema": {
+                            "$ref": "#/definitions/models.WashTradeCluster"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or cluster already reviewed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Cluster not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wash-trading/reports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the most recent runs of the wash-trading detection job, without their clusters (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List wash-trading reports",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of reports, at most 100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reports",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WashTradeReport"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wash-trading/reports/{report_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a detection run with its suspicious clusters, their accounts and sales (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a wash-trading report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Report ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report",
+                        "schema": {
+                            "$ref": "#/definitions/models.WashTradeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/withdrawals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List withdrawals by status, the ones waiting for review by default (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List withdrawals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending_review, approved, processing, settled, failed or rejected (default pending_review)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Withdrawals",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Withdrawal"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/withdrawals/{withdrawal_id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Approve a withdrawal waiting for review and queue its payout (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Approve a withdrawal",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Withdrawal ID",
+                        "name": "withdrawal_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Approved withdrawal",
+                        "schema": {
+                            "$ref": "#/definitions/models.Withdrawal"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or withdrawal not pending review",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Withdrawal not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/withdrawals/{withdrawal_id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reject a withdrawal waiting for review and return the reserved amount to the user's balance (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reject a withdrawal",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Withdrawal ID",
+                        "name": "withdrawal_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rejection reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RejectWithdrawalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rejected withdrawal",
+                        "schema": {
+                            "$ref": "#/definitions/models.Withdrawal"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or withdrawal not pending review",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Withdrawal not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the disputes the authenticated user is a buyer or seller in",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "List my disputes",
+                "responses": {
+                    "200": {
+                        "description": "Disputes",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Dispute"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes/{dispute_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a dispute with its message thread (buyer, seller or admin)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Get a dispute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Dispute ID",
+                        "name": "dispute_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dispute with messages",
+                        "schema": {
+                            "$ref": "#/definitions/models.DisputeDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid dispute ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a party to the dispute",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes/{dispute_id}/messages": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a message to the thread of an open dispute (buyer, seller or admin)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Post a dispute message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Dispute ID",
+                        "name": "dispute_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Message",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DisputeMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Message added",
+                        "schema": {
+                            "$ref": "#/definitions/models.DisputeMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or dispute resolved",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a party to the dispute",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/guns": {
+            "get": {
+                "description": "Get a list of all available guns in the system",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "skins"
+                ],
+                "summary": "Get all available guns",
+                "responses": {
+                    "200": {
+                        "description": "List of available guns",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Gun"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/limits": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Per-transaction, rolling 24h and rolling 30 day limits on deposits, withdrawals and purchases, with how much of each is left. Null limits are unlimited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Get my limits",
+                "responses": {
+                    "200": {
+                        "description": "Limits",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LimitStatus"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Login with email and password to receive JWT token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Authenticate user",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login successful",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/orders/{order_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get details of a specific order by ID (only if owned by the user)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Get order details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order details",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - order not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/orders/{order_id}/disputes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dispute a completed order within the dispute window. The seller's proceeds from the order are frozen until an admin resolves it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Open a dispute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dispute reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OpenDisputeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Dispute opened",
+                        "schema": {
+                            "$ref": "#/definitions/models.Dispute"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or order cannot be disputed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not your order",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Order already disputed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/orders/{order_id}/invoice": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the invoice of one of the authenticated user's paid orders as a PDF, or as UBL 2.1 XML with format=xml or an Accept header asking for application/xml. The archived PDF is served; a missing one is regenerated",
+                "produces": [
+                    "application/pdf",
+                    "application/xml"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Download an order's invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pdf or xml (default pdf)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invoice PDF or UBL XML",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID, format or order not paid",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - order not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/prices": {
+            "get": {
+                "description": "Get the last sale price and the median sale price over a window for a skin type",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "market data"
+                ],
+                "summary": "Get price summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Skin name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Gun",
+                        "name": "gun",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wear",
+                        "name": "wear",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Median window in days (default 30, max 365)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Price summary",
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/prices/candles": {
+            "get": {
+                "description": "Get OHLC candles (open/high/low/close/volume) of completed sales for a skin type",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "market data"
+                ],
+                "summary": "Get price candles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Skin name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Gun",
+                        "name": "gun",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wear",
+                        "name": "wear",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket size: 1h, 1d or 1w (default 1d)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339), defaults to 100 buckets before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Candles ordered by bucket start",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Candle"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/purchase": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Purchase a skin from the marketplace using user's balance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Purchase a skin",
+                "parameters": [
+                    {
+                        "description": "Purchase request",
+                        "name": "purchase",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.purchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Purchase successful",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Skin not available",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/sell": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sell a skin that you own. Price must be \u003e 0 and \u003c= 1,000,000, or set auto_price to list at the suggested market price.\nWith duration_days (1, 7 or 30) the listing expires and is taken down after that many days.\nThe response contains the price suggestion and warns when the price deviates wildly from the market.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Sell a skin",
+                "parameters": [
+                    {
+                        "description": "Sell request",
+                        "name": "sell",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.sellRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Listed skin with final price and warnings",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (e.g., invalid skinID, invalid price, skin already listed, no market data for auto price)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: skin ownership required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Skin not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/skins": {
+            "get": {
+                "description": "Get all skins available for purchase in the marketplace",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "List available skins",
+                "responses": {
+                    "200": {
+                        "description": "List of available skins",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Skin"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/skins/mine": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all skins owned by the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "List user's skins",
+                "responses": {
+                    "200": {
+                        "description": "List of user's skins",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Skin"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/skins/{skin_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a user's skin from listing",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Remove a skin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Skin ID to remove from listing",
+                        "name": "skin_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Skin ID removed successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Skin not available",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/skins/{skin_id}/price": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the price of a skin you currently have listed. Price must be \u003e 0 and \u003c= 1,000,000.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Change listing price",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Listed skin ID",
+                        "name": "skin_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New price",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.updatePriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "UUID of repriced skin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: skin ownership required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Skin not found or not listed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/skins/{skin_id}/price-suggestion": {
+            "get": {
+                "description": "Recommend a listing price for a skin from recent sales and the cheapest current listings of the same name/gun/wear, adjusted by the skin's condition within its wear band",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "market data"
+                ],
+                "summary": "Suggest a listing price",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Skin ID",
+                        "name": "skin_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Price suggestion",
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceSuggestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid skin ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Skin not found or no market data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/skins/{skin_id}/relist": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List a skin whose listing expired again, at its previous price and for the previous duration unless overridden. The body is optional.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Relist an expired listing",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Skin ID",
+                        "name": "skin_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Relist options",
+                        "name": "relist",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.relistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Listed skin with final price and warnings",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or the listing didn't expire",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: skin ownership required",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Skin not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/stream": {
+            "get": {
+                "description": "Server-Sent Events stream of listing.created, listing.removed, listing.price_changed and skin.sold events.\nAuthenticated clients (Bearer header or token query param) also receive balance.updated and order.updated events.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Stream marketplace events (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events for this gun",
+                        "name": "gun",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events for this wear",
+                        "name": "wear",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum skin price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum skin price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT for private events",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/realtime.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketplace/ws": {
+            "get": {
+                "description": "WebSocket stream with the same events and filters as /marketplace/stream. Each message is a JSON-encoded event.",
+                "tags": [
+                    "marketplace"
+                ],
+                "summary": "Stream marketplace events (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events for this gun",
+                        "name": "gun",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events for this wear",
+                        "name": "wear",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum skin price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum skin price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT for private events",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching protocols",
+                        "schema": {
+                            "$ref": "#/definitions/realtime.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the authenticated user's profile information (name, email, balance)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user profile",
+                "responses": {
+                    "200": {
+                        "description": "User profile data",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/billing": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the country the authenticated user is taxed in, and a VAT ID when buying as a business. VAT on purchases follows these details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set billing details",
+                "parameters": [
+                    {
+                        "description": "Billing country and optional VAT ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateBillingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Billing details updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid country or VAT ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Create a new user account with email, password, and name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserSignupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/skins": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new skin and add it to the marketplace. Wear is automatically calculated based on condition.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "skins"
+                ],
+                "summary": "Create a new skin",
+                "parameters": [
+                    {
+                        "description": "Skin creation data",
+                        "name": "skin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.createSkinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Skin created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Skin"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/deposit": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a payment intent at the payment provider. Pay at the returned checkout URL; the balance is credited once the provider confirms the payment.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Start a deposit",
+                "parameters": [
+                    {
+                        "description": "Deposit request",
+                        "name": "deposit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DepositRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Payment intent created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaymentIntent"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/deposits/{intent_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status of one of your deposits",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Get a deposit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Payment intent ID",
+                        "name": "intent_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payment intent",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaymentIntent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Deposit not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the authenticated user's transactions, newest first. Pass next_cursor as cursor to get the following page",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Get transaction history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated transaction types, e.g. deposit,withdraw,purchase,sale",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339), inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339), exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Skin ID",
+                        "name": "skin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Counterparty user ID",
+                        "name": "counterparty_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum absolute amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum absolute amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 200 (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction history",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/history/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download every transaction of the authenticated user matching the filters, newest first, as CSV or newline-delimited JSON",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Export transaction history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson (default csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated transaction types, e.g. deposit,withdraw,purchase,sale",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339), inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339), exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Skin ID",
+                        "name": "skin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Counterparty user ID",
+                        "name": "counterparty_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum absolute amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum absolute amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction export",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/statements/{month}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the authenticated user's account statement for a month as a PDF: opening balance, every transaction with the running balances, closing balance and totals per type",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Download a monthly statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month as YYYY-MM",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statement PDF",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid month",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/withdraw": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Request a payout. The amount is reserved from the available balance right away; withdrawals above the review threshold wait for admin approval, the others are paid out in the background.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Withdraw money from balance",
+                "parameters": [
+                    {
+                        "description": "Withdrawal request",
+                        "name": "withdrawal",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WithdrawRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Withdrawal accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Withdrawal"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error or insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/withdrawals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the authenticated user's withdrawals and their payout status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "List my withdrawals",
+                "responses": {
+                    "200": {
+                        "description": "Withdrawals",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Withdrawal"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/wears": {
+            "get": {
+                "description": "Get a list of all available wear levels in the system",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "skins"
+                ],
+                "summary": "Get all available wear levels",
+                "responses": {
+                    "200": {
+                        "description": "List of available wear levels",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Wear"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/payments": {
+            "post": {
+                "description": "Receives signed payment notifications from the payment provider. The Payment-Signature header must carry a valid HMAC of the raw body; redelivered events are acknowledged without being applied again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Payment provider webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "t=\u003cunix seconds\u003e,v1=\u003chex HMAC-SHA256\u003e",
+                        "name": "Payment-Signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Unreadable body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired signature",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.createSkinRequest": {
+            "type": "object",
+            "required": [
+                "condition",
+                "gun",
+                "name",
+                "price",
+                "rarity"
+            ],
+            "properties": {
+                "condition": {
+                    "type": "number"
+                },
+                "gun": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "rarity": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.purchaseRequest": {
+            "type": "object",
+            "required": [
+                "skin_id"
+            ],
+            "properties": {
+                "skin_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.relistRequest": {
+            "type": "object",
+            "properties": {
+                "auto_price": {
+                    "type": "boolean"
+                },
+                "duration_days": {
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        7,
+                        30
+                    ]
+                }
+            }
+        },
+        "handlers.sellRequest": {
+            "type": "object",
+            "required": [
+                "skin_id"
+            ],
+            "properties": {
+                "auto_price": {
+                    "type": "boolean"
+                },
+                "duration_days": {
+                    "description": "DurationDays makes the listing expire, it stays listed without one",
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        7,
+                        30
+                    ]
+                },
+                "price": {
+                    "type": "number",
+                    "maximum": 1000000
+                },
+                "skin_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.updatePriceRequest": {
+            "type": "object",
+            "required": [
+                "price"
+            ],
+            "properties": {
+                "price": {
+                    "type": "number",
+                    "maximum": 1000000
+                }
+            }
+        },
+        "models.BalanceType": {
+            "type": "string",
+            "enum": [
+                "available",
+                "pending"
+            ],
+            "x-enum-varnames": [
+                "BalanceAvailable",
+                "BalancePending"
+            ]
+        },
+        "models.Candle": {
+            "type": "object",
+            "properties": {
+                "bucket_start": {
+                    "type": "string"
+                },
+                "close": {
+                    "type": "number"
+                },
+                "gun": {
+                    "$ref": "#/definitions/models.Gun"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "interval": {
+                    "$ref": "#/definitions/models.CandleInterval"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "open": {
+                    "type": "number"
+                },
+                "turnover": {
+                    "type": "number"
+                },
+                "volume": {
+                    "type": "integer"
+                },
+                "wear": {
+                    "$ref": "#/definitions/models.Wear"
+                }
+            }
+        },
+        "models.CandleInterval": {
+            "type": "string",
+            "enum": [
+                "1h",
+                "1d",
+                "1w"
+            ],
+            "x-enum-varnames": [
+                "Interval1h",
+                "Interval1d",
+                "Interval1w"
+            ]
+        },
+        "models.DepositRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Discrepancy": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "expected": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.DiscrepancyKind"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DiscrepancyKind": {
+            "type": "string",
+            "enum": [
+                "balance_mismatch",
+                "pending_mismatch",
+                "frozen_mismatch",
+                "reserved_mismatch",
+                "chain_gap",
+                "broken_row",
+                "orphan_order"
+            ],
+            "x-enum-varnames": [
+                "DiscrepancyBalance",
+                "DiscrepancyPending",
+                "DiscrepancyFrozen",
+                "DiscrepancyReserved",
+                "DiscrepancyGap",
+                "DiscrepancyBrokenRow",
+                "DiscrepancyOrphanOrder"
+            ]
+        },
+        "models.Dispute": {
+            "type": "object",
+            "properties": {
+                "buyer_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "frozen_amount": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refund_amount": {
+                    "type": "number"
+                },
+                "resolution": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DisputeStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DisputeDetails": {
+            "type": "object",
+            "properties": {
+                "buyer_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "frozen_amount": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DisputeMessage"
+                    }
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refund_amount": {
+                    "type": "number"
+                },
+                "resolution": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DisputeStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DisputeMessage": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dispute_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DisputeMessageRequest": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "models.DisputeResolution": {
+            "type": "string",
+            "enum": [
+                "refund",
+                "partial_refund",
+                "reject"
+            ],
+            "x-enum-varnames": [
+                "ResolutionRefund",
+                "ResolutionPartialRefund",
+                "ResolutionReject"
+            ]
+        },
+        "models.DisputeStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "refunded",
+                "partially_refunded",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "DisputeOpen",
+                "DisputeRefunded",
+                "DisputePartialRefunded",
+                "DisputeRejected"
+            ]
+        },
+        "models.Gun": {
+            "type": "string",
+            "enum": [
+                "AK-47",
+                "M4A4",
+                "M4A1-S",
+                "Desert Eagle",
+                "USP-S",
+                "Glock-18",
+                "P250",
+                "Tec-9",
+                "CZ75-Auto",
+                "AWP",
+                "SSG 08",
+                "SCAR-20",
+                "G3SG1",
+                "MP9",
+                "MAC-10",
+                "MP7",
+                "P90",
+                "UMP-45",
+                "PP-Bizon",
+                "Nova",
+                "XM1014",
+                "MAG-7",
+                "Sawed-Off",
+                "M249",
+                "Negev",
+                "Karambit",
+                "Butterfly Knife",
+                "M9 Bayonet",
+                "Bayonet",
+                "Flip Knife",
+                "Gut Knife",
+                "Huntsman Knife",
+                "Shadow Daggers",
+                "Falchion Knife",
+                "Bowie Knife",
+                "Navaja Knife",
+                "Stiletto Knife",
+                "Ursus Knife",
+                "Nomad Knife",
+                "Paracord Knife",
+                "Survival Knife",
+                "Classic Knife"
+            ],
+            "x-enum-varnames": [
+                "AK47",
+                "M4A4",
+                "M4A1S",
+                "DesertEagle",
+                "USPS",
+                "Glock18",
+                "P250",
+                "Tec9",
+                "CZ75",
+                "AWP",
+                "SSG08",
+                "SCAR20",
+                "G3SG1",
+                "MP9",
+                "MAC10",
+                "MP7",
+                "P90",
+                "UMP45",
+                "PPBizon",
+                "Nova",
+                "XM1014",
+                "MAG7",
+                "SawedOff",
+                "M249",
+                "Negev",
+                "Karambit",
+                "Butterfly",
+                "M9Bayonet",
+                "Bayonet",
+                "FlipKnife",
+                "GutKnife",
+                "Huntsman",
+                "ShadowDaggers",
+                "Falchion",
+                "Bowie",
+                "Navaja",
+                "Stiletto",
+                "Ursus",
+                "Nomad",
+                "Paracord",
+                "Survival",
+                "Classic"
+            ]
+        },
+        "models.JobInfo": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "description": "LastError is the error of the last failed attempt",
+                    "type": "string"
+                },
+                "last_failed_at": {
+                    "type": "string"
+                },
+                "max_retry": {
+                    "type": "integer"
+                },
+                "next_process_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "queue": {
+                    "type": "string"
+                },
+                "retried": {
+                    "type": "integer"
+                },
+                "state": {
+                    "$ref": "#/definitions/models.JobState"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobState": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "active",
+                "scheduled",
+                "retry",
+                "archived",
+                "completed"
+            ],
+            "x-enum-varnames": [
+                "JobPending",
+                "JobActive",
+                "JobScheduled",
+                "JobRetry",
+                "JobArchived",
+                "JobCompleted"
+            ]
+        },
+        "models.LedgerEntryType": {
+            "type": "string",
+            "enum": [
+                "fee"
+            ],
+            "x-enum-varnames": [
+                "LedgerFee"
+            ]
+        },
+        "models.LimitKind": {
+            "type": "string",
+            "enum": [
+                "deposit",
+                "withdraw",
+                "purchase"
+            ],
+            "x-enum-varnames": [
+                "LimitDeposit",
+                "LimitWithdraw",
+                "LimitPurchase"
+            ]
+        },
+        "models.LimitOverride": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "daily": {
+                    "type": "number"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.LimitKind"
+                },
+                "monthly": {
+                    "type": "number"
+                },
+                "per_transaction": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LimitStatus": {
+            "type": "object",
+            "properties": {
+                "daily": {
+                    "$ref": "#/definitions/models.LimitWindow"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.LimitKind"
+                },
+                "monthly": {
+                    "$ref": "#/definitions/models.LimitWindow"
+                },
+                "overridden": {
+                    "type": "boolean"
+                },
+                "per_transaction": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.LimitWindow": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "number"
+                },
+                "remaining": {
+                    "type": "number"
+                },
+                "used": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ListingResult": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt is when the listing ends, nil when it doesn't expire",
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "skin_id": {
+                    "type": "string"
+                },
+                "suggestion": {
+                    "$ref": "#/definitions/models.PriceSuggestion"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.OpenDisputeRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "minLength": 10
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "buyerVatId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "refundedAmount": {
+                    "description": "RefundedAmount is what partial and full refunds paid back so far",
+                    "type": "number"
+                },
+                "reverseCharge": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "taxAmount": {
+                    "description": "TaxAmount is the VAT charged on top of the item prices at purchase time",
+                    "type": "number"
                 },
-                "error": {
+                "taxCountry": {
+                    "type": "string"
+                },
+                "taxRate": {
+                    "type": "number"
+                },
+                "totalAmount": {
+                    "description": "TotalAmount is what the buyer paid, tax included",
+                    "type": "number"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "paid",
+                "completed",
+                "refunded",
+                "disputed"
+            ],
+            "x-enum-varnames": [
+                "OrderStatusPending",
+                "OrderStatusPaid",
+                "OrderStatusCompleted",
+                "OrderStatusRefunded",
+                "OrderStatusDisputed"
+            ]
+        },
+        "models.OrderStatusChange": {
+            "type": "object",
+            "properties": {
+                "changedBy": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "toStatus": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                }
+            }
+        },
+        "models.PaymentIntent": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "checkout_url": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "provider_reference": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.PaymentIntentStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PaymentIntentStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "PaymentPending",
+                "PaymentSucceeded",
+                "PaymentFailed"
+            ]
+        },
+        "models.PriceConfidence": {
+            "type": "string",
+            "enum": [
+                "high",
+                "medium",
+                "low"
+            ],
+            "x-enum-varnames": [
+                "ConfidenceHigh",
+                "ConfidenceMedium",
+                "ConfidenceLow"
+            ]
+        },
+        "models.PriceSuggestion": {
+            "type": "object",
+            "properties": {
+                "condition_adjustment": {
+                    "type": "number"
+                },
+                "confidence": {
+                    "$ref": "#/definitions/models.PriceConfidence"
+                },
+                "last_sale_price": {
+                    "type": "number"
+                },
+                "lowest_listing": {
+                    "type": "number"
+                },
+                "median_price": {
+                    "type": "number"
+                },
+                "sales_count": {
+                    "type": "integer"
+                },
+                "skin_id": {
+                    "type": "string"
+                },
+                "suggested_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.PriceSummary": {
+            "type": "object",
+            "properties": {
+                "gun": {
+                    "$ref": "#/definitions/models.Gun"
+                },
+                "last_sale_at": {
+                    "type": "string"
+                },
+                "last_sale_price": {
+                    "type": "number"
+                },
+                "median_price": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sales_count": {
+                    "type": "integer"
+                },
+                "wear": {
+                    "$ref": "#/definitions/models.Wear"
+                },
+                "window_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QueueStats": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "archived": {
+                    "type": "integer"
+                },
+                "completed": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "latency_seconds": {
+                    "description": "LatencySeconds is how long the oldest pending job has been waiting",
+                    "type": "number"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "description": "Processed and Failed count today's jobs",
+                    "type": "integer"
+                },
+                "queue": {
+                    "type": "string"
+                },
+                "retry": {
+                    "type": "integer"
+                },
+                "scheduled": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReconciliationReport": {
+            "type": "object",
+            "properties": {
+                "discrepancies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Discrepancy"
+                    }
+                },
+                "discrepancy_count": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "orders_checked": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "transactions_checked": {
+                    "type": "integer"
+                },
+                "users_checked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RefundOrderRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "models.RejectWithdrawalRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "models.ReportPeriod": {
+            "type": "string",
+            "enum": [
+                "day",
+                "week",
+                "month"
+            ],
+            "x-enum-varnames": [
+                "PeriodDay",
+                "PeriodWeek",
+                "PeriodMonth"
+            ]
+        },
+        "models.ResolveDisputeRequest": {
+            "type": "object",
+            "required": [
+                "note",
+                "resolution"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "Amount refunded to the buyer, required for partial_refund",
+                    "type": "number"
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "resolution": {
+                    "enum": [
+                        "refund",
+                        "partial_refund",
+                        "reject"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DisputeResolution"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.RevenueReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "period": {
+                    "$ref": "#/definitions/models.ReportPeriod"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RevenueRow"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.LedgerEntryType"
+                }
+            }
+        },
+        "models.RevenueRow": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "period_start": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ReviewRiskFlagRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "status": {
+                    "enum": [
+                        "cleared",
+                        "confirmed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RiskFlagStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.ReviewWashTradeRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "status": {
+                    "enum": [
+                        "cleared",
+                        "confirmed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.WashTradeStatus"
+                        }
+                    ]
                 }
             }
         },
-        "handlers.createSkinRequest": {
-            "type": "object",
-            "required": [
-                "condition",
-                "gun",
-                "name",
-                "price",
-                "rarity"
+        "models.RiskAction": {
+            "type": "string",
+            "enum": [
+                "allow",
+                "flag",
+                "hold",
+                "block"
+            ],
+            "x-enum-varnames": [
+                "RiskActionAllow",
+                "RiskActionFlag",
+                "RiskActionHold",
+                "RiskActionBlock"
+            ]
+        },
+        "models.RiskEventKind": {
+            "type": "string",
+            "enum": [
+                "deposit",
+                "withdraw",
+                "purchase",
+                "sell"
             ],
+            "x-enum-varnames": [
+                "RiskDeposit",
+                "RiskWithdraw",
+                "RiskPurchase",
+                "RiskSell"
+            ]
+        },
+        "models.RiskFlag": {
+            "type": "object",
             "properties": {
-                "condition": {
+                "action": {
+                    "$ref": "#/definitions/models.RiskAction"
+                },
+                "amount": {
                     "type": "number"
                 },
-                "gun": {
+                "created_at": {
                     "type": "string"
                 },
-                "image": {
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RiskRuleHit"
+                    }
+                },
+                "id": {
                     "type": "string"
                 },
-                "name": {
+                "kind": {
+                    "$ref": "#/definitions/models.RiskEventKind"
+                },
+                "reference_id": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "review_note": {
+                    "type": "string"
                 },
-                "rarity": {
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.RiskFlagStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "handlers.purchaseRequest": {
-            "type": "object",
-            "required": [
-                "skin_id"
+        "models.RiskFlagStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "cleared",
+                "confirmed"
             ],
+            "x-enum-varnames": [
+                "RiskFlagOpen",
+                "RiskFlagCleared",
+                "RiskFlagConfirmed"
+            ]
+        },
+        "models.RiskReplayHit": {
+            "type": "object",
             "properties": {
-                "skin_id": {
+                "action": {
+                    "$ref": "#/definitions/models.RiskAction"
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.RiskEventKind"
+                },
+                "rule": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "description": "TransactionID is the listing's ID for sell hits",
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "handlers.sellRequest": {
+        "models.RiskReplayReport": {
             "type": "object",
-            "required": [
-                "price",
-                "skin_id"
-            ],
             "properties": {
-                "price": {
-                    "type": "number",
-                    "maximum": 1000000
+                "decisions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 },
-                "skin_id": {
+                "events": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RiskReplayHit"
+                    }
+                },
+                "rule_hits": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "to": {
                     "type": "string"
+                },
+                "truncated": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.DepositRequest": {
+        "models.RiskReplayRequest": {
+            "type": "object"
+        },
+        "models.RiskRuleHit": {
             "type": "object",
-            "required": [
-                "amount"
-            ],
             "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.RiskAction"
+                },
                 "amount": {
                     "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "flag_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.RiskEventKind"
+                },
+                "reference_id": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                },
+                "rule_type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.Gun": {
-            "type": "string",
-            "enum": [
-                "AK-47",
-                "M4A4",
-                "M4A1-S",
-                "Desert Eagle",
-                "USP-S",
-                "Glock-18",
-                "P250",
-                "Tec-9",
-                "CZ75-Auto",
-                "AWP",
-                "SSG 08",
-                "SCAR-20",
-                "G3SG1",
-                "MP9",
-                "MAC-10",
-                "MP7",
-                "P90",
-                "UMP-45",
-                "PP-Bizon",
-                "Nova",
-                "XM1014",
-                "MAG-7",
-                "Sawed-Off",
-                "M249",
-                "Negev",
-                "Karambit",
-                "Butterfly Knife",
-                "M9 Bayonet",
-                "Bayonet",
-                "Flip Knife",
-                "Gut Knife",
-                "Huntsman Knife",
-                "Shadow Daggers",
-                "Falchion Knife",
-                "Bowie Knife",
-                "Navaja Knife",
-                "Stiletto Knife",
-                "Ursus Knife",
-                "Nomad Knife",
-                "Paracord Knife",
-                "Survival Knife",
-                "Classic Knife"
-            ],
-            "x-enum-varnames": [
-                "AK47",
-                "M4A4",
-                "M4A1S",
-                "DesertEagle",
-                "USPS",
-                "Glock18",
-                "P250",
-                "Tec9",
-                "CZ75",
-                "AWP",
-                "SSG08",
-                "SCAR20",
-                "G3SG1",
-                "MP9",
-                "MAC10",
-                "MP7",
-                "P90",
-                "UMP45",
-                "PPBizon",
-                "Nova",
-                "XM1014",
-                "MAG7",
-                "SawedOff",
-                "M249",
-                "Negev",
-                "Karambit",
-                "Butterfly",
-                "M9Bayonet",
-                "Bayonet",
-                "FlipKnife",
-                "GutKnife",
-                "Huntsman",
-                "ShadowDaggers",
-                "Falchion",
-                "Bowie",
-                "Navaja",
-                "Stiletto",
-                "Ursus",
-                "Nomad",
-                "Paracord",
-                "Survival",
-                "Classic"
-            ]
-        },
-        "models.Order": {
+        "models.Schedule": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "last_run": {
+                    "$ref": "#/definitions/models.ScheduleRun"
+                },
+                "name": {
                     "type": "string"
                 },
-                "id": {
+                "next_run_at": {
                     "type": "string"
                 },
-                "status": {
-                    "$ref": "#/definitions/models.OrderStatus"
+                "queue": {
+                    "type": "string"
                 },
-                "totalAmount": {
-                    "type": "number"
+                "spec": {
+                    "description": "Spec is a cron spec or an @every interval, in UTC; empty when turned off",
+                    "type": "string"
                 },
-                "updatedAt": {
+                "task_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScheduleRun": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error says why the job wasn't queued, e.g. the previous run is still waiting",
+                    "type": "string"
+                },
+                "task_id": {
                     "type": "string"
+                }
+            }
+        },
+        "models.Schedules": {
+            "type": "object",
+            "properties": {
+                "leader": {
+                    "description": "Leader is the worker instance that holds the scheduler lock, empty when\nno worker is running",
+                    "type": "string"
+                },
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Schedule"
+                    }
+                }
+            }
+        },
+        "models.SetLimitOverrideRequest": {
+            "type": "object",
+            "properties": {
+                "daily": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "monthly": {
+                    "type": "number",
+                    "minimum": 0
                 },
-                "userId": {
-                    "type": "string"
+                "per_transaction": {
+                    "type": "number",
+                    "minimum": 0
                 }
             }
         },
-        "models.OrderStatus": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "completed",
-                "failed",
-                "cancelled"
-            ],
-            "x-enum-comments": {
-                "OrderStatusFailed": "Add this - when payment/processing fails"
-            },
-            "x-enum-varnames": [
-                "OrderStatusPending",
-                "OrderStatusCompleted",
-                "OrderStatusFailed",
-                "OrderStatusCancelled"
-            ]
-        },
         "models.Skin": {
             "type": "object",
             "properties": {
@@ -1014,6 +5797,10 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "expires_at": {
+                    "description": "ExpiresAt is when the listing ends; once passed on an unlisted skin\nthe listing expired and can be relisted",
+                    "type": "string"
+                },
                 "gun": {
                     "$ref": "#/definitions/models.Gun"
                 },
@@ -1023,6 +5810,10 @@ const docTemplate = `{
                 "image": {
                     "type": "string"
                 },
+                "listing_days": {
+                    "description": "ListingDays is how long the current or last listing runs, nil when it\ndoesn't expire",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -1043,6 +5834,58 @@ const docTemplate = `{
                 }
             }
         },
+        "models.TaxReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "net_total": {
+                    "type": "number"
+                },
+                "period": {
+                    "$ref": "#/definitions/models.ReportPeriod"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaxReportRow"
+                    }
+                },
+                "tax_total": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaxReportRow": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "net_amount": {
+                    "type": "number"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "reverse_charge": {
+                    "type": "boolean"
+                },
+                "tax_amount": {
+                    "type": "number"
+                }
+            }
+        },
         "models.Transaction": {
             "type": "object",
             "properties": {
@@ -1055,6 +5898,9 @@ const docTemplate = `{
                 "balance_before": {
                     "type": "number"
                 },
+                "balance_type": {
+                    "$ref": "#/definitions/models.BalanceType"
+                },
                 "counterparty_id": {
                     "type": "string"
                 },
@@ -1081,21 +5927,104 @@ const docTemplate = `{
                 }
             }
         },
+        "models.TransactionPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "NextCursor fetches the next page; it is empty on the last page",
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Transaction"
+                    }
+                }
+            }
+        },
         "models.TransactionType": {
             "type": "string",
             "enum": [
                 "withdraw",
                 "deposit",
                 "purchase",
-                "sale"
+                "sale",
+                "fee",
+                "refund",
+                "reversal",
+                "dispute_hold",
+                "dispute_release",
+                "hold_release",
+                "withdraw_release"
             ],
             "x-enum-varnames": [
                 "Withdraw",
                 "Deposit",
                 "Purchase",
-                "Sale"
+                "Sale",
+                "Fee",
+                "Refund",
+                "Reversal",
+                "DisputeHold",
+                "DisputeRelease",
+                "HoldRelease",
+                "WithdrawRelease"
             ]
         },
+        "models.UpdateBillingRequest": {
+            "type": "object",
+            "required": [
+                "country"
+            ],
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "vat_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateTierRequest": {
+            "type": "object",
+            "required": [
+                "tier"
+            ],
+            "properties": {
+                "tier": {
+                    "enum": [
+                        "standard",
+                        "verified",
+                        "pro"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserTier"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.UserLimits": {
+            "type": "object",
+            "properties": {
+                "limits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LimitStatus"
+                    }
+                },
+                "overrides": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LimitOverride"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
         "models.UserLoginRequest": {
             "type": "object",
             "required": [
@@ -1117,11 +6046,26 @@ const docTemplate = `{
                 "balance": {
                     "type": "number"
                 },
+                "billing_country": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
+                "frozen_balance": {
+                    "type": "number"
+                },
                 "name": {
                     "type": "string"
+                },
+                "pending_balance": {
+                    "type": "number"
+                },
+                "reserved_balance": {
+                    "type": "number"
+                },
+                "vat_id": {
+                    "type": "string"
                 }
             }
         },
@@ -1144,6 +6088,147 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UserTier": {
+            "type": "string",
+            "enum": [
+                "standard",
+                "verified",
+                "pro"
+            ],
+            "x-enum-varnames": [
+                "TierStandard",
+                "TierVerified",
+                "TierPro"
+            ]
+        },
+        "models.WashTradeCluster": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "review_note": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "string"
+                },
+                "sales": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WashTradeSale"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.WashTradeStatus"
+                },
+                "trade_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "volume": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.WashTradeReport": {
+            "type": "object",
+            "properties": {
+                "clusters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WashTradeCluster"
+                    }
+                },
+                "clusters_found": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "sales_flagged": {
+                    "type": "integer"
+                },
+                "trades_analysed": {
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WashTradeSale": {
+            "type": "object",
+            "properties": {
+                "buyer_id": {
+                    "type": "string"
+                },
+                "cluster_id": {
+                    "type": "string"
+                },
+                "order_item_id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "reasons": {
+                    "description": "Reasons is a comma separated list of signals, see internal/surveillance",
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "skin_id": {
+                    "type": "string"
+                },
+                "sold_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WashTradeStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "cleared",
+                "confirmed"
+            ],
+            "x-enum-varnames": [
+                "WashTradeOpen",
+                "WashTradeCleared",
+                "WashTradeConfirmed"
+            ]
+        },
         "models.Wear": {
             "type": "string",
             "enum": [
@@ -1171,6 +6256,113 @@ const docTemplate = `{
                     "type": "number"
                 }
             }
+        },
+        "models.Withdrawal": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "provider_reference": {
+                    "type": "string"
+                },
+                "requires_review": {
+                    "type": "boolean"
+                },
+                "review_reason": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.WithdrawalStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WithdrawalStatus": {
+            "type": "string",
+            "enum": [
+                "pending_review",
+                "approved",
+                "processing",
+                "settled",
+                "failed",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "WithdrawalPendingReview",
+                "WithdrawalApproved",
+                "WithdrawalProcessing",
+                "WithdrawalSettled",
+                "WithdrawalFailed",
+                "WithdrawalRejected"
+            ]
+        },
+        "realtime.Event": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "old_price": {
+                    "type": "number"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "pending_balance": {
+                    "type": "number"
+                },
+                "skin": {
+                    "$ref": "#/definitions/models.Skin"
+                },
+                "type": {
+                    "$ref": "#/definitions/realtime.EventType"
+                }
+            }
+        },
+        "realtime.EventType": {
+            "type": "string",
+            "enum": [
+                "listing.created",
+                "listing.removed",
+                "listing.price_changed",
+                "skin.sold",
+                "balance.updated",
+                "order.updated",
+                "listing.expired"
+            ],
+            "x-enum-varnames": [
+                "EventListingCreated",
+                "EventListingRemoved",
+                "EventPriceChanged",
+                "EventSkinSold",
+                "EventBalanceUpdated",
+                "EventOrderUpdated",
+                "EventListingExpired"
+            ]
         }
     },
     "securityDefinitions": {
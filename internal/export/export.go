@@ -0,0 +1,134 @@
+// Package export writes transaction history in downloadable formats, one
+// transaction at a time so exports never have to fit in memory.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+func (f Format) Valid() bool {
+	return f == FormatCSV || f == FormatNDJSON
+}
+
+// ContentType is the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension is the file extension of the format, without the dot
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// TransactionWriter writes transactions one by one. Close flushes what is
+// buffered; nothing is guaranteed to reach the underlying writer before.
+type TransactionWriter interface {
+	Write(txn *models.Transaction) error
+	Close() error
+}
+
+// NewTransactionWriter returns a writer for the format. CSV output starts
+// with a header row.
+func NewTransactionWriter(format Format, w io.Writer) (TransactionWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+var csvHeader = []string{
+	"id", "created_at", "type", "balance_type", "amount", "balance_before", "balance_after",
+	"skin_id", "order_id", "counterparty_id", "description",
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(txn *models.Transaction) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+
+	description := ""
+	if txn.Description != nil {
+		description = *txn.Description
+	}
+	return c.w.Write([]string{
+		txn.ID.String(),
+		txn.CreatedAt.UTC().Format(time.RFC3339),
+		string(txn.Type),
+		string(txn.BalanceType),
+		amount(txn.Amount),
+		amount(txn.BalanceBefore),
+		amount(txn.BalanceAfter),
+		optionalID(txn.SkinID),
+		optionalID(txn.OrderID),
+		optionalID(txn.CounterpartyID),
+		description,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	// An empty export still gets its header
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(txn *models.Transaction) error {
+	return n.enc.Encode(txn)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.buf.Flush()
+}
+
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleTransaction() *models.Transaction {
+	skinID := uuid.MustParse("6f1c8c1e-2f7a-4d8e-9a52-0d6a1c3b9e11")
+	description := `Sold "AK-47 | Redline", field-tested`
+	return &models.Transaction{
+		ID:            uuid.MustParse("0b8e7f5a-3c2d-4e1f-8a9b-7c6d5e4f3a2b"),
+		Type:          models.Sale,
+		BalanceType:   models.BalancePending,
+		Amount:        12.5,
+		BalanceBefore: 0,
+		BalanceAfter:  12.5,
+		SkinID:        &skinID,
+		Description:   &description,
+		CreatedAt:     time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTransactionWriter(FormatCSV, &buf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.Write(sampleTransaction()))
+	assert.NoError(t, w.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "id,created_at,type,balance_type,amount,balance_before,balance_after,skin_id,order_id,counterparty_id,description", lines[0])
+		assert.Equal(t, `0b8e7f5a-3c2d-4e1f-8a9b-7c6d5e4f3a2b,2025-03-10T12:00:00Z,sale,pending,12.50,0.00,12.50,6f1c8c1e-2f7a-4d8e-9a52-0d6a1c3b9e11,,,"Sold ""AK-47 | Redline"", field-tested"`, lines[1])
+	}
+}
+
+func TestCSVWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTransactionWriter(FormatCSV, &buf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, strings.Join(csvHeader, ",")+"\n", buf.String())
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTransactionWriter(FormatNDJSON, &buf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.Write(sampleTransaction()))
+	assert.NoError(t, w.Write(sampleTransaction()))
+	assert.NoError(t, w.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var txn models.Transaction
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &txn))
+		assert.Equal(t, models.Sale, txn.Type)
+		assert.Equal(t, 12.5, txn.Amount)
+	}
+}
+
+func TestNewTransactionWriter_UnknownFormat(t *testing.T) {
+	_, err := NewTransactionWriter("xlsx", &bytes.Buffer{})
+
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	assert.False(t, Format("xlsx").Valid())
+}
@@ -0,0 +1,56 @@
+package fees
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+)
+
+// Fee is the platform commission charged to the seller of one item
+type Fee struct {
+	Amount  float64 `json:"amount"`
+	Percent float64 `json:"percent"`
+	Rule    string  `json:"rule"`
+}
+
+// Schedule resolves which fee rule applies to a sale
+type Schedule struct {
+	cfg config.FeeConfig
+}
+
+// NewSchedule rejects rules for unknown gun categories or seller tiers, which
+// would otherwise never apply
+func NewSchedule(cfg config.FeeConfig) (*Schedule, error) {
+	for category := range cfg.Categories {
+		if !models.GunCategory(category).Valid() {
+			return nil, fmt.Errorf("unknown gun category %q", category)
+		}
+	}
+	for tier := range cfg.Tiers {
+		if !models.UserTier(tier).Valid() {
+			return nil, fmt.Errorf("unknown seller tier %q", tier)
+		}
+	}
+	return &Schedule{cfg: cfg}, nil
+}
+
+// Calculate returns the fee for selling a skin of the given gun at price.
+// Seller tier rules win over gun category rules, which win over the default.
+// The fee never exceeds the price itself.
+func (s *Schedule) Calculate(price float64, gun models.Gun, sellerTier models.UserTier) Fee {
+	rule, name := s.cfg.Default, "default"
+	if r, ok := s.cfg.Categories[string(models.GetGunCategory(gun))]; ok {
+		rule, name = r, "category:"+string(models.GetGunCategory(gun))
+	}
+	if r, ok := s.cfg.Tiers[string(sellerTier)]; ok {
+		rule, name = r, "tier:"+string(sellerTier)
+	}
+
+	amount := math.Round(price*rule.Percent) / 100
+	amount = math.Max(amount, rule.Min)
+	amount = math.Min(amount, price)
+
+	return Fee{Amount: amount, Percent: rule.Percent, Rule: name}
+}
@@ -0,0 +1,66 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Calculate(t *testing.T) {
+	schedule, err := NewSchedule(config.FeeConfig{
+		Default: config.FeeRule{Percent: 5, Min: 0.05},
+		Categories: map[string]config.FeeRule{
+			"knife": {Percent: 3, Min: 1},
+		},
+		Tiers: map[string]config.FeeRule{
+			"pro": {Percent: 2, Min: 0},
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		price      float64
+		gun        models.Gun
+		tier       models.UserTier
+		wantAmount float64
+		wantRule   string
+	}{
+		{"default rule", 100, models.AK47, models.TierStandard, 5, "default"},
+		{"minimum applies", 0.5, models.AK47, models.TierStandard, 0.05, "default"},
+		{"category rule", 1000, models.Karambit, models.TierStandard, 30, "category:knife"},
+		{"category minimum", 10, models.Karambit, models.TierStandard, 1, "category:knife"},
+		{"tier wins over category", 1000, models.Karambit, models.TierPro, 20, "tier:pro"},
+		{"fee never exceeds price", 0.02, models.AK47, models.TierStandard, 0.02, "default"},
+		{"rounded to cents", 33.33, models.AK47, models.TierStandard, 1.67, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee := schedule.Calculate(tt.price, tt.gun, tt.tier)
+			assert.InDelta(t, tt.wantAmount, fee.Amount, 1e-9)
+			assert.Equal(t, tt.wantRule, fee.Rule)
+		})
+	}
+}
+
+func TestNewSchedule_UnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.FeeConfig
+		wantErr string
+	}{
+		{"unknown category", config.FeeConfig{Categories: map[string]config.FeeRule{"knives": {Percent: 3}}}, `unknown gun category "knives"`},
+		{"unknown tier", config.FeeConfig{Tiers: map[string]config.FeeRule{"gold": {Percent: 2}}}, `unknown seller tier "gold"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := NewSchedule(tt.cfg)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, schedule)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+)
+
+// Rule types. Thresholds are upper bounds: a rule matches once the event
+// goes past them.
+const (
+	// TypeAmount matches a single movement of at least Threshold
+	TypeAmount = "amount"
+	// TypeVelocity matches more than Threshold movements of the kind within Window
+	TypeVelocity = "velocity"
+	// TypeVolume matches when the kind's total within Window would exceed Threshold
+	TypeVolume = "volume"
+	// TypeDepositThenWithdraw matches a withdrawal of at least Threshold (a
+	// ratio) of the money deposited within Window, once those deposits reach
+	// MinAmount
+	TypeDepositThenWithdraw = "deposit_then_withdraw"
+	// TypeCounterparty matches more than Threshold purchases from the same
+	// seller within Window
+	TypeCounterparty = "counterparty"
+	// TypeNewAccount matches accounts younger than Window moving at least
+	// Threshold, optionally only for skins of Category
+	TypeNewAccount = "new_account"
+)
+
+// Event is a money movement about to happen
+type Event struct {
+	Kind             models.RiskEventKind
+	UserID           uuid.UUID
+	Amount           float64
+	CounterpartyID   *uuid.UUID
+	Category         models.GunCategory
+	AccountCreatedAt time.Time
+	At               time.Time
+}
+
+// History answers questions about a user's earlier movements. The event
+// being evaluated must not be part of it yet.
+type History interface {
+	Sum(ctx context.Context, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (float64, error)
+	Count(ctx context.Context, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (int, error)
+	CountWith(ctx context.Context, userID uuid.UUID, kind models.RiskEventKind, counterpartyID uuid.UUID, since time.Time) (int, error)
+}
+
+// Hit is a rule that matched an event
+type Hit struct {
+	Rule   string
+	Type   string
+	Action models.RiskAction
+	Detail string
+}
+
+// Decision is the strongest action of all matching rules
+type Decision struct {
+	Action models.RiskAction
+	Hits   []Hit
+}
+
+// Engine evaluates events against the configured rules
+type Engine struct {
+	rules []config.RiskRule
+}
+
+// NewEngine validates the rules and returns an engine for them
+func NewEngine(rules []config.RiskRule) (*Engine, error) {
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if err := validate(r); err != nil {
+			return nil, fmt.Errorf("risk rule %q: %w", r.Name, err)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("risk rule %q is defined twice", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return &Engine{rules: rules}, nil
+}
+
+func validate(r config.RiskRule) error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if !models.RiskEventKind(r.Kind).Valid() {
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+	if !models.RiskAction(r.Action).Valid() {
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.Threshold <= 0 {
+		return errors.New("threshold must be greater than zero")
+	}
+	if r.MinAmount < 0 {
+		return errors.New("min_amount must not be negative")
+	}
+
+	switch r.Type {
+	case TypeAmount:
+		return nil
+	case TypeVelocity, TypeVolume, TypeNewAccount:
+	case TypeDepositThenWithdraw:
+		if models.RiskEventKind(r.Kind) != models.RiskWithdraw {
+			return errors.New("deposit_then_withdraw only applies to withdrawals")
+		}
+	case TypeCounterparty:
+		if models.RiskEventKind(r.Kind) != models.RiskPurchase {
+			return errors.New("counterparty only applies to purchases")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", r.Type)
+	}
+	if r.Window <= 0 {
+		return errors.New("window must be greater than zero")
+	}
+	return nil
+}
+
+// Rules returns the engine's rules
+func (e *Engine) Rules() []config.RiskRule {
+	return e.rules
+}
+
+// MaxWindow is the longest window of any rule, i.e. how much history the
+// rules can look at
+func (e *Engine) MaxWindow() time.Duration {
+	var longest time.Duration
+	for _, r := range e.rules {
+		if w := time.Duration(r.Window); w > longest {
+			longest = w
+		}
+	}
+	return longest
+}
+
+// Evaluate runs every rule for the event's kind and returns the strongest
+// action among the ones that matched, allow when none did
+func (e *Engine) Evaluate(ctx context.Context, ev Event, h History) (Decision, error) {
+	decision := Decision{Action: models.RiskActionAllow}
+	for _, r := range e.rules {
+		if models.RiskEventKind(r.Kind) != ev.Kind {
+			continue
+		}
+		detail, matched, err := match(ctx, r, ev, h)
+		if err != nil {
+			return Decision{}, fmt.Errorf("risk rule %q: %w", r.Name, err)
+		}
+		if !matched {
+			continue
+		}
+
+		action := models.RiskAction(r.Action)
+		decision.Hits = append(decision.Hits, Hit{Rule: r.Name, Type: r.Type, Action: action, Detail: detail})
+		if action.Stronger(decision.Action) {
+			decision.Action = action
+		}
+	}
+	return decision, nil
+}
+
+func match(ctx context.Context, r config.RiskRule, ev Event, h History) (string, bool, error) {
+	window := time.Duration(r.Window)
+	since := ev.At.Add(-window)
+
+	switch r.Type {
+	case TypeAmount:
+		return fmt.Sprintf("%s of %.2f, threshold %.2f", ev.Kind, ev.Amount, r.Threshold), ev.Amount >= r.Threshold, nil
+
+	case TypeVelocity:
+		count, err := h.Count(ctx, ev.UserID, ev.Kind, since)
+		if err != nil {
+			return "", false, err
+		}
+		count++
+		return fmt.Sprintf("%d %s within %s, threshold %.0f", count, ev.Kind, window, r.Threshold), float64(count) > r.Threshold, nil
+
+	case TypeVolume:
+		sum, err := h.Sum(ctx, ev.UserID, ev.Kind, since)
+		if err != nil {
+			return "", false, err
+		}
+		total := sum + ev.Amount
+		return fmt.Sprintf("%s volume of %.2f within %s, threshold %.2f", ev.Kind, total, window, r.Threshold), total > r.Threshold, nil
+
+	case TypeDepositThenWithdraw:
+		deposited, err := h.Sum(ctx, ev.UserID, models.RiskDeposit, since)
+		if err != nil {
+			return "", false, err
+		}
+		if deposited <= 0 || deposited < r.MinAmount {
+			return "", false, nil
+		}
+		return fmt.Sprintf("withdrawing %.2f of %.2f deposited within %s", ev.Amount, deposited, window), ev.Amount >= r.Threshold*deposited, nil
+
+	case TypeCounterparty:
+		if ev.CounterpartyID == nil {
+			return "", false, nil
+		}
+		count, err := h.CountWith(ctx, ev.UserID, ev.Kind, *ev.CounterpartyID, since)
+		if err != nil {
+			return "", false, err
+		}
+		count++
+		return fmt.Sprintf("%d %s with %s within %s, threshold %.0f", count, ev.Kind, *ev.CounterpartyID, window, r.Threshold), float64(count) > r.Threshold, nil
+
+	case TypeNewAccount:
+		if r.Category != "" && models.GunCategory(r.Category) != ev.Category {
+			return "", false, nil
+		}
+		age := ev.At.Sub(ev.AccountCreatedAt)
+		matched := age < window && ev.Amount >= r.Threshold
+		return fmt.Sprintf("%s of %.2f from an account created %s ago", ev.Kind, ev.Amount, age.Round(time.Minute)), matched, nil
+	}
+	return "", false, fmt.Errorf("unknown type %q", r.Type)
+}
@@ -0,0 +1,199 @@
+package fraud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEngine_Validation(t *testing.T) {
+	valid := config.RiskRule{Name: "r", Type: TypeVelocity, Kind: "withdraw", Window: config.Duration(time.Hour), Threshold: 3, Action: "flag"}
+
+	tests := []struct {
+		name    string
+		edit    func(r *config.RiskRule)
+		wantErr string
+	}{
+		{name: "valid", edit: func(r *config.RiskRule) {}},
+		{name: "unknown type", edit: func(r *config.RiskRule) { r.Type = "magic" }, wantErr: `risk rule "r": unknown type "magic"`},
+		{name: "unknown kind", edit: func(r *config.RiskRule) { r.Kind = "transfer" }, wantErr: `risk rule "r": unknown kind "transfer"`},
+		{name: "unknown action", edit: func(r *config.RiskRule) { r.Action = "panic" }, wantErr: `risk rule "r": unknown action "panic"`},
+		{name: "missing window", edit: func(r *config.RiskRule) { r.Window = 0 }, wantErr: `risk rule "r": window must be greater than zero`},
+		{name: "amount needs no window", edit: func(r *config.RiskRule) { r.Type = TypeAmount; r.Window = 0 }},
+		{name: "deposit_then_withdraw on purchases", edit: func(r *config.RiskRule) { r.Type = TypeDepositThenWithdraw; r.Kind = "purchase" },
+			wantErr: `risk rule "r": deposit_then_withdraw only applies to withdrawals`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.edit(&rule)
+			_, err := NewEngine([]config.RiskRule{rule})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := NewEngine([]config.RiskRule{valid, valid})
+	assert.EqualError(t, err, `risk rule "r" is defined twice`)
+
+	_, err = NewEngine(config.DefaultRiskRules())
+	assert.NoError(t, err)
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	user, seller := uuid.New(), uuid.New()
+	engine, err := NewEngine(config.DefaultRiskRules())
+	assert.NoError(t, err)
+
+	history := func(activity ...*models.RiskActivity) History {
+		h := newMemoryHistory()
+		for _, a := range activity {
+			a.UserID = user
+			h.add(a)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		event   Event
+		history History
+		want    models.RiskAction
+		rules   []string
+	}{
+		{
+			name:    "quiet withdrawal",
+			event:   Event{Kind: models.RiskWithdraw, UserID: user, Amount: 50, AccountCreatedAt: now.AddDate(-1, 0, 0), At: now},
+			history: history(),
+			want:    models.RiskActionAllow,
+		},
+		{
+			name:  "withdrawing a fresh deposit",
+			event: Event{Kind: models.RiskWithdraw, UserID: user, Amount: 450, AccountCreatedAt: now.AddDate(-1, 0, 0), At: now},
+			history: history(
+				&models.RiskActivity{Kind: models.RiskDeposit, Amount: 500, CreatedAt: now.Add(-2 * time.Hour)},
+			),
+			want:  models.RiskActionHold,
+			rules: []string{"deposit_then_withdraw"},
+		},
+		{
+			name:  "deposit outside the window",
+			event: Event{Kind: models.RiskWithdraw, UserID: user, Amount: 450, AccountCreatedAt: now.AddDate(-1, 0, 0), At: now},
+			history: history(
+				&models.RiskActivity{Kind: models.RiskDeposit, Amount: 500, CreatedAt: now.Add(-25 * time.Hour)},
+			),
+			want: models.RiskActionAllow,
+		},
+		{
+			name:  "sixth withdrawal within an hour is blocked",
+			event: Event{Kind: models.RiskWithdraw, UserID: user, Amount: 10, AccountCreatedAt: now.AddDate(-1, 0, 0), At: now},
+			history: history(
+				&models.RiskActivity{Kind: models.RiskWithdraw, Amount: 10, CreatedAt: now.Add(-50 * time.Minute)},
+				&models.RiskActivity{Kind: models.RiskWithdraw, Amount: 10, CreatedAt: now.Add(-40 * time.Minute)},
+				&models.RiskActivity{Kind: models.RiskWithdraw, Amount: 10, CreatedAt: now.Add(-30 * time.Minute)},
+				&models.RiskActivity{Kind: models.RiskWithdraw, Amount: 10, CreatedAt: now.Add(-20 * time.Minute)},
+				&models.RiskActivity{Kind: models.RiskWithdraw, Amount: 10, CreatedAt: now.Add(-10 * time.Minute)},
+			),
+			want:  models.RiskActionBlock,
+			rules: []string{"withdraw_velocity"},
+		},
+		{
+			name: "new account buying an expensive knife",
+			event: Event{Kind: models.RiskPurchase, UserID: user, Amount: 800, CounterpartyID: &seller,
+				Category: models.CategoryKnife, AccountCreatedAt: now.Add(-48 * time.Hour), At: now},
+			history: history(),
+			want:    models.RiskActionHold,
+			rules:   []string{"new_account_knife"},
+		},
+		{
+			name: "new account buying an expensive rifle",
+			event: Event{Kind: models.RiskPurchase, UserID: user, Amount: 800, CounterpartyID: &seller,
+				Category: models.CategoryRifle, AccountCreatedAt: now.Add(-48 * time.Hour), At: now},
+			history: history(),
+			want:    models.RiskActionAllow,
+		},
+		{
+			name: "buying from the same seller again and again",
+			event: Event{Kind: models.RiskPurchase, UserID: user, Amount: 5, CounterpartyID: &seller,
+				Category: models.CategoryPistol, AccountCreatedAt: now.AddDate(-1, 0, 0), At: now},
+			history: history(
+				&models.RiskActivity{Kind: models.RiskPurchase, Amount: 5, CounterpartyID: &seller, CreatedAt: now.Add(-5 * time.Hour)},
+				&models.RiskActivity{Kind: models.RiskPurchase, Amount: 5, CounterpartyID: &seller, CreatedAt: now.Add(-4 * time.Hour)},
+				&models.RiskActivity{Kind: models.RiskPurchase, Amount: 5, CounterpartyID: &seller, CreatedAt: now.Add(-3 * time.Hour)},
+				&models.RiskActivity{Kind: models.RiskPurchase, Amount: 5, CounterpartyID: &seller, CreatedAt: now.Add(-2 * time.Hour)},
+				&models.RiskActivity{Kind: models.RiskPurchase, Amount: 5, CounterpartyID: &seller, CreatedAt: now.Add(-1 * time.Hour)},
+			),
+			want:  models.RiskActionFlag,
+			rules: []string{"repeat_seller"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := engine.Evaluate(ctx, tt.event, tt.history)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.want, decision.Action)
+			var rules []string
+			for _, hit := range decision.Hits {
+				rules = append(rules, hit.Rule)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func TestEngine_Evaluate_StrongestActionWins(t *testing.T) {
+	engine, err := NewEngine([]config.RiskRule{
+		{Name: "big", Type: TypeAmount, Kind: "deposit", Threshold: 100, Action: "flag"},
+		{Name: "huge", Type: TypeAmount, Kind: "deposit", Threshold: 1000, Action: "block"},
+		{Name: "audit", Type: TypeAmount, Kind: "deposit", Threshold: 1, Action: "allow"},
+	})
+	assert.NoError(t, err)
+
+	decision, err := engine.Evaluate(context.Background(), Event{Kind: models.RiskDeposit, Amount: 5000, At: time.Now()}, newMemoryHistory())
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RiskActionBlock, decision.Action)
+	assert.Len(t, decision.Hits, 3)
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	user := uuid.New()
+	engine, err := NewEngine([]config.RiskRule{
+		{Name: "fast_cashout", Type: TypeDepositThenWithdraw, Kind: "withdraw", Window: config.Duration(24 * time.Hour), Threshold: 0.8, Action: "hold"},
+	})
+	assert.NoError(t, err)
+
+	activity := []*models.RiskActivity{
+		// Before the replayed range, only feeds the history
+		{ID: uuid.New(), UserID: user, Kind: models.RiskDeposit, Amount: 1000, CreatedAt: start.Add(-time.Hour)},
+		{ID: uuid.New(), UserID: user, Kind: models.RiskWithdraw, Amount: 900, CreatedAt: start.Add(time.Hour)},
+		{ID: uuid.New(), UserID: user, Kind: models.RiskWithdraw, Amount: 50, CreatedAt: start.Add(2 * time.Hour)},
+		// After the range
+		{ID: uuid.New(), UserID: user, Kind: models.RiskWithdraw, Amount: 900, CreatedAt: start.Add(30 * time.Hour)},
+	}
+
+	report, err := Replay(context.Background(), engine, activity, start, start.Add(24*time.Hour), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Events)
+	assert.Equal(t, map[models.RiskAction]int{models.RiskActionHold: 1, models.RiskActionAllow: 1}, report.Decisions)
+	assert.Equal(t, map[string]int{"fast_cashout": 1}, report.RuleHits)
+	if assert.Len(t, report.Hits, 1) {
+		assert.Equal(t, activity[1].ID, report.Hits[0].TransactionID)
+	}
+	assert.False(t, report.Truncated)
+}
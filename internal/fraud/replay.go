@@ -0,0 +1,120 @@
+package fraud
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+)
+
+// Replay evaluates the engine's rules against past activity and reports what
+// they would have done. Activity must be sorted by time and should start
+// MaxWindow before from so the first evaluated events see a full history;
+// only events from from on are evaluated. At most maxHits hits are listed.
+func Replay(ctx context.Context, e *Engine, activity []*models.RiskActivity, from, to time.Time, maxHits int) (*models.RiskReplayReport, error) {
+	report := &models.RiskReplayReport{
+		From:      from,
+		To:        to,
+		Decisions: make(map[models.RiskAction]int),
+		RuleHits:  make(map[string]int),
+		Hits:      []models.RiskReplayHit{},
+	}
+	h := newMemoryHistory()
+
+	for _, a := range activity {
+		if a.CreatedAt.After(to) {
+			break
+		}
+		if !a.CreatedAt.Before(from) {
+			ev := Event{
+				Kind:             a.Kind,
+				UserID:           a.UserID,
+				Amount:           a.Amount,
+				CounterpartyID:   a.CounterpartyID,
+				AccountCreatedAt: a.AccountCreatedAt,
+				At:               a.CreatedAt,
+			}
+			if a.Gun != nil {
+				ev.Category = models.GetGunCategory(*a.Gun)
+			}
+
+			decision, err := e.Evaluate(ctx, ev, h)
+			if err != nil {
+				return nil, err
+			}
+			report.Events++
+			report.Decisions[decision.Action]++
+			for _, hit := range decision.Hits {
+				report.RuleHits[hit.Rule]++
+				if len(report.Hits) >= maxHits {
+					report.Truncated = true
+					continue
+				}
+				report.Hits = append(report.Hits, models.RiskReplayHit{
+					TransactionID: a.ID,
+					UserID:        a.UserID,
+					Kind:          a.Kind,
+					Amount:        a.Amount,
+					Rule:          hit.Rule,
+					Action:        hit.Action,
+					Detail:        hit.Detail,
+					At:            a.CreatedAt,
+				})
+			}
+		}
+		h.add(a)
+	}
+	return report, nil
+}
+
+// memoryHistory is a History over activity replayed so far
+type memoryHistory struct {
+	byUser map[uuid.UUID][]*models.RiskActivity
+}
+
+func newMemoryHistory() *memoryHistory {
+	return &memoryHistory{byUser: make(map[uuid.UUID][]*models.RiskActivity)}
+}
+
+func (h *memoryHistory) add(a *models.RiskActivity) {
+	h.byUser[a.UserID] = append(h.byUser[a.UserID], a)
+}
+
+// since returns the user's activity from the given time on
+func (h *memoryHistory) since(userID uuid.UUID, since time.Time) []*models.RiskActivity {
+	all := h.byUser[userID]
+	i := sort.Search(len(all), func(i int) bool { return !all[i].CreatedAt.Before(since) })
+	return all[i:]
+}
+
+func (h *memoryHistory) Sum(_ context.Context, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (float64, error) {
+	var sum float64
+	for _, a := range h.since(userID, since) {
+		if a.Kind == kind {
+			sum += a.Amount
+		}
+	}
+	return sum, nil
+}
+
+func (h *memoryHistory) Count(_ context.Context, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (int, error) {
+	count := 0
+	for _, a := range h.since(userID, since) {
+		if a.Kind == kind {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (h *memoryHistory) CountWith(_ context.Context, userID uuid.UUID, kind models.RiskEventKind, counterpartyID uuid.UUID, since time.Time) (int, error) {
+	count := 0
+	for _, a := range h.since(userID, since) {
+		if a.Kind == kind && a.CounterpartyID != nil && *a.CounterpartyID == counterpartyID {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+	users       *services.User
+	reports     *services.ReportService
+	marketplace *services.MarketplaceService
+}
+
+func NewAdminHandler(users *services.User, reports *services.ReportService, marketplace *services.MarketplaceService) *AdminHandler {
+	return &AdminHandler{users: users, reports: reports, marketplace: marketplace}
+}
+
+// GetFeeReport godoc
+// @Summary Get fee revenue report
+// @Description Aggregate the marketplace fees earned by the platform per day, week or month (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param period query string false "Aggregation period: day, week or month (default day)"
+// @Param from query string false "Start time (RFC3339), defaults to 30 days before to"
+// @Param to query string false "End time (RFC3339), defaults to now"
+// @Success 200 {object} models.RevenueReport "Fee revenue report"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reports/fees [get]
+func (h *AdminHandler) GetFeeReport(c *gin.Context) {
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	report, err := h.reports.GetFeeRevenue(c.Request.Context(), models.ReportPeriod(c.Query("period")), from, to)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetTaxReport godoc
+// @Summary Get VAT report
+// @Description Sum the VAT charged on purchases per day, week or month, buyer country and rate, with reverse-charged sales listed separately. Refunds count in the period they happened (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param period query string false "Aggregation period: day, week or month (default day)"
+// @Param from query string false "Start time (RFC3339), defaults to 30 days before to"
+// @Param to query string false "End time (RFC3339), defaults to now"
+// @Success 200 {object} models.TaxReport "VAT report"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reports/tax [get]
+func (h *AdminHandler) GetTaxReport(c *gin.Context) {
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	report, err := h.reports.GetTaxReport(c.Request.Context(), models.ReportPeriod(c.Query("period")), from, to)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// UpdateUserTier godoc
+// @Summary Update a user's seller tier
+// @Description Change the seller tier that selects the user's marketplace fee rule (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "User ID" format(uuid)
+// @Param request body models.UpdateTierRequest true "New tier"
+// @Success 200 {object} map[string]string "Tier updated"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{user_id}/tier [put]
+func (h *AdminHandler) UpdateUserTier(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid user_id"))
+		return
+	}
+
+	var req models.UpdateTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := h.users.UpdateTier(c.Request.Context(), userID, req.Tier); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID.String(), "tier": string(req.Tier)})
+}
+
+// RefundOrder godoc
+// @Summary Refund an order
+// @Description Undo a purchase: refund the buyer, debit the seller's proceeds, reverse the platform fee and return the skin to the seller (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path string true "Order ID" format(uuid)
+// @Param request body models.RefundOrderRequest true "Refund reason"
+// @Success 200 {object} models.Order "Refunded order"
+// @Failure 400 {object} ErrorResponse "Invalid request or order cannot be refunded"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/orders/{order_id}/refund [post]
+func (h *AdminHandler) RefundOrder(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid order_id"))
+		return
+	}
+
+	var req models.RefundOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	ord, err := h.marketplace.RefundOrder(c.Request.Context(), adminID, orderID, req.Reason)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ord)
+}
+
+// GetOrderHistory godoc
+// @Summary Get order status history
+// @Description List every status change of an order, oldest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path string true "Order ID" format(uuid)
+// @Success 200 {array} models.OrderStatusChange "Status history"
+// @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/orders/{order_id}/history [get]
+func (h *AdminHandler) GetOrderHistory(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid order_id"))
+		return
+	}
+
+	history, err := h.marketplace.GetOrderHistory(c.Request.Context(), orderID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
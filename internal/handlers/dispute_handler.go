@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/auth"
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DisputeHandler struct {
+	svc *services.DisputeService
+}
+
+func NewDisputeHandler(svc *services.DisputeService) *DisputeHandler {
+	return &DisputeHandler{svc: svc}
+}
+
+func isAdmin(c *gin.Context) bool {
+	role, ok := middleware.GetUserRole(c)
+	return ok && role == auth.Admin
+}
+
+// Open godoc
+// @Summary Open a dispute
+// @Description Dispute a completed order within the dispute window. The seller's proceeds from the order are frozen until an admin resolves it.
+// @Tags disputes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path string true "Order ID" format(uuid)
+// @Param request body models.OpenDisputeRequest true "Dispute reason"
+// @Success 201 {object} models.Dispute "Dispute opened"
+// @Failure 400 {object} ErrorResponse "Invalid request or order cannot be disputed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not your order"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Order already disputed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/orders/{order_id}/disputes [post]
+func (h *DisputeHandler) Open(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid order_id"))
+		return
+	}
+
+	var req models.OpenDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	d, err := h.svc.OpenDispute(c.Request.Context(), userID, orderID, req.Reason)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, d)
+}
+
+// ListMine godoc
+// @Summary List my disputes
+// @Description List the disputes the authenticated user is a buyer or seller in
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Dispute "Disputes"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /disputes [get]
+func (h *DisputeHandler) ListMine(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	disputes, err := h.svc.ListUserDisputes(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, disputes)
+}
+
+// Get godoc
+// @Summary Get a dispute
+// @Description Get a dispute with its message thread (buyer, seller or admin)
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Param dispute_id path string true "Dispute ID" format(uuid)
+// @Success 200 {object} models.DisputeDetails "Dispute with messages"
+// @Failure 400 {object} ErrorResponse "Invalid dispute ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not a party to the dispute"
+// @Failure 404 {object} ErrorResponse "Dispute not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /disputes/{dispute_id} [get]
+func (h *DisputeHandler) Get(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("dispute_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid dispute_id"))
+		return
+	}
+
+	details, err := h.svc.GetDispute(c.Request.Context(), userID, isAdmin(c), disputeID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, details)
+}
+
+// AddMessage godoc
+// @Summary Post a dispute message
+// @Description Add a message to the thread of an open dispute (buyer, seller or admin)
+// @Tags disputes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param dispute_id path string true "Dispute ID" format(uuid)
+// @Param request body models.DisputeMessageRequest true "Message"
+// @Success 201 {object} models.DisputeMessage "Message added"
+// @Failure 400 {object} ErrorResponse "Invalid request or dispute resolved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not a party to the dispute"
+// @Failure 404 {object} ErrorResponse "Dispute not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /disputes/{dispute_id}/messages [post]
+func (h *DisputeHandler) AddMessage(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("dispute_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid dispute_id"))
+		return
+	}
+
+	var req models.DisputeMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	message, err := h.svc.AddMessage(c.Request.Context(), userID, isAdmin(c), disputeID, req.Body)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, message)
+}
+
+// AdminList godoc
+// @Summary List disputes
+// @Description List disputes by status, open ones by default (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "open, refunded, partially_refunded or rejected (default open)"
+// @Success 200 {array} models.Dispute "Disputes"
+// @Failure 400 {object} ErrorResponse "Invalid status"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/disputes [get]
+func (h *DisputeHandler) AdminList(c *gin.Context) {
+	disputes, err := h.svc.ListDisputes(c.Request.Context(), models.DisputeStatus(c.Query("status")))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, disputes)
+}
+
+// Resolve godoc
+// @Summary Resolve a dispute
+// @Description Release the frozen proceeds and refund the order, refund part of it or reject the dispute (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param dispute_id path string true "Dispute ID" format(uuid)
+// @Param request body models.ResolveDisputeRequest true "Resolution"
+// @Success 200 {object} models.Dispute "Resolved dispute"
+// @Failure 400 {object} ErrorResponse "Invalid request or dispute already resolved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Dispute not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/disputes/{dispute_id}/resolve [post]
+func (h *DisputeHandler) Resolve(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("dispute_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid dispute_id"))
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	d, err := h.svc.ResolveDispute(c.Request.Context(), adminID, disputeID, req)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
@@ -139,6 +139,8 @@ func mapErrorCodeToHTTPStatus(code apperrors.ErrorCode) int {
 		return http.StatusUnauthorized // 401
 	case apperrors.CodeForbidden:
 		return http.StatusForbidden // 403
+	case apperrors.CodeLimitExceeded:
+		return http.StatusUnprocessableEntity // 422
 	case apperrors.CodeInternal:
 		return http.StatusInternalServerError // 500
 	default:
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/internal/ubl"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvoiceHandler struct {
+	svc *services.InvoiceService
+}
+
+func NewInvoiceHandler(svc *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{svc: svc}
+}
+
+// Download godoc
+// @Summary Download an order's invoice
+// @Description Get the invoice of one of the authenticated user's paid orders as a PDF, or as UBL 2.1 XML with format=xml or an Accept header asking for application/xml. The archived PDF is served; a missing one is regenerated
+// @Tags marketplace
+// @Produce application/pdf
+// @Produce application/xml
+// @Security BearerAuth
+// @Param order_id path string true "Order ID" format(uuid)
+// @Param format query string false "pdf or xml (default pdf)"
+// @Success 200 {file} file "Invoice PDF or UBL XML"
+// @Failure 400 {object} ErrorResponse "Invalid order ID, format or order not paid"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden - order not owned by user"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/orders/{order_id}/invoice [get]
+func (h *InvoiceHandler) Download(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid order_id"))
+		return
+	}
+
+	xml, err := wantsXML(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	inv, data, err := h.svc.Download(c.Request.Context(), userID, orderID, xml)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if xml {
+		c.Header("Content-Disposition", `attachment; filename="`+inv.Number+`.xml"`)
+		c.Data(http.StatusOK, ubl.ContentType, data)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+inv.Number+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// wantsXML picks the invoice format. An explicit format parameter wins over
+// the Accept header.
+func wantsXML(c *gin.Context) (bool, error) {
+	switch c.Query("format") {
+	case "xml":
+		return true, nil
+	case "pdf":
+		return false, nil
+	case "":
+		format := c.NegotiateFormat("application/pdf", ubl.ContentType, "text/xml")
+		return format == ubl.ContentType || format == "text/xml", nil
+	default:
+		return false, apperrors.NewValidationError("format must be pdf or xml")
+	}
+}
+
+// Resend godoc
+// @Summary Re-send an order's invoice
+// @Description Queue the invoice email to the order's buyer again (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path string true "Order ID" format(uuid)
+// @Success 202 {object} map[string]string "Invoice email queued"
+// @Failure 400 {object} ErrorResponse "Invalid order ID or order not paid"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Invoice email already queued"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/orders/{order_id}/invoice/resend [post]
+func (h *InvoiceHandler) Resend(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid order_id"))
+		return
+	}
+
+	if err := h.svc.Resend(c.Request.Context(), orderID); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+}
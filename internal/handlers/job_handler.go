@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type JobHandler struct {
+	svc       *services.JobService
+	schedules *services.ScheduleService
+}
+
+func NewJobHandler(svc *services.JobService, schedules *services.ScheduleService) *JobHandler {
+	return &JobHandler{svc: svc, schedules: schedules}
+}
+
+// ListQueues godoc
+// @Summary List background job queues
+// @Description List every background job queue with the number of jobs in each state and whether it is paused (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.QueueStats "Queues"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/queues [get]
+func (h *JobHandler) ListQueues(c *gin.Context) {
+	queues, err := h.svc.ListQueues(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, queues)
+}
+
+// ListTasks godoc
+// @Summary List a queue's jobs
+// @Description List the jobs of a queue in one state with their payloads and last errors; archived jobs are the ones that ran out of retries (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param queue path string true "Queue name"
+// @Param state query string false "pending, active, scheduled, retry, archived or completed (default archived)"
+// @Param page query int false "Page, starting at 1 (default 1)"
+// @Param page_size query int false "Jobs per page, at most 100 (default 20)"
+// @Success 200 {array} models.JobInfo "Jobs"
+// @Failure 400 {object} ErrorResponse "Invalid state or paging"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Queue not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/queues/{queue}/tasks [get]
+func (h *JobHandler) ListTasks(c *gin.Context) {
+	state := models.JobState(c.DefaultQuery("state", string(models.JobArchived)))
+
+	page, pageSize := 0, 0
+	if v := c.Query("page"); v != "" {
+		var err error
+		if page, err = strconv.Atoi(v); err != nil || page <= 0 {
+			HandleError(c, apperrors.NewValidationError("invalid page"))
+			return
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		var err error
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize <= 0 {
+			HandleError(c, apperrors.NewValidationError("invalid page_size"))
+			return
+		}
+	}
+
+	tasks, err := h.svc.ListTasks(c.Request.Context(), c.Param("queue"), state, page, pageSize)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// RetryTask godoc
+// @Summary Retry an archived job
+// @Description Run a job that ran out of retries again right away (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param queue path string true "Queue name"
+// @Param task_id path string true "Task ID"
+// @Success 202 {object} map[string]string "Job queued"
+// @Failure 400 {object} ErrorResponse "Job is not archived"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Queue or job not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/queues/{queue}/tasks/{task_id}/retry [post]
+func (h *JobHandler) RetryTask(c *gin.Context) {
+	if err := h.svc.RetryTask(c.Request.Context(), c.Param("queue"), c.Param("task_id")); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+}
+
+// DeleteTask godoc
+// @Summary Delete an archived job
+// @Description Drop a job that ran out of retries for good (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Param queue path string true "Queue name"
+// @Param task_id path string true "Task ID"
+// @Success 204 "Job deleted"
+// @Failure 400 {object} ErrorResponse "Job is not archived"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Queue or job not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/queues/{queue}/tasks/{task_id} [delete]
+func (h *JobHandler) DeleteTask(c *gin.Context) {
+	if err := h.svc.DeleteTask(c.Request.Context(), c.Param("queue"), c.Param("task_id")); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PauseQueue godoc
+// @Summary Pause a queue
+// @Description Stop the workers from processing a queue; new jobs are still queued and run once it is resumed (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param queue path string true "Queue name"
+// @Success 200 {object} map[string]string "Queue paused"
+// @Failure 400 {object} ErrorResponse "Queue is already paused"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Queue not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/queues/{queue}/pause [post]
+func (h *JobHandler) PauseQueue(c *gin.Context) {
+	if err := h.svc.PauseQueue(c.Request.Context(), c.Param("queue")); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "paused"})
+}
+
+// ResumeQueue godoc
+// @Summary Resume a queue
+// @Description Let the workers process a paused queue again (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param queue path string true "Queue name"
+// @Success 200 {object} map[string]string "Queue resumed"
+// @Failure 400 {object} ErrorResponse "Queue is not paused"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Queue not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/queues/{queue}/resume [post]
+func (h *JobHandler) ResumeQueue(c *gin.Context) {
+	if err := h.svc.ResumeQueue(c.Request.Context(), c.Param("queue")); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "resumed"})
+}
+
+// ListOrderJobs godoc
+// @Summary List an order's jobs
+// @Description List the background jobs of an order, such as its invoice, credit notes and market data, with their states (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path string true "Order ID" format(uuid)
+// @Success 200 {array} models.JobInfo "Jobs"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/orders/{order_id}/jobs [get]
+func (h *JobHandler) ListOrderJobs(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid order_id"))
+		return
+	}
+
+	jobs, err := h.svc.ListOrderJobs(c.Request.Context(), orderID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// ListSchedules godoc
+// @Summary List periodic jobs
+// @Description List the worker's periodic jobs with their schedules, when they were last queued and when they run next, and the worker instance queueing them (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Schedules "Schedules"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/jobs/schedules [get]
+func (h *JobHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.schedules.ListSchedules(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type LimitHandler struct {
+	svc *services.LimitService
+}
+
+func NewLimitHandler(svc *services.LimitService) *LimitHandler {
+	return &LimitHandler{svc: svc}
+}
+
+// GetMine godoc
+// @Summary Get my limits
+// @Description Per-transaction, rolling 24h and rolling 30 day limits on deposits, withdrawals and purchases, with how much of each is left. Null limits are unlimited.
+// @Tags transactions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.LimitStatus "Limits"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /limits [get]
+func (h *LimitHandler) GetMine(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	statuses, err := h.svc.GetLimits(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
+// AdminGet godoc
+// @Summary Get a user's limits
+// @Description Effective limits of a user and the overrides set for them (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "User ID" format(uuid)
+// @Success 200 {object} models.UserLimits "Limits"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{user_id}/limits [get]
+func (h *LimitHandler) AdminGet(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid user_id"))
+		return
+	}
+
+	limits, err := h.svc.GetUserLimits(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// SetOverride godoc
+// @Summary Override a user's limits
+// @Description Replace the user's tier limits for one kind. Omitted fields keep the tier's value, 0 lifts the limit (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "User ID" format(uuid)
+// @Param kind path string true "deposit, withdraw or purchase"
+// @Param request body models.SetLimitOverrideRequest true "Override"
+// @Success 200 {object} models.LimitOverride "Override"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{user_id}/limits/{kind} [put]
+func (h *LimitHandler) SetOverride(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid user_id"))
+		return
+	}
+
+	var req models.SetLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	override, err := h.svc.SetOverride(c.Request.Context(), adminID, userID, models.LimitKind(c.Param("kind")), req)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteOverride godoc
+// @Summary Remove a user's limit override
+// @Description Put the user back on their tier's limits for one kind (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "User ID" format(uuid)
+// @Param kind path string true "deposit, withdraw or purchase"
+// @Success 200 {object} map[string]string "Override removed"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Override not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{user_id}/limits/{kind} [delete]
+func (h *LimitHandler) DeleteOverride(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid user_id"))
+		return
+	}
+
+	kind := models.LimitKind(c.Param("kind"))
+	if err := h.svc.DeleteOverride(c.Request.Context(), userID, kind); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID.String(), "kind": string(kind)})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MarketDataHandler struct {
+	svc     *services.MarketDataService
+	pricing *services.PricingService
+}
+
+func NewMarketDataHandler(svc *services.MarketDataService, pricing *services.PricingService) *MarketDataHandler {
+	return &MarketDataHandler{svc: svc, pricing: pricing}
+}
+
+// parseTimeQuery reads an optional RFC3339 timestamp from the query string
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, apperrors.NewValidationError("invalid " + key + ", expected RFC3339 timestamp")
+	}
+	return t, nil
+}
+
+// GetCandles godoc
+// @Summary Get price candles
+// @Description Get OHLC candles (open/high/low/close/volume) of completed sales for a skin type
+// @Tags market data
+// @Produce json
+// @Param name query string true "Skin name"
+// @Param gun query string true "Gun"
+// @Param wear query string true "Wear"
+// @Param interval query string false "Bucket size: 1h, 1d or 1w (default 1d)"
+// @Param from query string false "Start time (RFC3339), defaults to 100 buckets before to"
+// @Param to query string false "End time (RFC3339), defaults to now"
+// @Success 200 {array} models.Candle "Candles ordered by bucket start"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/prices/candles [get]
+func (h *MarketDataHandler) GetCandles(c *gin.Context) {
+	var skinType models.SkinType
+	if err := c.ShouldBindQuery(&skinType); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	interval := models.CandleInterval(c.DefaultQuery("interval", string(models.Interval1d)))
+	candles, err := h.svc.GetCandles(c.Request.Context(), skinType, interval, from, to)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, candles)
+}
+
+// GetPriceSummary godoc
+// @Summary Get price summary
+// @Description Get the last sale price and the median sale price over a window for a skin type
+// @Tags market data
+// @Produce json
+// @Param name query string true "Skin name"
+// @Param gun query string true "Gun"
+// @Param wear query string true "Wear"
+// @Param days query int false "Median window in days (default 30, max 365)"
+// @Success 200 {object} models.PriceSummary "Price summary"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/prices [get]
+func (h *MarketDataHandler) GetPriceSummary(c *gin.Context) {
+	var skinType models.SkinType
+	if err := c.ShouldBindQuery(&skinType); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	days := 0
+	if v := c.Query("days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil || days <= 0 {
+			HandleError(c, apperrors.NewValidationError("invalid days"))
+			return
+		}
+	}
+
+	summary, err := h.svc.GetPriceSummary(c.Request.Context(), skinType, days)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// SuggestPrice godoc
+// @Summary Suggest a listing price
+// @Description Recommend a listing price for a skin from recent sales and the cheapest current listings of the same name/gun/wear, adjusted by the skin's condition within its wear band
+// @Tags market data
+// @Produce json
+// @Param skin_id path string true "Skin ID" format(uuid)
+// @Success 200 {object} models.PriceSuggestion "Price suggestion"
+// @Failure 400 {object} ErrorResponse "Invalid skin ID"
+// @Failure 404 {object} ErrorResponse "Skin not found or no market data"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/skins/{skin_id}/price-suggestion [get]
+func (h *MarketDataHandler) SuggestPrice(c *gin.Context) {
+	skinID, err := uuid.Parse(c.Param("skin_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid skin_id"))
+		return
+	}
+
+	suggestion, err := h.pricing.SuggestPriceForSkin(c.Request.Context(), skinID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, suggestion)
+}
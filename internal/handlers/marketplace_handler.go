@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"io"
 	"net/http"
 
 	"github.com/Uranury/RBK_finalProject/internal/middleware"
@@ -66,20 +68,25 @@ func (h *MarketplaceHandler) Purchase(c *gin.Context) {
 }
 
 type sellRequest struct {
-	SkinID string  `json:"skin_id" binding:"required"`
-	Price  float64 `json:"price" binding:"required,gt=0,lte=1000000" description:"price must be > 0 and <= 1,000,000"`
+	SkinID    string  `json:"skin_id" binding:"required"`
+	Price     float64 `json:"price" binding:"omitempty,gt=0,lte=1000000" description:"price must be > 0 and <= 1,000,000; ignored with auto_price"`
+	AutoPrice bool    `json:"auto_price" description:"list at the suggested market price"`
+	// DurationDays makes the listing expire, it stays listed without one
+	DurationDays int `json:"duration_days" binding:"omitempty,oneof=1 7 30" description:"listing duration in days: 1, 7 or 30; omit to keep it listed until sold or removed"`
 }
 
 // Sell godoc
 // @Summary Sell a skin
-// @Description Sell a skin that you own. Price must be > 0 and <= 1,000,000.
+// @Description Sell a skin that you own. Price must be > 0 and <= 1,000,000, or set auto_price to list at the suggested market price.
+// @Description With duration_days (1, 7 or 30) the listing expires and is taken down after that many days.
+// @Description The response contains the price suggestion and warns when the price deviates wildly from the market.
 // @Tags marketplace
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param sell body sellRequest true "Sell request"
-// @Success 201 {string} string "UUID of listed skin"
-// @Failure 400 {object} ErrorResponse "Invalid request (e.g., invalid skinID, invalid price, skin already listed)"
+// @Success 201 {object} models.ListingResult "Listed skin with final price and warnings"
+// @Failure 400 {object} ErrorResponse "Invalid request (e.g., invalid skinID, invalid price, skin already listed, no market data for auto price)"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 403 {object} ErrorResponse "Forbidden: skin ownership required"
 // @Failure 404 {object} ErrorResponse "Skin not found"
@@ -92,6 +99,11 @@ func (h *MarketplaceHandler) Sell(c *gin.Context) {
 		return
 	}
 
+	if !req.AutoPrice && req.Price == 0 {
+		HandleError(c, apperrors.NewValidationError("price is required unless auto_price is set"))
+		return
+	}
+
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		HandleError(c, apperrors.ErrUnauthorized)
@@ -104,11 +116,58 @@ func (h *MarketplaceHandler) Sell(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.SellSkin(c.Request.Context(), userID, skinID, req.Price); err != nil {
+	result, err := h.svc.SellSkin(c.Request.Context(), userID, skinID, req.Price, req.AutoPrice, req.DurationDays)
+	if err != nil {
 		HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, skinID.String())
+	c.JSON(http.StatusCreated, result)
+}
+
+type updatePriceRequest struct {
+	Price float64 `json:"price" binding:"required,gt=0,lte=1000000"`
+}
+
+// UpdatePrice godoc
+// @Summary Change listing price
+// @Description Change the price of a skin you currently have listed. Price must be > 0 and <= 1,000,000.
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param skin_id path string true "Listed skin ID" format(uuid)
+// @Param price body updatePriceRequest true "New price"
+// @Success 200 {string} string "UUID of repriced skin"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden: skin ownership required"
+// @Failure 404 {object} ErrorResponse "Skin not found or not listed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/skins/{skin_id}/price [patch]
+func (h *MarketplaceHandler) UpdatePrice(c *gin.Context) {
+	var req updatePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	skinID, err := uuid.Parse(c.Param("skin_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid skin_id"))
+		return
+	}
+
+	if err := h.svc.UpdateListingPrice(c.Request.Context(), userID, skinID, req.Price); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, skinID.String())
 }
 
 // RemoveFromListing godoc
@@ -151,6 +210,54 @@ func (h *MarketplaceHandler) RemoveFromListing(c *gin.Context) {
 	c.JSON(http.StatusOK, skinID.String())
 }
 
+type relistRequest struct {
+	AutoPrice    bool `json:"auto_price" description:"list at a refreshed market suggestion instead of the previous price"`
+	DurationDays *int `json:"duration_days" binding:"omitempty,oneof=1 7 30" description:"listing duration in days: 1, 7 or 30; defaults to the previous listing's"`
+}
+
+// Relist godoc
+// @Summary Relist an expired listing
+// @Description List a skin whose listing expired again, at its previous price and for the previous duration unless overridden. The body is optional.
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param skin_id path string true "Skin ID" format(uuid)
+// @Param relist body relistRequest false "Relist options"
+// @Success 201 {object} models.ListingResult "Listed skin with final price and warnings"
+// @Failure 400 {object} ErrorResponse "Invalid request, or the listing didn't expire"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden: skin ownership required"
+// @Failure 404 {object} ErrorResponse "Skin not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /marketplace/skins/{skin_id}/relist [post]
+func (h *MarketplaceHandler) Relist(c *gin.Context) {
+	var req relistRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		HandleError(c, err)
+		return
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	skinID, err := uuid.Parse(c.Param("skin_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid skin_id"))
+		return
+	}
+
+	result, err := h.svc.RelistSkin(c.Request.Context(), userID, skinID, req.AutoPrice, req.DurationDays)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
 // ListAvailable godoc
 // @Summary List available skins
 // @Description Get all skins available for purchase in the marketplace
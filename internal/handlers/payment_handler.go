@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Webhook bodies larger than this are rejected before verification
+const maxWebhookBodyBytes = 64 << 10
+
+type PaymentHandler struct {
+	svc *services.PaymentService
+}
+
+func NewPaymentHandler(svc *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{svc: svc}
+}
+
+// Deposit godoc
+// @Summary Start a deposit
+// @Description Create a payment intent at the payment provider. Pay at the returned checkout URL; the balance is credited once the provider confirms the payment.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param deposit body models.DepositRequest true "Deposit request"
+// @Success 201 {object} models.PaymentIntent "Payment intent created"
+// @Failure 400 {object} ErrorResponse "Validation error"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /transactions/deposit [post]
+func (h *PaymentHandler) Deposit(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var req models.DepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	intent, err := h.svc.CreateDeposit(c.Request.Context(), userID, req.Amount)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, intent)
+}
+
+// GetDeposit godoc
+// @Summary Get a deposit
+// @Description Get the status of one of your deposits
+// @Tags transactions
+// @Produce json
+// @Security BearerAuth
+// @Param intent_id path string true "Payment intent ID" format(uuid)
+// @Success 200 {object} models.PaymentIntent "Payment intent"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Deposit not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /transactions/deposits/{intent_id} [get]
+func (h *PaymentHandler) GetDeposit(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	intentID, err := uuid.Parse(c.Param("intent_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid intent_id"))
+		return
+	}
+
+	intent, err := h.svc.GetDeposit(c.Request.Context(), userID, intentID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, intent)
+}
+
+// Webhook godoc
+// @Summary Payment provider webhook
+// @Description Receives signed payment notifications from the payment provider. The Payment-Signature header must carry a valid HMAC of the raw body; redelivered events are acknowledged without being applied again.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param Payment-Signature header string true "t=<unix seconds>,v1=<hex HMAC-SHA256>"
+// @Success 200 {object} map[string]string "Event accepted"
+// @Failure 400 {object} ErrorResponse "Unreadable body"
+// @Failure 401 {object} ErrorResponse "Invalid or expired signature"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/payments [post]
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes)
+	payload, err := c.GetRawData()
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("could not read request body"))
+		return
+	}
+
+	if err := h.svc.HandleWebhook(c.Request.Context(), payload, c.Request.Header); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReconciliationHandler struct {
+	svc *services.ReconciliationService
+}
+
+func NewReconciliationHandler(svc *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{svc: svc}
+}
+
+// ListReports godoc
+// @Summary List reconciliation reports
+// @Description List the most recent runs of the balance reconciliation job, without their discrepancies (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of reports, at most 100 (default 20)"
+// @Success 200 {array} models.ReconciliationReport "Reports"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reconciliation/reports [get]
+func (h *ReconciliationHandler) ListReports(c *gin.Context) {
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			HandleError(c, apperrors.NewValidationError("invalid limit"))
+			return
+		}
+	}
+
+	reports, err := h.svc.ListReports(c.Request.Context(), limit)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetReport godoc
+// @Summary Get a reconciliation report
+// @Description Get a reconciliation run with the discrepancies it found (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param report_id path string true "Report ID" format(uuid)
+// @Param kind query string false "Only discrepancies of this kind: balance_mismatch, pending_mismatch, frozen_mismatch, reserved_mismatch, chain_gap, broken_row or orphan_order"
+// @Success 200 {object} models.ReconciliationReport "Report"
+// @Failure 400 {object} ErrorResponse "Invalid ID or kind"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Report not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reconciliation/reports/{report_id} [get]
+func (h *ReconciliationHandler) GetReport(c *gin.Context) {
+	reportID, err := uuid.Parse(c.Param("report_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid report_id"))
+		return
+	}
+
+	report, err := h.svc.GetReport(c.Request.Context(), reportID, models.DiscrepancyKind(c.Query("kind")))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Run godoc
+// @Summary Run a reconciliation now
+// @Description Queue the balance reconciliation job instead of waiting for its schedule; the report shows up once the worker is done (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} map[string]string "Reconciliation queued"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "A reconciliation is already queued"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reconciliation/run [post]
+func (h *ReconciliationHandler) Run(c *gin.Context) {
+	if err := h.svc.Run(c.Request.Context()); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+}
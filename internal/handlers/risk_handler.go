@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RiskHandler struct {
+	svc *services.RiskService
+}
+
+func NewRiskHandler(svc *services.RiskService) *RiskHandler {
+	return &RiskHandler{svc: svc}
+}
+
+// ListFlags godoc
+// @Summary List risk flags
+// @Description List deposits, withdrawals, purchases and listings flagged by the risk rules, the open ones by default (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "open, cleared or confirmed (default open)"
+// @Success 200 {array} models.RiskFlag "Flags"
+// @Failure 400 {object} ErrorResponse "Invalid status"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/risk/flags [get]
+func (h *RiskHandler) ListFlags(c *gin.Context) {
+	flags, err := h.svc.ListFlags(c.Request.Context(), models.RiskFlagStatus(c.Query("status")))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// GetFlag godoc
+// @Summary Get a risk flag
+// @Description Get a flag with the rule hits that raised it (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param flag_id path string true "Flag ID" format(uuid)
+// @Success 200 {object} models.RiskFlag "Flag"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Flag not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/risk/flags/{flag_id} [get]
+func (h *RiskHandler) GetFlag(c *gin.Context) {
+	flagID, err := uuid.Parse(c.Param("flag_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid flag_id"))
+		return
+	}
+
+	flag, err := h.svc.GetFlag(c.Request.Context(), flagID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// ReviewFlag godoc
+// @Summary Review a risk flag
+// @Description Clear a flag as legitimate or confirm it as fraud. Clearing a held purchase lets the seller's proceeds be released (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param flag_id path string true "Flag ID" format(uuid)
+// @Param request body models.ReviewRiskFlagRequest true "Review"
+// @Success 200 {object} models.RiskFlag "Reviewed flag"
+// @Failure 400 {object} ErrorResponse "Invalid request or flag already reviewed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Flag not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/risk/flags/{flag_id}/review [post]
+func (h *RiskHandler) ReviewFlag(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	flagID, err := uuid.Parse(c.Param("flag_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid flag_id"))
+		return
+	}
+
+	var req models.ReviewRiskFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	flag, err := h.svc.ReviewFlag(c.Request.Context(), adminID, flagID, req.Status, req.Note)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// ListUserHits godoc
+// @Summary List a user's risk rule hits
+// @Description Audit trail of the risk rules a user's money movements matched, including allow-only rules (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "User ID" format(uuid)
+// @Param since query string false "RFC3339 start time (default 30 days ago)"
+// @Success 200 {array} models.RiskRuleHit "Rule hits"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/risk/users/{user_id}/hits [get]
+func (h *RiskHandler) ListUserHits(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid user_id"))
+		return
+	}
+	since, err := parseTimeQuery(c, "since")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	hits, err := h.svc.ListUserHits(c.Request.Context(), userID, since)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, hits)
+}
+
+// Replay godoc
+// @Summary Replay risk rules against history
+// @Description Run the configured rules, or the rules in the body, against past deposits, withdrawals, purchases and sales and report what they would have done. Nothing is recorded (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "RFC3339 start time (default 7 days before to)"
+// @Param to query string false "RFC3339 end time (default now)"
+// @Param request body models.RiskReplayRequest false "Rules to try instead of the configured ones"
+// @Success 200 {object} models.RiskReplayReport "Replay report"
+// @Failure 400 {object} ErrorResponse "Invalid range or rules"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/risk/replay [post]
+func (h *RiskHandler) Replay(c *gin.Context) {
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// The body is optional, without it the configured rules are replayed
+	var req models.RiskReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		HandleError(c, err)
+		return
+	}
+
+	report, err := h.svc.Replay(c.Request.Context(), from, to, req.Rules)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+type StatementHandler struct {
+	svc *services.StatementService
+}
+
+func NewStatementHandler(svc *services.StatementService) *StatementHandler {
+	return &StatementHandler{svc: svc}
+}
+
+// Download godoc
+// @Summary Download a monthly statement
+// @Description Get the authenticated user's account statement for a month as a PDF: opening balance, every transaction with the running balances, closing balance and totals per type
+// @Tags transactions
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param month path string true "Month as YYYY-MM"
+// @Success 200 {file} file "Statement PDF"
+// @Failure 400 {object} ErrorResponse "Invalid month"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /transactions/statements/{month} [get]
+func (h *StatementHandler) Download(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	month, err := time.Parse("2006-01", c.Param("month"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid month, expected YYYY-MM"))
+		return
+	}
+
+	pdf, err := h.svc.GeneratePDF(c.Request.Context(), userID, month)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="statement-`+month.Format("2006-01")+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamHeartbeatInterval = 25 * time.Second
+	wsWriteTimeout          = 10 * time.Second
+	wsPongTimeout           = 60 * time.Second
+)
+
+type StreamHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewStreamHandler accepts WebSocket connections from the allowed origins,
+// or only from the API's own origin when none are configured
+func NewStreamHandler(hub *realtime.Hub, allowedOrigins []string) *StreamHandler {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+	if len(allowedOrigins) > 0 {
+		upgrader.CheckOrigin = checkOrigin(allowedOrigins)
+	}
+	return &StreamHandler{hub: hub, upgrader: upgrader}
+}
+
+// checkOrigin lets non-browser clients, which send no Origin, and the
+// allowed origins through
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || slices.Contains(allowed, origin)
+	}
+}
+
+// subscribe parses the filter query parameters and attaches the client to the hub.
+// Authenticated clients additionally receive their private balance/order updates.
+func (h *StreamHandler) subscribe(c *gin.Context) (*realtime.Subscription, error) {
+	filter := realtime.Filter{
+		Gun:  models.Gun(c.Query("gun")),
+		Wear: models.Wear(c.Query("wear")),
+	}
+
+	var err error
+	if v := c.Query("min_price"); v != "" {
+		if filter.MinPrice, err = strconv.ParseFloat(v, 64); err != nil || filter.MinPrice < 0 {
+			return nil, apperrors.NewValidationError("invalid min_price")
+		}
+	}
+	if v := c.Query("max_price"); v != "" {
+		if filter.MaxPrice, err = strconv.ParseFloat(v, 64); err != nil || filter.MaxPrice < 0 {
+			return nil, apperrors.NewValidationError("invalid max_price")
+		}
+	}
+
+	var userID *uuid.UUID
+	if id, ok := middleware.GetUserID(c); ok {
+		userID = &id
+	}
+
+	return h.hub.Subscribe(userID, filter), nil
+}
+
+// Events godoc
+// @Summary Stream marketplace events (SSE)
+// @Description Server-Sent Events stream of listing.created, listing.removed, listing.price_changed and skin.sold events.
+// @Description Authenticated clients (Bearer header or token query param) also receive balance.updated and order.updated events.
+// @Tags marketplace
+// @Produce text/event-stream
+// @Param gun query string false "Only events for this gun"
+// @Param wear query string false "Only events for this wear"
+// @Param min_price query number false "Minimum skin price"
+// @Param max_price query number false "Maximum skin price"
+// @Param token query string false "JWT for private events"
+// @Success 200 {object} realtime.Event "Event stream"
+// @Failure 400 {object} ErrorResponse "Invalid filter"
+// @Failure 401 {object} ErrorResponse "Invalid token"
+// @Router /marketplace/stream [get]
+func (h *StreamHandler) Events(c *gin.Context) {
+	sub, err := h.subscribe(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	// The server-wide write timeout would otherwise cut the stream
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			c.SSEvent(string(event.Type), event)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// WebSocket godoc
+// @Summary Stream marketplace events (WebSocket)
+// @Description WebSocket stream with the same events and filters as /marketplace/stream. Each message is a JSON-encoded event.
+// @Tags marketplace
+// @Param gun query string false "Only events for this gun"
+// @Param wear query string false "Only events for this wear"
+// @Param min_price query number false "Minimum skin price"
+// @Param max_price query number false "Maximum skin price"
+// @Param token query string false "JWT for private events"
+// @Success 101 {object} realtime.Event "Switching protocols"
+// @Failure 400 {object} ErrorResponse "Invalid filter"
+// @Failure 401 {object} ErrorResponse "Invalid token"
+// @Router /marketplace/ws [get]
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	sub, err := h.subscribe(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade already replied with an HTTP error
+		return
+	}
+	defer conn.Close()
+
+	// Clients don't send anything meaningful; the read loop only handles
+	// control frames and notices disconnects.
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
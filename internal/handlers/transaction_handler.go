@@ -1,13 +1,17 @@
 package handlers
 
 import (
-	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Uranury/RBK_finalProject/internal/export"
 	"github.com/Uranury/RBK_finalProject/internal/middleware"
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type TransactionHandler struct {
@@ -18,99 +22,176 @@ func NewTransactionHandler(svc *services.TransactionService) *TransactionHandler
 	return &TransactionHandler{svc: svc}
 }
 
-// Withdraw godoc
-// @Summary Withdraw money from balance
-// @Description Withdraw a specified amount from the user's balance
-// @Tags transactions
-// @Accept json
-// @Produce json
-// @Security BearerAuth
-// @Param withdrawal body models.WithdrawRequest true "Withdrawal request"
-// @Success 200 {object} models.Transaction "Withdrawal successful"
-// @Failure 400 {object} ErrorResponse "Validation error"
-// @Failure 401 {object} ErrorResponse "Unauthorized"
-// @Failure 422 {object} ErrorResponse "Insufficient funds"
-// @Failure 500 {object} ErrorResponse "Internal server error"
-// @Router /transactions/withdraw [post]
-func (h *TransactionHandler) Withdraw(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
-		HandleError(c, apperrors.ErrUnauthorized)
-		return
-	}
+// parseTransactionFilter reads the history filters shared by the list and
+// export endpoints
+func parseTransactionFilter(c *gin.Context) (models.TransactionFilter, error) {
+	var filter models.TransactionFilter
+	var err error
 
-	var req models.WithdrawRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		HandleError(c, err)
-		return
+	if v := c.Query("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			filter.Types = append(filter.Types, models.TransactionType(strings.TrimSpace(t)))
+		}
+	}
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return filter, err
+	}
+	if filter.SkinID, err = parseUUIDQuery(c, "skin_id"); err != nil {
+		return filter, err
+	}
+	if filter.CounterpartyID, err = parseUUIDQuery(c, "counterparty_id"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount, err = parseAmountQuery(c, "min_amount"); err != nil {
+		return filter, err
 	}
+	if filter.MaxAmount, err = parseAmountQuery(c, "max_amount"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
 
-	transaction, err := h.svc.Withdraw(c.Request.Context(), userID, req.Amount)
+func parseUUIDQuery(c *gin.Context, key string) (*uuid.UUID, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(v)
 	if err != nil {
-		HandleError(c, err)
-		return
+		return nil, apperrors.NewValidationError("invalid " + key)
 	}
+	return &id, nil
+}
 
-	c.JSON(http.StatusOK, transaction)
+func parseAmountQuery(c *gin.Context, key string) (*float64, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, apperrors.NewValidationError("invalid " + key)
+	}
+	return &amount, nil
 }
 
-// Deposit godoc
-// @Summary Deposit money to balance
-// @Description Deposit a specified amount to the user's balance
+// GetHistory godoc
+// @Summary Get transaction history
+// @Description Get a page of the authenticated user's transactions, newest first. Pass next_cursor as cursor to get the following page
 // @Tags transactions
-// @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param deposit body models.DepositRequest true "Deposit request"
-// @Success 200 {object} models.Transaction "Deposit successful"
-// @Failure 400 {object} ErrorResponse "Validation error"
+// @Param type query string false "Comma-separated transaction types, e.g. deposit,withdraw,purchase,sale"
+// @Param from query string false "Start time (RFC3339), inclusive"
+// @Param to query string false "End time (RFC3339), exclusive"
+// @Param skin_id query string false "Skin ID" format(uuid)
+// @Param counterparty_id query string false "Counterparty user ID" format(uuid)
+// @Param min_amount query number false "Minimum absolute amount"
+// @Param max_amount query number false "Maximum absolute amount"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size, at most 200 (default 50)"
+// @Success 200 {object} models.TransactionPage "Transaction history"
+// @Failure 400 {object} ErrorResponse "Invalid filter or cursor"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 500 {object} ErrorResponse "Internal server error"
-// @Router /transactions/deposit [post]
-func (h *TransactionHandler) Deposit(c *gin.Context) {
+// @Router /transactions/history [get]
+func (h *TransactionHandler) GetHistory(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		HandleError(c, apperrors.ErrUnauthorized)
 		return
 	}
 
-	var req models.DepositRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			HandleError(c, apperrors.NewValidationError("invalid limit"))
+			return
+		}
+	}
 
-	transaction, err := h.svc.Deposit(c.Request.Context(), userID, req.Amount)
+	page, err := h.svc.ListTransactions(c.Request.Context(), userID, filter, c.Query("cursor"), limit)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transaction)
+	c.JSON(http.StatusOK, page)
 }
 
-// GetHistory godoc
-// @Summary Get transaction history
-// @Description Get the transaction history for the authenticated user
+// exportWriter sends the download headers right before the first byte of
+// the export, so validation errors can still be answered with JSON
+type exportWriter struct {
+	c      *gin.Context
+	format export.Format
+}
+
+func (w *exportWriter) start() {
+	if w.c.Writer.Written() {
+		return
+	}
+	w.c.Header("Content-Type", w.format.ContentType())
+	w.c.Header("Content-Disposition", `attachment; filename="transactions.`+w.format.Extension()+`"`)
+	w.c.Status(http.StatusOK)
+	w.c.Writer.WriteHeaderNow()
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.c.Writer.Write(p)
+}
+
+// Export godoc
+// @Summary Export transaction history
+// @Description Download every transaction of the authenticated user matching the filters, newest first, as CSV or newline-delimited JSON
 // @Tags transactions
-// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
 // @Security BearerAuth
-// @Success 200 {array} models.Transaction "Transaction history"
+// @Param format query string false "csv or ndjson (default csv)"
+// @Param type query string false "Comma-separated transaction types, e.g. deposit,withdraw,purchase,sale"
+// @Param from query string false "Start time (RFC3339), inclusive"
+// @Param to query string false "End time (RFC3339), exclusive"
+// @Param skin_id query string false "Skin ID" format(uuid)
+// @Param counterparty_id query string false "Counterparty user ID" format(uuid)
+// @Param min_amount query number false "Minimum absolute amount"
+// @Param max_amount query number false "Maximum absolute amount"
+// @Success 200 {file} file "Transaction export"
+// @Failure 400 {object} ErrorResponse "Invalid filter or format"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 500 {object} ErrorResponse "Internal server error"
-// @Router /transactions/history [get]
-func (h *TransactionHandler) GetHistory(c *gin.Context) {
+// @Router /transactions/history/export [get]
+func (h *TransactionHandler) Export(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		HandleError(c, apperrors.ErrUnauthorized)
 		return
 	}
 
-	transactions, err := h.svc.GetUserTransactions(c.Request.Context(), userID)
+	filter, err := parseTransactionFilter(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	format := export.Format(c.DefaultQuery("format", string(export.FormatCSV)))
 
-	c.JSON(http.StatusOK, transactions)
+	w := &exportWriter{c: c, format: format}
+	if err := h.svc.ExportTransactions(c.Request.Context(), userID, filter, format, w); err != nil {
+		if c.Writer.Written() {
+			// Too late for an error response. Dropping the connection keeps the
+			// client from saving a truncated file as if it were complete.
+			panic(http.ErrAbortHandler)
+		}
+		HandleError(c, err)
+		return
+	}
+	w.start()
 }
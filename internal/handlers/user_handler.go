@@ -104,3 +104,37 @@ func (h *UserHandler) Profile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, userProfile)
 }
+
+// UpdateBilling godoc
+// @Summary Set billing details
+// @Description Set the country the authenticated user is taxed in, and a VAT ID when buying as a business. VAT on purchases follows these details
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.UpdateBillingRequest true "Billing country and optional VAT ID"
+// @Success 200 {object} map[string]string "Billing details updated"
+// @Failure 400 {object} ErrorResponse "Invalid country or VAT ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /profile/billing [put]
+func (h *UserHandler) UpdateBilling(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var req models.UpdateBillingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := h.svc.UpdateBilling(c.Request.Context(), userID, req.Country, req.VATID); err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
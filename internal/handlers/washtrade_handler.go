@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WashTradeHandler struct {
+	svc *services.WashTradeService
+}
+
+func NewWashTradeHandler(svc *services.WashTradeService) *WashTradeHandler {
+	return &WashTradeHandler{svc: svc}
+}
+
+// ListReports godoc
+// @Summary List wash-trading reports
+// @Description List the most recent runs of the wash-trading detection job, without their clusters (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of reports, at most 100 (default 20)"
+// @Success 200 {array} models.WashTradeReport "Reports"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/wash-trading/reports [get]
+func (h *WashTradeHandler) ListReports(c *gin.Context) {
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			HandleError(c, apperrors.NewValidationError("invalid limit"))
+			return
+		}
+	}
+
+	reports, err := h.svc.ListReports(c.Request.Context(), limit)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetReport godoc
+// @Summary Get a wash-trading report
+// @Description Get a detection run with its suspicious clusters, their accounts and sales (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param report_id path string true "Report ID" format(uuid)
+// @Success 200 {object} models.WashTradeReport "Report"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Report not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/wash-trading/reports/{report_id} [get]
+func (h *WashTradeHandler) GetReport(c *gin.Context) {
+	reportID, err := uuid.Parse(c.Param("report_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid report_id"))
+		return
+	}
+
+	report, err := h.svc.GetReport(c.Request.Context(), reportID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ListClusters godoc
+// @Summary List wash-trading clusters
+// @Description List suspicious clusters by status, the open ones by default, most suspicious first (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "open, cleared or confirmed (default open)"
+// @Success 200 {array} models.WashTradeCluster "Clusters"
+// @Failure 400 {object} ErrorResponse "Invalid status"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/wash-trading/clusters [get]
+func (h *WashTradeHandler) ListClusters(c *gin.Context) {
+	clusters, err := h.svc.ListClusters(c.Request.Context(), models.WashTradeStatus(c.Query("status")))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, clusters)
+}
+
+// GetCluster godoc
+// @Summary Get a wash-trading cluster
+// @Description Get a cluster with its accounts and the sales that made it suspicious (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param cluster_id path string true "Cluster ID" format(uuid)
+// @Success 200 {object} models.WashTradeCluster "Cluster"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Cluster not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/wash-trading/clusters/{cluster_id} [get]
+func (h *WashTradeHandler) GetCluster(c *gin.Context) {
+	clusterID, err := uuid.Parse(c.Param("cluster_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid cluster_id"))
+		return
+	}
+
+	cluster, err := h.svc.GetCluster(c.Request.Context(), clusterID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cluster)
+}
+
+// ReviewCluster godoc
+// @Summary Review a wash-trading cluster
+// @Description Clear a cluster as legitimate trading, which returns its sales to the price statistics, or confirm it as wash trading (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param cluster_id path string true "Cluster ID" format(uuid)
+// @Param request body models.ReviewWashTradeRequest true "Review"
+// @Success 200 {object} models.WashTradeCluster "Reviewed cluster"
+// @Failure 400 {object} ErrorResponse "Invalid request or cluster already reviewed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Cluster not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/wash-trading/clusters/{cluster_id}/review [post]
+func (h *WashTradeHandler) ReviewCluster(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	clusterID, err := uuid.Parse(c.Param("cluster_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid cluster_id"))
+		return
+	}
+
+	var req models.ReviewWashTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	cluster, err := h.svc.ReviewCluster(c.Request.Context(), adminID, clusterID, req.Status, req.Note)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cluster)
+}
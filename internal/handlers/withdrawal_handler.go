@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WithdrawalHandler struct {
+	svc *services.WithdrawalService
+}
+
+func NewWithdrawalHandler(svc *services.WithdrawalService) *WithdrawalHandler {
+	return &WithdrawalHandler{svc: svc}
+}
+
+// Request godoc
+// @Summary Withdraw money from balance
+// @Description Request a payout. The amount is reserved from the available balance right away; withdrawals above the review threshold wait for admin approval, the others are paid out in the background.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param withdrawal body models.WithdrawRequest true "Withdrawal request"
+// @Success 202 {object} models.Withdrawal "Withdrawal accepted"
+// @Failure 400 {object} ErrorResponse "Validation error or insufficient funds"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /transactions/withdraw [post]
+func (h *WithdrawalHandler) Request(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var req models.WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	w, err := h.svc.RequestWithdrawal(c.Request.Context(), userID, req.Amount)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, w)
+}
+
+// ListMine godoc
+// @Summary List my withdrawals
+// @Description List the authenticated user's withdrawals and their payout status
+// @Tags transactions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Withdrawal "Withdrawals"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /transactions/withdrawals [get]
+func (h *WithdrawalHandler) ListMine(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	withdrawals, err := h.svc.ListUserWithdrawals(c.Request.Context(), userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+// AdminList godoc
+// @Summary List withdrawals
+// @Description List withdrawals by status, the ones waiting for review by default (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending_review, approved, processing, settled, failed or rejected (default pending_review)"
+// @Success 200 {array} models.Withdrawal "Withdrawals"
+// @Failure 400 {object} ErrorResponse "Invalid status"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/withdrawals [get]
+func (h *WithdrawalHandler) AdminList(c *gin.Context) {
+	withdrawals, err := h.svc.ListWithdrawals(c.Request.Context(), models.WithdrawalStatus(c.Query("status")))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+// Approve godoc
+// @Summary Approve a withdrawal
+// @Description Approve a withdrawal waiting for review and queue its payout (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param withdrawal_id path string true "Withdrawal ID" format(uuid)
+// @Success 200 {object} models.Withdrawal "Approved withdrawal"
+// @Failure 400 {object} ErrorResponse "Invalid ID or withdrawal not pending review"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Withdrawal not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/withdrawals/{withdrawal_id}/approve [post]
+func (h *WithdrawalHandler) Approve(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	withdrawalID, err := uuid.Parse(c.Param("withdrawal_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid withdrawal_id"))
+		return
+	}
+
+	w, err := h.svc.ApproveWithdrawal(c.Request.Context(), adminID, withdrawalID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// Reject godoc
+// @Summary Reject a withdrawal
+// @Description Reject a withdrawal waiting for review and return the reserved amount to the user's balance (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param withdrawal_id path string true "Withdrawal ID" format(uuid)
+// @Param request body models.RejectWithdrawalRequest true "Rejection reason"
+// @Success 200 {object} models.Withdrawal "Rejected withdrawal"
+// @Failure 400 {object} ErrorResponse "Invalid request or withdrawal not pending review"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Withdrawal not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/withdrawals/{withdrawal_id}/reject [post]
+func (h *WithdrawalHandler) Reject(c *gin.Context) {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		HandleError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	withdrawalID, err := uuid.Parse(c.Param("withdrawal_id"))
+	if err != nil {
+		HandleError(c, apperrors.NewValidationError("invalid withdrawal_id"))
+		return
+	}
+
+	var req models.RejectWithdrawalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	w, err := h.svc.RejectWithdrawal(c.Request.Context(), adminID, withdrawalID, req.Reason)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
@@ -1,6 +1,7 @@
 package http_server
 
 import (
+	"github.com/Uranury/RBK_finalProject/internal/auth"
 	"github.com/Uranury/RBK_finalProject/internal/middleware"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -8,11 +9,14 @@ import (
 
 func (s *Server) setupRoutes() {
 	protected := s.router.Group("/", middleware.JWTAuthMiddleware(s.authService))
+	optionalAuth := s.router.Group("/", middleware.OptionalJWTAuthMiddleware(s.authService))
+	admin := s.router.Group("/admin", middleware.JWTAuthMiddleware(s.authService), middleware.RequireRole(auth.Admin))
 	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	s.router.POST("/signup", s.userHandler.Signup)
 	s.router.POST("/login", s.userHandler.Login)
 	protected.GET("/profile", s.userHandler.Profile)
+	protected.PUT("/profile/billing", s.userHandler.UpdateBilling)
 
 	// Public endpoints
 	s.router.GET("/guns", s.skinHandler.GetGuns)
@@ -20,15 +24,73 @@ func (s *Server) setupRoutes() {
 
 	// Marketplace
 	s.router.GET("/marketplace/skins", s.marketplaceHandler.ListAvailable)
+	s.router.GET("/marketplace/prices", s.marketDataHandler.GetPriceSummary)
+	s.router.GET("/marketplace/prices/candles", s.marketDataHandler.GetCandles)
+	s.router.GET("/marketplace/skins/:skin_id/price-suggestion", s.marketDataHandler.SuggestPrice)
 	protected.GET("/marketplace/skins/mine", s.marketplaceHandler.ListMine)
 	protected.GET("/marketplace/orders/:order_id", s.marketplaceHandler.GetOrder)
+	protected.GET("/marketplace/orders/:order_id/invoice", s.invoiceHandler.Download)
 	protected.POST("/marketplace/purchase", s.marketplaceHandler.Purchase)
 	protected.DELETE("/marketplace/skins/:skin_id", s.marketplaceHandler.RemoveFromListing)
+	protected.PATCH("/marketplace/skins/:skin_id/price", s.marketplaceHandler.UpdatePrice)
+	protected.POST("/marketplace/skins/:skin_id/relist", s.marketplaceHandler.Relist)
 	protected.POST("/marketplace/sell", s.marketplaceHandler.Sell)
+	// Disputes
+	protected.POST("/marketplace/orders/:order_id/disputes", s.disputeHandler.Open)
+	protected.GET("/disputes", s.disputeHandler.ListMine)
+	protected.GET("/disputes/:dispute_id", s.disputeHandler.Get)
+	protected.POST("/disputes/:dispute_id/messages", s.disputeHandler.AddMessage)
+	// Realtime feed (public, private events when authenticated)
+	optionalAuth.GET("/marketplace/stream", s.streamHandler.Events)
+	optionalAuth.GET("/marketplace/ws", s.streamHandler.WebSocket)
 	// Skin creation (protected)
 	protected.POST("/skins", s.skinHandler.Create)
 	// Transactions
-	protected.POST("/transactions/withdraw", s.transactionHandler.Withdraw)
-	protected.POST("/transactions/deposit", s.transactionHandler.Deposit)
+	protected.POST("/transactions/withdraw", s.withdrawalHandler.Request)
+	protected.GET("/transactions/withdrawals", s.withdrawalHandler.ListMine)
+	protected.POST("/transactions/deposit", s.paymentHandler.Deposit)
+	protected.GET("/transactions/deposits/:intent_id", s.paymentHandler.GetDeposit)
 	protected.GET("/transactions/history", s.transactionHandler.GetHistory)
+	protected.GET("/transactions/history/export", s.transactionHandler.Export)
+	protected.GET("/transactions/statements/:month", s.statementHandler.Download)
+	protected.GET("/limits", s.limitHandler.GetMine)
+	// Payment provider callbacks, authenticated by their signature
+	s.router.POST("/webhooks/payments", s.paymentHandler.Webhook)
+	// Admin
+	admin.GET("/reports/fees", s.adminHandler.GetFeeReport)
+	admin.GET("/reports/tax", s.adminHandler.GetTaxReport)
+	admin.PUT("/users/:user_id/tier", s.adminHandler.UpdateUserTier)
+	admin.GET("/users/:user_id/limits", s.limitHandler.AdminGet)
+	admin.PUT("/users/:user_id/limits/:kind", s.limitHandler.SetOverride)
+	admin.DELETE("/users/:user_id/limits/:kind", s.limitHandler.DeleteOverride)
+	admin.POST("/orders/:order_id/refund", s.adminHandler.RefundOrder)
+	admin.GET("/orders/:order_id/history", s.adminHandler.GetOrderHistory)
+	admin.POST("/orders/:order_id/invoice/resend", s.invoiceHandler.Resend)
+	admin.GET("/orders/:order_id/jobs", s.jobHandler.ListOrderJobs)
+	admin.GET("/disputes", s.disputeHandler.AdminList)
+	admin.POST("/disputes/:dispute_id/resolve", s.disputeHandler.Resolve)
+	admin.GET("/withdrawals", s.withdrawalHandler.AdminList)
+	admin.POST("/withdrawals/:withdrawal_id/approve", s.withdrawalHandler.Approve)
+	admin.POST("/withdrawals/:withdrawal_id/reject", s.withdrawalHandler.Reject)
+	admin.GET("/risk/flags", s.riskHandler.ListFlags)
+	admin.GET("/risk/flags/:flag_id", s.riskHandler.GetFlag)
+	admin.POST("/risk/flags/:flag_id/review", s.riskHandler.ReviewFlag)
+	admin.GET("/risk/users/:user_id/hits", s.riskHandler.ListUserHits)
+	admin.POST("/risk/replay", s.riskHandler.Replay)
+	admin.GET("/wash-trading/reports", s.washTradeHandler.ListReports)
+	admin.GET("/wash-trading/reports/:report_id", s.washTradeHandler.GetReport)
+	admin.GET("/wash-trading/clusters", s.washTradeHandler.ListClusters)
+	admin.GET("/wash-trading/clusters/:cluster_id", s.washTradeHandler.GetCluster)
+	admin.POST("/wash-trading/clusters/:cluster_id/review", s.washTradeHandler.ReviewCluster)
+	admin.GET("/reconciliation/reports", s.reconcileHandler.ListReports)
+	admin.GET("/reconciliation/reports/:report_id", s.reconcileHandler.GetReport)
+	admin.POST("/reconciliation/run", s.reconcileHandler.Run)
+	// Background jobs
+	admin.GET("/jobs/queues", s.jobHandler.ListQueues)
+	admin.GET("/jobs/schedules", s.jobHandler.ListSchedules)
+	admin.GET("/jobs/queues/:queue/tasks", s.jobHandler.ListTasks)
+	admin.POST("/jobs/queues/:queue/tasks/:task_id/retry", s.jobHandler.RetryTask)
+	admin.DELETE("/jobs/queues/:queue/tasks/:task_id", s.jobHandler.DeleteTask)
+	admin.POST("/jobs/queues/:queue/pause", s.jobHandler.PauseQueue)
+	admin.POST("/jobs/queues/:queue/resume", s.jobHandler.ResumeQueue)
 }
@@ -2,11 +2,13 @@ package http_server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/Uranury/RBK_finalProject/internal/auth"
 	"github.com/Uranury/RBK_finalProject/internal/handlers"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
 	"github.com/Uranury/RBK_finalProject/pkg/config"
 	"github.com/gin-gonic/gin"
 	"github.com/hibiken/asynq"
@@ -17,15 +19,32 @@ import (
 type Server struct {
 	router             *gin.Engine
 	httpServer         *http.Server
+	metricsServer      *http.Server
 	cfg                *config.Config
 	db                 *sqlx.DB
 	asynqClient        *asynq.Client
+	asynqInspector     *asynq.Inspector
 	authService        *auth.Service
 	redisClient        *redis.Client
 	userHandler        *handlers.UserHandler
 	marketplaceHandler *handlers.MarketplaceHandler
 	skinHandler        *handlers.SkinHandler
 	transactionHandler *handlers.TransactionHandler
+	statementHandler   *handlers.StatementHandler
+	streamHandler      *handlers.StreamHandler
+	marketDataHandler  *handlers.MarketDataHandler
+	adminHandler       *handlers.AdminHandler
+	disputeHandler     *handlers.DisputeHandler
+	withdrawalHandler  *handlers.WithdrawalHandler
+	paymentHandler     *handlers.PaymentHandler
+	limitHandler       *handlers.LimitHandler
+	riskHandler        *handlers.RiskHandler
+	washTradeHandler   *handlers.WashTradeHandler
+	reconcileHandler   *handlers.ReconciliationHandler
+	invoiceHandler     *handlers.InvoiceHandler
+	jobHandler         *handlers.JobHandler
+	hub                *realtime.Hub
+	stopHub            context.CancelFunc
 	logger             *slog.Logger
 }
 
@@ -34,17 +53,19 @@ func NewServer(
 	db *sqlx.DB,
 	redisClient *redis.Client,
 	asynqClient *asynq.Client,
+	asynqInspector *asynq.Inspector,
 	logger *slog.Logger) (*Server, error) {
 
 	router := gin.Default()
 
 	s := &Server{
-		router:      router,
-		cfg:         cfg,
-		db:          db,
-		asynqClient: asynqClient,
-		redisClient: redisClient,
-		logger:      logger,
+		router:         router,
+		cfg:            cfg,
+		db:             db,
+		asynqClient:    asynqClient,
+		asynqInspector: asynqInspector,
+		redisClient:    redisClient,
+		logger:         logger,
 	}
 
 	s.initHTTPServer()
@@ -58,6 +79,17 @@ func NewServer(
 }
 
 func (s *Server) Run() error {
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	s.stopHub = stopHub
+	go s.hub.Run(hubCtx)
+
+	go func() {
+		s.logger.Info("Serving metrics", "address", s.cfg.MetricsAddr)
+		if err := s.metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Metrics listener failed", "error", err)
+		}
+	}()
+
 	s.logger.Info("Server starting", "address", s.cfg.ListenAddr)
 	return s.httpServer.ListenAndServe()
 }
@@ -66,7 +98,16 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Starting graceful shutdown")
 	done := make(chan error, 1)
 
+	// Streaming connections never go idle on their own, so disconnect them first
+	if s.stopHub != nil {
+		s.stopHub()
+	}
+	s.hub.Close()
+
 	go func() {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			s.logger.Warn("Failed to stop metrics listener", "error", err)
+		}
 		if err := s.httpServer.Shutdown(ctx); err != nil {
 			s.logger.Error("HTTP http_server shutdown failed", "error", err)
 			done <- err
@@ -93,6 +134,13 @@ func (s *Server) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		if s.asynqInspector != nil {
+			s.logger.Info("Closing Asynq inspector...")
+			if err := s.asynqInspector.Close(); err != nil {
+				s.logger.Error("Failed to close Asynq inspector", "error", err)
+			}
+		}
+
 		s.logger.Info("Graceful shutdown completed")
 		done <- nil
 	}()
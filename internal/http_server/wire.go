@@ -1,16 +1,39 @@
 package http_server
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/Uranury/RBK_finalProject/internal/auth"
+	"github.com/Uranury/RBK_finalProject/internal/fees"
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
 	"github.com/Uranury/RBK_finalProject/internal/handlers"
+	"github.com/Uranury/RBK_finalProject/internal/limits"
+	"github.com/Uranury/RBK_finalProject/internal/metrics"
+	"github.com/Uranury/RBK_finalProject/internal/middleware"
+	"github.com/Uranury/RBK_finalProject/internal/payments"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	disputeRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/dispute"
+	holdRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/hold"
+	invoiceRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/invoice"
+	ledgerRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/ledger"
+	limitRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/limit"
+	marketDataRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/marketdata"
 	orderRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/order"
+	paymentRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/payment"
+	reconRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/reconciliation"
+	riskRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/risk"
 	skinRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/skin"
+	taxLedgerRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/taxledger"
 	transactionRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	washTradeRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/washtrade"
+	withdrawalRepoPkg "github.com/Uranury/RBK_finalProject/internal/repositories/withdrawal"
+	"github.com/Uranury/RBK_finalProject/internal/scheduler"
 	"github.com/Uranury/RBK_finalProject/internal/services"
+	"github.com/Uranury/RBK_finalProject/internal/storage"
+	"github.com/Uranury/RBK_finalProject/internal/tax"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,25 +43,97 @@ func (s *Server) initDependencies() error {
 	skinRepo := skinRepoPkg.NewRepository(s.db)
 	ordRepo := orderRepoPkg.NewRepository(s.db)
 	transactionRepo := transactionRepoPkg.NewRepository(s.db)
+	marketDataRepo := marketDataRepoPkg.NewRepository(s.db)
+	ledgerRepo := ledgerRepoPkg.NewRepository(s.db)
+	disputeRepo := disputeRepoPkg.NewRepository(s.db)
+	holdRepo := holdRepoPkg.NewRepository(s.db)
+	withdrawalRepo := withdrawalRepoPkg.NewRepository(s.db)
+	paymentRepo := paymentRepoPkg.NewRepository(s.db)
+	limitRepo := limitRepoPkg.NewRepository(s.db)
+	riskRepo := riskRepoPkg.NewRepository(s.db)
+	washTradeRepo := washTradeRepoPkg.NewRepository(s.db)
+	reconRepo := reconRepoPkg.NewRepository(s.db)
+	invoiceRepo := invoiceRepoPkg.NewRepository(s.db)
+	taxLedgerRepo := taxLedgerRepoPkg.NewRepository(s.db)
+
+	// Archive of generated documents, shared with the worker
+	blobs, err := storage.NewLocalStore(s.cfg.BlobDir)
+	if err != nil {
+		return err
+	}
+
+	// Connection pool gauges on /metrics
+	if err := metrics.RegisterDB(s.db); err != nil {
+		return fmt.Errorf("failed to register database metrics: %w", err)
+	}
+
+	// Realtime feed
+	publisher := realtime.NewRedisPublisher(s.redisClient)
+	s.hub = realtime.NewHub(s.redisClient, s.logger)
+
+	// Payment provider used for deposits and payouts
+	paymentProvider := payments.NewFakeProvider(s.cfg.PaymentWebhookSecret, s.logger)
+
+	// Fraud and velocity rules checked on every money movement
+	riskEngine, err := fraud.NewEngine(s.cfg.RiskRules)
+	if err != nil {
+		return fmt.Errorf("invalid risk rules: %w", err)
+	}
+
+	// Marketplace fee rules per gun category and seller tier
+	feeSchedule, err := fees.NewSchedule(s.cfg.Fees)
+	if err != nil {
+		return fmt.Errorf("invalid FEE_SCHEDULE: %w", err)
+	}
 
 	// Initialize services
 	s.authService = auth.NewService(s.cfg.JWTKey)
 	userService := services.NewUser(userRepo, s.authService, s.logger)
-	skinService := services.NewSkin(skinRepo, s.logger)
-	marketplaceService := services.NewMarketplaceService(skinRepo, ordRepo, userRepo, transactionRepo, s.asynqClient, s.db, s.logger)
-	transactionService := services.NewTransactionService(transactionRepo, userRepo, s.db, s.logger)
+	skinService := services.NewSkin(skinRepo, publisher, s.logger)
+	pricingService := services.NewPricingService(marketDataRepo, skinRepo, s.logger)
+	limitService := services.NewLimitService(limitRepo, userRepo, limits.NewPolicy(s.cfg.Limits), s.db, s.logger)
+	riskService := services.NewRiskService(riskRepo, riskEngine, s.db, s.logger)
+	marketDataService := services.NewMarketDataService(marketDataRepo, s.db, s.logger)
+	marketplaceService := services.NewMarketplaceService(skinRepo, ordRepo, userRepo, transactionRepo, ledgerRepo, holdRepo, taxLedgerRepo, s.asynqClient, publisher, pricingService, marketDataService, feeSchedule, tax.NewRules(s.cfg.Tax), limitService, riskService, s.cfg.TradeHold, s.db, s.logger)
+	transactionService := services.NewTransactionService(transactionRepo, s.asynqClient, s.logger)
+	statementService := services.NewStatementService(transactionRepo, s.logger)
+	reportService := services.NewReportService(ledgerRepo, taxLedgerRepo, s.logger)
+	disputeService := services.NewDisputeService(disputeRepo, ordRepo, userRepo, transactionRepo, holdRepo, marketplaceService, publisher, s.cfg.DisputeWindow, s.db, s.logger)
+	paymentService := services.NewPaymentService(paymentRepo, userRepo, transactionRepo, limitService, riskService, paymentProvider, publisher, s.db, s.logger)
+	withdrawalService := services.NewWithdrawalService(withdrawalRepo, userRepo, transactionRepo, limitService, riskService, paymentProvider, s.asynqClient, s.asynqInspector, publisher, s.cfg.WithdrawalReviewThreshold, s.db, s.logger)
+	washTradeService := services.NewWashTradeService(washTradeRepo, marketDataService, s.cfg.WashTrade, s.db, s.logger)
+	// Reconciliations run in the worker, the API only queues them
+	reconciliationService := services.NewReconciliationService(reconRepo, s.asynqClient, nil, s.db, s.logger)
+	invoiceService := services.NewInvoiceService(invoiceRepo, ordRepo, userRepo, blobs, s.asynqClient, s.asynqInspector, s.cfg.Company, s.cfg.Invoice, s.db, s.logger)
+	jobService := services.NewJobService(s.asynqInspector, transactionRepo, s.logger)
+	scheduleService := services.NewScheduleService(scheduler.Registry(s.cfg), scheduler.NewStore(s.redisClient), s.logger)
 
 	// Initialize handlers
 	s.userHandler = handlers.NewUserHandler(userService)
 	s.skinHandler = handlers.NewSkinHandler(skinService)
 	s.marketplaceHandler = handlers.NewMarketplaceHandler(marketplaceService)
 	s.transactionHandler = handlers.NewTransactionHandler(transactionService)
+	s.statementHandler = handlers.NewStatementHandler(statementService)
+	s.streamHandler = handlers.NewStreamHandler(s.hub, s.cfg.StreamAllowedOrigins)
+	s.marketDataHandler = handlers.NewMarketDataHandler(marketDataService, pricingService)
+	s.adminHandler = handlers.NewAdminHandler(userService, reportService, marketplaceService)
+	s.disputeHandler = handlers.NewDisputeHandler(disputeService)
+	s.withdrawalHandler = handlers.NewWithdrawalHandler(withdrawalService)
+	s.paymentHandler = handlers.NewPaymentHandler(paymentService)
+	s.limitHandler = handlers.NewLimitHandler(limitService)
+	s.riskHandler = handlers.NewRiskHandler(riskService)
+	s.washTradeHandler = handlers.NewWashTradeHandler(washTradeService)
+	s.reconcileHandler = handlers.NewReconciliationHandler(reconciliationService)
+	s.invoiceHandler = handlers.NewInvoiceHandler(invoiceService)
+	s.jobHandler = handlers.NewJobHandler(jobService, scheduleService)
 
 	return nil
 }
 
 func (s *Server) initHTTPServer() {
-	s.router = gin.Default()
+	// gin.Default's logger would log the token query parameter of stream clients
+	s.router = gin.New()
+	s.router.Use(middleware.Logger(), middleware.Recovery(), middleware.Metrics())
 
 	s.httpServer = &http.Server{
 		Addr:         s.cfg.ListenAddr,
@@ -47,4 +142,9 @@ func (s *Server) initHTTPServer() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+
+	// Metrics are scraped on their own listener, off the public one
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", metrics.Handler())
+	s.metricsServer = &http.Server{Addr: s.cfg.MetricsAddr, Handler: metricsMux, ReadHeaderTimeout: 5 * time.Second}
 }
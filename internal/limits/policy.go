@@ -0,0 +1,104 @@
+package limits
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+)
+
+const (
+	DailyWindow   = 24 * time.Hour
+	MonthlyWindow = 30 * 24 * time.Hour
+)
+
+// Usage is how much a user already moved within the rolling windows
+type Usage struct {
+	Daily   float64
+	Monthly float64
+}
+
+// Policy resolves the limits that apply to a user
+type Policy struct {
+	cfg config.LimitsConfig
+}
+
+func NewPolicy(cfg config.LimitsConfig) *Policy {
+	return &Policy{cfg: cfg}
+}
+
+// Rule returns the effective rule for a tier and kind with the user's
+// override applied. Unknown tiers get the standard tier's limits.
+func (p *Policy) Rule(tier models.UserTier, kind models.LimitKind, override *models.LimitOverride) config.LimitRule {
+	kinds, ok := p.cfg[string(tier)]
+	if !ok {
+		kinds = p.cfg[string(models.TierStandard)]
+	}
+	rule := kinds[string(kind)]
+
+	if override != nil {
+		if override.PerTransaction != nil {
+			rule.PerTransaction = *override.PerTransaction
+		}
+		if override.Daily != nil {
+			rule.Daily = *override.Daily
+		}
+		if override.Monthly != nil {
+			rule.Monthly = *override.Monthly
+		}
+	}
+	return rule
+}
+
+// Check returns a limit exceeded error when amount doesn't fit the rule on
+// top of what was already used
+func Check(kind models.LimitKind, rule config.LimitRule, amount float64, usage Usage) error {
+	if rule.PerTransaction > 0 && amount > rule.PerTransaction {
+		return apperrors.NewLimitExceededError(fmt.Sprintf(
+			"%s of %.2f exceeds the per-transaction limit of %.2f", kind, amount, rule.PerTransaction))
+	}
+	if rule.Daily > 0 && usage.Daily+amount > rule.Daily {
+		return apperrors.NewLimitExceededError(fmt.Sprintf(
+			"%s of %.2f exceeds the daily limit of %.2f, %.2f remaining", kind, amount, rule.Daily, remaining(rule.Daily, usage.Daily)))
+	}
+	if rule.Monthly > 0 && usage.Monthly+amount > rule.Monthly {
+		return apperrors.NewLimitExceededError(fmt.Sprintf(
+			"%s of %.2f exceeds the 30-day limit of %.2f, %.2f remaining", kind, amount, rule.Monthly, remaining(rule.Monthly, usage.Monthly)))
+	}
+	return nil
+}
+
+// Status describes the rule and usage for the user
+func Status(kind models.LimitKind, rule config.LimitRule, usage Usage, overridden bool) models.LimitStatus {
+	return models.LimitStatus{
+		Kind:           kind,
+		PerTransaction: limitPtr(rule.PerTransaction),
+		Daily:          window(rule.Daily, usage.Daily),
+		Monthly:        window(rule.Monthly, usage.Monthly),
+		Overridden:     overridden,
+	}
+}
+
+func window(limit, used float64) models.LimitWindow {
+	w := models.LimitWindow{Limit: limitPtr(limit), Used: used}
+	if limit > 0 {
+		r := remaining(limit, used)
+		w.Remaining = &r
+	}
+	return w
+}
+
+func remaining(limit, used float64) float64 {
+	return math.Max(0, math.Round((limit-used)*100)/100)
+}
+
+// limitPtr returns nil for unlimited (zero) limits
+func limitPtr(v float64) *float64 {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
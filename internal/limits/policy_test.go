@@ -0,0 +1,72 @@
+package limits
+
+import (
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestPolicy_Rule(t *testing.T) {
+	policy := NewPolicy(config.LimitsConfig{
+		"standard": {"withdraw": {PerTransaction: 100, Daily: 200, Monthly: 1000}},
+		"pro":      {"withdraw": {PerTransaction: 1000, Daily: 0, Monthly: 10000}},
+	})
+
+	assert.Equal(t, config.LimitRule{PerTransaction: 1000, Monthly: 10000}, policy.Rule(models.TierPro, models.LimitWithdraw, nil))
+	// Unknown tiers fall back to standard
+	assert.Equal(t, config.LimitRule{PerTransaction: 100, Daily: 200, Monthly: 1000}, policy.Rule("gold", models.LimitWithdraw, nil))
+	// Kinds without a rule are unlimited
+	assert.Equal(t, config.LimitRule{}, policy.Rule(models.TierStandard, models.LimitDeposit, nil))
+
+	override := &models.LimitOverride{Daily: floatPtr(5000), Monthly: floatPtr(0)}
+	assert.Equal(t, config.LimitRule{PerTransaction: 100, Daily: 5000, Monthly: 0},
+		policy.Rule(models.TierStandard, models.LimitWithdraw, override))
+}
+
+func TestCheck(t *testing.T) {
+	rule := config.LimitRule{PerTransaction: 100, Daily: 200, Monthly: 500}
+
+	tests := []struct {
+		name    string
+		amount  float64
+		usage   Usage
+		wantErr string
+	}{
+		{name: "within limits", amount: 100, usage: Usage{Daily: 100, Monthly: 400}},
+		{name: "per transaction", amount: 100.01, wantErr: "withdraw of 100.01 exceeds the per-transaction limit of 100.00"},
+		{name: "daily", amount: 60, usage: Usage{Daily: 150, Monthly: 150}, wantErr: "withdraw of 60.00 exceeds the daily limit of 200.00, 50.00 remaining"},
+		{name: "monthly", amount: 50, usage: Usage{Daily: 0, Monthly: 480}, wantErr: "withdraw of 50.00 exceeds the 30-day limit of 500.00, 20.00 remaining"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(models.LimitWithdraw, rule, tt.amount, tt.usage)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, apperrors.NewLimitExceededError(tt.wantErr), err)
+		})
+	}
+
+	assert.NoError(t, Check(models.LimitWithdraw, config.LimitRule{}, 1e9, Usage{Daily: 1e9, Monthly: 1e9}))
+}
+
+func TestStatus(t *testing.T) {
+	status := Status(models.LimitPurchase, config.LimitRule{Daily: 200}, Usage{Daily: 250, Monthly: 300}, true)
+
+	assert.Nil(t, status.PerTransaction)
+	assert.Equal(t, 200.0, *status.Daily.Limit)
+	assert.Equal(t, 0.0, *status.Daily.Remaining)
+	assert.Nil(t, status.Monthly.Limit)
+	assert.Nil(t, status.Monthly.Remaining)
+	assert.Equal(t, 300.0, status.Monthly.Used)
+	assert.True(t, status.Overridden)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"errors"
+
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	purchases = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "marketplace_purchases_total",
+		Help: "Completed skin purchases.",
+	})
+	salesVolume = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "marketplace_sales_volume_total",
+		Help: "Amount paid by buyers for completed purchases, tax included.",
+	})
+	failedPurchases = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketplace_purchases_failed_total",
+		Help: "Purchases that failed, by error code.",
+	}, []string{"code"})
+	deposits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "deposits_total",
+		Help: "Deposits settled by the payment provider, by status.",
+	}, []string{"status"})
+	depositVolume = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "deposits_amount_total",
+		Help: "Amount credited by succeeded deposits.",
+	})
+	withdrawals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "withdrawals_total",
+		Help: "Withdrawals requested, by the status they start in.",
+	}, []string{"status"})
+	withdrawalVolume = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "withdrawals_amount_total",
+		Help: "Amount reserved by requested withdrawals.",
+	})
+	payouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payouts_total",
+		Help: "Payouts finished by the payment provider, by status.",
+	}, []string{"status"})
+)
+
+var errorCodes = map[apperrors.ErrorCode]string{
+	apperrors.CodeInternal:      "internal",
+	apperrors.CodeNotFound:      "not_found",
+	apperrors.CodeAlreadyExists: "already_exists",
+	apperrors.CodeInvalidInput:  "invalid_input",
+	apperrors.CodeUnauthorized:  "unauthorized",
+	apperrors.CodeForbidden:     "forbidden",
+	apperrors.CodeValidation:    "validation",
+	apperrors.CodeLimitExceeded: "limit_exceeded",
+}
+
+// PurchaseCompleted records a committed purchase of amount, tax included
+func PurchaseCompleted(amount float64) {
+	purchases.Inc()
+	salesVolume.Add(amount)
+}
+
+// PurchaseFailed records a failed purchase by the code of its error
+func PurchaseFailed(err error) {
+	failedPurchases.WithLabelValues(errorCode(err)).Inc()
+}
+
+// DepositSettled records a deposit the provider confirmed or failed, only
+// succeeded ones add to the volume
+func DepositSettled(status string, succeeded bool, amount float64) {
+	deposits.WithLabelValues(status).Inc()
+	if succeeded {
+		depositVolume.Add(amount)
+	}
+}
+
+// WithdrawalRequested records a withdrawal with the status it starts in,
+// e.g. approved or waiting for review
+func WithdrawalRequested(status string, amount float64) {
+	withdrawals.WithLabelValues(status).Inc()
+	withdrawalVolume.Add(amount)
+}
+
+// PayoutFinished records a payout that settled or failed for good
+func PayoutFinished(status string) {
+	payouts.WithLabelValues(status).Inc()
+}
+
+// errorCode names the code of an application error, errors that aren't
+// one count as internal
+func errorCode(err error) string {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return errorCodes[apperrors.CodeInternal]
+	}
+	if name, ok := errorCodes[appErr.Code]; ok {
+		return name
+	}
+	return "unknown"
+}
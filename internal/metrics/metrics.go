@@ -0,0 +1,24 @@
+// Package metrics holds the Prometheus metrics of the API and the worker.
+// Both processes register on the default registry and only export the
+// series they record, e.g. task metrics only show up on the worker.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves every registered metric in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB exports the connection pool stats of db, such as open, in use
+// and idle connections and how long callers waited for one
+func RegisterDB(db *sqlx.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db.DB, "postgres"))
+}
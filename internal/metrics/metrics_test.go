@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeInspector struct {
+	queues map[string]*asynq.QueueInfo
+	err    error
+}
+
+func (f *fakeInspector) Queues() ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	names := make([]string, 0, len(f.queues))
+	for name := range f.queues {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *fakeInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	return f.queues[queue], nil
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "validation", errorCode(apperrors.NewValidationError("insufficient funds")))
+	assert.Equal(t, "not_found", errorCode(apperrors.NewNotFoundError("skin not found")))
+	assert.Equal(t, "internal", errorCode(apperrors.WrapInternal(errors.New("boom"), "failed to commit transaction")))
+	assert.Equal(t, "internal", errorCode(errors.New("boom")))
+}
+
+func TestObserveRequest_UnmatchedRoute(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", UnmatchedRoute, "404"))
+
+	ObserveRequest("GET", "", 404, time.Millisecond)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues("GET", UnmatchedRoute, "404")))
+}
+
+func TestObserveTask(t *testing.T) {
+	processed := testutil.ToFloat64(tasksProcessed.WithLabelValues("test:task", "default"))
+	failed := testutil.ToFloat64(tasksFailed.WithLabelValues("test:task", "default"))
+
+	ObserveTask("test:task", "default", time.Second, nil)
+	ObserveTask("test:task", "default", time.Second, errors.New("boom"))
+
+	assert.Equal(t, processed+2, testutil.ToFloat64(tasksProcessed.WithLabelValues("test:task", "default")))
+	assert.Equal(t, failed+1, testutil.ToFloat64(tasksFailed.WithLabelValues("test:task", "default")))
+}
+
+func TestQueueCollector(t *testing.T) {
+	inspector := &fakeInspector{queues: map[string]*asynq.QueueInfo{
+		"default": {Queue: "default", Pending: 3, Active: 1, Retry: 2, Latency: 4 * time.Second, Paused: true},
+	}}
+
+	expected := `
+# HELP worker_queue_latency_seconds How long the oldest pending task of a queue has waited.
+# TYPE worker_queue_latency_seconds gauge
+worker_queue_latency_seconds{queue="default"} 4
+# HELP worker_queue_paused Whether a queue is paused.
+# TYPE worker_queue_paused gauge
+worker_queue_paused{queue="default"} 1
+# HELP worker_queue_tasks Tasks in a queue, by state.
+# TYPE worker_queue_tasks gauge
+worker_queue_tasks{queue="default",state="active"} 1
+worker_queue_tasks{queue="default",state="archived"} 0
+worker_queue_tasks{queue="default",state="pending"} 3
+worker_queue_tasks{queue="default",state="retry"} 2
+worker_queue_tasks{queue="default",state="scheduled"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(newQueueCollector(inspector), strings.NewReader(expected)))
+}
+
+func TestQueueCollector_InspectorError(t *testing.T) {
+	collector := newQueueCollector(&fakeInspector{err: errors.New("redis down")})
+
+	assert.Error(t, testutil.CollectAndCompare(collector, strings.NewReader("")))
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_tasks_processed_total",
+		Help: "Task runs, failed ones included, by task type and queue.",
+	}, []string{"task_type", "queue"})
+	tasksFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_tasks_failed_total",
+		Help: "Task runs that returned an error, by task type and queue.",
+	}, []string{"task_type", "queue"})
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "worker_task_duration_seconds",
+		Help: "Time to run a task, by task type and queue.",
+		// Tasks range from an email to a sweep over every order
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+	}, []string{"task_type", "queue"})
+)
+
+// ObserveTask records a task run, err is what the handler returned
+func ObserveTask(taskType, queue string, d time.Duration, err error) {
+	tasksProcessed.WithLabelValues(taskType, queue).Inc()
+	taskDuration.WithLabelValues(taskType, queue).Observe(d.Seconds())
+	if err != nil {
+		tasksFailed.WithLabelValues(taskType, queue).Inc()
+	}
+}
+
+// QueueInspector reads the state of the queues, it is implemented by
+// *asynq.Inspector
+type QueueInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+// queueCollector reads the queues from Redis on every scrape, so the depth
+// is current no matter which worker instance is scraped
+type queueCollector struct {
+	inspector QueueInspector
+	size      *prometheus.Desc
+	latency   *prometheus.Desc
+	paused    *prometheus.Desc
+}
+
+// RegisterQueues exports the number of tasks per queue and state, how long
+// the oldest pending task has waited and whether the queue is paused
+func RegisterQueues(inspector QueueInspector) error {
+	return prometheus.Register(newQueueCollector(inspector))
+}
+
+func newQueueCollector(inspector QueueInspector) *queueCollector {
+	return &queueCollector{
+		inspector: inspector,
+		size: prometheus.NewDesc("worker_queue_tasks",
+			"Tasks in a queue, by state.", []string{"queue", "state"}, nil),
+		latency: prometheus.NewDesc("worker_queue_latency_seconds",
+			"How long the oldest pending task of a queue has waited.", []string{"queue"}, nil),
+		paused: prometheus.NewDesc("worker_queue_paused",
+			"Whether a queue is paused.", []string{"queue"}, nil),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.latency
+	ch <- c.paused
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.size, err)
+		return
+	}
+	for _, queue := range queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.size, err)
+			continue
+		}
+		for state, n := range map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+		} {
+			ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(n), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+		paused := 0.0
+		if info.Paused {
+			paused = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.paused, prometheus.GaugeValue, paused, queue)
+	}
+}
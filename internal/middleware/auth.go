@@ -27,6 +27,44 @@ func JWTAuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 	}
 }
 
+// OptionalJWTAuthMiddleware authenticates the request when a token is present
+// but lets anonymous requests through. Browsers can't set headers on
+// WebSocket/EventSource requests, so the token may also come from the
+// "token" query parameter.
+func OptionalJWTAuthMiddleware(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			tokenString = c.Query("token")
+		}
+		if tokenString == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := authService.VerifyJWT(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// RequireRole rejects authenticated requests whose token doesn't carry the
+// given role. It must run after JWTAuthMiddleware.
+func RequireRole(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actual, ok := GetUserRole(c)
+		if !ok || actual != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
 func GetUserID(c *gin.Context) (uuid.UUID, bool) {
 	claimsVal, exists := c.Get("claims")
 	if !exists {
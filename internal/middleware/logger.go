@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger is gin's request logger without the query string, which can carry
+// the JWT of a stream client (see OptionalJWTAuthMiddleware)
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		path, _, _ := strings.Cut(p.Path, "?")
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			p.StatusCode,
+			p.Latency,
+			p.ClientIP,
+			p.Method,
+			path,
+			p.ErrorMessage,
+		)
+	})
+}
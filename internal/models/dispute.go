@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeOpen            DisputeStatus = "open"
+	DisputeRefunded        DisputeStatus = "refunded"
+	DisputePartialRefunded DisputeStatus = "partially_refunded"
+	DisputeRejected        DisputeStatus = "rejected"
+)
+
+// Valid reports whether the status is a known dispute status
+func (s DisputeStatus) Valid() bool {
+	switch s {
+	case DisputeOpen, DisputeRefunded, DisputePartialRefunded, DisputeRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+type DisputeResolution string
+
+const (
+	ResolutionRefund        DisputeResolution = "refund"
+	ResolutionPartialRefund DisputeResolution = "partial_refund"
+	ResolutionReject        DisputeResolution = "reject"
+)
+
+type Dispute struct {
+	ID           uuid.UUID     `json:"id" db:"id"`
+	OrderID      uuid.UUID     `json:"order_id" db:"order_id"`
+	BuyerID      uuid.UUID     `json:"buyer_id" db:"buyer_id"`
+	SellerID     *uuid.UUID    `json:"seller_id,omitempty" db:"seller_id"`
+	Status       DisputeStatus `json:"status" db:"status"`
+	Reason       string        `json:"reason" db:"reason"`
+	FrozenAmount float64       `json:"frozen_amount" db:"frozen_amount"`
+	RefundAmount float64       `json:"refund_amount" db:"refund_amount"`
+	Resolution   *string       `json:"resolution,omitempty" db:"resolution"`
+	ResolvedBy   *uuid.UUID    `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt   *time.Time    `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type DisputeMessage struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	DisputeID uuid.UUID `json:"dispute_id" db:"dispute_id"`
+	AuthorID  uuid.UUID `json:"author_id" db:"author_id"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// DisputeDetails is a dispute together with its message thread
+type DisputeDetails struct {
+	Dispute
+	Messages []*DisputeMessage `json:"messages"`
+}
+
+type OpenDisputeRequest struct {
+	Reason string `json:"reason" binding:"required,min=10,max=2000"`
+}
+
+type DisputeMessageRequest struct {
+	Body string `json:"body" binding:"required,max=2000"`
+}
+
+type ResolveDisputeRequest struct {
+	Resolution DisputeResolution `json:"resolution" binding:"required,oneof=refund partial_refund reject"`
+	// Amount refunded to the buyer, required for partial_refund
+	Amount float64 `json:"amount" binding:"omitempty,gt=0"`
+	Note   string  `json:"note" binding:"required,max=2000"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HoldStatus string
+
+const (
+	HoldHeld      HoldStatus = "held"
+	HoldReleased  HoldStatus = "released"
+	HoldCancelled HoldStatus = "cancelled"
+)
+
+// BalanceHold keeps sale proceeds in the seller's pending balance until
+// ReleaseAt, after which they become available for withdrawal
+type BalanceHold struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	OrderID     uuid.UUID  `json:"order_id" db:"order_id"`
+	OrderItemID *uuid.UUID `json:"order_item_id,omitempty" db:"order_item_id"`
+	Amount      float64    `json:"amount" db:"amount"`
+	Status      HoldStatus `json:"status" db:"status"`
+	ReleaseAt   time.Time  `json:"release_at" db:"release_at"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty" db:"released_at"`
+	// RiskFlagID keeps the hold from being released while the flag is open
+	RiskFlagID *uuid.UUID `json:"risk_flag_id,omitempty" db:"risk_flag_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
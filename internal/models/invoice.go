@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice is the numbered invoice issued for an order. Numbers run without
+// gaps per calendar year.
+type Invoice struct {
+	ID       uuid.UUID `json:"id" db:"id"`
+	OrderID  uuid.UUID `json:"order_id" db:"order_id"`
+	Number   string    `json:"number" db:"number"`
+	Year     int       `json:"year" db:"year"`
+	Sequence int       `json:"sequence" db:"sequence"`
+	IssuedAt time.Time `json:"issued_at" db:"issued_at"`
+	// BuyerName and BuyerEmail are the buyer's details when the invoice was issued
+	BuyerName  string `json:"buyer_name" db:"buyer_name"`
+	BuyerEmail string `json:"buyer_email" db:"buyer_email"`
+	// FileKey locates the archived PDF in blob storage, nil until it is stored
+	FileKey    *string    `json:"file_key,omitempty" db:"file_key"`
+	FileSize   *int       `json:"file_size,omitempty" db:"file_size"`
+	FileSHA256 *string    `json:"file_sha256,omitempty" db:"file_sha256"`
+	StoredAt   *time.Time `json:"stored_at,omitempty" db:"stored_at"`
+	// SentAt is when the invoice was last emailed to the buyer
+	SentAt *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+// InvoiceLine is one order item with the skin it sold, as it was when the
+// invoice was issued
+type InvoiceLine struct {
+	OrderItemID uuid.UUID  `db:"order_item_id"`
+	SkinID      uuid.UUID  `db:"skin_id"`
+	SkinName    string     `db:"skin_name"`
+	Gun         Gun        `db:"gun"`
+	Wear        Wear       `db:"wear"`
+	Condition   float64    `db:"condition"`
+	SellerID    *uuid.UUID `db:"seller_id"`
+	SellerName  *string    `db:"seller_name"`
+	Price       float64    `db:"price"`
+	Fee         float64    `db:"fee"`
+	Tax         float64    `db:"tax"`
+}
+
+// InvoiceDocument is everything printed on an invoice
+type InvoiceDocument struct {
+	Invoice    *Invoice
+	Order      *Order
+	BuyerName  string
+	BuyerEmail string
+	Lines      []*InvoiceLine
+	// Subtotal is the sum of the item prices before tax, Fees the marketplace
+	// fees the sellers paid out of them
+	Subtotal float64
+	Fees     float64
+	// Tax is the VAT charged on top of the subtotal
+	Tax float64
+}
+
+// Numbering series of invoices and credit notes
+const (
+	InvoiceSeries    = "INV"
+	CreditNoteSeries = "CN"
+)
+
+// CreditNote reverses all or part of an invoice after a refund. Amount is
+// negative.
+type CreditNote struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	InvoiceID uuid.UUID `json:"invoice_id" db:"invoice_id"`
+	OrderID   uuid.UUID `json:"order_id" db:"order_id"`
+	// TransactionID is the buyer's refund transaction the note documents
+	TransactionID uuid.UUID  `json:"transaction_id" db:"transaction_id"`
+	Number        string     `json:"number" db:"number"`
+	Year          int        `json:"year" db:"year"`
+	Sequence      int        `json:"sequence" db:"sequence"`
+	Amount        float64    `json:"amount" db:"amount"`
+	Reason        *string    `json:"reason,omitempty" db:"reason"`
+	IssuedAt      time.Time  `json:"issued_at" db:"issued_at"`
+	FileKey       *string    `json:"file_key,omitempty" db:"file_key"`
+	FileSize      *int       `json:"file_size,omitempty" db:"file_size"`
+	FileSHA256    *string    `json:"file_sha256,omitempty" db:"file_sha256"`
+	StoredAt      *time.Time `json:"stored_at,omitempty" db:"stored_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+// CreditNoteDocument is everything printed on a credit note
+type CreditNoteDocument struct {
+	CreditNote *CreditNote
+	Invoice    *Invoice
+	Order      *Order
+	BuyerName  string
+	BuyerEmail string
+	// Lines are the invoice lines with negated amounts when the whole order
+	// was refunded, and empty for a partial refund
+	Lines []*InvoiceLine
+}
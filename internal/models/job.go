@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobState is where a background job is in its queue
+type JobState string
+
+const (
+	JobPending   JobState = "pending"
+	JobActive    JobState = "active"
+	JobScheduled JobState = "scheduled"
+	// JobRetry is a job that failed and waits for its next attempt
+	JobRetry JobState = "retry"
+	// JobArchived is a job that ran out of retries, the dead-letter queue
+	JobArchived  JobState = "archived"
+	JobCompleted JobState = "completed"
+)
+
+func (s JobState) Valid() bool {
+	switch s {
+	case JobPending, JobActive, JobScheduled, JobRetry, JobArchived, JobCompleted:
+		return true
+	}
+	return false
+}
+
+// QueueStats is a snapshot of one background job queue
+type QueueStats struct {
+	Queue     string `json:"queue"`
+	Size      int    `json:"size"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	Completed int    `json:"completed"`
+	// Processed and Failed count today's jobs
+	Processed int  `json:"processed"`
+	Failed    int  `json:"failed"`
+	Paused    bool `json:"paused"`
+	// LatencySeconds is how long the oldest pending job has been waiting
+	LatencySeconds float64 `json:"latency_seconds"`
+}
+
+// JobInfo is one background job
+type JobInfo struct {
+	ID       string          `json:"id"`
+	Queue    string          `json:"queue"`
+	Type     string          `json:"type"`
+	State    JobState        `json:"state"`
+	Payload  json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	MaxRetry int             `json:"max_retry"`
+	Retried  int             `json:"retried"`
+	// LastError is the error of the last failed attempt
+	LastError     string     `json:"last_error,omitempty"`
+	LastFailedAt  *time.Time `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+// ScheduleRun is the last time the scheduler queued a periodic job
+type ScheduleRun struct {
+	At     time.Time `json:"at"`
+	TaskID string    `json:"task_id,omitempty"`
+	// Error says why the job wasn't queued, e.g. the previous run is still waiting
+	Error string `json:"error,omitempty"`
+}
+
+// Schedule is a periodic job of the worker
+type Schedule struct {
+	Name     string `json:"name"`
+	TaskType string `json:"task_type"`
+	Queue    string `json:"queue"`
+	// Spec is a cron spec or an @every interval, in UTC; empty when turned off
+	Spec      string       `json:"spec"`
+	Enabled   bool         `json:"enabled"`
+	LastRun   *ScheduleRun `json:"last_run,omitempty"`
+	NextRunAt *time.Time   `json:"next_run_at,omitempty"`
+}
+
+// Schedules are the worker's periodic jobs and the worker instance queueing them
+type Schedules struct {
+	// Leader is the worker instance that holds the scheduler lock, empty when
+	// no worker is running
+	Leader    string      `json:"leader,omitempty"`
+	Schedules []*Schedule `json:"schedules"`
+}
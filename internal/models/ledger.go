@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LedgerEntryType string
+
+const (
+	LedgerFee LedgerEntryType = "fee"
+)
+
+// LedgerEntry is a movement on the platform's own revenue account
+type LedgerEntry struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	OrderID     *uuid.UUID      `json:"order_id,omitempty" db:"order_id"`
+	OrderItemID *uuid.UUID      `json:"order_item_id,omitempty" db:"order_item_id"`
+	Type        LedgerEntryType `json:"type" db:"type"`
+	Amount      float64         `json:"amount" db:"amount"`
+	Description *string         `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+type ReportPeriod string
+
+const (
+	PeriodDay   ReportPeriod = "day"
+	PeriodWeek  ReportPeriod = "week"
+	PeriodMonth ReportPeriod = "month"
+)
+
+// Valid reports whether the period can be used with date_trunc
+func (p ReportPeriod) Valid() bool {
+	switch p {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// RevenueRow is one period of an aggregated ledger report
+type RevenueRow struct {
+	PeriodStart time.Time `json:"period_start" db:"period_start"`
+	Total       float64   `json:"total" db:"total"`
+	Entries     int       `json:"entries" db:"entries"`
+}
+
+type RevenueReport struct {
+	Type   LedgerEntryType `json:"type"`
+	Period ReportPeriod    `json:"period"`
+	From   time.Time       `json:"from"`
+	To     time.Time       `json:"to"`
+	Total  float64         `json:"total"`
+	Rows   []*RevenueRow   `json:"rows"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LimitKind is the kind of money movement a limit applies to
+type LimitKind string
+
+const (
+	LimitDeposit  LimitKind = "deposit"
+	LimitWithdraw LimitKind = "withdraw"
+	LimitPurchase LimitKind = "purchase"
+)
+
+// LimitKinds lists every limited kind of money movement
+var LimitKinds = []LimitKind{LimitDeposit, LimitWithdraw, LimitPurchase}
+
+// Valid reports whether the kind is a known limit kind
+func (k LimitKind) Valid() bool {
+	switch k {
+	case LimitDeposit, LimitWithdraw, LimitPurchase:
+		return true
+	default:
+		return false
+	}
+}
+
+// LimitOverride replaces parts of a user's tier limits for one kind. Nil
+// fields keep the tier's value, zero means unlimited.
+type LimitOverride struct {
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	Kind           LimitKind  `json:"kind" db:"kind"`
+	PerTransaction *float64   `json:"per_transaction" db:"per_transaction"`
+	Daily          *float64   `json:"daily" db:"daily"`
+	Monthly        *float64   `json:"monthly" db:"monthly"`
+	UpdatedBy      *uuid.UUID `json:"updated_by,omitempty" db:"updated_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type SetLimitOverrideRequest struct {
+	PerTransaction *float64 `json:"per_transaction" binding:"omitempty,gte=0"`
+	Daily          *float64 `json:"daily" binding:"omitempty,gte=0"`
+	Monthly        *float64 `json:"monthly" binding:"omitempty,gte=0"`
+}
+
+// LimitWindow is a rolling window limit and how much of it is used. Limit
+// and Remaining are null when the window is unlimited.
+type LimitWindow struct {
+	Limit     *float64 `json:"limit"`
+	Used      float64  `json:"used"`
+	Remaining *float64 `json:"remaining"`
+}
+
+// LimitStatus describes a user's effective limits for one kind
+type LimitStatus struct {
+	Kind           LimitKind   `json:"kind"`
+	PerTransaction *float64    `json:"per_transaction"`
+	Daily          LimitWindow `json:"daily"`
+	Monthly        LimitWindow `json:"monthly"`
+	Overridden     bool        `json:"overridden"`
+}
+
+// UserLimits is the admin view of a user's limits and the overrides behind them
+type UserLimits struct {
+	UserID    uuid.UUID        `json:"user_id"`
+	Limits    []LimitStatus    `json:"limits"`
+	Overrides []*LimitOverride `json:"overrides"`
+}
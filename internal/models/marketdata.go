@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CandleInterval string
+
+const (
+	Interval1h CandleInterval = "1h"
+	Interval1d CandleInterval = "1d"
+	Interval1w CandleInterval = "1w"
+)
+
+// CandleIntervals lists every interval the aggregator maintains
+var CandleIntervals = []CandleInterval{Interval1h, Interval1d, Interval1w}
+
+// Duration returns the length of one bucket
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case Interval1h:
+		return time.Hour
+	case Interval1d:
+		return 24 * time.Hour
+	case Interval1w:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Valid reports whether the interval is supported
+func (i CandleInterval) Valid() bool {
+	return i.Duration() > 0
+}
+
+// BucketStart returns the start of the bucket containing t (in UTC).
+// Weekly buckets start on Monday.
+func (i CandleInterval) BucketStart(t time.Time) time.Time {
+	t = t.UTC()
+	switch i {
+	case Interval1h:
+		return t.Truncate(time.Hour)
+	case Interval1d:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case Interval1w:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset)
+	default:
+		return t
+	}
+}
+
+// MarketSale is a completed sale of one skin, denormalized by skin type
+type MarketSale struct {
+	OrderItemID uuid.UUID `json:"order_item_id" db:"order_item_id"`
+	Name        string    `json:"name" db:"name"`
+	Gun         Gun       `json:"gun" db:"gun"`
+	Wear        Wear      `json:"wear" db:"wear"`
+	Price       float64   `json:"price" db:"price"`
+	SoldAt      time.Time `json:"sold_at" db:"sold_at"`
+	// Excluded sales were flagged as wash trades or refunded and don't count
+	// towards prices and candles
+	Excluded bool `json:"excluded" db:"excluded"`
+}
+
+// SkinType identifies interchangeable skins for pricing purposes
+type SkinType struct {
+	Name string `json:"name" form:"name" binding:"required"`
+	Gun  Gun    `json:"gun" form:"gun" binding:"required"`
+	Wear Wear   `json:"wear" form:"wear" binding:"required"`
+}
+
+type Candle struct {
+	Name        string         `json:"name" db:"name"`
+	Gun         Gun            `json:"gun" db:"gun"`
+	Wear        Wear           `json:"wear" db:"wear"`
+	Interval    CandleInterval `json:"interval" db:"interval"`
+	BucketStart time.Time      `json:"bucket_start" db:"bucket_start"`
+	Open        float64        `json:"open" db:"open"`
+	High        float64        `json:"high" db:"high"`
+	Low         float64        `json:"low" db:"low"`
+	Close       float64        `json:"close" db:"close"`
+	Volume      int            `json:"volume" db:"volume"`
+	Turnover    float64        `json:"turnover" db:"turnover"`
+	FirstSaleAt time.Time      `json:"-" db:"first_sale_at"`
+	LastSaleAt  time.Time      `json:"-" db:"last_sale_at"`
+}
+
+// PriceSummary is a quick view of where a skin type trades
+type PriceSummary struct {
+	Name          string     `json:"name"`
+	Gun           Gun        `json:"gun"`
+	Wear          Wear       `json:"wear"`
+	LastSalePrice *float64   `json:"last_sale_price"`
+	LastSaleAt    *time.Time `json:"last_sale_at"`
+	MedianPrice   *float64   `json:"median_price"`
+	SalesCount    int        `json:"sales_count"`
+	WindowDays    int        `json:"window_days"`
+}
+
+type PriceConfidence string
+
+const (
+	ConfidenceHigh   PriceConfidence = "high"
+	ConfidenceMedium PriceConfidence = "medium"
+	ConfidenceLow    PriceConfidence = "low"
+)
+
+// PriceSuggestion is the recommended listing price for a specific skin
+type PriceSuggestion struct {
+	SkinID              uuid.UUID       `json:"skin_id"`
+	SuggestedPrice      float64         `json:"suggested_price"`
+	MedianPrice         *float64        `json:"median_price"`
+	LastSalePrice       *float64        `json:"last_sale_price"`
+	LowestListing       *float64        `json:"lowest_listing"`
+	SalesCount          int             `json:"sales_count"`
+	ConditionAdjustment float64         `json:"condition_adjustment"`
+	Confidence          PriceConfidence `json:"confidence"`
+}
+
+// ListingResult is returned when a skin is listed for sale
+type ListingResult struct {
+	SkinID     uuid.UUID        `json:"skin_id"`
+	Price      float64          `json:"price"`
+	Suggestion *PriceSuggestion `json:"suggestion,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+	// ExpiresAt is when the listing ends, nil when it doesn't expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
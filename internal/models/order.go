@@ -9,22 +9,81 @@ type OrderStatus string
 
 const (
 	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
 	OrderStatusCompleted OrderStatus = "completed"
+	OrderStatusRefunded  OrderStatus = "refunded"
+	OrderStatusDisputed  OrderStatus = "disputed"
 )
 
+// orderTransitions lists the statuses each status may move to.
+// Refunded orders are final. A purchase pays and completes its order in the
+// same database transaction, so an order can't be cancelled before it
+// completes; it is refunded instead.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid},
+	OrderStatusPaid:      {OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusCompleted: {OrderStatusRefunded, OrderStatusDisputed},
+	OrderStatusDisputed:  {OrderStatusCompleted, OrderStatusRefunded},
+}
+
+// CanTransitionTo reports whether an order in status s may move to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Order struct {
-	ID          uuid.UUID   `json:"id" db:"id"`
-	UserID      uuid.UUID   `json:"userId" db:"user_id"`
-	TotalAmount float64     `json:"totalAmount" db:"total_amount"`
-	Status      OrderStatus `json:"status" db:"status"`
-	CreatedAt   time.Time   `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time   `json:"updatedAt" db:"updated_at"`
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"userId" db:"user_id"`
+	// TotalAmount is what the buyer paid, tax included
+	TotalAmount float64 `json:"totalAmount" db:"total_amount"`
+	// TaxAmount is the VAT charged on top of the item prices at purchase time
+	TaxAmount     float64 `json:"taxAmount" db:"tax_amount"`
+	TaxRate       float64 `json:"taxRate" db:"tax_rate"`
+	TaxCountry    *string `json:"taxCountry,omitempty" db:"tax_country"`
+	ReverseCharge bool    `json:"reverseCharge" db:"reverse_charge"`
+	BuyerVATID    *string `json:"buyerVatId,omitempty" db:"buyer_vat_id"`
+	// RefundedAmount is what partial and full refunds paid back so far
+	RefundedAmount float64     `json:"refundedAmount" db:"refunded_amount"`
+	Status         OrderStatus `json:"status" db:"status"`
+	CreatedAt      time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// NetAmount is the order total without tax
+func (o *Order) NetAmount() float64 {
+	return o.TotalAmount - o.TaxAmount
 }
 
 type OrderItem struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	OrderID   uuid.UUID `json:"orderId" db:"order_id"`
-	SkinID    uuid.UUID `json:"skinId" db:"skin_id"`
-	Price     float64   `json:"price" db:"price"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	ID        uuid.UUID  `json:"id" db:"id"`
+	OrderID   uuid.UUID  `json:"orderId" db:"order_id"`
+	SkinID    uuid.UUID  `json:"skinId" db:"skin_id"`
+	SellerID  *uuid.UUID `json:"sellerId,omitempty" db:"seller_id"`
+	Price     float64    `json:"price" db:"price"`
+	Fee       float64    `json:"fee" db:"fee"`
+	Tax       float64    `json:"tax" db:"tax"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// OrderStatusChange is one entry of an order's status history
+type OrderStatusChange struct {
+	ID         uuid.UUID    `json:"id" db:"id"`
+	OrderID    uuid.UUID    `json:"orderId" db:"order_id"`
+	FromStatus *OrderStatus `json:"fromStatus,omitempty" db:"from_status"`
+	ToStatus   OrderStatus  `json:"toStatus" db:"to_status"`
+	ChangedBy  *uuid.UUID   `json:"changedBy,omitempty" db:"changed_by"`
+	Reason     *string      `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	// Seq orders the changes, the ones made in one database transaction
+	// share created_at
+	Seq int64 `json:"-" db:"seq"`
+}
+
+type RefundOrderRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
 }
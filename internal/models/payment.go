@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentIntentStatus string
+
+const (
+	PaymentPending   PaymentIntentStatus = "pending"
+	PaymentSucceeded PaymentIntentStatus = "succeeded"
+	PaymentFailed    PaymentIntentStatus = "failed"
+)
+
+// PaymentIntent is a deposit the user pays at the payment provider. The
+// balance is credited once the provider confirms it through a webhook.
+type PaymentIntent struct {
+	ID                uuid.UUID           `json:"id" db:"id"`
+	UserID            uuid.UUID           `json:"user_id" db:"user_id"`
+	Amount            float64             `json:"amount" db:"amount"`
+	Status            PaymentIntentStatus `json:"status" db:"status"`
+	Provider          string              `json:"provider" db:"provider"`
+	ProviderReference *string             `json:"provider_reference,omitempty" db:"provider_reference"`
+	CheckoutURL       *string             `json:"checkout_url,omitempty" db:"checkout_url"`
+	FailureReason     *string             `json:"failure_reason,omitempty" db:"failure_reason"`
+	CompletedAt       *time.Time          `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt         time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at" db:"updated_at"`
+}
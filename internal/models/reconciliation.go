@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DiscrepancyKind is what the reconciliation found wrong
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyBalance means users.balance is not what the user's
+	// transaction history adds up to
+	DiscrepancyBalance DiscrepancyKind = "balance_mismatch"
+	// DiscrepancyPending means users.pending_balance differs from the
+	// pending history or from the held trade holds
+	DiscrepancyPending DiscrepancyKind = "pending_mismatch"
+	// DiscrepancyFrozen means users.frozen_balance differs from the amounts
+	// frozen by open disputes
+	DiscrepancyFrozen DiscrepancyKind = "frozen_mismatch"
+	// DiscrepancyReserved means users.reserved_balance differs from the
+	// withdrawals still waiting for review or payout
+	DiscrepancyReserved DiscrepancyKind = "reserved_mismatch"
+	// DiscrepancyGap means a transaction doesn't start where the previous one
+	// of the same balance ended, so rows are missing or were altered
+	DiscrepancyGap DiscrepancyKind = "chain_gap"
+	// DiscrepancyBrokenRow means a transaction's balance_before and
+	// balance_after don't differ by its amount
+	DiscrepancyBrokenRow DiscrepancyKind = "broken_row"
+	// DiscrepancyOrphanOrder means a paid order has no purchase or sale
+	// transaction
+	DiscrepancyOrphanOrder DiscrepancyKind = "orphan_order"
+)
+
+func (k DiscrepancyKind) Valid() bool {
+	switch k {
+	case DiscrepancyBalance, DiscrepancyPending, DiscrepancyFrozen, DiscrepancyReserved,
+		DiscrepancyGap, DiscrepancyBrokenRow, DiscrepancyOrphanOrder:
+		return true
+	}
+	return false
+}
+
+// ReconciliationReport is one run of the balance reconciliation job
+type ReconciliationReport struct {
+	ID                  uuid.UUID `json:"id" db:"id"`
+	UsersChecked        int       `json:"users_checked" db:"users_checked"`
+	TransactionsChecked int       `json:"transactions_checked" db:"transactions_checked"`
+	OrdersChecked       int       `json:"orders_checked" db:"orders_checked"`
+	DiscrepancyCount    int       `json:"discrepancy_count" db:"discrepancy_count"`
+	StartedAt           time.Time `json:"started_at" db:"started_at"`
+	FinishedAt          time.Time `json:"finished_at" db:"finished_at"`
+
+	Discrepancies []*Discrepancy `json:"discrepancies,omitempty" db:"-"`
+}
+
+// Discrepancy is one problem a reconciliation run found
+type Discrepancy struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	ReportID      uuid.UUID       `json:"report_id" db:"report_id"`
+	Kind          DiscrepancyKind `json:"kind" db:"kind"`
+	UserID        *uuid.UUID      `json:"user_id,omitempty" db:"user_id"`
+	OrderID       *uuid.UUID      `json:"order_id,omitempty" db:"order_id"`
+	TransactionID *uuid.UUID      `json:"transaction_id,omitempty" db:"transaction_id"`
+	Expected      *float64        `json:"expected,omitempty" db:"expected"`
+	Actual        *float64        `json:"actual,omitempty" db:"actual"`
+	Detail        string          `json:"detail" db:"detail"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AccountSnapshot is a user's stored balances next to what the rest of the
+// database says they should be
+type AccountSnapshot struct {
+	UserID          uuid.UUID `db:"user_id"`
+	Balance         float64   `db:"balance"`
+	PendingBalance  float64   `db:"pending_balance"`
+	FrozenBalance   float64   `db:"frozen_balance"`
+	ReservedBalance float64   `db:"reserved_balance"`
+	// HeldAmount is the total of the user's held trade holds
+	HeldAmount float64 `db:"held_amount"`
+	// DisputedAmount is the total frozen by open disputes against the user
+	DisputedAmount float64 `db:"disputed_amount"`
+	// WithdrawingAmount is the total of withdrawals waiting for review or payout
+	WithdrawingAmount float64 `db:"withdrawing_amount"`
+}
+
+// OrphanOrder is a paid order missing a buyer or seller transaction
+type OrphanOrder struct {
+	OrderID uuid.UUID   `db:"order_id"`
+	UserID  uuid.UUID   `db:"user_id"`
+	Side    string      `db:"side"`
+	Amount  float64     `db:"amount"`
+	Status  OrderStatus `db:"status"`
+}
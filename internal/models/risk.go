@@ -0,0 +1,161 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+)
+
+// RiskEventKind is the kind of money movement the risk rules look at
+type RiskEventKind string
+
+const (
+	RiskDeposit  RiskEventKind = "deposit"
+	RiskWithdraw RiskEventKind = "withdraw"
+	RiskPurchase RiskEventKind = "purchase"
+	// RiskSell is a skin listed for sale
+	RiskSell RiskEventKind = "sell"
+)
+
+// Valid reports whether the kind is a known risk event kind
+func (k RiskEventKind) Valid() bool {
+	switch k {
+	case RiskDeposit, RiskWithdraw, RiskPurchase, RiskSell:
+		return true
+	default:
+		return false
+	}
+}
+
+// RiskAction is what happens to a money movement that matches a rule, from
+// the weakest to the strongest
+type RiskAction string
+
+const (
+	// RiskActionAllow only records the rule hit
+	RiskActionAllow RiskAction = "allow"
+	// RiskActionFlag lets the movement through and queues it for review
+	RiskActionFlag RiskAction = "flag"
+	// RiskActionHold lets the movement through but keeps the money from moving on
+	// until an admin has reviewed it
+	RiskActionHold RiskAction = "hold"
+	// RiskActionBlock rejects the movement
+	RiskActionBlock RiskAction = "block"
+)
+
+var riskActionRank = map[RiskAction]int{RiskActionAllow: 0, RiskActionFlag: 1, RiskActionHold: 2, RiskActionBlock: 3}
+
+// Valid reports whether the action is a known risk action
+func (a RiskAction) Valid() bool {
+	_, ok := riskActionRank[a]
+	return ok
+}
+
+// Stronger reports whether a is a stronger action than b
+func (a RiskAction) Stronger(b RiskAction) bool {
+	return riskActionRank[a] > riskActionRank[b]
+}
+
+type RiskFlagStatus string
+
+const (
+	RiskFlagOpen RiskFlagStatus = "open"
+	// RiskFlagCleared was reviewed and found legitimate
+	RiskFlagCleared RiskFlagStatus = "cleared"
+	// RiskFlagConfirmed was reviewed and found fraudulent
+	RiskFlagConfirmed RiskFlagStatus = "confirmed"
+)
+
+// Valid reports whether the status is a known flag status
+func (s RiskFlagStatus) Valid() bool {
+	switch s {
+	case RiskFlagOpen, RiskFlagCleared, RiskFlagConfirmed:
+		return true
+	default:
+		return false
+	}
+}
+
+// RiskFlag is a money movement waiting for admin review because it matched
+// rules with a flag, hold or block action
+type RiskFlag struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	UserID      uuid.UUID      `json:"user_id" db:"user_id"`
+	Kind        RiskEventKind  `json:"kind" db:"kind"`
+	Action      RiskAction     `json:"action" db:"action"`
+	Amount      float64        `json:"amount" db:"amount"`
+	ReferenceID *uuid.UUID     `json:"reference_id,omitempty" db:"reference_id"`
+	Status      RiskFlagStatus `json:"status" db:"status"`
+	ReviewedBy  *uuid.UUID     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote  *string        `json:"review_note,omitempty" db:"review_note"`
+	ReviewedAt  *time.Time     `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+
+	Hits []*RiskRuleHit `json:"hits,omitempty" db:"-"`
+}
+
+// RiskRuleHit records that a rule matched a money movement
+type RiskRuleHit struct {
+	ID          uuid.UUID     `json:"id" db:"id"`
+	FlagID      *uuid.UUID    `json:"flag_id,omitempty" db:"flag_id"`
+	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	Kind        RiskEventKind `json:"kind" db:"kind"`
+	Rule        string        `json:"rule" db:"rule"`
+	RuleType    string        `json:"rule_type" db:"rule_type"`
+	Action      RiskAction    `json:"action" db:"action"`
+	Amount      float64       `json:"amount" db:"amount"`
+	ReferenceID *uuid.UUID    `json:"reference_id,omitempty" db:"reference_id"`
+	Detail      string        `json:"detail" db:"detail"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+}
+
+type ReviewRiskFlagRequest struct {
+	Status RiskFlagStatus `json:"status" binding:"required,oneof=cleared confirmed"`
+	Note   string         `json:"note" binding:"max=1000"`
+}
+
+// RiskActivity is a past money movement from transaction_history, or a
+// listing from listing_history, as the risk rules see it, used to replay
+// rules against history
+type RiskActivity struct {
+	ID               uuid.UUID     `json:"id" db:"id"`
+	UserID           uuid.UUID     `json:"user_id" db:"user_id"`
+	Kind             RiskEventKind `json:"kind" db:"kind"`
+	Amount           float64       `json:"amount" db:"amount"`
+	CounterpartyID   *uuid.UUID    `json:"counterparty_id,omitempty" db:"counterparty_id"`
+	Gun              *Gun          `json:"gun,omitempty" db:"gun"`
+	AccountCreatedAt time.Time     `json:"account_created_at" db:"account_created_at"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+}
+
+// RiskReplayHit is a rule that would have matched a past money movement
+type RiskReplayHit struct {
+	// TransactionID is the listing's ID for sell hits
+	TransactionID uuid.UUID     `json:"transaction_id"`
+	UserID        uuid.UUID     `json:"user_id"`
+	Kind          RiskEventKind `json:"kind"`
+	Amount        float64       `json:"amount"`
+	Rule          string        `json:"rule"`
+	Action        RiskAction    `json:"action"`
+	Detail        string        `json:"detail"`
+	At            time.Time     `json:"at"`
+}
+
+// RiskReplayReport summarises how rules would have acted on past activity
+type RiskReplayReport struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Events    int                `json:"events"`
+	Decisions map[RiskAction]int `json:"decisions"`
+	RuleHits  map[string]int     `json:"rule_hits"`
+	Hits      []RiskReplayHit    `json:"hits"`
+	Truncated bool               `json:"truncated"`
+}
+
+// RiskReplayRequest optionally carries rules to try instead of the
+// configured ones
+type RiskReplayRequest struct {
+	Rules []config.RiskRule `json:"rules"`
+}
@@ -66,6 +66,48 @@ const (
 	Classic  Gun = "Classic Knife"
 )
 
+type GunCategory string
+
+const (
+	CategoryPistol     GunCategory = "pistol"
+	CategoryRifle      GunCategory = "rifle"
+	CategorySniper     GunCategory = "sniper"
+	CategorySMG        GunCategory = "smg"
+	CategoryShotgun    GunCategory = "shotgun"
+	CategoryMachineGun GunCategory = "machine_gun"
+	CategoryKnife      GunCategory = "knife"
+)
+
+// Valid reports whether the category is one of the known categories
+func (c GunCategory) Valid() bool {
+	switch c {
+	case CategoryPistol, CategoryRifle, CategorySniper, CategorySMG, CategoryShotgun, CategoryMachineGun, CategoryKnife:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetGunCategory groups guns the way fees and limits are configured
+func GetGunCategory(gun Gun) GunCategory {
+	switch gun {
+	case DesertEagle, USPS, Glock18, P250, Tec9, CZ75:
+		return CategoryPistol
+	case AK47, M4A4, M4A1S:
+		return CategoryRifle
+	case AWP, SSG08, SCAR20, G3SG1:
+		return CategorySniper
+	case MP9, MAC10, MP7, P90, UMP45, PPBizon:
+		return CategorySMG
+	case Nova, XM1014, MAG7, SawedOff:
+		return CategoryShotgun
+	case M249, Negev:
+		return CategoryMachineGun
+	default:
+		return CategoryKnife
+	}
+}
+
 type Wear string
 
 const (
@@ -94,6 +136,24 @@ func GetWearFromCondition(condition float64) Wear {
 	}
 }
 
+// GetWearRange returns the condition bounds of a wear level
+func GetWearRange(wear Wear) (min, max float64) {
+	switch wear {
+	case FactoryNew:
+		return 0.00, 0.07
+	case MinimalWear:
+		return 0.07, 0.15
+	case FieldTested:
+		return 0.15, 0.38
+	case WellWorn:
+		return 0.38, 0.45
+	case BattleScarred:
+		return 0.45, 1.00
+	default:
+		return 0.00, 1.00
+	}
+}
+
 type Skin struct {
 	ID        uuid.UUID  `json:"id" db:"id"`
 	OwnerID   *uuid.UUID `json:"owner_id" db:"owner_id"`
@@ -105,6 +165,31 @@ type Skin struct {
 	Price     float64    `json:"price" db:"price"`
 	Image     string     `json:"image" db:"image"`
 	Available bool       `json:"available" db:"available"`
+	// ListingDays is how long the current or last listing runs, nil when it
+	// doesn't expire
+	ListingDays *int `json:"listing_days,omitempty" db:"listing_days"`
+	// ExpiresAt is when the listing ends; once passed on an unlisted skin
+	// the listing expired and can be relisted
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
 }
+
+// Listing is a skin put up for sale, kept for the sell risk rules
+type Listing struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	SkinID    uuid.UUID `json:"skin_id" db:"skin_id"`
+	Price     float64   `json:"price" db:"price"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ListingDurations are the number of days a listing can run for
+var ListingDurations = []int{1, 7, 30}
+
+// ListingExpired reports whether the skin's listing ran out. The listing is
+// off the marketplace from then on, even before the expire-listings job
+// marks the skin unavailable.
+func (s *Skin) ListingExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !s.ExpiresAt.After(now)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaxEntry books the VAT of a sale. A refund books the same entry with
+// negative amounts, a partial refund its share of them.
+type TaxEntry struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	OrderID       uuid.UUID `json:"order_id" db:"order_id"`
+	Country       string    `json:"country" db:"country"`
+	Rate          float64   `json:"rate" db:"rate"`
+	ReverseCharge bool      `json:"reverse_charge" db:"reverse_charge"`
+	NetAmount     float64   `json:"net_amount" db:"net_amount"`
+	TaxAmount     float64   `json:"tax_amount" db:"tax_amount"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// TaxReportRow sums the entries of one period, country and rate
+type TaxReportRow struct {
+	PeriodStart   time.Time `json:"period_start" db:"period_start"`
+	Country       string    `json:"country" db:"country"`
+	Rate          float64   `json:"rate" db:"rate"`
+	ReverseCharge bool      `json:"reverse_charge" db:"reverse_charge"`
+	NetAmount     float64   `json:"net_amount" db:"net_amount"`
+	TaxAmount     float64   `json:"tax_amount" db:"tax_amount"`
+	Entries       int       `json:"entries" db:"entries"`
+}
+
+type TaxReport struct {
+	Period   ReportPeriod    `json:"period"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	NetTotal float64         `json:"net_total"`
+	TaxTotal float64         `json:"tax_total"`
+	Rows     []*TaxReportRow `json:"rows"`
+}
@@ -13,6 +13,34 @@ const (
 	Deposit  TransactionType = "deposit"
 	Purchase TransactionType = "purchase"
 	Sale     TransactionType = "sale"
+	Fee      TransactionType = "fee"
+	Refund   TransactionType = "refund"
+	Reversal TransactionType = "reversal"
+	// DisputeHold moves seller proceeds from the available to the frozen balance
+	DisputeHold TransactionType = "dispute_hold"
+	// DisputeRelease moves frozen proceeds back to the available balance
+	DisputeRelease TransactionType = "dispute_release"
+	// HoldRelease moves matured sale proceeds from the pending to the available balance
+	HoldRelease TransactionType = "hold_release"
+	// WithdrawRelease returns the reserved amount of a rejected or failed withdrawal
+	WithdrawRelease TransactionType = "withdraw_release"
+)
+
+func (t TransactionType) Valid() bool {
+	switch t {
+	case Withdraw, Deposit, Purchase, Sale, Fee, Refund, Reversal,
+		DisputeHold, DisputeRelease, HoldRelease, WithdrawRelease:
+		return true
+	}
+	return false
+}
+
+// BalanceType tells which of the user's balances a transaction moved
+type BalanceType string
+
+const (
+	BalanceAvailable BalanceType = "available"
+	BalancePending   BalanceType = "pending"
 )
 
 type Transaction struct {
@@ -22,6 +50,7 @@ type Transaction struct {
 	Type          TransactionType `json:"type" db:"type"`
 	BalanceBefore float64         `json:"balance_before" db:"balance_before"`
 	BalanceAfter  float64         `json:"balance_after" db:"balance_after"`
+	BalanceType   BalanceType     `json:"balance_type" db:"balance_type"`
 
 	SkinID         *uuid.UUID `json:"skin_id,omitempty" db:"skin_id"`
 	OrderID        *uuid.UUID `json:"order_id,omitempty" db:"order_id"`
@@ -29,6 +58,42 @@ type Transaction struct {
 	Description    *string    `json:"description,omitempty" db:"description"`
 
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// Seq orders the rows the way they were applied, created_at is shared by
+	// the rows of one database transaction
+	Seq int64 `json:"-" db:"seq"`
+}
+
+// TransactionFilter narrows down a user's transaction history. Zero values
+// don't filter.
+type TransactionFilter struct {
+	Types          []TransactionType
+	From           time.Time
+	To             time.Time
+	SkinID         *uuid.UUID
+	CounterpartyID *uuid.UUID
+	// MinAmount and MaxAmount bound the absolute amount, whichever way the
+	// money moved
+	MinAmount *float64
+	MaxAmount *float64
+}
+
+// TransactionCursor is the position of the last transaction of a page
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// TransactionPage is one page of a user's transaction history, newest first
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ExportRecipient is a user who gets a transaction export by email
+type ExportRecipient struct {
+	UserID uuid.UUID `db:"user_id"`
+	Email  string    `db:"email"`
 }
 
 type WithdrawRequest struct {
@@ -38,3 +103,36 @@ type WithdrawRequest struct {
 type DepositRequest struct {
 	Amount float64 `json:"amount" binding:"required,gt=0"`
 }
+
+// StatementBalances are a user's available and pending balances at one point
+// in time
+type StatementBalances struct {
+	Available float64 `json:"available" db:"available"`
+	Pending   float64 `json:"pending" db:"pending"`
+}
+
+// StatementLine is a transaction with the user's balances right after it
+type StatementLine struct {
+	Transaction *Transaction `json:"transaction"`
+	Available   float64      `json:"available"`
+	Pending     float64      `json:"pending"`
+}
+
+// StatementTotal sums the transactions of one type. Net is what they added to
+// or took from the balances they moved.
+type StatementTotal struct {
+	Type  TransactionType `json:"type"`
+	Count int             `json:"count"`
+	Net   float64         `json:"net"`
+}
+
+// Statement is a user's account statement for [PeriodStart, PeriodEnd)
+type Statement struct {
+	UserID      uuid.UUID         `json:"user_id"`
+	PeriodStart time.Time         `json:"period_start"`
+	PeriodEnd   time.Time         `json:"period_end"`
+	Opening     StatementBalances `json:"opening"`
+	Closing     StatementBalances `json:"closing"`
+	Lines       []StatementLine   `json:"lines"`
+	Totals      []StatementTotal  `json:"totals"`
+}
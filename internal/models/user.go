@@ -7,21 +7,55 @@ import (
 	"github.com/google/uuid"
 )
 
+type UserTier string
+
+const (
+	TierStandard UserTier = "standard"
+	TierVerified UserTier = "verified"
+	TierPro      UserTier = "pro"
+)
+
+// Valid reports whether the tier is one of the known tiers
+func (t UserTier) Valid() bool {
+	switch t {
+	case TierStandard, TierVerified, TierPro:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password"`
-	Balance   float64   `json:"balance" db:"balance"`
-	Role      auth.Role `json:"role" db:"role"`
+	ID       uuid.UUID `json:"id" db:"id"`
+	Name     string    `json:"name" db:"name"`
+	Email    string    `json:"email" db:"email"`
+	Password string    `json:"-" db:"password"`
+	Balance  float64   `json:"balance" db:"balance"`
+	// PendingBalance holds sale proceeds still in their trade hold period
+	PendingBalance float64 `json:"pending_balance" db:"pending_balance"`
+	// FrozenBalance holds sale proceeds locked by open disputes
+	FrozenBalance float64 `json:"frozen_balance" db:"frozen_balance"`
+	// ReservedBalance holds withdrawals waiting for review or payout
+	ReservedBalance float64   `json:"reserved_balance" db:"reserved_balance"`
+	Role            auth.Role `json:"role" db:"role"`
+	Tier            UserTier  `json:"tier" db:"tier"`
+	// BillingCountry is the ISO 3166-1 alpha-2 country the user is taxed in
+	BillingCountry *string `json:"billing_country,omitempty" db:"billing_country"`
+	// VATID is set for business buyers
+	VATID     *string   `json:"vat_id,omitempty" db:"vat_id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type UserProfile struct {
-	Name    string  `json:"name" db:"name"`
-	Email   string  `json:"email" db:"email"`
-	Balance float64 `json:"balance" db:"balance"`
+	Name            string  `json:"name" db:"name"`
+	Email           string  `json:"email" db:"email"`
+	Balance         float64 `json:"balance" db:"balance"`
+	PendingBalance  float64 `json:"pending_balance" db:"pending_balance"`
+	FrozenBalance   float64 `json:"frozen_balance" db:"frozen_balance"`
+	ReservedBalance float64 `json:"reserved_balance" db:"reserved_balance"`
+	BillingCountry  *string `json:"billing_country,omitempty" db:"billing_country"`
+	VATID           *string `json:"vat_id,omitempty" db:"vat_id"`
 }
 
 type UserSignupRequest struct {
@@ -34,3 +68,14 @@ type UserLoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
+
+// UpdateBillingRequest sets where the user is taxed. VATID is only given by
+// business buyers.
+type UpdateBillingRequest struct {
+	Country string `json:"country" binding:"required"`
+	VATID   string `json:"vat_id"`
+}
+
+type UpdateTierRequest struct {
+	Tier UserTier `json:"tier" binding:"required,oneof=standard verified pro"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WashTradeStatus string
+
+const (
+	WashTradeOpen WashTradeStatus = "open"
+	// WashTradeCleared was reviewed and found legitimate, its sales count
+	// towards prices again
+	WashTradeCleared WashTradeStatus = "cleared"
+	// WashTradeConfirmed was reviewed and found to be wash trading
+	WashTradeConfirmed WashTradeStatus = "confirmed"
+)
+
+// Valid reports whether the status is a known cluster status
+func (s WashTradeStatus) Valid() bool {
+	switch s {
+	case WashTradeOpen, WashTradeCleared, WashTradeConfirmed:
+		return true
+	default:
+		return false
+	}
+}
+
+// WashTradeReport is one run of the wash-trading detection job
+type WashTradeReport struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	WindowStart    time.Time `json:"window_start" db:"window_start"`
+	WindowEnd      time.Time `json:"window_end" db:"window_end"`
+	TradesAnalysed int       `json:"trades_analysed" db:"trades_analysed"`
+	ClustersFound  int       `json:"clusters_found" db:"clusters_found"`
+	SalesFlagged   int       `json:"sales_flagged" db:"sales_flagged"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+
+	Clusters []*WashTradeCluster `json:"clusters,omitempty" db:"-"`
+}
+
+// WashTradeCluster is a group of accounts whose trades with each other look
+// like wash trading. The higher the score, the more suspicious.
+type WashTradeCluster struct {
+	ID         uuid.UUID       `json:"id" db:"id"`
+	ReportID   uuid.UUID       `json:"report_id" db:"report_id"`
+	Score      float64         `json:"score" db:"score"`
+	TradeCount int             `json:"trade_count" db:"trade_count"`
+	Volume     float64         `json:"volume" db:"volume"`
+	Status     WashTradeStatus `json:"status" db:"status"`
+	ReviewedBy *uuid.UUID      `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote *string         `json:"review_note,omitempty" db:"review_note"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+
+	UserIDs []uuid.UUID      `json:"user_ids,omitempty" db:"-"`
+	Sales   []*WashTradeSale `json:"sales,omitempty" db:"-"`
+}
+
+// WashTradeSale is a sale of a cluster with the signals that made it suspicious
+type WashTradeSale struct {
+	ClusterID   uuid.UUID `json:"cluster_id" db:"cluster_id"`
+	OrderItemID uuid.UUID `json:"order_item_id" db:"order_item_id"`
+	SkinID      uuid.UUID `json:"skin_id" db:"skin_id"`
+	SellerID    uuid.UUID `json:"seller_id" db:"seller_id"`
+	BuyerID     uuid.UUID `json:"buyer_id" db:"buyer_id"`
+	Price       float64   `json:"price" db:"price"`
+	SoldAt      time.Time `json:"sold_at" db:"sold_at"`
+	// Reasons is a comma separated list of signals, see internal/surveillance
+	Reasons string  `json:"reasons" db:"reasons"`
+	Score   float64 `json:"score" db:"score"`
+}
+
+// Trade is a completed sale between two users as the wash-trading detection
+// sees it
+type Trade struct {
+	OrderItemID uuid.UUID `db:"order_item_id"`
+	SkinID      uuid.UUID `db:"skin_id"`
+	SellerID    uuid.UUID `db:"seller_id"`
+	BuyerID     uuid.UUID `db:"buyer_id"`
+	Name        string    `db:"name"`
+	Gun         Gun       `db:"gun"`
+	Wear        Wear      `db:"wear"`
+	Price       float64   `db:"price"`
+	SoldAt      time.Time `db:"sold_at"`
+}
+
+// SkinType returns the interchangeable skin type the trade was for
+func (t Trade) SkinType() SkinType {
+	return SkinType{Name: t.Name, Gun: t.Gun, Wear: t.Wear}
+}
+
+type ReviewWashTradeRequest struct {
+	Status WashTradeStatus `json:"status" binding:"required,oneof=cleared confirmed"`
+	Note   string          `json:"note" binding:"max=1000"`
+}
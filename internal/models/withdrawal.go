@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WithdrawalStatus string
+
+const (
+	// WithdrawalPendingReview waits for an admin to approve or reject it
+	WithdrawalPendingReview WithdrawalStatus = "pending_review"
+	// WithdrawalApproved is queued for payout
+	WithdrawalApproved WithdrawalStatus = "approved"
+	// WithdrawalProcessing has been handed to the payment provider
+	WithdrawalProcessing WithdrawalStatus = "processing"
+	WithdrawalSettled    WithdrawalStatus = "settled"
+	WithdrawalFailed     WithdrawalStatus = "failed"
+	WithdrawalRejected   WithdrawalStatus = "rejected"
+)
+
+// Valid reports whether the status is a known withdrawal status
+func (s WithdrawalStatus) Valid() bool {
+	switch s {
+	case WithdrawalPendingReview, WithdrawalApproved, WithdrawalProcessing,
+		WithdrawalSettled, WithdrawalFailed, WithdrawalRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// Withdrawal is a payout request. Its amount is reserved from the user's
+// balance until the payout settles or the request is rejected or fails.
+type Withdrawal struct {
+	ID                uuid.UUID        `json:"id" db:"id"`
+	UserID            uuid.UUID        `json:"user_id" db:"user_id"`
+	Amount            float64          `json:"amount" db:"amount"`
+	Status            WithdrawalStatus `json:"status" db:"status"`
+	RequiresReview    bool             `json:"requires_review" db:"requires_review"`
+	ReviewedBy        *uuid.UUID       `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt        *time.Time       `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewReason      *string          `json:"review_reason,omitempty" db:"review_reason"`
+	ProviderReference *string          `json:"provider_reference,omitempty" db:"provider_reference"`
+	FailureReason     *string          `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+type RejectWithdrawalRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
@@ -0,0 +1,101 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeProviderName identifies the fake provider in stored payment intents
+const FakeProviderName = "fake"
+
+// FakeProvider settles payments locally without moving real money. It is
+// used for development and tests; cmd/paysim plays the provider's side and
+// sends the signed webhooks.
+type FakeProvider struct {
+	// DeclineAbove makes payouts larger than this amount fail, 0 disables it
+	DeclineAbove float64
+
+	webhookSecret []byte
+	mu            sync.Mutex
+	payouts       map[string]string
+	intents       map[string]string
+	logger        *slog.Logger
+}
+
+func NewFakeProvider(webhookSecret string, logger *slog.Logger) *FakeProvider {
+	return &FakeProvider{
+		webhookSecret: []byte(webhookSecret),
+		payouts:       make(map[string]string),
+		intents:       make(map[string]string),
+		logger:        logger,
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) Payout(ctx context.Context, req PayoutRequest) (*PayoutResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrDeclined)
+	}
+	if p.DeclineAbove > 0 && req.Amount > p.DeclineAbove {
+		return nil, fmt.Errorf("%w: amount %.2f exceeds %.2f", ErrDeclined, req.Amount, p.DeclineAbove)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ref, ok := p.payouts[req.IdempotencyKey]
+	if !ok {
+		ref = "fake_po_" + uuid.NewString()
+		p.payouts[req.IdempotencyKey] = ref
+		p.logger.Info("fake payout sent", "reference", ref, "user_id", req.UserID, "amount", req.Amount)
+	}
+	return &PayoutResult{Reference: ref}, nil
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ref, ok := p.intents[req.IdempotencyKey]
+	if !ok {
+		ref = "fake_pi_" + uuid.NewString()
+		p.intents[req.IdempotencyKey] = ref
+		p.logger.Info("fake payment intent created", "reference", ref, "user_id", req.UserID, "amount", req.Amount)
+	}
+	return &Intent{Reference: ref, CheckoutURL: "fake://checkout/" + ref}, nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if len(p.webhookSecret) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	if err := VerifySignature(p.webhookSecret, payload, header.Get(SignatureHeader), DefaultWebhookTolerance, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("%w: malformed payload", ErrInvalidSignature)
+	}
+	if event.ID == "" || event.Reference == "" {
+		return nil, fmt.Errorf("%w: missing event id or reference", ErrInvalidSignature)
+	}
+	return &event, nil
+}
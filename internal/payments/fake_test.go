@@ -0,0 +1,114 @@
+package payments
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeProvider_Payout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	t.Run("retries with the same key return the same reference", func(t *testing.T) {
+		p := NewFakeProvider("secret", logger)
+		req := PayoutRequest{IdempotencyKey: "w-1", UserID: uuid.New(), Amount: 25}
+
+		first, err := p.Payout(ctx, req)
+		assert.NoError(t, err)
+		second, err := p.Payout(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, first.Reference, second.Reference)
+
+		other, err := p.Payout(ctx, PayoutRequest{IdempotencyKey: "w-2", UserID: req.UserID, Amount: 25})
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.Reference, other.Reference)
+	})
+
+	t.Run("declines above the limit", func(t *testing.T) {
+		p := NewFakeProvider("secret", logger)
+		p.DeclineAbove = 100
+
+		_, err := p.Payout(ctx, PayoutRequest{IdempotencyKey: "w-3", Amount: 150})
+		assert.ErrorIs(t, err, ErrDeclined)
+
+		_, err = p.Payout(ctx, PayoutRequest{IdempotencyKey: "w-4", Amount: 100})
+		assert.NoError(t, err)
+	})
+}
+
+func TestFakeProvider_CreateIntent(t *testing.T) {
+	p := NewFakeProvider("secret", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	req := IntentRequest{IdempotencyKey: "d-1", UserID: uuid.New(), Amount: 10}
+
+	first, err := p.CreateIntent(context.Background(), req)
+	assert.NoError(t, err)
+	second, err := p.CreateIntent(context.Background(), req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first.Reference, second.Reference)
+	assert.Equal(t, "fake://checkout/"+first.Reference, first.CheckoutURL)
+}
+
+func TestFakeProvider_ParseWebhook(t *testing.T) {
+	secret := "whsec_test"
+	p := NewFakeProvider(secret, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	event := WebhookEvent{ID: "evt_1", Type: EventPaymentSucceeded, Reference: "fake_pi_1", Amount: 42.5}
+
+	signed := func(secret string, ts time.Time) ([]byte, http.Header) {
+		body, sig, err := NewSignedWebhook([]byte(secret), event, ts)
+		assert.NoError(t, err)
+		header := http.Header{}
+		header.Set(SignatureHeader, sig)
+		return body, header
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		body, header := signed(secret, time.Now())
+
+		got, err := p.ParseWebhook(body, header)
+
+		assert.NoError(t, err)
+		assert.Equal(t, event.ID, got.ID)
+		assert.Equal(t, event.Amount, got.Amount)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		body, header := signed("other", time.Now())
+
+		_, err := p.ParseWebhook(body, header)
+
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		body, header := signed(secret, time.Now())
+		body[len(body)-2] = 'x'
+
+		_, err := p.ParseWebhook(body, header)
+
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("replayed after the tolerance", func(t *testing.T) {
+		body, header := signed(secret, time.Now().Add(-DefaultWebhookTolerance-time.Minute))
+
+		_, err := p.ParseWebhook(body, header)
+
+		assert.ErrorIs(t, err, ErrStaleWebhook)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		body, _ := signed(secret, time.Now())
+
+		_, err := p.ParseWebhook(body, http.Header{})
+
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+}
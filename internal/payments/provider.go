@@ -0,0 +1,73 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDeclined is returned (possibly wrapped) when the provider refuses a
+// payout for good. Any other error is treated as temporary and retried.
+var ErrDeclined = errors.New("payout declined")
+
+type PayoutRequest struct {
+	// IdempotencyKey identifies the payout so a retried call never pays twice
+	IdempotencyKey string
+	UserID         uuid.UUID
+	Amount         float64
+}
+
+type PayoutResult struct {
+	// Reference is the provider's ID of the transfer
+	Reference string
+}
+
+// PayoutProvider sends money out of the platform to a user
+type PayoutProvider interface {
+	Payout(ctx context.Context, req PayoutRequest) (*PayoutResult, error)
+}
+
+type IntentRequest struct {
+	// IdempotencyKey identifies the deposit so a retried call creates one intent
+	IdempotencyKey string
+	UserID         uuid.UUID
+	Amount         float64
+}
+
+// Intent is a payment the user still has to complete at the provider
+type Intent struct {
+	// Reference is the provider's ID of the payment, echoed back in webhooks
+	Reference   string
+	CheckoutURL string
+}
+
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+)
+
+// WebhookEvent is a verified notification about the outcome of a payment
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	Reference string    `json:"reference"`
+	// IdempotencyKey echoes the key the intent was created with
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	Amount         float64   `json:"amount"`
+	FailureReason  string    `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PaymentProvider takes money into the platform. The balance is only credited
+// once ParseWebhook returns a verified success event.
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	// ParseWebhook verifies the signature of a webhook request and decodes it
+	ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
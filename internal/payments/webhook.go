@@ -0,0 +1,89 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>" where the
+// HMAC is computed over "<t>.<body>" with the shared webhook secret
+const SignatureHeader = "Payment-Signature"
+
+// DefaultWebhookTolerance is how old a signed webhook may be before it is
+// rejected as a possible replay
+const DefaultWebhookTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleWebhook     = errors.New("webhook timestamp outside tolerance")
+)
+
+func computeSignature(secret, payload []byte, ts int64) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignPayload returns the SignatureHeader value for payload signed at ts
+func SignPayload(secret, payload []byte, ts time.Time) string {
+	return fmt.Sprintf("t=%d,v1=%s", ts.Unix(), computeSignature(secret, payload, ts.Unix()))
+}
+
+// VerifySignature checks the SignatureHeader value of payload. Signatures
+// older or newer than tolerance are rejected so captured requests can't be
+// replayed later.
+func VerifySignature(secret, payload []byte, header string, tolerance time.Duration, now time.Time) error {
+	var ts int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			ts = parsed
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if ts == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleWebhook
+	}
+
+	expected := []byte(computeSignature(secret, payload, ts))
+	for _, sig := range signatures {
+		if hmac.Equal(expected, []byte(sig)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// NewSignedWebhook encodes event and signs it, returning the request body and
+// the SignatureHeader value. Used by the fake provider's simulator.
+func NewSignedWebhook(secret []byte, event WebhookEvent, now time.Time) ([]byte, string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, SignPayload(secret, payload, now), nil
+}
@@ -0,0 +1,27 @@
+// Package pdfdoc creates the PDF documents the marketplace hands out, with an
+// embedded Unicode font so names in any script render.
+package pdfdoc
+
+import (
+	_ "embed"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Font is the family registered on every document, in regular and bold ("B")
+const Font = "DejaVu"
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var regular []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var bold []byte
+
+// New returns an empty A4 portrait document using millimetres
+func New() *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(Font, "", regular)
+	pdf.AddUTF8FontFromBytes(Font, "B", bold)
+	pdf.SetFont(Font, "", 11)
+	return pdf
+}
@@ -0,0 +1,19 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_RendersUnicode(t *testing.T) {
+	pdf := New()
+	pdf.AddPage()
+	pdf.SetFont(Font, "B", 14)
+	pdf.Cell(0, 10, "Иван Петров · Zoë Ağaoğlu")
+
+	var buf bytes.Buffer
+	assert.NoError(t, pdf.Output(&buf))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF")))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"time"
 
 	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
 	"github.com/Uranury/RBK_finalProject/internal/services"
@@ -11,13 +12,21 @@ import (
 )
 
 type WorkerHandler struct {
-	EmailService   *services.EmailService
-	InvoiceService *services.InvoiceService
-	logger         *slog.Logger
+	EmailService      *services.EmailService
+	InvoiceService    *services.InvoiceService
+	MarketDataService *services.MarketDataService
+	HoldService       *services.HoldService
+	WithdrawalService *services.WithdrawalService
+	WashTradeService  *services.WashTradeService
+	Reconciliation    *services.ReconciliationService
+	Transactions      *services.TransactionService
+	Statements        *services.StatementService
+	Listings          *services.ListingService
+	logger            *slog.Logger
 }
 
-func NewWorkerHandler(emailService *services.EmailService, invoiceService *services.InvoiceService, logger *slog.Logger) *WorkerHandler {
-	return &WorkerHandler{EmailService: emailService, InvoiceService: invoiceService, logger: logger}
+func NewWorkerHandler(emailService *services.EmailService, invoiceService *services.InvoiceService, marketDataService *services.MarketDataService, holdService *services.HoldService, withdrawalService *services.WithdrawalService, washTradeService *services.WashTradeService, reconciliation *services.ReconciliationService, transactions *services.TransactionService, statements *services.StatementService, listings *services.ListingService, logger *slog.Logger) *WorkerHandler {
+	return &WorkerHandler{EmailService: emailService, InvoiceService: invoiceService, MarketDataService: marketDataService, HoldService: holdService, WithdrawalService: withdrawalService, WashTradeService: washTradeService, Reconciliation: reconciliation, Transactions: transactions, Statements: statements, Listings: listings, logger: logger}
 }
 
 func (h *WorkerHandler) HandleSendInvoiceTask(ctx context.Context, t *asynq.Task) error {
@@ -29,9 +38,9 @@ func (h *WorkerHandler) HandleSendInvoiceTask(ctx context.Context, t *asynq.Task
 		return err
 	}
 
-	h.logger.Info("unmarshalled payload successfully", "order_id", payload.OrderID, "order_item_id", payload.OrderItemID, "to_email", payload.ToEmail)
+	h.logger.Info("unmarshalled payload successfully", "order_id", payload.OrderID, "to_email", payload.ToEmail)
 
-	pdfBytes, err := h.InvoiceService.GenerateInvoicePDF(ctx, payload.OrderID, payload.OrderItemID)
+	inv, pdfBytes, err := h.InvoiceService.InvoicePDF(ctx, payload.OrderID)
 	if err != nil {
 		h.logger.Error("failed to generate PDF", "err", err)
 		return err
@@ -39,11 +48,181 @@ func (h *WorkerHandler) HandleSendInvoiceTask(ctx context.Context, t *asynq.Task
 
 	h.logger.Info("PDF generated successfully", "pdf_size", len(pdfBytes))
 
-	if err := h.EmailService.SendInvoice(payload.ToEmail, pdfBytes); err != nil {
+	var xmlBytes []byte
+	if h.InvoiceService.AttachXML() {
+		if _, xmlBytes, err = h.InvoiceService.InvoiceXML(ctx, payload.OrderID); err != nil {
+			h.logger.Error("failed to generate XML", "err", err)
+			return err
+		}
+	}
+
+	if err := h.EmailService.SendInvoice(payload.ToEmail, pdfBytes, xmlBytes); err != nil {
 		h.logger.Error("failed to send invoice email", "to", payload.ToEmail, "err", err)
 		return err
 	}
+	if err := h.InvoiceService.MarkSent(ctx, inv); err != nil {
+		// The email is out, retrying would send it twice
+		h.logger.Warn("invoice sent but not marked as sent", "order_id", payload.OrderID, "err", err)
+	}
 
 	h.logger.Info("send-invoice task completed successfully", "to", payload.ToEmail)
 	return nil
 }
+
+// HandleSendCreditNoteTask issues the credit note of a refund and emails it
+// to the buyer once
+func (h *WorkerHandler) HandleSendCreditNoteTask(ctx context.Context, t *asynq.Task) error {
+	var payload jobs.SendCreditNotePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		h.logger.Error("failed to unmarshal SendCreditNote payload", "err", err)
+		return err
+	}
+
+	note, err := h.InvoiceService.IssueCreditNote(ctx, payload.OrderID, payload.TransactionID, payload.Amount, payload.Reason)
+	if err != nil {
+		h.logger.Error("failed to issue credit note", "order_id", payload.OrderID, "err", err)
+		return err
+	}
+	if note.SentAt != nil {
+		return nil
+	}
+
+	doc, pdf, err := h.InvoiceService.CreditNotePDF(ctx, note)
+	if err != nil {
+		h.logger.Error("failed to generate credit note PDF", "credit_note_id", note.ID, "err", err)
+		return err
+	}
+	if err := h.EmailService.SendCreditNote(doc.BuyerEmail, note.Number, pdf); err != nil {
+		return err
+	}
+	if err := h.InvoiceService.MarkCreditNoteSent(ctx, note); err != nil {
+		// The email is out, retrying would send it twice
+		h.logger.Warn("credit note sent but not marked as sent", "credit_note_id", note.ID, "err", err)
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleRecordMarketSalesTask(ctx context.Context, t *asynq.Task) error {
+	var payload jobs.RecordMarketSalesPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		h.logger.Error("failed to unmarshal RecordMarketSales payload", "err", err)
+		return err
+	}
+
+	if err := h.MarketDataService.RecordOrderSales(ctx, payload.OrderID); err != nil {
+		h.logger.Error("failed to record market sales", "order_id", payload.OrderID, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleBackfillMarketDataTask(ctx context.Context, t *asynq.Task) error {
+	recorded, err := h.MarketDataService.Backfill(ctx)
+	if err != nil {
+		h.logger.Error("market data backfill failed", "recorded", recorded, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleReleaseHoldsTask(ctx context.Context, t *asynq.Task) error {
+	released, err := h.HoldService.ReleaseMatured(ctx)
+	if err != nil {
+		h.logger.Error("failed to release balance holds", "released", released, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleExpireListingsTask(ctx context.Context, t *asynq.Task) error {
+	expired, err := h.Listings.ExpireListings(ctx)
+	if err != nil {
+		h.logger.Error("failed to expire listings", "expired", expired, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleProcessPayoutTask(ctx context.Context, t *asynq.Task) error {
+	var payload jobs.ProcessPayoutPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		h.logger.Error("failed to unmarshal ProcessPayout payload", "err", err)
+		return err
+	}
+
+	if err := h.WithdrawalService.ProcessPayout(ctx, payload.WithdrawalID); err != nil {
+		h.logger.Error("failed to process payout", "withdrawal_id", payload.WithdrawalID, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleRequeueStalePayoutsTask(ctx context.Context, t *asynq.Task) error {
+	requeued, err := h.WithdrawalService.RequeueStalePayouts(ctx)
+	if err != nil {
+		h.logger.Error("failed to requeue stale payouts", "requeued", requeued, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleQueueMissingCreditNotesTask(ctx context.Context, t *asynq.Task) error {
+	queued, err := h.InvoiceService.QueueMissingCreditNotes(ctx)
+	if err != nil {
+		h.logger.Error("failed to queue missing credit notes", "queued", queued, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleDetectWashTradingTask(ctx context.Context, t *asynq.Task) error {
+	if _, err := h.WashTradeService.Detect(ctx); err != nil {
+		h.logger.Error("wash trade detection failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleReconcileBalancesTask(ctx context.Context, t *asynq.Task) error {
+	if _, err := h.Reconciliation.Reconcile(ctx); err != nil {
+		h.logger.Error("balance reconciliation failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// HandleQueueTransactionExportsTask queues the exports of the month before the
+// one the task runs in
+func (h *WorkerHandler) HandleQueueTransactionExportsTask(ctx context.Context, t *asynq.Task) error {
+	now := time.Now().UTC()
+	lastMonth := now.AddDate(0, 0, -now.Day())
+	if _, err := h.Transactions.QueueMonthlyExports(ctx, lastMonth); err != nil {
+		h.logger.Error("failed to queue transaction exports", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (h *WorkerHandler) HandleSendTransactionExportTask(ctx context.Context, t *asynq.Task) error {
+	var payload jobs.SendTransactionExportPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		h.logger.Error("failed to unmarshal SendTransactionExport payload", "err", err)
+		return err
+	}
+
+	pdf, err := h.Statements.GeneratePDF(ctx, payload.UserID, payload.Month)
+	if err != nil {
+		h.logger.Error("failed to generate statement", "user_id", payload.UserID, "err", err)
+		return err
+	}
+	csv, err := h.Transactions.MonthlyExport(ctx, payload.UserID, payload.Month)
+	if err != nil {
+		h.logger.Error("failed to generate transaction export", "user_id", payload.UserID, "err", err)
+		return err
+	}
+	if err := h.EmailService.SendMonthlyStatement(payload.ToEmail, payload.Month, pdf, csv); err != nil {
+		h.logger.Error("failed to send transaction export", "user_id", payload.UserID, "err", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/metrics"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/hibiken/asynq"
+)
+
+// Logging logs the outcome of every task attempt and how long it took
+func Logging(logger *slog.Logger) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			taskID, _ := asynq.GetTaskID(ctx)
+			queue, _ := asynq.GetQueueName(ctx)
+			retried, _ := asynq.GetRetryCount(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+			start := time.Now()
+
+			err := next.ProcessTask(ctx, t)
+
+			attrs := []any{"type", t.Type(), "task_id", taskID, "queue", queue, "attempt", retried + 1, "duration", time.Since(start)}
+			switch {
+			case err == nil:
+				logger.Info("task done", attrs...)
+			case errors.Is(err, asynq.SkipRetry):
+				logger.Error("task failed permanently, archived", append(attrs, "err", err)...)
+			case retried >= maxRetry:
+				logger.Error("task failed and is out of retries, archived", append(attrs, "err", err)...)
+			default:
+				logger.Warn("task failed, will retry", append(attrs, "err", err)...)
+			}
+			return err
+		})
+	}
+}
+
+// Metrics records every task attempt with its duration and whether it failed
+func Metrics(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		queue, _ := asynq.GetQueueName(ctx)
+		start := time.Now()
+		err := next.ProcessTask(ctx, t)
+		metrics.ObserveTask(t.Type(), queue, time.Since(start), err)
+		return err
+	})
+}
+
+// SkipPermanentFailures archives tasks that failed in a way another attempt
+// can't fix right away instead of retrying them until they run out
+func SkipPermanentFailures(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		err := next.ProcessTask(ctx, t)
+		if err != nil && !errors.Is(err, asynq.SkipRetry) && jobs.IsPermanent(err) {
+			return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+		}
+		return err
+	})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+)
+
+func failingHandler(err error) asynq.Handler {
+	return asynq.HandlerFunc(func(context.Context, *asynq.Task) error { return err })
+}
+
+func TestSkipPermanentFailures(t *testing.T) {
+	task := asynq.NewTask("invoice:send", nil)
+
+	err := SkipPermanentFailures(failingHandler(apperrors.NewNotFoundError("order not found"))).ProcessTask(context.Background(), task)
+	assert.ErrorIs(t, err, asynq.SkipRetry)
+	assert.ErrorContains(t, err, "order not found")
+
+	transient := errors.New("mailgun: 503")
+	err = SkipPermanentFailures(failingHandler(transient)).ProcessTask(context.Background(), task)
+	assert.Equal(t, transient, err)
+
+	assert.NoError(t, SkipPermanentFailures(failingHandler(nil)).ProcessTask(context.Background(), task))
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	task := asynq.NewTask("invoice:send", nil)
+	handler := Logging(logger)(SkipPermanentFailures(failingHandler(apperrors.NewNotFoundError("order not found"))))
+
+	err := handler.ProcessTask(context.Background(), task)
+
+	assert.ErrorIs(t, err, asynq.SkipRetry)
+	assert.Contains(t, buf.String(), "task failed permanently")
+	assert.Contains(t, buf.String(), "type=invoice:send")
+}
@@ -2,29 +2,164 @@ package jobs
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
 const (
-	SendInvoice = "invoice:send"
+	SendInvoice        = "invoice:send"
+	RecordMarketSales  = "marketdata:record-sales"
+	BackfillMarketData = "marketdata:backfill"
+	ReleaseHolds       = "holds:release"
+	ProcessPayout      = "payout:process"
+	DetectWashTrading  = "washtrade:detect"
+	ReconcileBalances  = "reconcile:balances"
+	// QueueTransactionExports fans out the monthly statement emails
+	QueueTransactionExports = "transactions:queue-exports"
+	SendTransactionExport   = "transactions:send-export"
+	// SendCreditNote issues and emails the credit note of a refund
+	SendCreditNote = "invoice:send-credit-note"
+	// ExpireListings delists skins whose listing ran out
+	ExpireListings = "listings:expire"
+	// RequeueStalePayouts queues payouts again that were never queued or ran out of retries
+	RequeueStalePayouts = "payout:requeue-stale"
+	// QueueMissingCreditNotes queues credit notes of refunds that never got one
+	QueueMissingCreditNotes = "invoice:queue-missing-credit-notes"
 )
 
 type SendInvoicePayload struct {
-	OrderID     uuid.UUID `json:"order_id"`
-	OrderItemID uuid.UUID `json:"order_item_id"`
-	ToEmail     string    `json:"to_email"`
+	OrderID uuid.UUID `json:"order_id"`
+	ToEmail string    `json:"to_email"`
 }
 
-func NewSendInvoiceTask(orderID uuid.UUID, orderItemID uuid.UUID, toEmail string) (*asynq.Task, error) {
+func NewSendInvoiceTask(orderID uuid.UUID, toEmail string) (*asynq.Task, error) {
 	payload, err := json.Marshal(SendInvoicePayload{
-		OrderID:     orderID,
-		OrderItemID: orderItemID,
-		ToEmail:     toEmail,
+		OrderID: orderID,
+		ToEmail: toEmail,
 	})
 	if err != nil {
 		return nil, err
 	}
-	return asynq.NewTask(SendInvoice, payload), nil
+	return newTask(SendInvoice, payload), nil
+}
+
+// InvoiceTaskID identifies the invoice task of an order, so it can be looked
+// up without scanning the queues
+func InvoiceTaskID(orderID uuid.UUID) string {
+	return "invoice:" + orderID.String()
+}
+
+type SendCreditNotePayload struct {
+	OrderID uuid.UUID `json:"order_id"`
+	// TransactionID is the buyer's refund transaction
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Amount        float64   `json:"amount"`
+	Reason        string    `json:"reason"`
+}
+
+func NewSendCreditNoteTask(orderID, transactionID uuid.UUID, amount float64, reason string) (*asynq.Task, error) {
+	payload, err := json.Marshal(SendCreditNotePayload{
+		OrderID:       orderID,
+		TransactionID: transactionID,
+		Amount:        amount,
+		Reason:        reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newTask(SendCreditNote, payload), nil
+}
+
+// CreditNoteTaskID identifies the credit note task of a refund, so the same
+// refund is never queued twice
+func CreditNoteTaskID(transactionID uuid.UUID) string {
+	return "credit-note:" + transactionID.String()
+}
+
+type RecordMarketSalesPayload struct {
+	OrderID uuid.UUID `json:"order_id"`
+}
+
+func NewRecordMarketSalesTask(orderID uuid.UUID) (*asynq.Task, error) {
+	payload, err := json.Marshal(RecordMarketSalesPayload{OrderID: orderID})
+	if err != nil {
+		return nil, err
+	}
+	return newTask(RecordMarketSales, payload), nil
+}
+
+// MarketSalesTaskID identifies the market data task of an order
+func MarketSalesTaskID(orderID uuid.UUID) string {
+	return "market-sales:" + orderID.String()
+}
+
+// NewBackfillMarketDataTask sweeps completed sales that were never recorded,
+// e.g. sales made before the aggregator existed or whose task was lost.
+func NewBackfillMarketDataTask() *asynq.Task {
+	return newTask(BackfillMarketData, nil)
+}
+
+// NewReleaseHoldsTask releases every trade hold that has matured
+func NewReleaseHoldsTask() *asynq.Task {
+	return newTask(ReleaseHolds, nil)
+}
+
+// NewExpireListingsTask delists every skin whose listing ran out and tells the owners
+func NewExpireListingsTask() *asynq.Task {
+	return newTask(ExpireListings, nil)
+}
+
+// NewRequeueStalePayoutsTask queues the payout job again for withdrawals stuck in approved or processing
+func NewRequeueStalePayoutsTask() *asynq.Task {
+	return newTask(RequeueStalePayouts, nil)
+}
+
+// NewQueueMissingCreditNotesTask queues the credit note task of refunds that have no credit note
+func NewQueueMissingCreditNotesTask() *asynq.Task {
+	return newTask(QueueMissingCreditNotes, nil)
+}
+
+// NewDetectWashTradingTask analyses recent sales for wash trading
+func NewDetectWashTradingTask() *asynq.Task {
+	return newTask(DetectWashTrading, nil)
+}
+
+// NewReconcileBalancesTask checks every balance against the transaction history
+func NewReconcileBalancesTask() *asynq.Task {
+	return newTask(ReconcileBalances, nil)
+}
+
+// NewQueueTransactionExportsTask queues last month's transaction export for
+// every user who had transactions
+func NewQueueTransactionExportsTask() *asynq.Task {
+	return newTask(QueueTransactionExports, nil)
+}
+
+type SendTransactionExportPayload struct {
+	UserID  uuid.UUID `json:"user_id"`
+	ToEmail string    `json:"to_email"`
+	// Month is any instant of the exported month
+	Month time.Time `json:"month"`
+}
+
+func NewSendTransactionExportTask(userID uuid.UUID, toEmail string, month time.Time) (*asynq.Task, error) {
+	payload, err := json.Marshal(SendTransactionExportPayload{UserID: userID, ToEmail: toEmail, Month: month})
+	if err != nil {
+		return nil, err
+	}
+	return newTask(SendTransactionExport, payload), nil
+}
+
+type ProcessPayoutPayload struct {
+	WithdrawalID uuid.UUID `json:"withdrawal_id"`
+}
+
+func NewProcessPayoutTask(withdrawalID uuid.UUID) (*asynq.Task, error) {
+	payload, err := json.Marshal(ProcessPayoutPayload{WithdrawalID: withdrawalID})
+	if err != nil {
+		return nil, err
+	}
+	return newTask(ProcessPayout, payload), nil
 }
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/hibiken/asynq"
+)
+
+// Policy is where a task type runs, how often it is retried and how long
+// one attempt may take
+type Policy struct {
+	Queue    string
+	MaxRetry int
+	Timeout  time.Duration
+	// Backoff returns the delay before the n-th retry, starting at 1
+	Backoff func(n int) time.Duration
+	// Retention is how long a completed task is kept, zero deletes it right away
+	Retention time.Duration
+}
+
+// Order jobs are kept after completing so an order's jobs can be looked up
+const orderJobRetention = 7 * 24 * time.Hour
+
+// defaultPolicy applies to task types without their own policy
+var defaultPolicy = Policy{
+	Queue:    "default",
+	MaxRetry: 25,
+	Timeout:  30 * time.Minute,
+	Backoff:  exponential(30*time.Second, 6*time.Hour),
+}
+
+var policies = map[string]Policy{
+	// Emails go through a third party that fails now and then, keep trying for about a day
+	SendInvoice:           {Queue: "default", MaxRetry: 12, Timeout: 2 * time.Minute, Backoff: exponential(30*time.Second, 4*time.Hour), Retention: orderJobRetention},
+	SendCreditNote:        {Queue: "default", MaxRetry: 12, Timeout: 2 * time.Minute, Backoff: exponential(30*time.Second, 4*time.Hour), Retention: orderJobRetention},
+	SendTransactionExport: {Queue: "low", MaxRetry: 12, Timeout: 5 * time.Minute, Backoff: exponential(time.Minute, 4*time.Hour)},
+	// Money leaves the platform, retry fast but don't hammer the provider
+	ProcessPayout:     {Queue: "critical", MaxRetry: 10, Timeout: 2 * time.Minute, Backoff: exponential(15*time.Second, time.Hour)},
+	RecordMarketSales: {Queue: "low", MaxRetry: 8, Timeout: time.Minute, Backoff: exponential(10*time.Second, 30*time.Minute), Retention: orderJobRetention},
+	// Scheduled sweeps run again on their next tick anyway
+	ReleaseHolds:            {Queue: "default", MaxRetry: 3, Timeout: 5 * time.Minute, Backoff: constant(time.Minute)},
+	ExpireListings:          {Queue: "default", MaxRetry: 3, Timeout: 5 * time.Minute, Backoff: constant(time.Minute)},
+	RequeueStalePayouts:     {Queue: "critical", MaxRetry: 3, Timeout: 5 * time.Minute, Backoff: constant(time.Minute)},
+	QueueMissingCreditNotes: {Queue: "default", MaxRetry: 3, Timeout: 5 * time.Minute, Backoff: constant(time.Minute)},
+	BackfillMarketData:      {Queue: "low", MaxRetry: 3, Timeout: 30 * time.Minute, Backoff: constant(5 * time.Minute)},
+	DetectWashTrading:       {Queue: "low", MaxRetry: 2, Timeout: 30 * time.Minute, Backoff: constant(5 * time.Minute)},
+	ReconcileBalances:       {Queue: "low", MaxRetry: 2, Timeout: time.Hour, Backoff: constant(10 * time.Minute)},
+	QueueTransactionExports: {Queue: "low", MaxRetry: 5, Timeout: 10 * time.Minute, Backoff: exponential(time.Minute, time.Hour)},
+}
+
+// PolicyFor returns the policy of a task type
+func PolicyFor(taskType string) Policy {
+	if p, ok := policies[taskType]; ok {
+		return p
+	}
+	return defaultPolicy
+}
+
+// Options are the enqueue options of the policy, tasks carry them so callers
+// only add what is specific to one enqueue such as a task ID
+func (p Policy) Options() []asynq.Option {
+	opts := []asynq.Option{asynq.Queue(p.Queue), asynq.MaxRetry(p.MaxRetry), asynq.Timeout(p.Timeout)}
+	if p.Retention > 0 {
+		opts = append(opts, asynq.Retention(p.Retention))
+	}
+	return opts
+}
+
+// RetryDelay is the worker's asynq.RetryDelayFunc, it backs off by the policy
+// of the failed task's type
+func RetryDelay(n int, err error, t *asynq.Task) time.Duration {
+	return PolicyFor(t.Type()).Backoff(n)
+}
+
+// newTask creates a task with the policy of its type
+func newTask(taskType string, payload []byte) *asynq.Task {
+	return asynq.NewTask(taskType, payload, PolicyFor(taskType).Options()...)
+}
+
+// IsPermanent reports whether a task failed in a way another attempt can't
+// fix: the payload can't be decoded, or a service rejected the task itself,
+// e.g. the order is gone. Only internal errors, such as a database or an
+// email provider being down, are worth retrying.
+func IsPermanent(err error) bool {
+	if errors.Is(err, asynq.SkipRetry) {
+		return true
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return true
+	}
+	var appErr *apperrors.AppError
+	return errors.As(err, &appErr) && appErr.Code != apperrors.CodeInternal
+}
+
+func exponential(base, max time.Duration) func(int) time.Duration {
+	return func(n int) time.Duration {
+		d := base
+		for i := 1; i < n && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+func constant(d time.Duration) func(int) time.Duration {
+	return func(int) time.Duration { return d }
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyFor(t *testing.T) {
+	assert.Equal(t, "critical", PolicyFor(ProcessPayout).Queue)
+	assert.Equal(t, 10, PolicyFor(ProcessPayout).MaxRetry)
+	assert.Equal(t, defaultPolicy.MaxRetry, PolicyFor("unknown:task").MaxRetry)
+
+	for taskType, p := range policies {
+		assert.NotEmpty(t, p.Queue, taskType)
+		assert.Positive(t, p.Timeout, taskType)
+		assert.NotNil(t, p.Backoff, taskType)
+	}
+}
+
+func TestPolicy_Options_Retention(t *testing.T) {
+	retention := func(taskType string) any {
+		for _, opt := range PolicyFor(taskType).Options() {
+			if opt.Type() == asynq.RetentionOpt {
+				return opt.Value()
+			}
+		}
+		return nil
+	}
+
+	assert.Equal(t, 7*24*time.Hour, retention(SendInvoice))
+	assert.Equal(t, 7*24*time.Hour, retention(SendCreditNote))
+	assert.Equal(t, 7*24*time.Hour, retention(RecordMarketSales))
+	assert.Nil(t, retention(ReleaseHolds))
+}
+
+func TestRetryDelay(t *testing.T) {
+	invoice := asynq.NewTask(SendInvoice, nil)
+	assert.Equal(t, 30*time.Second, RetryDelay(1, errors.New("boom"), invoice))
+	assert.Equal(t, time.Minute, RetryDelay(2, errors.New("boom"), invoice))
+	assert.Equal(t, 4*time.Hour, RetryDelay(12, errors.New("boom"), invoice))
+
+	holds := asynq.NewTask(ReleaseHolds, nil)
+	assert.Equal(t, time.Minute, RetryDelay(3, errors.New("boom"), holds))
+}
+
+func TestExponential(t *testing.T) {
+	backoff := exponential(time.Second, 10*time.Second)
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, 8*time.Second, backoff(4))
+	assert.Equal(t, 10*time.Second, backoff(5))
+	assert.Equal(t, 10*time.Second, backoff(1000))
+}
+
+func TestIsPermanent(t *testing.T) {
+	var payload SendInvoicePayload
+	decodeErr := json.Unmarshal([]byte(`{"order_id":`), &payload)
+	typeErr := json.Unmarshal([]byte(`{"order_id":1}`), &payload)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"order not found", apperrors.NewNotFoundError("order not found"), true},
+		{"wrapped validation error", fmt.Errorf("issue credit note: %w", apperrors.NewValidationError("nothing to refund")), true},
+		{"broken payload", decodeErr, true},
+		{"payload of another shape", typeErr, true},
+		{"explicit skip", fmt.Errorf("give up: %w", asynq.SkipRetry), true},
+		{"database down", apperrors.WrapInternal(errors.New("connection refused"), "failed to get order"), false},
+		{"email provider error", errors.New("mailgun: 503"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsPermanent(tt.err))
+		})
+	}
+}
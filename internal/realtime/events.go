@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	// Public marketplace events
+	EventListingCreated EventType = "listing.created"
+	EventListingRemoved EventType = "listing.removed"
+	EventPriceChanged   EventType = "listing.price_changed"
+	EventSkinSold       EventType = "skin.sold"
+
+	// Private per-user events
+	EventBalanceUpdated EventType = "balance.updated"
+	EventOrderUpdated   EventType = "order.updated"
+	EventListingExpired EventType = "listing.expired"
+)
+
+const (
+	marketChannel     = "realtime:market"
+	userChannelPrefix = "realtime:user:"
+)
+
+// Event is the message pushed to streaming clients. Marketplace events carry
+// the affected skin, private events carry the user's order or balance.
+type Event struct {
+	Type           EventType     `json:"type"`
+	Skin           *models.Skin  `json:"skin,omitempty"`
+	OldPrice       *float64      `json:"old_price,omitempty"`
+	Order          *models.Order `json:"order,omitempty"`
+	Balance        *float64      `json:"balance,omitempty"`
+	PendingBalance *float64      `json:"pending_balance,omitempty"`
+	OccurredAt     time.Time     `json:"occurred_at"`
+}
+
+func userChannel(userID uuid.UUID) string {
+	return userChannelPrefix + userID.String()
+}
+
+// NewSkinEvent builds a marketplace event for the given skin
+func NewSkinEvent(eventType EventType, skin *models.Skin) Event {
+	return Event{Type: eventType, Skin: skin, OccurredAt: time.Now()}
+}
+
+// NewPriceChangedEvent builds a listing.price_changed event keeping the previous price
+func NewPriceChangedEvent(skin *models.Skin, oldPrice float64) Event {
+	return Event{Type: EventPriceChanged, Skin: skin, OldPrice: &oldPrice, OccurredAt: time.Now()}
+}
+
+// NewBalanceEvent builds a private balance.updated event
+func NewBalanceEvent(balance float64) Event {
+	return Event{Type: EventBalanceUpdated, Balance: &balance, OccurredAt: time.Now()}
+}
+
+// NewBalancesEvent builds a private balance.updated event carrying both the
+// available and the pending (on hold) balance
+func NewBalancesEvent(available, pending float64) Event {
+	return Event{Type: EventBalanceUpdated, Balance: &available, PendingBalance: &pending, OccurredAt: time.Now()}
+}
+
+// NewOrderEvent builds a private order.updated event
+func NewOrderEvent(order *models.Order) Event {
+	return Event{Type: EventOrderUpdated, Order: order, OccurredAt: time.Now()}
+}
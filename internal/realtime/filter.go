@@ -0,0 +1,35 @@
+package realtime
+
+import (
+	"github.com/Uranury/RBK_finalProject/internal/models"
+)
+
+// Filter narrows down which marketplace events a subscriber receives.
+// Zero values mean "no restriction".
+type Filter struct {
+	Gun      models.Gun
+	Wear     models.Wear
+	MinPrice float64
+	MaxPrice float64
+}
+
+// Match reports whether the event passes the filter. Events that don't carry
+// a skin (private balance/order updates) are never filtered out.
+func (f Filter) Match(e Event) bool {
+	if e.Skin == nil {
+		return true
+	}
+	if f.Gun != "" && e.Skin.Gun != f.Gun {
+		return false
+	}
+	if f.Wear != "" && e.Skin.Wear != f.Wear {
+		return false
+	}
+	if f.MinPrice > 0 && e.Skin.Price < f.MinPrice {
+		return false
+	}
+	if f.MaxPrice > 0 && e.Skin.Price > f.MaxPrice {
+		return false
+	}
+	return true
+}
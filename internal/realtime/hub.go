@@ -0,0 +1,148 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const subscriptionBuffer = 64
+
+// Subscription is a single streaming client attached to the hub
+type Subscription struct {
+	id     uuid.UUID
+	userID *uuid.UUID
+	filter Filter
+	events chan Event
+}
+
+// Events returns the channel the client reads from. It is closed when the
+// subscription is removed or the hub shuts down.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub receives events from Redis and fans them out to the clients connected
+// to this API instance.
+type Hub struct {
+	redisClient *redis.Client
+	logger      *slog.Logger
+
+	mu     sync.RWMutex
+	subs   map[uuid.UUID]*Subscription
+	closed bool
+}
+
+func NewHub(redisClient *redis.Client, logger *slog.Logger) *Hub {
+	return &Hub{
+		redisClient: redisClient,
+		logger:      logger,
+		subs:        make(map[uuid.UUID]*Subscription),
+	}
+}
+
+// Run listens on the Redis channels until ctx is cancelled
+func (h *Hub) Run(ctx context.Context) {
+	pubsub := h.redisClient.PSubscribe(ctx, marketChannel, userChannelPrefix+"*")
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			h.logger.Error("failed to close realtime pubsub", "error", err)
+		}
+	}()
+
+	h.logger.Info("realtime hub started")
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("realtime hub stopped")
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				h.logger.Warn("failed to decode realtime event", "channel", msg.Channel, "error", err)
+				continue
+			}
+			h.dispatch(msg.Channel, event)
+		}
+	}
+}
+
+func (h *Hub) dispatch(channel string, event Event) {
+	var target *uuid.UUID
+	if strings.HasPrefix(channel, userChannelPrefix) {
+		id, err := uuid.Parse(strings.TrimPrefix(channel, userChannelPrefix))
+		if err != nil {
+			h.logger.Warn("invalid user channel", "channel", channel)
+			return
+		}
+		target = &id
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.subs {
+		if target != nil && (sub.userID == nil || *sub.userID != *target) {
+			continue
+		}
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Slow consumers lose events rather than blocking everyone else
+			h.logger.Warn("dropping realtime event for slow subscriber", "subscription_id", sub.id, "type", event.Type)
+		}
+	}
+}
+
+// Subscribe registers a client. userID is nil for anonymous clients, which
+// only receive public marketplace events.
+func (h *Hub) Subscribe(userID *uuid.UUID, filter Filter) *Subscription {
+	sub := &Subscription{
+		id:     uuid.New(),
+		userID: userID,
+		filter: filter,
+		events: make(chan Event, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.events)
+		return sub
+	}
+	h.subs[sub.id] = sub
+	return sub
+}
+
+// Unsubscribe removes the client and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub.id]; ok {
+		delete(h.subs, sub.id)
+		close(sub.events)
+	}
+}
+
+// Close disconnects every client so long-lived streams don't block shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for id, sub := range h.subs {
+		delete(h.subs, id)
+		close(sub.events)
+	}
+}
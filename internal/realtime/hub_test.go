@@ -0,0 +1,66 @@
+package realtime
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Match(t *testing.T) {
+	skin := &models.Skin{Gun: models.AWP, Wear: models.FactoryNew, Price: 150}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		event  Event
+		want   bool
+	}{
+		{"empty filter matches everything", Filter{}, NewSkinEvent(EventListingCreated, skin), true},
+		{"gun matches", Filter{Gun: models.AWP}, NewSkinEvent(EventListingCreated, skin), true},
+		{"gun mismatch", Filter{Gun: models.AK47}, NewSkinEvent(EventListingCreated, skin), false},
+		{"wear mismatch", Filter{Wear: models.BattleScarred}, NewSkinEvent(EventSkinSold, skin), false},
+		{"price inside range", Filter{MinPrice: 100, MaxPrice: 200}, NewSkinEvent(EventSkinSold, skin), true},
+		{"price below min", Filter{MinPrice: 151}, NewSkinEvent(EventSkinSold, skin), false},
+		{"price above max", Filter{MaxPrice: 149.99}, NewSkinEvent(EventSkinSold, skin), false},
+		{"private events ignore filters", Filter{Gun: models.AK47}, NewBalanceEvent(10), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(tt.event))
+		})
+	}
+}
+
+func TestHub_Dispatch(t *testing.T) {
+	hub := NewHub(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	alice := uuid.New()
+	bob := uuid.New()
+
+	anonymous := hub.Subscribe(nil, Filter{Gun: models.AWP})
+	aliceSub := hub.Subscribe(&alice, Filter{})
+	bobSub := hub.Subscribe(&bob, Filter{})
+
+	hub.dispatch(marketChannel, NewSkinEvent(EventListingCreated, &models.Skin{Gun: models.AWP}))
+	hub.dispatch(marketChannel, NewSkinEvent(EventListingCreated, &models.Skin{Gun: models.AK47}))
+	hub.dispatch(userChannel(alice), NewBalanceEvent(42))
+
+	assert.Len(t, anonymous.Events(), 1)
+	assert.Len(t, aliceSub.Events(), 3)
+	assert.Len(t, bobSub.Events(), 2)
+
+	hub.Unsubscribe(aliceSub)
+	hub.Close()
+
+	_, open := <-bobSub.Events()
+	assert.True(t, open, "buffered events are still delivered after close")
+
+	late := hub.Subscribe(nil, Filter{})
+	_, open = <-late.Events()
+	assert.False(t, open, "subscribing after close yields a closed channel")
+}
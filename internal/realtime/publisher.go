@@ -0,0 +1,47 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Publisher sends events to every API instance through Redis pub/sub
+type Publisher interface {
+	PublishMarket(ctx context.Context, event Event) error
+	PublishUser(ctx context.Context, userID uuid.UUID, event Event) error
+}
+
+type redisPublisher struct {
+	client *redis.Client
+}
+
+func NewRedisPublisher(client *redis.Client) Publisher {
+	return &redisPublisher{client: client}
+}
+
+func (p *redisPublisher) PublishMarket(ctx context.Context, event Event) error {
+	return p.publish(ctx, marketChannel, event)
+}
+
+func (p *redisPublisher) PublishUser(ctx context.Context, userID uuid.UUID, event Event) error {
+	return p.publish(ctx, userChannel(userID), event)
+}
+
+func (p *redisPublisher) publish(ctx context.Context, channel string, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(ctx, channel, payload).Err()
+}
+
+// NopPublisher discards every event. Useful for tests and for processes
+// that don't stream anything (e.g. the worker).
+type NopPublisher struct{}
+
+func (NopPublisher) PublishMarket(context.Context, Event) error { return nil }
+
+func (NopPublisher) PublishUser(context.Context, uuid.UUID, Event) error { return nil }
@@ -0,0 +1,152 @@
+package reconcile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+)
+
+// CheckAccount replays a user's transaction history, oldest first, and
+// reports where it doesn't hold together or doesn't match the stored balances.
+//
+// Every row must start where the previous row of the same balance type ended
+// (the first one at zero) and move it by its amount. A hold release moves
+// money from the pending to the available balance but is recorded on the
+// available side only, so it also lowers the pending chain. The available
+// and pending balances must equal where their chains end; the pending,
+// frozen and reserved balances must also equal the held trade holds, the
+// open disputes and the outstanding withdrawals.
+func CheckAccount(a models.AccountSnapshot, history []*models.Transaction) []*models.Discrepancy {
+	var found []*models.Discrepancy
+	userID := a.UserID
+	running := map[models.BalanceType]float64{
+		models.BalanceAvailable: 0,
+		models.BalancePending:   0,
+	}
+
+	for _, txn := range chainOrder(history, running) {
+		bt := balanceType(txn)
+		txnID := txn.ID
+		if !equal(txn.BalanceBefore, running[bt]) {
+			found = append(found, discrepancy(models.DiscrepancyGap, &userID, &txnID, running[bt], txn.BalanceBefore,
+				fmt.Sprintf("%s %s balance starts at %.2f but the previous row ended at %.2f", txn.Type, bt, txn.BalanceBefore, running[bt])))
+		}
+		moved := math.Abs(txn.BalanceAfter - txn.BalanceBefore)
+		if !equal(moved, math.Abs(txn.Amount)) {
+			found = append(found, discrepancy(models.DiscrepancyBrokenRow, &userID, &txnID, math.Abs(txn.Amount), moved,
+				fmt.Sprintf("%s of %.2f moved the %s balance from %.2f to %.2f", txn.Type, txn.Amount, bt, txn.BalanceBefore, txn.BalanceAfter)))
+		}
+
+		running[bt] = txn.BalanceAfter
+		if txn.Type == models.HoldRelease {
+			running[models.BalancePending] = round(running[models.BalancePending] - math.Abs(txn.Amount))
+		}
+	}
+
+	check := func(kind models.DiscrepancyKind, expected, actual float64, detail string) {
+		if !equal(expected, actual) {
+			found = append(found, discrepancy(kind, &userID, nil, expected, actual, detail))
+		}
+	}
+	check(models.DiscrepancyBalance, running[models.BalanceAvailable], a.Balance,
+		"balance differs from where the transaction history ends")
+	check(models.DiscrepancyPending, running[models.BalancePending], a.PendingBalance,
+		"pending balance differs from where the pending transaction history ends")
+	check(models.DiscrepancyPending, a.HeldAmount, a.PendingBalance,
+		"pending balance differs from the held trade holds")
+	check(models.DiscrepancyFrozen, a.DisputedAmount, a.FrozenBalance,
+		"frozen balance differs from the amounts frozen by open disputes")
+	check(models.DiscrepancyReserved, a.WithdrawingAmount, a.ReservedBalance,
+		"reserved balance differs from the withdrawals waiting for review or payout")
+	return found
+}
+
+// CheckOrders reports paid orders without the transactions that should have
+// moved their money
+func CheckOrders(orphans []models.OrphanOrder) []*models.Discrepancy {
+	found := make([]*models.Discrepancy, 0, len(orphans))
+	for _, o := range orphans {
+		orderID, userID := o.OrderID, o.UserID
+		d := discrepancy(models.DiscrepancyOrphanOrder, &userID, nil, o.Amount, 0,
+			fmt.Sprintf("%s order has no %s transaction for the %s", o.Status, sideTransaction(o.Side), o.Side))
+		d.OrderID = &orderID
+		found = append(found, d)
+	}
+	return found
+}
+
+func sideTransaction(side string) models.TransactionType {
+	if side == "seller" {
+		return models.Sale
+	}
+	return models.Purchase
+}
+
+// chainOrder returns the rows in the order they were applied. Rows written in
+// the same database transaction share a timestamp (a sale and its fee), so
+// within one timestamp the row that continues a chain goes first.
+func chainOrder(history []*models.Transaction, start map[models.BalanceType]float64) []*models.Transaction {
+	running := make(map[models.BalanceType]float64, len(start))
+	for bt, v := range start {
+		running[bt] = v
+	}
+
+	ordered := make([]*models.Transaction, 0, len(history))
+	for i := 0; i < len(history); {
+		j := i
+		for j < len(history) && history[j].CreatedAt.Equal(history[i].CreatedAt) {
+			j++
+		}
+		group := append([]*models.Transaction(nil), history[i:j]...)
+		for len(group) > 0 {
+			next := 0
+			for k, txn := range group {
+				if equal(txn.BalanceBefore, running[balanceType(txn)]) {
+					next = k
+					break
+				}
+			}
+			txn := group[next]
+			group = append(group[:next], group[next+1:]...)
+
+			ordered = append(ordered, txn)
+			running[balanceType(txn)] = txn.BalanceAfter
+			if txn.Type == models.HoldRelease {
+				running[models.BalancePending] = round(running[models.BalancePending] - math.Abs(txn.Amount))
+			}
+		}
+		i = j
+	}
+	return ordered
+}
+
+func balanceType(txn *models.Transaction) models.BalanceType {
+	if txn.BalanceType == "" {
+		return models.BalanceAvailable
+	}
+	return txn.BalanceType
+}
+
+func discrepancy(kind models.DiscrepancyKind, userID, txnID *uuid.UUID, expected, actual float64, detail string) *models.Discrepancy {
+	expected, actual = round(expected), round(actual)
+	return &models.Discrepancy{
+		Kind:          kind,
+		UserID:        userID,
+		TransactionID: txnID,
+		Expected:      &expected,
+		Actual:        &actual,
+		Detail:        detail,
+	}
+}
+
+// equal compares amounts to the cent. Balances are stored as floats, so they
+// can be off by a rounding error.
+func equal(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
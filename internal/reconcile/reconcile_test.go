@@ -0,0 +1,130 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func row(typ models.TransactionType, bt models.BalanceType, amount, before, after float64, minutes int) *models.Transaction {
+	return &models.Transaction{
+		ID:            uuid.New(),
+		Type:          typ,
+		BalanceType:   bt,
+		Amount:        amount,
+		BalanceBefore: before,
+		BalanceAfter:  after,
+		CreatedAt:     start.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func kinds(found []*models.Discrepancy) []models.DiscrepancyKind {
+	out := make([]models.DiscrepancyKind, 0, len(found))
+	for _, d := range found {
+		out = append(out, d.Kind)
+	}
+	return out
+}
+
+func TestCheckAccount_Consistent(t *testing.T) {
+	history := []*models.Transaction{
+		row(models.Deposit, models.BalanceAvailable, 100, 0, 100, 0),
+		row(models.Purchase, models.BalanceAvailable, -30, 100, 70, 1),
+		// a sale with its fee, written in the same transaction in either order
+		row(models.Fee, models.BalancePending, -1, 20, 19, 2),
+		row(models.Sale, models.BalancePending, 20, 0, 20, 2),
+		row(models.HoldRelease, models.BalanceAvailable, 19, 70, 89, 3),
+		row(models.Withdraw, models.BalanceAvailable, 50, 89, 39, 4),
+	}
+	account := models.AccountSnapshot{Balance: 39, ReservedBalance: 50, WithdrawingAmount: 50}
+
+	assert.Empty(t, CheckAccount(account, history))
+}
+
+func TestCheckAccount_Gap(t *testing.T) {
+	history := []*models.Transaction{
+		row(models.Deposit, models.BalanceAvailable, 100, 0, 100, 0),
+		// a 20.00 purchase row is missing here
+		row(models.Deposit, models.BalanceAvailable, 10, 80, 90, 2),
+	}
+
+	found := CheckAccount(models.AccountSnapshot{Balance: 90}, history)
+
+	if assert.Equal(t, []models.DiscrepancyKind{models.DiscrepancyGap}, kinds(found)) {
+		assert.Equal(t, history[1].ID, *found[0].TransactionID)
+		assert.Equal(t, 100.0, *found[0].Expected)
+		assert.Equal(t, 80.0, *found[0].Actual)
+	}
+}
+
+func TestCheckAccount_BrokenRow(t *testing.T) {
+	history := []*models.Transaction{
+		row(models.Deposit, models.BalanceAvailable, 100, 0, 110, 0),
+	}
+
+	found := CheckAccount(models.AccountSnapshot{Balance: 110}, history)
+
+	assert.Equal(t, []models.DiscrepancyKind{models.DiscrepancyBrokenRow}, kinds(found))
+}
+
+func TestCheckAccount_BalanceWithoutHistory(t *testing.T) {
+	// e.g. a deposit whose transaction row was never written
+	history := []*models.Transaction{
+		row(models.Deposit, models.BalanceAvailable, 100, 0, 100, 0),
+	}
+
+	found := CheckAccount(models.AccountSnapshot{Balance: 150}, history)
+
+	if assert.Equal(t, []models.DiscrepancyKind{models.DiscrepancyBalance}, kinds(found)) {
+		assert.Equal(t, 100.0, *found[0].Expected)
+		assert.Equal(t, 150.0, *found[0].Actual)
+		assert.Nil(t, found[0].TransactionID)
+	}
+}
+
+func TestCheckAccount_SideBalances(t *testing.T) {
+	account := models.AccountSnapshot{
+		PendingBalance:    5,
+		HeldAmount:        0,
+		FrozenBalance:     12,
+		DisputedAmount:    10,
+		ReservedBalance:   0,
+		WithdrawingAmount: 25,
+	}
+
+	found := CheckAccount(account, nil)
+
+	assert.Equal(t, []models.DiscrepancyKind{
+		models.DiscrepancyPending, // pending history ends at zero
+		models.DiscrepancyPending, // no held trade holds
+		models.DiscrepancyFrozen,
+		models.DiscrepancyReserved,
+	}, kinds(found))
+}
+
+func TestCheckAccount_FloatNoise(t *testing.T) {
+	history := []*models.Transaction{
+		row(models.Deposit, models.BalanceAvailable, 0.1, 0, 0.1, 0),
+		row(models.Deposit, models.BalanceAvailable, 0.2, 0.1, 0.3, 1),
+	}
+
+	assert.Empty(t, CheckAccount(models.AccountSnapshot{Balance: 0.1 + 0.2}, history))
+}
+
+func TestCheckOrders(t *testing.T) {
+	orderID, buyerID := uuid.New(), uuid.New()
+
+	found := CheckOrders([]models.OrphanOrder{{OrderID: orderID, UserID: buyerID, Side: "buyer", Amount: 25, Status: models.OrderStatusCompleted}})
+
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, models.DiscrepancyOrphanOrder, found[0].Kind)
+		assert.Equal(t, orderID, *found[0].OrderID)
+		assert.Equal(t, buyerID, *found[0].UserID)
+		assert.Equal(t, "completed order has no purchase transaction for the buyer", found[0].Detail)
+	}
+}
@@ -0,0 +1,21 @@
+package dispute
+
+import (
+	"context"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, dispute *models.Dispute) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Dispute, error)
+	GetByOrderID(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*models.Dispute, error)
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Dispute, error)
+	ListByStatus(ctx context.Context, status models.DisputeStatus) ([]*models.Dispute, error)
+	Resolve(ctx context.Context, tx *sqlx.Tx, dispute *models.Dispute) error
+	AddMessage(ctx context.Context, message *models.DisputeMessage) error
+	GetMessages(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeMessage, error)
+}
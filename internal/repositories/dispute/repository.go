@@ -0,0 +1,111 @@
+package dispute
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, dispute *models.Dispute) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO disputes (id, order_id, buyer_id, seller_id, status, reason, frozen_amount, refund_amount, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		dispute.ID, dispute.OrderID, dispute.BuyerID, dispute.SellerID, dispute.Status, dispute.Reason,
+		dispute.FrozenAmount, dispute.RefundAmount, dispute.CreatedAt, dispute.UpdatedAt)
+	return err
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	dispute := &models.Dispute{}
+	err := r.db.GetContext(ctx, dispute, "SELECT * FROM disputes WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return dispute, nil
+}
+
+func (r *repository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Dispute, error) {
+	dispute := &models.Dispute{}
+	err := tx.GetContext(ctx, dispute, "SELECT * FROM disputes WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return dispute, nil
+}
+
+func (r *repository) GetByOrderID(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*models.Dispute, error) {
+	dispute := &models.Dispute{}
+	err := tx.GetContext(ctx, dispute, "SELECT * FROM disputes WHERE order_id = $1", orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return dispute, nil
+}
+
+func (r *repository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Dispute, error) {
+	disputes := []*models.Dispute{}
+	err := r.db.SelectContext(ctx, &disputes,
+		"SELECT * FROM disputes WHERE buyer_id = $1 OR seller_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+func (r *repository) ListByStatus(ctx context.Context, status models.DisputeStatus) ([]*models.Dispute, error) {
+	disputes := []*models.Dispute{}
+	err := r.db.SelectContext(ctx, &disputes,
+		"SELECT * FROM disputes WHERE status = $1 ORDER BY created_at", status)
+	if err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+func (r *repository) Resolve(ctx context.Context, tx *sqlx.Tx, dispute *models.Dispute) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE disputes
+         SET status = $1, refund_amount = $2, resolution = $3, resolved_by = $4, resolved_at = $5, updated_at = NOW()
+         WHERE id = $6`,
+		dispute.Status, dispute.RefundAmount, dispute.Resolution, dispute.ResolvedBy, dispute.ResolvedAt, dispute.ID)
+	return err
+}
+
+func (r *repository) AddMessage(ctx context.Context, message *models.DisputeMessage) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dispute_messages (id, dispute_id, author_id, body, created_at)
+         VALUES ($1, $2, $3, $4, $5)`,
+		message.ID, message.DisputeID, message.AuthorID, message.Body, message.CreatedAt)
+	return err
+}
+
+func (r *repository) GetMessages(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeMessage, error) {
+	messages := []*models.DisputeMessage{}
+	err := r.db.SelectContext(ctx, &messages,
+		"SELECT * FROM dispute_messages WHERE dispute_id = $1 ORDER BY created_at, id", disputeID)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
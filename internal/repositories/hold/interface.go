@@ -0,0 +1,17 @@
+package hold
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, hold *models.BalanceHold) error
+	GetActiveByOrder(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.BalanceHold, error)
+	GetMatured(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) ([]*models.BalanceHold, error)
+	Update(ctx context.Context, tx *sqlx.Tx, hold *models.BalanceHold) error
+}
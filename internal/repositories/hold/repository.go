@@ -0,0 +1,66 @@
+package hold
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, hold *models.BalanceHold) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO balance_holds (id, user_id, order_id, order_item_id, amount, status, release_at, risk_flag_id, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		hold.ID, hold.UserID, hold.OrderID, hold.OrderItemID, hold.Amount, hold.Status, hold.ReleaseAt, hold.RiskFlagID, hold.CreatedAt, hold.UpdatedAt)
+	return err
+}
+
+// GetActiveByOrder locks the holds of an order that haven't been released yet
+func (r *repository) GetActiveByOrder(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.BalanceHold, error) {
+	holds := []*models.BalanceHold{}
+	err := tx.SelectContext(ctx, &holds,
+		`SELECT * FROM balance_holds WHERE order_id = $1 AND status = $2 ORDER BY created_at FOR UPDATE`,
+		orderID, models.HoldHeld)
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// GetMatured locks up to limit holds due for release. Holds of disputed orders
+// stay pending until the dispute is resolved, holds placed by a risk rule until
+// an admin clears the flag, and rows locked by another worker are skipped.
+func (r *repository) GetMatured(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) ([]*models.BalanceHold, error) {
+	holds := []*models.BalanceHold{}
+	err := tx.SelectContext(ctx, &holds,
+		`SELECT h.* FROM balance_holds h
+         JOIN orders o ON o.id = h.order_id
+         LEFT JOIN risk_flags f ON f.id = h.risk_flag_id
+         WHERE h.status = $1 AND h.release_at <= $2 AND o.status <> $3
+           AND (f.id IS NULL OR f.status = $4)
+         ORDER BY h.release_at
+         LIMIT $5
+         FOR UPDATE OF h SKIP LOCKED`,
+		models.HoldHeld, now, models.OrderStatusDisputed, models.RiskFlagCleared, limit)
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *repository) Update(ctx context.Context, tx *sqlx.Tx, hold *models.BalanceHold) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE balance_holds SET amount = $1, status = $2, released_at = $3, updated_at = NOW() WHERE id = $4`,
+		hold.Amount, hold.Status, hold.ReleasedAt, hold.ID)
+	return err
+}
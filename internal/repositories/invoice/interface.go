@@ -0,0 +1,33 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error)
+	GetByOrderIDTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*models.Invoice, error)
+	// NextNumber hands out the next number of the series in the year. The
+	// counter stays locked until tx ends, so a rolled back document leaves no gap.
+	NextNumber(ctx context.Context, tx *sqlx.Tx, series string, year int) (int, error)
+	Create(ctx context.Context, tx *sqlx.Tx, invoice *models.Invoice) error
+	// SetFile records where the invoice's PDF is archived
+	SetFile(ctx context.Context, id uuid.UUID, key string, size int, sha256 string, storedAt time.Time) error
+	MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error
+	GetCreditNoteByTransaction(ctx context.Context, transactionID uuid.UUID) (*models.CreditNote, error)
+	GetCreditNoteByTransactionTx(ctx context.Context, tx *sqlx.Tx, transactionID uuid.UUID) (*models.CreditNote, error)
+	CreateCreditNote(ctx context.Context, tx *sqlx.Tx, note *models.CreditNote) error
+	SetCreditNoteFile(ctx context.Context, id uuid.UUID, key string, size int, sha256 string, storedAt time.Time) error
+	// ListRefundsWithoutCreditNote returns refund transactions created before
+	// the given time that have no credit note yet, oldest first
+	ListRefundsWithoutCreditNote(ctx context.Context, before time.Time, limit int) ([]*models.Transaction, error)
+	MarkCreditNoteSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error
+	// CreateLines copies the order's items with their skins and sellers onto the invoice
+	CreateLines(ctx context.Context, tx *sqlx.Tx, invoiceID, orderID uuid.UUID) error
+	ListLines(ctx context.Context, invoiceID uuid.UUID) ([]*models.InvoiceLine, error)
+}
@@ -0,0 +1,162 @@
+package invoice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	inv := &models.Invoice{}
+	err := r.db.GetContext(ctx, inv, "SELECT * FROM invoices WHERE order_id = $1", orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (r *repository) GetByOrderIDTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*models.Invoice, error) {
+	inv := &models.Invoice{}
+	err := tx.GetContext(ctx, inv, "SELECT * FROM invoices WHERE order_id = $1", orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (r *repository) NextNumber(ctx context.Context, tx *sqlx.Tx, series string, year int) (int, error) {
+	var number int
+	err := tx.GetContext(ctx, &number,
+		`INSERT INTO invoice_sequences (series, year, last_number) VALUES ($1, $2, 1)
+         ON CONFLICT (series, year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+         RETURNING last_number`,
+		series, year)
+	return number, err
+}
+
+func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, inv *models.Invoice) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO invoices (id, order_id, number, year, sequence, issued_at, buyer_name, buyer_email)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		inv.ID, inv.OrderID, inv.Number, inv.Year, inv.Sequence, inv.IssuedAt, inv.BuyerName, inv.BuyerEmail)
+	return err
+}
+
+func (r *repository) SetFile(ctx context.Context, id uuid.UUID, key string, size int, sha256 string, storedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE invoices SET file_key = $1, file_size = $2, file_sha256 = $3, stored_at = $4 WHERE id = $5`,
+		key, size, sha256, storedAt, id)
+	return err
+}
+
+func (r *repository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE invoices SET sent_at = $1 WHERE id = $2", sentAt, id)
+	return err
+}
+
+func (r *repository) GetCreditNoteByTransaction(ctx context.Context, transactionID uuid.UUID) (*models.CreditNote, error) {
+	note := &models.CreditNote{}
+	err := r.db.GetContext(ctx, note, "SELECT * FROM credit_notes WHERE transaction_id = $1", transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return note, nil
+}
+
+func (r *repository) GetCreditNoteByTransactionTx(ctx context.Context, tx *sqlx.Tx, transactionID uuid.UUID) (*models.CreditNote, error) {
+	note := &models.CreditNote{}
+	err := tx.GetContext(ctx, note, "SELECT * FROM credit_notes WHERE transaction_id = $1", transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return note, nil
+}
+
+func (r *repository) CreateCreditNote(ctx context.Context, tx *sqlx.Tx, note *models.CreditNote) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO credit_notes (id, invoice_id, order_id, transaction_id, number, year, sequence, amount, reason, issued_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		note.ID, note.InvoiceID, note.OrderID, note.TransactionID, note.Number, note.Year, note.Sequence,
+		note.Amount, note.Reason, note.IssuedAt)
+	return err
+}
+
+func (r *repository) SetCreditNoteFile(ctx context.Context, id uuid.UUID, key string, size int, sha256 string, storedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE credit_notes SET file_key = $1, file_size = $2, file_sha256 = $3, stored_at = $4 WHERE id = $5`,
+		key, size, sha256, storedAt, id)
+	return err
+}
+
+func (r *repository) MarkCreditNoteSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE credit_notes SET sent_at = $1 WHERE id = $2", sentAt, id)
+	return err
+}
+
+func (r *repository) CreateLines(ctx context.Context, tx *sqlx.Tx, invoiceID, orderID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO invoice_lines (invoice_id, position, order_item_id, skin_id, skin_name, gun, wear, condition,
+                                    seller_id, seller_name, price, fee, tax)
+         SELECT $1, ROW_NUMBER() OVER (ORDER BY oi.created_at, oi.id),
+                oi.id, oi.skin_id, s.name, s.gun, s.wear, s.condition, oi.seller_id, u.name, oi.price, oi.fee, oi.tax
+         FROM order_items oi
+         JOIN skins s ON s.id = oi.skin_id
+         LEFT JOIN users u ON u.id = oi.seller_id
+         WHERE oi.order_id = $2`,
+		invoiceID, orderID)
+	return err
+}
+
+func (r *repository) ListRefundsWithoutCreditNote(ctx context.Context, before time.Time, limit int) ([]*models.Transaction, error) {
+	refunds := []*models.Transaction{}
+	err := r.db.SelectContext(ctx, &refunds,
+		`SELECT t.* FROM transaction_history t
+         LEFT JOIN credit_notes cn ON cn.transaction_id = t.id
+         WHERE t.type = $1 AND t.order_id IS NOT NULL AND cn.id IS NULL AND t.created_at < $2
+         ORDER BY t.created_at
+         LIMIT $3`,
+		models.Refund, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *repository) ListLines(ctx context.Context, invoiceID uuid.UUID) ([]*models.InvoiceLine, error) {
+	lines := []*models.InvoiceLine{}
+	err := r.db.SelectContext(ctx, &lines,
+		`SELECT order_item_id, skin_id, skin_name, gun, wear, condition, seller_id, seller_name, price, fee, tax
+         FROM invoice_lines
+         WHERE invoice_id = $1
+         ORDER BY position`,
+		invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,14 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, entry *models.LedgerEntry) error
+	GetRevenueByPeriod(ctx context.Context, entryType models.LedgerEntryType, period models.ReportPeriod, from, to time.Time) ([]*models.RevenueRow, error)
+}
@@ -0,0 +1,42 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, entry *models.LedgerEntry) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO platform_ledger (id, order_id, order_item_id, type, amount, description, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.ID, entry.OrderID, entry.OrderItemID, entry.Type, entry.Amount, entry.Description, entry.CreatedAt)
+	return err
+}
+
+func (r *repository) GetRevenueByPeriod(ctx context.Context, entryType models.LedgerEntryType, period models.ReportPeriod, from, to time.Time) ([]*models.RevenueRow, error) {
+	rows := []*models.RevenueRow{}
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT date_trunc($1, created_at) AS period_start,
+                SUM(amount) AS total,
+                COUNT(*) AS entries
+         FROM platform_ledger
+         WHERE type = $2 AND created_at >= $3 AND created_at < $4
+         GROUP BY 1
+         ORDER BY 1`,
+		string(period), entryType, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,19 @@
+package limit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetOverride(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.LimitKind) (*models.LimitOverride, error)
+	ListOverrides(ctx context.Context, userID uuid.UUID) ([]*models.LimitOverride, error)
+	UpsertOverride(ctx context.Context, override *models.LimitOverride) error
+	DeleteOverride(ctx context.Context, userID uuid.UUID, kind models.LimitKind) (bool, error)
+	// GetUsage sums the user's amount of the given kind since the given time
+	GetUsage(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.LimitKind, since time.Time) (float64, error)
+}
@@ -0,0 +1,94 @@
+package limit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetOverride(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.LimitKind) (*models.LimitOverride, error) {
+	override := &models.LimitOverride{}
+	err := tx.GetContext(ctx, override,
+		"SELECT * FROM user_limit_overrides WHERE user_id = $1 AND kind = $2", userID, kind)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return override, nil
+}
+
+func (r *repository) ListOverrides(ctx context.Context, userID uuid.UUID) ([]*models.LimitOverride, error) {
+	overrides := []*models.LimitOverride{}
+	err := r.db.SelectContext(ctx, &overrides,
+		"SELECT * FROM user_limit_overrides WHERE user_id = $1 ORDER BY kind", userID)
+	if err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func (r *repository) UpsertOverride(ctx context.Context, override *models.LimitOverride) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_limit_overrides (user_id, kind, per_transaction, daily, monthly, updated_by, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+         ON CONFLICT (user_id, kind) DO UPDATE
+         SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily, monthly = EXCLUDED.monthly,
+             updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		override.UserID, override.Kind, override.PerTransaction, override.Daily, override.Monthly,
+		override.UpdatedBy, override.CreatedAt, override.UpdatedAt)
+	return err
+}
+
+func (r *repository) DeleteOverride(ctx context.Context, userID uuid.UUID, kind models.LimitKind) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM user_limit_overrides WHERE user_id = $1 AND kind = $2", userID, kind)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Deposits count from the moment the intent is created so users can't open
+// more checkouts than their limit allows; failed intents stop counting.
+// Withdrawals count unless rejected or failed. Purchases are the debits of
+// the buyer's balance.
+var usageQueries = map[models.LimitKind]string{
+	models.LimitDeposit: `SELECT COALESCE(SUM(amount), 0) FROM payment_intents
+        WHERE user_id = $1 AND status IN ('pending', 'succeeded') AND created_at >= $2`,
+	models.LimitWithdraw: `SELECT COALESCE(SUM(amount), 0) FROM withdrawals
+        WHERE user_id = $1 AND status NOT IN ('rejected', 'failed') AND created_at >= $2`,
+	models.LimitPurchase: `SELECT COALESCE(SUM(-amount), 0) FROM transaction_history
+        WHERE user_id = $1 AND type = 'purchase' AND created_at >= $2`,
+}
+
+func (r *repository) GetUsage(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.LimitKind, since time.Time) (float64, error) {
+	query, ok := usageQueries[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown limit kind %q", kind)
+	}
+	var used float64
+	if err := tx.GetContext(ctx, &used, query, userID, since); err != nil {
+		return 0, err
+	}
+	return used, nil
+}
@@ -0,0 +1,24 @@
+package marketdata
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.MarketSale, error)
+	GetUnrecordedSales(ctx context.Context, tx *sqlx.Tx, limit int) ([]*models.MarketSale, error)
+	InsertSale(ctx context.Context, tx *sqlx.Tx, sale *models.MarketSale) (bool, error)
+	// ExcludeOrderSales excludes the order's recorded sales and returns those
+	// that were still included
+	ExcludeOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.MarketSale, error)
+	UpsertCandle(ctx context.Context, tx *sqlx.Tx, interval models.CandleInterval, sale *models.MarketSale) error
+	RebuildCandle(ctx context.Context, tx *sqlx.Tx, skinType models.SkinType, interval models.CandleInterval, bucketStart time.Time) error
+	GetCandles(ctx context.Context, skinType models.SkinType, interval models.CandleInterval, from, to time.Time) ([]*models.Candle, error)
+	GetLastSale(ctx context.Context, skinType models.SkinType) (*models.MarketSale, error)
+	GetMedianPrice(ctx context.Context, skinType models.SkinType, since time.Time) (*float64, int, error)
+}
@@ -0,0 +1,179 @@
+package marketdata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+const saleColumns = `
+        SELECT oi.id AS order_item_id, s.name, s.gun, s.wear, oi.price, o.created_at AS sold_at
+        FROM order_items oi
+        JOIN orders o ON o.id = oi.order_id
+        JOIN skins s ON s.id = oi.skin_id`
+
+func (r *repository) GetOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.MarketSale, error) {
+	var sales []*models.MarketSale
+	err := tx.SelectContext(ctx, &sales, saleColumns+`
+        WHERE oi.order_id = $1 AND o.status = $2`,
+		orderID, models.OrderStatusCompleted)
+	return sales, err
+}
+
+func (r *repository) GetUnrecordedSales(ctx context.Context, tx *sqlx.Tx, limit int) ([]*models.MarketSale, error) {
+	var sales []*models.MarketSale
+	err := tx.SelectContext(ctx, &sales, saleColumns+`
+        LEFT JOIN market_sales ms ON ms.order_item_id = oi.id
+        WHERE ms.order_item_id IS NULL AND o.status = $1
+        ORDER BY o.created_at
+        LIMIT $2`,
+		models.OrderStatusCompleted, limit)
+	return sales, err
+}
+
+// InsertSale records the sale and reports whether it was new. Already recorded
+// sales are skipped so candles are never counted twice. Sales that belong to a
+// wash-trading cluster under review or confirmed are recorded as excluded.
+func (r *repository) InsertSale(ctx context.Context, tx *sqlx.Tx, sale *models.MarketSale) (bool, error) {
+	err := tx.QueryRowxContext(ctx,
+		`INSERT INTO market_sales (order_item_id, name, gun, wear, price, sold_at, excluded)
+         VALUES ($1, $2, $3, $4, $5, $6, EXISTS (
+             SELECT 1 FROM wash_trade_sales ws
+             JOIN wash_trade_clusters c ON c.id = ws.cluster_id
+             WHERE ws.order_item_id = $1 AND c.status <> $7))
+         ON CONFLICT (order_item_id) DO NOTHING
+         RETURNING excluded`,
+		sale.OrderItemID, sale.Name, sale.Gun, sale.Wear, sale.Price, sale.SoldAt, models.WashTradeCleared).
+		Scan(&sale.Excluded)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *repository) ExcludeOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.MarketSale, error) {
+	sales := []*models.MarketSale{}
+	err := tx.SelectContext(ctx, &sales,
+		`UPDATE market_sales ms
+         SET excluded = true
+         FROM order_items oi
+         WHERE oi.id = ms.order_item_id AND oi.order_id = $1 AND NOT ms.excluded
+         RETURNING ms.*`,
+		orderID)
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// UpsertCandle folds a single sale into its bucket. Open and close follow the
+// sale timestamps, so sales processed out of order still produce correct candles.
+func (r *repository) UpsertCandle(ctx context.Context, tx *sqlx.Tx, interval models.CandleInterval, sale *models.MarketSale) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO price_candles (name, gun, wear, interval, bucket_start, open, high, low, close,
+                                    volume, turnover, first_sale_at, last_sale_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $6, $6, $6, 1, $6, $7, $7)
+         ON CONFLICT (name, gun, wear, interval, bucket_start) DO UPDATE SET
+             open = CASE WHEN EXCLUDED.first_sale_at < price_candles.first_sale_at
+                         THEN EXCLUDED.open ELSE price_candles.open END,
+             close = CASE WHEN EXCLUDED.last_sale_at >= price_candles.last_sale_at
+                          THEN EXCLUDED.close ELSE price_candles.close END,
+             high = GREATEST(price_candles.high, EXCLUDED.high),
+             low = LEAST(price_candles.low, EXCLUDED.low),
+             volume = price_candles.volume + 1,
+             turnover = price_candles.turnover + EXCLUDED.turnover,
+             first_sale_at = LEAST(price_candles.first_sale_at, EXCLUDED.first_sale_at),
+             last_sale_at = GREATEST(price_candles.last_sale_at, EXCLUDED.last_sale_at)`,
+		sale.Name, sale.Gun, sale.Wear, interval, interval.BucketStart(sale.SoldAt), sale.Price, sale.SoldAt)
+	return err
+}
+
+// RebuildCandle recomputes one bucket from the sales that are not excluded,
+// used when sales are excluded from or returned to the price statistics.
+// The candle is removed when no sales are left in the bucket.
+func (r *repository) RebuildCandle(ctx context.Context, tx *sqlx.Tx, skinType models.SkinType, interval models.CandleInterval, bucketStart time.Time) error {
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM price_candles
+         WHERE name = $1 AND gun = $2 AND wear = $3 AND interval = $4 AND bucket_start = $5`,
+		skinType.Name, skinType.Gun, skinType.Wear, interval, bucketStart); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO price_candles (name, gun, wear, interval, bucket_start, open, high, low, close,
+                                    volume, turnover, first_sale_at, last_sale_at)
+         SELECT $1, $2, $3, $4, $5,
+                (ARRAY_AGG(price ORDER BY sold_at))[1], MAX(price), MIN(price),
+                (ARRAY_AGG(price ORDER BY sold_at DESC))[1],
+                COUNT(*), SUM(price), MIN(sold_at), MAX(sold_at)
+         FROM market_sales
+         WHERE name = $1 AND gun = $2 AND wear = $3 AND sold_at >= $5 AND sold_at < $6 AND NOT excluded
+         HAVING COUNT(*) > 0`,
+		skinType.Name, skinType.Gun, skinType.Wear, interval, bucketStart, bucketStart.Add(interval.Duration()))
+	return err
+}
+
+func (r *repository) GetCandles(ctx context.Context, skinType models.SkinType, interval models.CandleInterval, from, to time.Time) ([]*models.Candle, error) {
+	candles := []*models.Candle{}
+	err := r.db.SelectContext(ctx, &candles,
+		`SELECT * FROM price_candles
+         WHERE name = $1 AND gun = $2 AND wear = $3 AND interval = $4
+           AND bucket_start >= $5 AND bucket_start < $6
+         ORDER BY bucket_start`,
+		skinType.Name, skinType.Gun, skinType.Wear, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+func (r *repository) GetLastSale(ctx context.Context, skinType models.SkinType) (*models.MarketSale, error) {
+	var sale models.MarketSale
+	err := r.db.GetContext(ctx, &sale,
+		`SELECT * FROM market_sales
+         WHERE name = $1 AND gun = $2 AND wear = $3 AND NOT excluded
+         ORDER BY sold_at DESC
+         LIMIT 1`,
+		skinType.Name, skinType.Gun, skinType.Wear)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sale, nil
+}
+
+// GetMedianPrice returns the median sale price since the given time together
+// with the number of sales it was computed from. The median is nil without
+// sales. Excluded sales are ignored, as they are by GetLastSale.
+func (r *repository) GetMedianPrice(ctx context.Context, skinType models.SkinType, since time.Time) (*float64, int, error) {
+	var row struct {
+		Median *float64 `db:"median"`
+		Count  int      `db:"count"`
+	}
+	err := r.db.GetContext(ctx, &row,
+		`SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY price) AS median, COUNT(*) AS count
+         FROM market_sales
+         WHERE name = $1 AND gun = $2 AND wear = $3 AND sold_at >= $4 AND NOT excluded`,
+		skinType.Name, skinType.Gun, skinType.Wear, since)
+	if err != nil {
+		return nil, 0, err
+	}
+	return row.Median, row.Count, nil
+}
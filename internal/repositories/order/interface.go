@@ -2,16 +2,24 @@ package order
 
 import (
 	"context"
+	"errors"
+
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrInvalidTransition is returned when an order can't move to the requested status
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
 type Repository interface {
 	Create(ctx context.Context, tx *sqlx.Tx, order *models.Order) error
 	CreateOrderItem(ctx context.Context, tx *sqlx.Tx, orderItem *models.OrderItem) error
 	GetOrderByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
 	GetOrderItemByID(ctx context.Context, id uuid.UUID) (*models.OrderItem, error)
+	GetOrderItems(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.OrderItem, error)
 	GetOrderByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Order, error)
-	UpdateStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status models.OrderStatus) error
+	UpdateStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status models.OrderStatus, changedBy *uuid.UUID, reason string) error
+	GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*models.OrderStatusChange, error)
+	AddRefundedAmount(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, amount float64) error
 }
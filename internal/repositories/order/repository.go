@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/google/uuid"
@@ -20,18 +21,22 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, order *models.Order) error {
 	_, err := tx.ExecContext(ctx,
-		`INSERT INTO orders (id, user_id, total_amount, status, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, $5, $6)`,
-		order.ID, order.UserID, order.TotalAmount, order.Status, order.CreatedAt, order.UpdatedAt)
+		`INSERT INTO orders (id, user_id, total_amount, tax_amount, tax_rate, tax_country, reverse_charge, buyer_vat_id, status, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		order.ID, order.UserID, order.TotalAmount, order.TaxAmount, order.TaxRate, order.TaxCountry, order.ReverseCharge, order.BuyerVATID,
+		order.Status, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return r.recordStatusChange(ctx, tx, order.ID, nil, order.Status, nil, "")
 }
 
 func (r *repository) CreateOrderItem(ctx context.Context, tx *sqlx.Tx, orderItem *models.OrderItem) error {
 	_, err := tx.ExecContext(ctx,
-		`INSERT INTO order_items (id, order_id, skin_id, price, created_at)
-				VALUES ($1, $2, $3, $4, $5)`,
-		orderItem.ID, orderItem.OrderID, orderItem.SkinID, orderItem.Price, orderItem.CreatedAt)
+		`INSERT INTO order_items (id, order_id, skin_id, seller_id, price, fee, tax, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		orderItem.ID, orderItem.OrderID, orderItem.SkinID, orderItem.SellerID, orderItem.Price, orderItem.Fee, orderItem.Tax, orderItem.CreatedAt)
 	return err
 }
 
@@ -78,7 +83,59 @@ func (r *repository) GetOrderByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id
 	return order, nil
 }
 
-func (r *repository) UpdateStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status models.OrderStatus) error {
-	_, err := tx.ExecContext(ctx, `UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2`, status, id)
+// UpdateStatus moves the order to status if the state machine allows it and
+// records the change in the order's status history
+func (r *repository) UpdateStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status models.OrderStatus, changedBy *uuid.UUID, reason string) error {
+	var current models.OrderStatus
+	if err := tx.GetContext(ctx, &current, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id); err != nil {
+		return err
+	}
+	if !current.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current, status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2`, status, id); err != nil {
+		return err
+	}
+
+	return r.recordStatusChange(ctx, tx, id, &current, status, changedBy, reason)
+}
+
+// AddRefundedAmount adds a refund to what was paid back on the order so far
+func (r *repository) AddRefundedAmount(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, amount float64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE orders SET refunded_amount = refunded_amount + $1, updated_at = NOW() WHERE id = $2`,
+		amount, id)
+	return err
+}
+
+func (r *repository) recordStatusChange(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, from *models.OrderStatus, to models.OrderStatus, changedBy *uuid.UUID, reason string) error {
+	var reasonArg *string
+	if reason != "" {
+		reasonArg = &reason
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO order_status_history (id, order_id, from_status, to_status, changed_by, reason, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+		uuid.New(), orderID, from, to, changedBy, reasonArg)
 	return err
 }
+
+func (r *repository) GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*models.OrderStatusChange, error) {
+	history := []*models.OrderStatusChange{}
+	err := r.db.SelectContext(ctx, &history,
+		"SELECT * FROM order_status_history WHERE order_id = $1 ORDER BY seq", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *repository) GetOrderItems(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.OrderItem, error) {
+	items := []*models.OrderItem{}
+	err := tx.SelectContext(ctx, &items, "SELECT * FROM order_items WHERE order_id = $1 ORDER BY created_at", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,65 @@
+package order
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Integration test, only runs against a database given by TEST_DATABASE_URL
+func TestRepository_GetStatusHistory_SinglePurchase(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL environment variable required for integration test")
+	}
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	conn, err := db.InitDB("postgres", dsn, "../../../migrations", logger)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	buyerID := uuid.New()
+	now := time.Now()
+	_, err = conn.ExecContext(ctx,
+		`INSERT INTO users (id, name, email, password, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)`,
+		buyerID, "buyer", buyerID.String()+"@example.com", "x", now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.ExecContext(ctx, "DELETE FROM users WHERE id = $1", buyerID)
+
+	// A purchase creates, pays and completes the order in one database transaction
+	repo := NewRepository(conn)
+	ord := &models.Order{ID: uuid.New(), UserID: buyerID, TotalAmount: 10, Status: models.OrderStatusPending, CreatedAt: now, UpdatedAt: now}
+	tx, err := conn.BeginTxx(ctx, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, repo.Create(ctx, tx, ord))
+	assert.NoError(t, repo.UpdateStatus(ctx, tx, ord.ID, models.OrderStatusPaid, nil, ""))
+	assert.NoError(t, repo.UpdateStatus(ctx, tx, ord.ID, models.OrderStatusCompleted, nil, ""))
+	if !assert.NoError(t, tx.Commit()) {
+		return
+	}
+	defer conn.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", ord.ID)
+
+	history, err := repo.GetStatusHistory(ctx, ord.ID)
+
+	assert.NoError(t, err)
+	var statuses []models.OrderStatus
+	for _, change := range history {
+		statuses = append(statuses, change.ToStatus)
+	}
+	assert.Equal(t, []models.OrderStatus{models.OrderStatusPending, models.OrderStatusPaid, models.OrderStatusCompleted}, statuses)
+}
@@ -0,0 +1,23 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	CreateIntent(ctx context.Context, tx *sqlx.Tx, intent *models.PaymentIntent) error
+	GetIntent(ctx context.Context, id uuid.UUID) (*models.PaymentIntent, error)
+	GetIntentForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.PaymentIntent, error)
+	GetIntentByReferenceForUpdate(ctx context.Context, tx *sqlx.Tx, provider, reference string) (*models.PaymentIntent, error)
+	UpdateIntent(ctx context.Context, tx *sqlx.Tx, intent *models.PaymentIntent) error
+	// SetProviderIntent stores the provider's reference and checkout URL of a saved intent
+	SetProviderIntent(ctx context.Context, id uuid.UUID, reference, checkoutURL string) error
+	// FailIntent marks an intent failed unless it was already settled
+	FailIntent(ctx context.Context, id uuid.UUID, reason string) error
+	// RecordWebhookEvent stores a processed event and reports false when it was already seen
+	RecordWebhookEvent(ctx context.Context, tx *sqlx.Tx, provider, eventID, eventType, reference string) (bool, error)
+}
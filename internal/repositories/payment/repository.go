@@ -0,0 +1,104 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateIntent(ctx context.Context, tx *sqlx.Tx, intent *models.PaymentIntent) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO payment_intents (id, user_id, amount, status, provider, provider_reference, checkout_url, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		intent.ID, intent.UserID, intent.Amount, intent.Status, intent.Provider, intent.ProviderReference,
+		intent.CheckoutURL, intent.CreatedAt, intent.UpdatedAt)
+	return err
+}
+
+func (r *repository) GetIntent(ctx context.Context, id uuid.UUID) (*models.PaymentIntent, error) {
+	intent := &models.PaymentIntent{}
+	err := r.db.GetContext(ctx, intent, "SELECT * FROM payment_intents WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (r *repository) GetIntentForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.PaymentIntent, error) {
+	intent := &models.PaymentIntent{}
+	err := tx.GetContext(ctx, intent, "SELECT * FROM payment_intents WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (r *repository) GetIntentByReferenceForUpdate(ctx context.Context, tx *sqlx.Tx, provider, reference string) (*models.PaymentIntent, error) {
+	intent := &models.PaymentIntent{}
+	err := tx.GetContext(ctx, intent,
+		"SELECT * FROM payment_intents WHERE provider = $1 AND provider_reference = $2 FOR UPDATE", provider, reference)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (r *repository) UpdateIntent(ctx context.Context, tx *sqlx.Tx, intent *models.PaymentIntent) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE payment_intents SET status = $1, failure_reason = $2, completed_at = $3, provider_reference = $4, updated_at = NOW()
+         WHERE id = $5`,
+		intent.Status, intent.FailureReason, intent.CompletedAt, intent.ProviderReference, intent.ID)
+	return err
+}
+
+func (r *repository) SetProviderIntent(ctx context.Context, id uuid.UUID, reference, checkoutURL string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE payment_intents SET provider_reference = $1, checkout_url = $2, updated_at = NOW() WHERE id = $3`,
+		reference, checkoutURL, id)
+	return err
+}
+
+func (r *repository) FailIntent(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE payment_intents SET status = 'failed', failure_reason = $1, completed_at = NOW(), updated_at = NOW()
+         WHERE id = $2 AND status = 'pending'`,
+		reason, id)
+	return err
+}
+
+func (r *repository) RecordWebhookEvent(ctx context.Context, tx *sqlx.Tx, provider, eventID, eventType, reference string) (bool, error) {
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO payment_webhook_events (provider, event_id, event_type, provider_reference)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (provider, event_id) DO NOTHING`,
+		provider, eventID, eventType, reference)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
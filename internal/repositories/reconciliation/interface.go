@@ -0,0 +1,32 @@
+package reconciliation
+
+import (
+	"context"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	// ListAccounts returns the next page of users after afterID, ordered by ID,
+	// with the amounts their side balances should add up to
+	ListAccounts(ctx context.Context, tx *sqlx.Tx, afterID uuid.UUID, limit int) ([]models.AccountSnapshot, error)
+	// ListTransactions returns the transaction history of the users, grouped
+	// by user and oldest first
+	ListTransactions(ctx context.Context, tx *sqlx.Tx, userIDs []uuid.UUID) ([]*models.Transaction, error)
+	// CountPaidOrders returns how many orders should have moved money
+	CountPaidOrders(ctx context.Context, tx *sqlx.Tx) (int, error)
+	// ListOrphanOrders returns paid orders missing the buyer's purchase or a
+	// seller's sale transaction
+	ListOrphanOrders(ctx context.Context, tx *sqlx.Tx) ([]models.OrphanOrder, error)
+
+	// CreateReport stores the report together with its discrepancies
+	CreateReport(ctx context.Context, tx *sqlx.Tx, report *models.ReconciliationReport) error
+	GetReport(ctx context.Context, id uuid.UUID) (*models.ReconciliationReport, error)
+	ListReports(ctx context.Context, limit int) ([]*models.ReconciliationReport, error)
+	ListDiscrepancies(ctx context.Context, reportID uuid.UUID, kind models.DiscrepancyKind) ([]*models.Discrepancy, error)
+
+	// ListAdminEmails returns where reconciliation alerts go
+	ListAdminEmails(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,165 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Uranury/RBK_finalProject/internal/auth"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+// paidStatuses are the order statuses whose money has moved
+var paidStatuses = []models.OrderStatus{
+	models.OrderStatusPaid, models.OrderStatusCompleted, models.OrderStatusDisputed, models.OrderStatusRefunded,
+}
+
+func (r *repository) ListAccounts(ctx context.Context, tx *sqlx.Tx, afterID uuid.UUID, limit int) ([]models.AccountSnapshot, error) {
+	accounts := []models.AccountSnapshot{}
+	err := tx.SelectContext(ctx, &accounts,
+		`SELECT u.id AS user_id, COALESCE(u.balance, 0) AS balance,
+                u.pending_balance, u.frozen_balance, u.reserved_balance,
+                COALESCE((SELECT SUM(h.amount) FROM balance_holds h
+                          WHERE h.user_id = u.id AND h.status = $3), 0) AS held_amount,
+                COALESCE((SELECT SUM(d.frozen_amount) FROM disputes d
+                          WHERE d.seller_id = u.id AND d.status = $4), 0) AS disputed_amount,
+                COALESCE((SELECT SUM(w.amount) FROM withdrawals w
+                          WHERE w.user_id = u.id AND w.status IN ($5, $6, $7)), 0) AS withdrawing_amount
+         FROM users u
+         WHERE u.id > $1
+         ORDER BY u.id
+         LIMIT $2`,
+		afterID, limit, models.HoldHeld, models.DisputeOpen,
+		models.WithdrawalPendingReview, models.WithdrawalApproved, models.WithdrawalProcessing)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *repository) ListTransactions(ctx context.Context, tx *sqlx.Tx, userIDs []uuid.UUID) ([]*models.Transaction, error) {
+	if len(userIDs) == 0 {
+		return []*models.Transaction{}, nil
+	}
+	query, args, err := sqlx.In(
+		"SELECT * FROM transaction_history WHERE user_id IN (?) ORDER BY user_id, created_at, seq",
+		userIDs)
+	if err != nil {
+		return nil, err
+	}
+	query = r.db.Rebind(query)
+
+	txns := []*models.Transaction{}
+	err = tx.SelectContext(ctx, &txns, query, args...)
+	return txns, err
+}
+
+func (r *repository) CountPaidOrders(ctx context.Context, tx *sqlx.Tx) (int, error) {
+	var count int
+	err := tx.GetContext(ctx, &count,
+		"SELECT COUNT(*) FROM orders WHERE status IN ($1, $2, $3, $4)",
+		paidStatuses[0], paidStatuses[1], paidStatuses[2], paidStatuses[3])
+	return count, err
+}
+
+func (r *repository) ListOrphanOrders(ctx context.Context, tx *sqlx.Tx) ([]models.OrphanOrder, error) {
+	orphans := []models.OrphanOrder{}
+	err := tx.SelectContext(ctx, &orphans,
+		`SELECT o.id AS order_id, o.user_id, 'buyer' AS side, o.total_amount AS amount, o.status
+         FROM orders o
+         WHERE o.status IN ($1, $2, $3, $4)
+           AND NOT EXISTS (SELECT 1 FROM transaction_history t
+                           WHERE t.order_id = o.id AND t.user_id = o.user_id AND t.type = $5)
+         UNION ALL
+         SELECT o.id AS order_id, oi.seller_id AS user_id, 'seller' AS side, oi.price AS amount, o.status
+         FROM order_items oi
+         JOIN orders o ON o.id = oi.order_id
+         WHERE oi.seller_id IS NOT NULL AND o.status IN ($1, $2, $3, $4)
+           AND NOT EXISTS (SELECT 1 FROM transaction_history t
+                           WHERE t.order_id = o.id AND t.user_id = oi.seller_id AND t.type = $6)
+         ORDER BY order_id, side`,
+		paidStatuses[0], paidStatuses[1], paidStatuses[2], paidStatuses[3], models.Purchase, models.Sale)
+	if err != nil {
+		return nil, err
+	}
+	return orphans, nil
+}
+
+func (r *repository) CreateReport(ctx context.Context, tx *sqlx.Tx, report *models.ReconciliationReport) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO reconciliation_reports (id, users_checked, transactions_checked, orders_checked,
+                                             discrepancy_count, started_at, finished_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		report.ID, report.UsersChecked, report.TransactionsChecked, report.OrdersChecked,
+		report.DiscrepancyCount, report.StartedAt, report.FinishedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range report.Discrepancies {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO reconciliation_discrepancies (id, report_id, kind, user_id, order_id, transaction_id,
+                                                       expected, actual, detail, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			d.ID, d.ReportID, d.Kind, d.UserID, d.OrderID, d.TransactionID, d.Expected, d.Actual, d.Detail, d.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) GetReport(ctx context.Context, id uuid.UUID) (*models.ReconciliationReport, error) {
+	report := &models.ReconciliationReport{}
+	err := r.db.GetContext(ctx, report, "SELECT * FROM reconciliation_reports WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *repository) ListReports(ctx context.Context, limit int) ([]*models.ReconciliationReport, error) {
+	reports := []*models.ReconciliationReport{}
+	err := r.db.SelectContext(ctx, &reports,
+		"SELECT * FROM reconciliation_reports ORDER BY started_at DESC LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// ListDiscrepancies returns the report's discrepancies, only those of kind
+// unless it is empty
+func (r *repository) ListDiscrepancies(ctx context.Context, reportID uuid.UUID, kind models.DiscrepancyKind) ([]*models.Discrepancy, error) {
+	discrepancies := []*models.Discrepancy{}
+	err := r.db.SelectContext(ctx, &discrepancies,
+		`SELECT * FROM reconciliation_discrepancies
+         WHERE report_id = $1 AND ($2 = '' OR kind = $2)
+         ORDER BY kind, user_id, created_at`,
+		reportID, kind)
+	if err != nil {
+		return nil, err
+	}
+	return discrepancies, nil
+}
+
+func (r *repository) ListAdminEmails(ctx context.Context) ([]string, error) {
+	emails := []string{}
+	err := r.db.SelectContext(ctx, &emails, "SELECT email FROM users WHERE role = $1 ORDER BY email", auth.Admin)
+	if err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
@@ -0,0 +1,31 @@
+package risk
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	CreateFlag(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag) error
+	GetFlag(ctx context.Context, id uuid.UUID) (*models.RiskFlag, error)
+	GetFlagForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.RiskFlag, error)
+	ListFlags(ctx context.Context, status models.RiskFlagStatus) ([]*models.RiskFlag, error)
+	UpdateFlag(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag) error
+
+	CreateHits(ctx context.Context, tx *sqlx.Tx, hits []*models.RiskRuleHit) error
+	ListHitsForFlag(ctx context.Context, flagID uuid.UUID) ([]*models.RiskRuleHit, error)
+	ListHitsForUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.RiskRuleHit, error)
+
+	// Sum, Count and CountWith look at the user's transaction history of the
+	// kind since the given time, or their listing history for sell
+	Sum(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (float64, error)
+	Count(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (int, error)
+	CountWith(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, counterpartyID uuid.UUID, since time.Time) (int, error)
+	// ListActivity returns every movement the rules look at between from and
+	// to, oldest first
+	ListActivity(ctx context.Context, from, to time.Time) ([]*models.RiskActivity, error)
+}
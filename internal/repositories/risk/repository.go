@@ -0,0 +1,208 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateFlag(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO risk_flags (id, user_id, kind, action, amount, reference_id, status, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		flag.ID, flag.UserID, flag.Kind, flag.Action, flag.Amount, flag.ReferenceID, flag.Status, flag.CreatedAt, flag.UpdatedAt)
+	return err
+}
+
+func (r *repository) GetFlag(ctx context.Context, id uuid.UUID) (*models.RiskFlag, error) {
+	flag := &models.RiskFlag{}
+	err := r.db.GetContext(ctx, flag, "SELECT * FROM risk_flags WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (r *repository) GetFlagForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.RiskFlag, error) {
+	flag := &models.RiskFlag{}
+	err := tx.GetContext(ctx, flag, "SELECT * FROM risk_flags WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (r *repository) ListFlags(ctx context.Context, status models.RiskFlagStatus) ([]*models.RiskFlag, error) {
+	flags := []*models.RiskFlag{}
+	err := r.db.SelectContext(ctx, &flags,
+		"SELECT * FROM risk_flags WHERE status = $1 ORDER BY created_at", status)
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func (r *repository) UpdateFlag(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE risk_flags
+         SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = $4, updated_at = $5
+         WHERE id = $6`,
+		flag.Status, flag.ReviewedBy, flag.ReviewNote, flag.ReviewedAt, flag.UpdatedAt, flag.ID)
+	return err
+}
+
+func (r *repository) CreateHits(ctx context.Context, tx *sqlx.Tx, hits []*models.RiskRuleHit) error {
+	for _, hit := range hits {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO risk_rule_hits (id, flag_id, user_id, kind, rule, rule_type, action, amount, reference_id, detail, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			hit.ID, hit.FlagID, hit.UserID, hit.Kind, hit.Rule, hit.RuleType, hit.Action, hit.Amount, hit.ReferenceID, hit.Detail, hit.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) ListHitsForFlag(ctx context.Context, flagID uuid.UUID) ([]*models.RiskRuleHit, error) {
+	hits := []*models.RiskRuleHit{}
+	err := r.db.SelectContext(ctx, &hits,
+		"SELECT * FROM risk_rule_hits WHERE flag_id = $1 ORDER BY created_at, rule", flagID)
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (r *repository) ListHitsForUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.RiskRuleHit, error) {
+	hits := []*models.RiskRuleHit{}
+	err := r.db.SelectContext(ctx, &hits,
+		"SELECT * FROM risk_rule_hits WHERE user_id = $1 AND created_at >= $2 ORDER BY created_at DESC", userID, since)
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// historyTypes maps risk event kinds to the transaction_history rows that
+// record them. Listings move no money, they are in listing_history.
+var historyTypes = map[models.RiskEventKind]models.TransactionType{
+	models.RiskDeposit:  models.Deposit,
+	models.RiskWithdraw: models.Withdraw,
+	models.RiskPurchase: models.Purchase,
+}
+
+func historyType(kind models.RiskEventKind) (models.TransactionType, error) {
+	t, ok := historyTypes[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown risk event kind %q", kind)
+	}
+	return t, nil
+}
+
+func (r *repository) Sum(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (float64, error) {
+	var sum float64
+	if kind == models.RiskSell {
+		err := tx.GetContext(ctx, &sum,
+			`SELECT COALESCE(SUM(price), 0) FROM listing_history
+             WHERE user_id = $1 AND created_at >= $2`,
+			userID, since)
+		return sum, err
+	}
+
+	txnType, err := historyType(kind)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.GetContext(ctx, &sum,
+		`SELECT COALESCE(SUM(ABS(amount)), 0) FROM transaction_history
+         WHERE user_id = $1 AND type = $2 AND created_at >= $3`,
+		userID, txnType, since)
+	return sum, err
+}
+
+func (r *repository) Count(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (int, error) {
+	var count int
+	if kind == models.RiskSell {
+		err := tx.GetContext(ctx, &count,
+			`SELECT COUNT(*) FROM listing_history
+             WHERE user_id = $1 AND created_at >= $2`,
+			userID, since)
+		return count, err
+	}
+
+	txnType, err := historyType(kind)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM transaction_history
+         WHERE user_id = $1 AND type = $2 AND created_at >= $3`,
+		userID, txnType, since)
+	return count, err
+}
+
+func (r *repository) CountWith(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, counterpartyID uuid.UUID, since time.Time) (int, error) {
+	// A listing has no counterparty yet
+	if kind == models.RiskSell {
+		return 0, nil
+	}
+
+	txnType, err := historyType(kind)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = tx.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM transaction_history
+         WHERE user_id = $1 AND type = $2 AND counterparty_id = $3 AND created_at >= $4`,
+		userID, txnType, counterpartyID, since)
+	return count, err
+}
+
+func (r *repository) ListActivity(ctx context.Context, from, to time.Time) ([]*models.RiskActivity, error) {
+	activity := []*models.RiskActivity{}
+	err := r.db.SelectContext(ctx, &activity,
+		`SELECT t.id, t.user_id, t.type AS kind,
+                ABS(t.amount) AS amount, t.counterparty_id, s.gun,
+                u.created_at AS account_created_at, t.created_at
+         FROM transaction_history t
+         JOIN users u ON u.id = t.user_id
+         LEFT JOIN skins s ON s.id = t.skin_id
+         WHERE t.type IN ('deposit', 'withdraw', 'purchase')
+           AND t.created_at >= $1 AND t.created_at <= $2
+         UNION ALL
+         SELECT l.id, l.user_id, 'sell' AS kind,
+                l.price AS amount, NULL AS counterparty_id, s.gun,
+                u.created_at AS account_created_at, l.created_at
+         FROM listing_history l
+         JOIN users u ON u.id = l.user_id
+         JOIN skins s ON s.id = l.skin_id
+         WHERE l.created_at >= $1 AND l.created_at <= $2
+         ORDER BY created_at, id`,
+		from, to)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
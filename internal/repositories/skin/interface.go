@@ -2,6 +2,8 @@ package skin
 
 import (
 	"context"
+	"time"
+
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -12,10 +14,15 @@ type Repository interface {
 	GetSkin(ctx context.Context, id uuid.UUID) (*models.Skin, error)
 	GetUserSkins(ctx context.Context, userID uuid.UUID) ([]*models.Skin, error)
 	GetAvailableSkins(ctx context.Context) ([]*models.Skin, error)
+	GetLowestListings(ctx context.Context, skinType models.SkinType, excludeID uuid.UUID, limit int) ([]*models.Skin, error)
 	GetSkinsForUpdate(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID) ([]*models.Skin, error)
 	GetSkinsForSellUpdate(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID) ([]*models.Skin, error)
 	UpdateOwnership(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID, newOwnerID uuid.UUID) error
+	ReleaseToMarket(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID) error
 	UpdatePrice(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, price float64) error
 	UpdateForSale(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, price float64, available bool) error
 	UpdateAvailability(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, available bool) error
+	SetListingExpiry(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, days *int, expiresAt *time.Time) error
+	GetExpiredListings(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) ([]*models.Skin, error)
+	RecordListing(ctx context.Context, tx *sqlx.Tx, listing *models.Listing) error
 }
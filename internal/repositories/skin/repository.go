@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/google/uuid"
@@ -51,10 +52,14 @@ func (r *repository) GetUserSkins(ctx context.Context, userID uuid.UUID) ([]*mod
 	return skins, nil
 }
 
+// GetAvailableSkins returns the listed skins. A listing that ran out is left
+// out even before the expire-listings job delisted it.
 func (r *repository) GetAvailableSkins(ctx context.Context) ([]*models.Skin, error) {
 	var skins []*models.Skin
 	err := r.db.SelectContext(ctx, &skins,
-		"SELECT * FROM skins WHERE available = true ORDER BY created_at DESC")
+		`SELECT * FROM skins
+         WHERE available = true AND (expires_at IS NULL OR expires_at > NOW())
+         ORDER BY created_at DESC`)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return []*models.Skin{}, nil // Return empty slice, not nil
@@ -64,9 +69,26 @@ func (r *repository) GetAvailableSkins(ctx context.Context) ([]*models.Skin, err
 	return skins, nil
 }
 
+func (r *repository) GetLowestListings(ctx context.Context, skinType models.SkinType, excludeID uuid.UUID, limit int) ([]*models.Skin, error) {
+	skins := []*models.Skin{}
+	err := r.db.SelectContext(ctx, &skins,
+		`SELECT * FROM skins
+         WHERE available = true AND (expires_at IS NULL OR expires_at > NOW())
+           AND name = $1 AND gun = $2 AND wear = $3 AND id <> $4
+         ORDER BY price ASC
+         LIMIT $5`,
+		skinType.Name, skinType.Gun, skinType.Wear, excludeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return skins, nil
+}
+
 func (r *repository) GetSkinsForUpdate(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID) ([]*models.Skin, error) {
 	query, args, err := sqlx.In(
-		"SELECT * FROM skins WHERE id IN (?) AND available = true FOR UPDATE",
+		`SELECT * FROM skins
+         WHERE id IN (?) AND available = true AND (expires_at IS NULL OR expires_at > NOW())
+         FOR UPDATE`,
 		skinIDs)
 	if err != nil {
 		return nil, err
@@ -93,7 +115,7 @@ func (r *repository) GetSkinsForSellUpdate(ctx context.Context, tx *sqlx.Tx, ski
 
 func (r *repository) UpdateOwnership(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID, newOwnerID uuid.UUID) error {
 	query, args, err := sqlx.In(
-		"UPDATE skins SET owner_id = ?, available = false, updated_at = NOW() WHERE id IN (?)",
+		"UPDATE skins SET owner_id = ?, available = false, listing_days = NULL, expires_at = NULL, updated_at = NOW() WHERE id IN (?)",
 		newOwnerID, skinIDs)
 	if err != nil {
 		return nil
@@ -104,6 +126,13 @@ func (r *repository) UpdateOwnership(ctx context.Context, tx *sqlx.Tx, skinIDs [
 	return err
 }
 
+// ReleaseToMarket clears the owner and lists the skin again, used when a
+// purchase of a market-created skin is undone
+func (r *repository) ReleaseToMarket(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE skins SET owner_id = NULL, available = true, listing_days = NULL, expires_at = NULL, updated_at = NOW() WHERE id = $1`, skinID)
+	return err
+}
+
 func (r *repository) UpdateForSale(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, price float64, available bool) error {
 	_, err := tx.ExecContext(ctx,
 		`UPDATE skins 
@@ -124,3 +153,36 @@ func (r *repository) UpdateAvailability(ctx context.Context, tx *sqlx.Tx, skinID
 	_, err := tx.ExecContext(ctx, query, available, skinID)
 	return err
 }
+
+// SetListingExpiry sets how long a listing runs, nil for both clears it
+func (r *repository) SetListingExpiry(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, days *int, expiresAt *time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE skins SET listing_days = $1, expires_at = $2, updated_at = NOW() WHERE id = $3`,
+		days, expiresAt, skinID)
+	return err
+}
+
+// GetExpiredListings locks listings that ran out by now, skipping the ones
+// another transaction is busy with, e.g. a purchase
+func (r *repository) GetExpiredListings(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) ([]*models.Skin, error) {
+	skins := []*models.Skin{}
+	err := tx.SelectContext(ctx, &skins,
+		`SELECT * FROM skins
+         WHERE available = true AND expires_at <= $1
+         ORDER BY expires_at
+         LIMIT $2
+         FOR UPDATE SKIP LOCKED`,
+		now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return skins, nil
+}
+
+// RecordListing adds a listing to the listing history
+func (r *repository) RecordListing(ctx context.Context, tx *sqlx.Tx, listing *models.Listing) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO listing_history (id, user_id, skin_id, price, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		listing.ID, listing.UserID, listing.SkinID, listing.Price, listing.CreatedAt)
+	return err
+}
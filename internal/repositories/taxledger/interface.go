@@ -0,0 +1,14 @@
+package taxledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, entry *models.TaxEntry) error
+	GetReport(ctx context.Context, period models.ReportPeriod, from, to time.Time) ([]*models.TaxReportRow, error)
+}
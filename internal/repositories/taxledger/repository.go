@@ -0,0 +1,44 @@
+package taxledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, entry *models.TaxEntry) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO tax_entries (id, order_id, country, rate, reverse_charge, net_amount, tax_amount, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		entry.ID, entry.OrderID, entry.Country, entry.Rate, entry.ReverseCharge, entry.NetAmount, entry.TaxAmount, entry.CreatedAt)
+	return err
+}
+
+func (r *repository) GetReport(ctx context.Context, period models.ReportPeriod, from, to time.Time) ([]*models.TaxReportRow, error) {
+	rows := []*models.TaxReportRow{}
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT date_trunc($1, created_at) AS period_start,
+                country, rate, reverse_charge,
+                SUM(net_amount) AS net_amount,
+                SUM(tax_amount) AS tax_amount,
+                COUNT(*) AS entries
+         FROM tax_entries
+         WHERE created_at >= $2 AND created_at < $3
+         GROUP BY 1, country, rate, reverse_charge
+         ORDER BY 1, country, rate, reverse_charge`,
+		string(period), from, to)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
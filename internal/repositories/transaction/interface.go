@@ -2,6 +2,8 @@ package transaction
 
 import (
 	"context"
+	"time"
+
 	"github.com/jmoiron/sqlx"
 
 	"github.com/Uranury/RBK_finalProject/internal/models"
@@ -10,5 +12,18 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, tx *sqlx.Tx, transaction *models.Transaction) error
-	GetUserTransactions(ctx context.Context, userID uuid.UUID) ([]*models.Transaction, error)
+	// ListUserTransactions returns up to limit of the user's transactions
+	// matching the filter, newest first, starting after the cursor if set
+	ListUserTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, after *models.TransactionCursor, limit int) ([]*models.Transaction, error)
+	// StreamUserTransactions calls fn for each of the user's transactions
+	// matching the filter, newest first, without loading them all at once.
+	// It stops at the first error fn returns.
+	StreamUserTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, fn func(*models.Transaction) error) error
+	// GetBalancesAt returns the user's balances right before at, as the
+	// transaction history recorded them
+	GetBalancesAt(ctx context.Context, userID uuid.UUID, at time.Time) (models.StatementBalances, error)
+	// ListActiveUsers returns the users with at least one transaction in [from, to)
+	ListActiveUsers(ctx context.Context, from, to time.Time) ([]models.ExportRecipient, error)
+	// ListOrderRefunds returns the buyer refunds of an order, oldest first
+	ListOrderRefunds(ctx context.Context, orderID uuid.UUID) ([]*models.Transaction, error)
 }
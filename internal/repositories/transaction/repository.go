@@ -2,8 +2,9 @@ package transaction
 
 import (
 	"context"
-	"database/sql"
-	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/google/uuid"
@@ -19,26 +20,157 @@ func NewRepository(db *sqlx.DB) Repository {
 }
 
 func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, transaction *models.Transaction) error {
+	if transaction.BalanceType == "" {
+		transaction.BalanceType = models.BalanceAvailable
+	}
 	_, err := tx.ExecContext(ctx,
-		`INSERT INTO transaction_history (id, user_id, amount, type, balance_before, balance_after, skin_id, 
-                                 order_id, counterparty_id, description, created_at)
-         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		`INSERT INTO transaction_history (id, user_id, amount, type, balance_before, balance_after, balance_type,
+                                 skin_id, order_id, counterparty_id, description, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
 		transaction.ID, transaction.UserID, transaction.Amount, transaction.Type,
-		transaction.BalanceBefore, transaction.BalanceAfter, transaction.SkinID,
-		transaction.OrderID, transaction.CounterpartyID, transaction.Description,
+		transaction.BalanceBefore, transaction.BalanceAfter, transaction.BalanceType,
+		transaction.SkinID, transaction.OrderID, transaction.CounterpartyID, transaction.Description,
 		transaction.CreatedAt)
 	return err
 }
 
-func (r *repository) GetUserTransactions(ctx context.Context, userID uuid.UUID) ([]*models.Transaction, error) {
-	var transactions []*models.Transaction
-	err := r.db.SelectContext(ctx, &transactions,
-		"SELECT * FROM transaction_history WHERE user_id = $1 ORDER BY created_at DESC", userID)
+// filterWhere builds the WHERE clause selecting the user's transactions that
+// match the filter
+func filterWhere(userID uuid.UUID, filter models.TransactionFilter) (string, []interface{}, error) {
+	conds := []string{"user_id = ?"}
+	args := []interface{}{userID}
+
+	if len(filter.Types) > 0 {
+		conds = append(conds, "type IN (?)")
+		args = append(args, filter.Types)
+	}
+	if !filter.From.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, filter.To)
+	}
+	if filter.SkinID != nil {
+		conds = append(conds, "skin_id = ?")
+		args = append(args, *filter.SkinID)
+	}
+	if filter.CounterpartyID != nil {
+		conds = append(conds, "counterparty_id = ?")
+		args = append(args, *filter.CounterpartyID)
+	}
+	if filter.MinAmount != nil {
+		conds = append(conds, "ABS(amount) >= ?")
+		args = append(args, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		conds = append(conds, "ABS(amount) <= ?")
+		args = append(args, *filter.MaxAmount)
+	}
+
+	// sqlx.In expands the type list and leaves the other arguments alone
+	where, args, err := sqlx.In(strings.Join(conds, " AND "), args...)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, args, nil
+}
+
+func (r *repository) ListUserTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, after *models.TransactionCursor, limit int) ([]*models.Transaction, error) {
+	where, args, err := filterWhere(userID, filter)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return []*models.Transaction{}, nil
-		}
+		return nil, err
+	}
+	if after != nil {
+		where += " AND (created_at, id) < (?, ?)"
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	args = append(args, limit)
+
+	query := r.db.Rebind(fmt.Sprintf(
+		"SELECT * FROM transaction_history WHERE %s ORDER BY created_at DESC, id DESC LIMIT ?", where))
+
+	transactions := []*models.Transaction{}
+	if err := r.db.SelectContext(ctx, &transactions, query, args...); err != nil {
 		return nil, err
 	}
 	return transactions, nil
 }
+
+func (r *repository) StreamUserTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, fn func(*models.Transaction) error) error {
+	where, args, err := filterWhere(userID, filter)
+	if err != nil {
+		return err
+	}
+	query := r.db.Rebind(fmt.Sprintf(
+		"SELECT * FROM transaction_history WHERE %s ORDER BY created_at DESC, seq DESC", where))
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		txn := &models.Transaction{}
+		if err := rows.StructScan(txn); err != nil {
+			return err
+		}
+		if err := fn(txn); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *repository) GetBalancesAt(ctx context.Context, userID uuid.UUID, at time.Time) (models.StatementBalances, error) {
+	// The pending balance is where its last row left it, less the hold
+	// releases since, which are recorded on the available side only
+	var balances models.StatementBalances
+	err := r.db.GetContext(ctx, &balances,
+		`WITH last_available AS (
+             SELECT balance_after FROM transaction_history
+             WHERE user_id = $1 AND balance_type = $3 AND created_at < $2
+             ORDER BY seq DESC LIMIT 1
+         ), last_pending AS (
+             SELECT balance_after, seq FROM transaction_history
+             WHERE user_id = $1 AND balance_type = $4 AND created_at < $2
+             ORDER BY seq DESC LIMIT 1
+         )
+         SELECT COALESCE((SELECT balance_after FROM last_available), 0) AS available,
+                COALESCE((SELECT balance_after FROM last_pending), 0)
+                  - COALESCE((SELECT SUM(ABS(t.amount)) FROM transaction_history t
+                              WHERE t.user_id = $1 AND t.type = $5 AND t.created_at < $2
+                                AND t.seq > (SELECT seq FROM last_pending)), 0) AS pending`,
+		userID, at, models.BalanceAvailable, models.BalancePending, models.HoldRelease)
+	return balances, err
+}
+
+func (r *repository) ListActiveUsers(ctx context.Context, from, to time.Time) ([]models.ExportRecipient, error) {
+	recipients := []models.ExportRecipient{}
+	err := r.db.SelectContext(ctx, &recipients,
+		`SELECT u.id AS user_id, u.email
+         FROM users u
+         WHERE EXISTS (SELECT 1 FROM transaction_history t
+                       WHERE t.user_id = u.id AND t.created_at >= $1 AND t.created_at < $2)
+         ORDER BY u.id`,
+		from, to)
+	if err != nil {
+		return nil, err
+	}
+	return recipients, nil
+}
+
+func (r *repository) ListOrderRefunds(ctx context.Context, orderID uuid.UUID) ([]*models.Transaction, error) {
+	refunds := []*models.Transaction{}
+	err := r.db.SelectContext(ctx, &refunds,
+		`SELECT * FROM transaction_history
+         WHERE order_id = $1 AND type = $2
+         ORDER BY created_at`,
+		orderID, models.Refund)
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
@@ -14,6 +14,9 @@ type Repository interface {
 	GetBalance(ctx context.Context, userID uuid.UUID) (float64, error)
 	GetUserProfile(ctx context.Context, userID uuid.UUID) (*models.UserProfile, error)
 	UpdateBalance(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, newBalance float64) error
+	UpdateBalances(ctx context.Context, tx *sqlx.Tx, user *models.User) error
+	UpdateTier(ctx context.Context, userID uuid.UUID, tier models.UserTier) (bool, error)
+	UpdateBilling(ctx context.Context, userID uuid.UUID, country string, vatID *string) (bool, error)
 	Create(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, userID uuid.UUID) error
 	GetUserByIdForUpdate(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) (*models.User, error)
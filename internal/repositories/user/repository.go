@@ -68,7 +68,7 @@ func (r *repository) GetBalance(ctx context.Context, userID uuid.UUID) (float64,
 
 func (r *repository) GetUserProfile(ctx context.Context, userID uuid.UUID) (*models.UserProfile, error) {
 	var user models.UserProfile
-	err := r.db.GetContext(ctx, &user, "SELECT name, email, balance FROM users WHERE id = $1", userID)
+	err := r.db.GetContext(ctx, &user, "SELECT name, email, balance, pending_balance, frozen_balance, reserved_balance, billing_country, vat_id FROM users WHERE id = $1", userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -83,12 +83,46 @@ func (r *repository) UpdateBalance(ctx context.Context, tx *sqlx.Tx, userID uuid
 	return err
 }
 
+// UpdateBalances stores the available, pending, frozen and reserved balances of the user
+func (r *repository) UpdateBalances(ctx context.Context, tx *sqlx.Tx, user *models.User) error {
+	_, err := tx.ExecContext(ctx,
+		"UPDATE users SET balance = $1, pending_balance = $2, frozen_balance = $3, reserved_balance = $4, updated_at = NOW() WHERE id = $5",
+		user.Balance, user.PendingBalance, user.FrozenBalance, user.ReservedBalance, user.ID)
+	return err
+}
+
+// UpdateTier changes the user's tier and reports whether the user exists
+func (r *repository) UpdateTier(ctx context.Context, userID uuid.UUID, tier models.UserTier) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET tier = $1, updated_at = NOW() WHERE id = $2", tier, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *repository) UpdateBilling(ctx context.Context, userID uuid.UUID, country string, vatID *string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET billing_country = $1, vat_id = $2, updated_at = NOW() WHERE id = $3", country, vatID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
 func (r *repository) Create(ctx context.Context, user *models.User) error {
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO users (id, name, email, password, balance, role, created_at, updated_at) 
-         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		user.ID, user.Name, user.Email, user.Password, user.Balance, user.Role, user.CreatedAt, user.UpdatedAt,
+		`INSERT INTO users (id, name, email, password, balance, role, tier, created_at, updated_at) 
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		user.ID, user.Name, user.Email, user.Password, user.Balance, user.Role, user.Tier, user.CreatedAt, user.UpdatedAt,
 	)
 	return err
 }
@@ -0,0 +1,39 @@
+package washtrade
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	// ListTrades returns the completed sales between two users made between
+	// from and to
+	ListTrades(ctx context.Context, from, to time.Time) ([]models.Trade, error)
+	// ListFlaggedOrderItems returns the order items made since the given time
+	// that already belong to a cluster, whatever its status
+	ListFlaggedOrderItems(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+
+	CreateReport(ctx context.Context, tx *sqlx.Tx, report *models.WashTradeReport) error
+	GetReport(ctx context.Context, id uuid.UUID) (*models.WashTradeReport, error)
+	ListReports(ctx context.Context, limit int) ([]*models.WashTradeReport, error)
+
+	// CreateCluster stores the cluster together with its users and sales
+	CreateCluster(ctx context.Context, tx *sqlx.Tx, cluster *models.WashTradeCluster) error
+	GetCluster(ctx context.Context, id uuid.UUID) (*models.WashTradeCluster, error)
+	GetClusterForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.WashTradeCluster, error)
+	ListClustersForReport(ctx context.Context, reportID uuid.UUID) ([]*models.WashTradeCluster, error)
+	ListClustersByStatus(ctx context.Context, status models.WashTradeStatus) ([]*models.WashTradeCluster, error)
+	UpdateCluster(ctx context.Context, tx *sqlx.Tx, cluster *models.WashTradeCluster) error
+	ListClusterUsers(ctx context.Context, clusterID uuid.UUID) ([]uuid.UUID, error)
+	ListClusterSales(ctx context.Context, clusterID uuid.UUID) ([]*models.WashTradeSale, error)
+
+	// SyncExcludedSales marks recorded market sales as excluded while they
+	// belong to a cluster that is not cleared and includes them again
+	// otherwise, unless their order was refunded. It returns the sales that
+	// changed.
+	SyncExcludedSales(ctx context.Context, tx *sqlx.Tx) ([]*models.MarketSale, error)
+}
@@ -0,0 +1,212 @@
+package washtrade
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ListTrades(ctx context.Context, from, to time.Time) ([]models.Trade, error) {
+	trades := []models.Trade{}
+	err := r.db.SelectContext(ctx, &trades,
+		`SELECT oi.id AS order_item_id, oi.skin_id, oi.seller_id, o.user_id AS buyer_id,
+                s.name, s.gun, s.wear, oi.price, o.created_at AS sold_at
+         FROM order_items oi
+         JOIN orders o ON o.id = oi.order_id
+         JOIN skins s ON s.id = oi.skin_id
+         WHERE o.status = $1 AND oi.seller_id IS NOT NULL
+           AND o.created_at >= $2 AND o.created_at <= $3
+         ORDER BY o.created_at, oi.id`,
+		models.OrderStatusCompleted, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+func (r *repository) ListFlaggedOrderItems(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	err := r.db.SelectContext(ctx, &ids,
+		`SELECT DISTINCT ws.order_item_id
+         FROM wash_trade_sales ws
+         JOIN order_items oi ON oi.id = ws.order_item_id
+         JOIN orders o ON o.id = oi.order_id
+         WHERE o.created_at >= $1`,
+		since)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *repository) CreateReport(ctx context.Context, tx *sqlx.Tx, report *models.WashTradeReport) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO wash_trade_reports (id, window_start, window_end, trades_analysed, clusters_found, sales_flagged, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		report.ID, report.WindowStart, report.WindowEnd, report.TradesAnalysed, report.ClustersFound, report.SalesFlagged, report.CreatedAt)
+	return err
+}
+
+func (r *repository) GetReport(ctx context.Context, id uuid.UUID) (*models.WashTradeReport, error) {
+	report := &models.WashTradeReport{}
+	err := r.db.GetContext(ctx, report, "SELECT * FROM wash_trade_reports WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *repository) ListReports(ctx context.Context, limit int) ([]*models.WashTradeReport, error) {
+	reports := []*models.WashTradeReport{}
+	err := r.db.SelectContext(ctx, &reports,
+		"SELECT * FROM wash_trade_reports ORDER BY created_at DESC LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (r *repository) CreateCluster(ctx context.Context, tx *sqlx.Tx, cluster *models.WashTradeCluster) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO wash_trade_clusters (id, report_id, score, trade_count, volume, status, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		cluster.ID, cluster.ReportID, cluster.Score, cluster.TradeCount, cluster.Volume, cluster.Status, cluster.CreatedAt, cluster.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range cluster.UserIDs {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO wash_trade_cluster_users (cluster_id, user_id) VALUES ($1, $2)",
+			cluster.ID, userID); err != nil {
+			return err
+		}
+	}
+	for _, sale := range cluster.Sales {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO wash_trade_sales (cluster_id, order_item_id, reasons, score)
+             VALUES ($1, $2, $3, $4)`,
+			cluster.ID, sale.OrderItemID, sale.Reasons, sale.Score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) GetCluster(ctx context.Context, id uuid.UUID) (*models.WashTradeCluster, error) {
+	cluster := &models.WashTradeCluster{}
+	err := r.db.GetContext(ctx, cluster, "SELECT * FROM wash_trade_clusters WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cluster, nil
+}
+
+func (r *repository) GetClusterForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.WashTradeCluster, error) {
+	cluster := &models.WashTradeCluster{}
+	err := tx.GetContext(ctx, cluster, "SELECT * FROM wash_trade_clusters WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cluster, nil
+}
+
+func (r *repository) ListClustersForReport(ctx context.Context, reportID uuid.UUID) ([]*models.WashTradeCluster, error) {
+	clusters := []*models.WashTradeCluster{}
+	err := r.db.SelectContext(ctx, &clusters,
+		"SELECT * FROM wash_trade_clusters WHERE report_id = $1 ORDER BY score DESC, id", reportID)
+	if err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+func (r *repository) ListClustersByStatus(ctx context.Context, status models.WashTradeStatus) ([]*models.WashTradeCluster, error) {
+	clusters := []*models.WashTradeCluster{}
+	err := r.db.SelectContext(ctx, &clusters,
+		"SELECT * FROM wash_trade_clusters WHERE status = $1 ORDER BY score DESC, created_at", status)
+	if err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+func (r *repository) UpdateCluster(ctx context.Context, tx *sqlx.Tx, cluster *models.WashTradeCluster) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE wash_trade_clusters
+         SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = $4, updated_at = $5
+         WHERE id = $6`,
+		cluster.Status, cluster.ReviewedBy, cluster.ReviewNote, cluster.ReviewedAt, cluster.UpdatedAt, cluster.ID)
+	return err
+}
+
+func (r *repository) ListClusterUsers(ctx context.Context, clusterID uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	err := r.db.SelectContext(ctx, &ids,
+		"SELECT user_id FROM wash_trade_cluster_users WHERE cluster_id = $1 ORDER BY user_id", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *repository) ListClusterSales(ctx context.Context, clusterID uuid.UUID) ([]*models.WashTradeSale, error) {
+	sales := []*models.WashTradeSale{}
+	err := r.db.SelectContext(ctx, &sales,
+		`SELECT ws.cluster_id, ws.order_item_id, oi.skin_id, oi.seller_id, o.user_id AS buyer_id,
+                oi.price, o.created_at AS sold_at, ws.reasons, ws.score
+         FROM wash_trade_sales ws
+         JOIN order_items oi ON oi.id = ws.order_item_id
+         JOIN orders o ON o.id = oi.order_id
+         WHERE ws.cluster_id = $1
+         ORDER BY o.created_at, oi.id`,
+		clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+func (r *repository) SyncExcludedSales(ctx context.Context, tx *sqlx.Tx) ([]*models.MarketSale, error) {
+	sales := []*models.MarketSale{}
+	err := tx.SelectContext(ctx, &sales,
+		`UPDATE market_sales ms
+         SET excluded = NOT ms.excluded
+         WHERE ms.order_item_id IN (SELECT order_item_id FROM wash_trade_sales)
+           AND ms.excluded <> (EXISTS (
+               SELECT 1 FROM wash_trade_sales ws
+               JOIN wash_trade_clusters c ON c.id = ws.cluster_id
+               WHERE ws.order_item_id = ms.order_item_id AND c.status <> $1)
+             OR EXISTS (
+               SELECT 1 FROM order_items oi
+               JOIN orders o ON o.id = oi.order_id
+               WHERE oi.id = ms.order_item_id AND o.status = $2))
+         RETURNING ms.*`,
+		models.WashTradeCleared, models.OrderStatusRefunded)
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
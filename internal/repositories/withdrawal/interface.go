@@ -0,0 +1,20 @@
+package withdrawal
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx *sqlx.Tx, withdrawal *models.Withdrawal) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Withdrawal, error)
+	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Withdrawal, error)
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Withdrawal, error)
+	ListByStatus(ctx context.Context, status models.WithdrawalStatus) ([]*models.Withdrawal, error)
+	ListStalePayouts(ctx context.Context, before time.Time) ([]*models.Withdrawal, error)
+	Update(ctx context.Context, tx *sqlx.Tx, withdrawal *models.Withdrawal) error
+}
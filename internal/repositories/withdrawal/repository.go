@@ -0,0 +1,96 @@
+package withdrawal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, tx *sqlx.Tx, withdrawal *models.Withdrawal) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO withdrawals (id, user_id, amount, status, requires_review, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		withdrawal.ID, withdrawal.UserID, withdrawal.Amount, withdrawal.Status, withdrawal.RequiresReview,
+		withdrawal.CreatedAt, withdrawal.UpdatedAt)
+	return err
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Withdrawal, error) {
+	withdrawal := &models.Withdrawal{}
+	err := r.db.GetContext(ctx, withdrawal, "SELECT * FROM withdrawals WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+func (r *repository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Withdrawal, error) {
+	withdrawal := &models.Withdrawal{}
+	err := tx.GetContext(ctx, withdrawal, "SELECT * FROM withdrawals WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+func (r *repository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Withdrawal, error) {
+	withdrawals := []*models.Withdrawal{}
+	err := r.db.SelectContext(ctx, &withdrawals,
+		"SELECT * FROM withdrawals WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	return withdrawals, nil
+}
+
+func (r *repository) ListByStatus(ctx context.Context, status models.WithdrawalStatus) ([]*models.Withdrawal, error) {
+	withdrawals := []*models.Withdrawal{}
+	err := r.db.SelectContext(ctx, &withdrawals,
+		"SELECT * FROM withdrawals WHERE status = $1 ORDER BY created_at", status)
+	if err != nil {
+		return nil, err
+	}
+	return withdrawals, nil
+}
+
+func (r *repository) ListStalePayouts(ctx context.Context, before time.Time) ([]*models.Withdrawal, error) {
+	withdrawals := []*models.Withdrawal{}
+	err := r.db.SelectContext(ctx, &withdrawals,
+		`SELECT * FROM withdrawals
+         WHERE status IN ('approved', 'processing') AND updated_at < $1
+         ORDER BY updated_at`, before)
+	if err != nil {
+		return nil, err
+	}
+	return withdrawals, nil
+}
+
+func (r *repository) Update(ctx context.Context, tx *sqlx.Tx, withdrawal *models.Withdrawal) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE withdrawals
+         SET status = $1, reviewed_by = $2, reviewed_at = $3, review_reason = $4,
+             provider_reference = $5, failure_reason = $6, updated_at = NOW()
+         WHERE id = $7`,
+		withdrawal.Status, withdrawal.ReviewedBy, withdrawal.ReviewedAt, withdrawal.ReviewReason,
+		withdrawal.ProviderReference, withdrawal.FailureReason, withdrawal.ID)
+	return err
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const leaderKey = "scheduler:leader"
+
+var (
+	// renewScript extends the lock only while this instance still holds it
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	// releaseScript deletes the lock only while this instance still holds it
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Lock elects the one worker instance that queues periodic jobs. The holder
+// has to renew it within its TTL, otherwise another instance takes over.
+type Lock struct {
+	client *redis.Client
+	id     string
+	ttl    time.Duration
+}
+
+func NewLock(client *redis.Client, id string, ttl time.Duration) *Lock {
+	return &Lock{client: client, id: id, ttl: ttl}
+}
+
+// Hold renews the lock when this instance holds it and tries to take it
+// otherwise, it reports whether this instance is the leader afterwards
+func (l *Lock) Hold(ctx context.Context) (bool, error) {
+	renewed, err := renewScript.Run(ctx, l.client, []string{leaderKey}, l.id, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	if renewed == 1 {
+		return true, nil
+	}
+	return l.client.SetNX(ctx, leaderKey, l.id, l.ttl).Result()
+}
+
+// Release gives up the lock so another instance doesn't have to wait for it
+// to expire
+func (l *Lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{leaderKey}, l.id).Err()
+}
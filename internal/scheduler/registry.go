@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+// Entry is a periodic job
+type Entry struct {
+	// Name identifies the job in JOB_SCHEDULES and the admin API
+	Name string
+	// Spec is a cron spec or an @every interval, in UTC; empty turns the job off
+	Spec string
+	Task *asynq.Task
+	// Unique keeps a run from being queued while the previous one still waits
+	Unique time.Duration
+}
+
+// Enabled reports whether the job is scheduled at all
+func (e Entry) Enabled() bool {
+	return e.Spec != ""
+}
+
+// Next returns when the job runs next after t
+func (e Entry) Next(t time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(e.Spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(t.UTC()), nil
+}
+
+// Registry returns every periodic job with its schedule: the defaults and
+// the older per-job settings come from the config, JOB_SCHEDULES overrides
+// any of them by name
+func Registry(cfg *config.Config) []Entry {
+	entries := []Entry{
+		// Move matured trade holds into sellers' available balance
+		{Name: "release-holds", Spec: cfg.HoldReleaseSchedule, Task: jobs.NewReleaseHoldsTask(), Unique: time.Minute},
+		// Delist skins whose listing ran out and tell their owners
+		{Name: "expire-listings", Spec: "@every 5m", Task: jobs.NewExpireListingsTask(), Unique: time.Minute},
+		// Queue payouts again that were never queued or ran out of retries
+		{Name: "requeue-stale-payouts", Spec: "@every 10m", Task: jobs.NewRequeueStalePayoutsTask(), Unique: time.Minute},
+		// Queue credit notes of refunds whose credit note task was lost
+		{Name: "queue-missing-credit-notes", Spec: "@every 15m", Task: jobs.NewQueueMissingCreditNotesTask(), Unique: time.Minute},
+		// Look for wash trading and keep flagged sales out of the prices
+		{Name: "detect-wash-trading", Spec: cfg.WashTrade.Schedule, Task: jobs.NewDetectWashTradingTask(), Unique: 10 * time.Minute},
+		// Check every balance against its transaction history and alert admins on drift
+		{Name: "reconcile-balances", Spec: cfg.ReconciliationSchedule, Task: jobs.NewReconcileBalancesTask(), Unique: 10 * time.Minute},
+		// Email every active user last month's statement and transactions
+		{Name: "queue-transaction-exports", Spec: cfg.TransactionExportSchedule, Task: jobs.NewQueueTransactionExportsTask(), Unique: time.Hour},
+		// Catch up on sales whose market data task was lost
+		{Name: "backfill-market-data", Spec: "0 4 * * *", Task: jobs.NewBackfillMarketDataTask(), Unique: 10 * time.Minute},
+	}
+	for i := range entries {
+		if spec, ok := cfg.JobSchedules[entries[i].Name]; ok {
+			entries[i].Spec = spec
+		}
+	}
+	return entries
+}
+
+// Validate checks that every enabled job has a valid spec and that
+// JOB_SCHEDULES only names known jobs
+func Validate(entries []Entry, overrides map[string]string) error {
+	known := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		known[e.Name] = true
+		if !e.Enabled() {
+			continue
+		}
+		if _, err := cron.ParseStandard(e.Spec); err != nil {
+			return fmt.Errorf("invalid schedule of %s %q: %w", e.Name, e.Spec, err)
+		}
+	}
+	for name := range overrides {
+		if !known[name] {
+			return fmt.Errorf("JOB_SCHEDULES: unknown job %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		HoldReleaseSchedule:       "@every 5m",
+		ReconciliationSchedule:    "0 3 * * *",
+		TransactionExportSchedule: "0 6 1 * *",
+		WashTrade:                 config.WashTradeConfig{Schedule: "@every 1h"},
+	}
+}
+
+func entryByName(entries []Entry, name string) Entry {
+	for _, e := range entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return Entry{}
+}
+
+func TestRegistry(t *testing.T) {
+	entries := Registry(testConfig())
+
+	releaseHolds := entryByName(entries, "release-holds")
+	assert.Equal(t, "@every 5m", releaseHolds.Spec)
+	assert.Equal(t, jobs.ReleaseHolds, releaseHolds.Task.Type())
+	assert.Equal(t, "0 4 * * *", entryByName(entries, "backfill-market-data").Spec)
+	assert.NoError(t, Validate(entries, nil))
+}
+
+func TestRegistry_Overrides(t *testing.T) {
+	cfg := testConfig()
+	cfg.JobSchedules = map[string]string{"reconcile-balances": "@every 6h", "backfill-market-data": ""}
+
+	entries := Registry(cfg)
+
+	assert.Equal(t, "@every 6h", entryByName(entries, "reconcile-balances").Spec)
+	assert.False(t, entryByName(entries, "backfill-market-data").Enabled())
+	assert.True(t, entryByName(entries, "release-holds").Enabled())
+	assert.NoError(t, Validate(entries, cfg.JobSchedules))
+}
+
+func TestValidate(t *testing.T) {
+	cfg := testConfig()
+	cfg.JobSchedules = map[string]string{"reconcile-balances": "every night"}
+	assert.ErrorContains(t, Validate(Registry(cfg), cfg.JobSchedules), "reconcile-balances")
+
+	cfg.JobSchedules = map[string]string{"reconcile-balance": "@daily"}
+	assert.ErrorContains(t, Validate(Registry(cfg), cfg.JobSchedules), "unknown job")
+}
+
+func TestEntry_Next(t *testing.T) {
+	now := time.Date(2026, 3, 10, 2, 30, 0, 0, time.UTC)
+
+	next, err := Entry{Spec: "0 3 * * *"}.Next(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC), next)
+
+	next, err = Entry{Spec: "@every 5m"}.Next(now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(5*time.Minute), next)
+}
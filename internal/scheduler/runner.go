@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// lockTTL is how long the other instances wait before taking over from a
+// leader that stopped renewing the lock, e.g. because it crashed
+const lockTTL = 15 * time.Second
+
+// Runner queues the periodic jobs while its worker instance is the leader.
+// asynq schedulers can't be restarted, so every term gets a new one.
+type Runner struct {
+	entries  []Entry
+	lock     *Lock
+	store    *Store
+	redisOpt asynq.RedisConnOpt
+	logger   *slog.Logger
+}
+
+func NewRunner(entries []Entry, redisOpt asynq.RedisConnOpt, client *redis.Client, instanceID string, logger *slog.Logger) *Runner {
+	return &Runner{
+		entries:  entries,
+		lock:     NewLock(client, instanceID, lockTTL),
+		store:    NewStore(client),
+		redisOpt: redisOpt,
+		logger:   logger,
+	}
+}
+
+// Run campaigns for the lock and schedules the jobs while holding it, until
+// ctx is done. It stops scheduling as soon as the lock can't be renewed,
+// a leader cut off from Redis may otherwise queue next to its successor.
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(lockTTL / 3)
+	defer ticker.Stop()
+
+	var current *asynq.Scheduler
+	for {
+		held, err := r.lock.Hold(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Warn("failed to hold scheduler lock", "err", err)
+		}
+		switch {
+		case held && current == nil:
+			r.logger.Info("became scheduler leader")
+			current = r.start()
+		case !held && current != nil:
+			r.logger.Warn("lost scheduler lock, stopping the scheduler")
+			current.Shutdown()
+			current = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if current != nil {
+				current.Shutdown()
+			}
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			if err := r.lock.Release(releaseCtx); err != nil {
+				r.logger.Warn("failed to release scheduler lock", "err", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// start registers every enabled job on a new scheduler, nil when it couldn't
+// be started so the next tick tries again
+func (r *Runner) start() *asynq.Scheduler {
+	names := make(map[string]string, len(r.entries))
+	s := asynq.NewScheduler(r.redisOpt, &asynq.SchedulerOpts{
+		PostEnqueueFunc: func(info *asynq.TaskInfo, err error) {
+			if err == nil {
+				r.record(names[info.Type], models.ScheduleRun{At: time.Now(), TaskID: info.ID})
+			}
+		},
+		// PostEnqueueFunc doesn't say which task failed to be queued
+		EnqueueErrorHandler: func(task *asynq.Task, _ []asynq.Option, err error) {
+			r.record(names[task.Type()], models.ScheduleRun{At: time.Now(), Error: enqueueError(err)})
+		},
+	})
+
+	for _, e := range r.entries {
+		if !e.Enabled() {
+			continue
+		}
+		names[e.Task.Type()] = e.Name
+		if _, err := s.Register(e.Spec, e.Task, asynq.Unique(e.Unique)); err != nil {
+			r.logger.Error("failed to register periodic job", "name", e.Name, "spec", e.Spec, "err", err)
+			return nil
+		}
+	}
+	if err := s.Start(); err != nil {
+		r.logger.Error("could not start asynq scheduler", "err", err)
+		return nil
+	}
+	return s
+}
+
+func (r *Runner) record(name string, run models.ScheduleRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if run.Error != "" {
+		r.logger.Warn("periodic job not queued", "name", name, "reason", run.Error)
+	}
+	if err := r.store.RecordRun(ctx, name, run); err != nil {
+		r.logger.Warn("failed to record periodic job run", "name", name, "err", err)
+	}
+}
+
+func enqueueError(err error) string {
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		return "previous run is still queued"
+	}
+	return err.Error()
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const runsKey = "scheduler:runs"
+
+// Store keeps the last run of every periodic job in Redis, where the API can
+// read it whichever worker instance queued it
+type Store struct {
+	client *redis.Client
+}
+
+func NewStore(client *redis.Client) *Store {
+	return &Store{client: client}
+}
+
+func (s *Store) RecordRun(ctx context.Context, name string, run models.ScheduleRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, runsKey, name, data).Err()
+}
+
+// Runs returns the last run of every job by name
+func (s *Store) Runs(ctx context.Context) (map[string]models.ScheduleRun, error) {
+	raw, err := s.client.HGetAll(ctx, runsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	runs := make(map[string]models.ScheduleRun, len(raw))
+	for name, data := range raw {
+		var run models.ScheduleRun
+		if err := json.Unmarshal([]byte(data), &run); err != nil {
+			return nil, err
+		}
+		runs[name] = run
+	}
+	return runs, nil
+}
+
+// Leader returns the worker instance holding the scheduler lock, empty when
+// there is none
+func (s *Store) Leader(ctx context.Context) (string, error) {
+	id, err := s.client.Get(ctx, leaderKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return id, err
+}
@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/dispute"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/hold"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/order"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type DisputeService struct {
+	disputeRepo     dispute.Repository
+	orderRepo       order.Repository
+	userRepo        user.Repository
+	transactionRepo transaction.Repository
+	holdRepo        hold.Repository
+	marketplace     *MarketplaceService
+	publisher       realtime.Publisher
+	window          time.Duration
+	db              *sqlx.DB
+	logger          *slog.Logger
+}
+
+func NewDisputeService(disputeRepo dispute.Repository,
+	orderRepo order.Repository,
+	userRepo user.Repository,
+	transactionRepo transaction.Repository,
+	holdRepo hold.Repository,
+	marketplace *MarketplaceService,
+	publisher realtime.Publisher,
+	window time.Duration,
+	db *sqlx.DB,
+	logger *slog.Logger) *DisputeService {
+	return &DisputeService{
+		disputeRepo:     disputeRepo,
+		orderRepo:       orderRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		holdRepo:        holdRepo,
+		marketplace:     marketplace,
+		publisher:       publisher,
+		window:          window,
+		db:              db,
+		logger:          logger,
+	}
+}
+
+func (s *DisputeService) publishBalance(ctx context.Context, u *models.User) {
+	if err := s.publisher.PublishUser(ctx, u.ID, realtime.NewBalancesEvent(u.Balance, u.PendingBalance)); err != nil {
+		s.logger.Warn("failed to publish balance event", "user_id", u.ID, "err", err)
+	}
+}
+
+func (s *DisputeService) publishOrder(ctx context.Context, ord *models.Order) {
+	if err := s.publisher.PublishUser(ctx, ord.UserID, realtime.NewOrderEvent(ord)); err != nil {
+		s.logger.Warn("failed to publish order event", "order_id", ord.ID, "err", err)
+	}
+}
+
+func (s *DisputeService) createTransaction(ctx context.Context, tx *sqlx.Tx, txn *models.Transaction) error {
+	if err := s.transactionRepo.Create(ctx, tx, txn); err != nil {
+		s.logger.Error("failed to create transaction record", "error", err, "user_id", txn.UserID, "type", txn.Type)
+		return apperrors.WrapInternal(err, "failed to create transaction record")
+	}
+	return nil
+}
+
+// OpenDispute lets the buyer contest a completed order within the dispute
+// window. The seller's proceeds from the order are frozen until an admin
+// resolves the dispute.
+func (s *DisputeService) OpenDispute(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, reason string) (*models.Dispute, error) {
+	s.logger.Info("opening dispute", "user_id", userID, "order_id", orderID)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	ord, err := s.orderRepo.GetOrderByIDForUpdate(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order for update", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order for update")
+	}
+	if ord == nil {
+		return nil, apperrors.NewNotFoundError("order not found")
+	}
+	if ord.UserID != userID {
+		s.logger.Warn("user attempted to dispute someone else's order", "user_id", userID, "order_id", orderID)
+		return nil, apperrors.NewForbiddenError("you can only dispute your own orders")
+	}
+	if ord.Status != models.OrderStatusCompleted {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("order in status %s cannot be disputed", ord.Status))
+	}
+	if time.Since(ord.CreatedAt) > s.window {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("orders can only be disputed within %s of purchase", s.window))
+	}
+
+	existing, err := s.disputeRepo.GetByOrderID(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to check existing dispute", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to check existing dispute")
+	}
+	if existing != nil {
+		return nil, apperrors.NewAlreadyExistsError("a dispute was already opened for this order")
+	}
+
+	items, err := s.orderRepo.GetOrderItems(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order items", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order items")
+	}
+
+	var sellerID *uuid.UUID
+	var proceeds float64
+	for _, item := range items {
+		if item.SellerID == nil {
+			continue
+		}
+		if sellerID != nil && *sellerID != *item.SellerID {
+			return nil, apperrors.NewValidationError("orders with several sellers cannot be disputed")
+		}
+		sellerID = item.SellerID
+		proceeds += item.Price - item.Fee
+	}
+
+	// Proceeds still in their trade hold can't be released while the order is
+	// disputed, so only the part already paid out needs freezing
+	holds, err := s.holdRepo.GetActiveByOrder(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get balance holds", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get balance holds")
+	}
+	for _, h := range holds {
+		proceeds -= h.Amount
+	}
+
+	now := time.Now()
+	d := &models.Dispute{
+		ID:        uuid.New(),
+		OrderID:   orderID,
+		BuyerID:   userID,
+		SellerID:  sellerID,
+		Status:    models.DisputeOpen,
+		Reason:    reason,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Freeze what the seller earned from the order, or whatever is left of it
+	var seller *models.User
+	if sellerID != nil {
+		seller, err = s.userRepo.GetUserByIdForUpdate(ctx, tx, *sellerID)
+		if err != nil {
+			s.logger.Error("failed to get seller for update", "error", err, "seller_id", *sellerID)
+			return nil, apperrors.WrapInternal(err, "failed to get seller for update")
+		}
+
+		freeze := roundCents(min(proceeds, seller.Balance))
+		if freeze > 0 {
+			before := seller.Balance
+			seller.Balance = roundCents(seller.Balance - freeze)
+			seller.FrozenBalance = roundCents(seller.FrozenBalance + freeze)
+			if err := s.userRepo.UpdateBalances(ctx, tx, seller); err != nil {
+				s.logger.Error("failed to freeze seller proceeds", "error", err, "seller_id", seller.ID)
+				return nil, apperrors.WrapInternal(err, "failed to freeze seller proceeds")
+			}
+
+			description := "proceeds frozen by dispute"
+			if err := s.createTransaction(ctx, tx, &models.Transaction{
+				ID:             uuid.New(),
+				UserID:         seller.ID,
+				Amount:         -freeze,
+				Type:           models.DisputeHold,
+				BalanceBefore:  before,
+				BalanceAfter:   seller.Balance,
+				OrderID:        &orderID,
+				CounterpartyID: &userID,
+				Description:    &description,
+				CreatedAt:      now,
+			}); err != nil {
+				return nil, err
+			}
+			d.FrozenAmount = freeze
+		}
+	}
+
+	if err := s.disputeRepo.Create(ctx, tx, d); err != nil {
+		s.logger.Error("failed to create dispute", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to create dispute")
+	}
+
+	if err := s.marketplace.transitionOrder(ctx, tx, ord, models.OrderStatusDisputed, &userID, reason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.publishOrder(ctx, ord)
+	if seller != nil {
+		s.publishBalance(ctx, seller)
+	}
+
+	s.logger.Info("dispute opened", "dispute_id", d.ID, "order_id", orderID, "frozen_amount", d.FrozenAmount)
+	return d, nil
+}
+
+// ResolveDispute closes an open dispute. The frozen proceeds are released
+// first, then the resolution is applied: a full refund undoes the order, a
+// partial refund moves amount from the seller to the buyer and a rejection
+// leaves the order as it was.
+func (s *DisputeService) ResolveDispute(ctx context.Context, adminID uuid.UUID, disputeID uuid.UUID, req models.ResolveDisputeRequest) (*models.Dispute, error) {
+	s.logger.Info("resolving dispute", "admin_id", adminID, "dispute_id", disputeID, "resolution", req.Resolution)
+
+	if req.Resolution == models.ResolutionPartialRefund && req.Amount <= 0 {
+		return nil, apperrors.NewValidationError("amount is required for a partial refund")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	d, err := s.disputeRepo.GetByIDForUpdate(ctx, tx, disputeID)
+	if err != nil {
+		s.logger.Error("failed to get dispute for update", "error", err, "dispute_id", disputeID)
+		return nil, apperrors.WrapInternal(err, "failed to get dispute for update")
+	}
+	if d == nil {
+		return nil, apperrors.NewNotFoundError("dispute not found")
+	}
+	if d.Status != models.DisputeOpen {
+		return nil, apperrors.NewValidationError("dispute is already resolved")
+	}
+
+	ord, err := s.orderRepo.GetOrderByIDForUpdate(ctx, tx, d.OrderID)
+	if err != nil {
+		s.logger.Error("failed to get order for update", "error", err, "order_id", d.OrderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order for update")
+	}
+	if ord == nil {
+		return nil, apperrors.NewNotFoundError("order not found")
+	}
+	// The seller pays a partial refund, so it is capped by the item prices
+	// without the tax the buyer paid on top
+	if req.Resolution == models.ResolutionPartialRefund && ord.RefundedAmount+req.Amount >= ord.NetAmount() {
+		return nil, apperrors.NewValidationError("partial refunds must add up to less than the order total before tax, use a full refund instead")
+	}
+
+	// Holds are locked before the accounts, and the buyer before the seller
+	holds, err := s.holdRepo.GetActiveByOrder(ctx, tx, ord.ID)
+	if err != nil {
+		s.logger.Error("failed to get balance holds", "error", err, "order_id", ord.ID)
+		return nil, apperrors.WrapInternal(err, "failed to get balance holds")
+	}
+
+	buyer, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, d.BuyerID)
+	if err != nil {
+		s.logger.Error("failed to get buyer for update", "error", err, "user_id", d.BuyerID)
+		return nil, apperrors.WrapInternal(err, "failed to get buyer for update")
+	}
+	var seller *models.User
+	if d.SellerID != nil {
+		seller, err = s.userRepo.GetUserByIdForUpdate(ctx, tx, *d.SellerID)
+		if err != nil {
+			s.logger.Error("failed to get seller for update", "error", err, "user_id", *d.SellerID)
+			return nil, apperrors.WrapInternal(err, "failed to get seller for update")
+		}
+	}
+
+	now := time.Now()
+	if seller != nil && d.FrozenAmount > 0 {
+		before := seller.Balance
+		seller.Balance = roundCents(seller.Balance + d.FrozenAmount)
+		seller.FrozenBalance = roundCents(max(0, seller.FrozenBalance-d.FrozenAmount))
+		if err := s.userRepo.UpdateBalances(ctx, tx, seller); err != nil {
+			s.logger.Error("failed to release frozen proceeds", "error", err, "seller_id", seller.ID)
+			return nil, apperrors.WrapInternal(err, "failed to release frozen proceeds")
+		}
+
+		description := "dispute closed: frozen proceeds released"
+		if err := s.createTransaction(ctx, tx, &models.Transaction{
+			ID:            uuid.New(),
+			UserID:        seller.ID,
+			Amount:        d.FrozenAmount,
+			Type:          models.DisputeRelease,
+			BalanceBefore: before,
+			BalanceAfter:  seller.Balance,
+			OrderID:       &ord.ID,
+			Description:   &description,
+			CreatedAt:     now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	var refund *refundResult
+	var refundTxn *models.Transaction
+	switch req.Resolution {
+	case models.ResolutionRefund:
+		refund, err = s.marketplace.refundLocked(ctx, tx, ord, adminID, "dispute: "+req.Note)
+		if err != nil {
+			return nil, err
+		}
+		refundTxn = refund.refund
+		d.Status = models.DisputeRefunded
+		d.RefundAmount = refundTxn.Amount
+	case models.ResolutionPartialRefund:
+		amount := roundCents(req.Amount)
+		if refundTxn, err = s.partialRefund(ctx, tx, ord, holds, buyer, seller, amount, req.Note); err != nil {
+			return nil, err
+		}
+		if err := s.marketplace.transitionOrder(ctx, tx, ord, models.OrderStatusCompleted, &adminID, req.Note); err != nil {
+			return nil, err
+		}
+		d.Status = models.DisputePartialRefunded
+		d.RefundAmount = amount
+	case models.ResolutionReject:
+		if err := s.marketplace.transitionOrder(ctx, tx, ord, models.OrderStatusCompleted, &adminID, req.Note); err != nil {
+			return nil, err
+		}
+		d.Status = models.DisputeRejected
+	default:
+		return nil, apperrors.NewValidationError("resolution must be one of refund, partial_refund, reject")
+	}
+
+	d.Resolution = &req.Note
+	d.ResolvedBy = &adminID
+	d.ResolvedAt = &now
+	d.UpdatedAt = now
+	if err := s.disputeRepo.Resolve(ctx, tx, d); err != nil {
+		s.logger.Error("failed to resolve dispute", "error", err, "dispute_id", disputeID)
+		return nil, apperrors.WrapInternal(err, "failed to resolve dispute")
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "dispute_id", disputeID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	if refund != nil {
+		s.marketplace.publishRefund(ctx, ord, refund)
+	} else {
+		s.publishOrder(ctx, ord)
+		s.publishBalance(ctx, buyer)
+		if seller != nil {
+			s.publishBalance(ctx, seller)
+		}
+	}
+
+	if refundTxn != nil {
+		s.marketplace.queueCreditNote(ctx, refundTxn, "dispute: "+req.Note)
+	}
+
+	s.logger.Info("dispute resolved", "dispute_id", disputeID, "status", d.Status, "refund_amount", d.RefundAmount)
+	return d, nil
+}
+
+// partialRefund credits the buyer amount and debits the seller its share
+// without VAT, from the order's pending proceeds when they are still on hold.
+// The VAT share comes out of the tax the platform collected.
+func (s *DisputeService) partialRefund(ctx context.Context, tx *sqlx.Tx, ord *models.Order, holds []*models.BalanceHold, buyer, seller *models.User, amount float64, note string) (*models.Transaction, error) {
+	now := time.Now()
+	description := "partial refund: " + note
+
+	if seller != nil {
+		charge := roundCents(amount - refundTaxShare(ord, amount))
+		reversal := &models.Transaction{
+			ID:             uuid.New(),
+			UserID:         seller.ID,
+			Amount:         -charge,
+			Type:           models.Reversal,
+			OrderID:        &ord.ID,
+			CounterpartyID: &buyer.ID,
+			Description:    &description,
+			CreatedAt:      now,
+		}
+
+		var pending *models.BalanceHold
+		for _, h := range holds {
+			if h.Amount >= charge {
+				pending = h
+				break
+			}
+		}
+		if pending != nil {
+			reversal.BalanceType = models.BalancePending
+			reversal.BalanceBefore = seller.PendingBalance
+			seller.PendingBalance = roundCents(max(0, seller.PendingBalance-charge))
+			reversal.BalanceAfter = seller.PendingBalance
+
+			pending.Amount = roundCents(pending.Amount - charge)
+			if err := s.holdRepo.Update(ctx, tx, pending); err != nil {
+				s.logger.Error("failed to update balance hold", "error", err, "hold_id", pending.ID)
+				return nil, apperrors.WrapInternal(err, "failed to update balance hold")
+			}
+		} else {
+			if seller.Balance < charge {
+				s.logger.Warn("seller cannot cover partial refund", "order_id", ord.ID, "seller_id", seller.ID, "balance", seller.Balance, "required", charge)
+				return nil, apperrors.NewValidationError("seller balance is too low for this refund amount")
+			}
+			reversal.BalanceType = models.BalanceAvailable
+			reversal.BalanceBefore = seller.Balance
+			seller.Balance = roundCents(seller.Balance - charge)
+			reversal.BalanceAfter = seller.Balance
+		}
+
+		if err := s.userRepo.UpdateBalances(ctx, tx, seller); err != nil {
+			s.logger.Error("failed to update seller balance", "error", err, "seller_id", seller.ID)
+			return nil, apperrors.WrapInternal(err, "failed to update seller balance")
+		}
+		if err := s.createTransaction(ctx, tx, reversal); err != nil {
+			return nil, err
+		}
+	}
+
+	before := buyer.Balance
+	buyer.Balance = roundCents(buyer.Balance + amount)
+	if err := s.userRepo.UpdateBalance(ctx, tx, buyer.ID, buyer.Balance); err != nil {
+		s.logger.Error("failed to update buyer balance", "error", err, "user_id", buyer.ID)
+		return nil, apperrors.WrapInternal(err, "failed to update buyer balance")
+	}
+	txn := &models.Transaction{
+		ID:            uuid.New(),
+		UserID:        buyer.ID,
+		Amount:        amount,
+		Type:          models.Refund,
+		BalanceBefore: before,
+		BalanceAfter:  buyer.Balance,
+		OrderID:       &ord.ID,
+		Description:   &description,
+		CreatedAt:     now,
+	}
+	if seller != nil {
+		txn.CounterpartyID = &seller.ID
+	}
+	if err := s.createTransaction(ctx, tx, txn); err != nil {
+		return nil, err
+	}
+
+	// The refund lowers what the buyer paid, and with it the VAT owed
+	if err := s.marketplace.recordRefund(ctx, tx, ord, amount, now); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// GetDispute returns a dispute with its messages to one of its parties or an admin
+func (s *DisputeService) GetDispute(ctx context.Context, userID uuid.UUID, isAdmin bool, disputeID uuid.UUID) (*models.DisputeDetails, error) {
+	d, err := s.getParticipantDispute(ctx, userID, isAdmin, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.disputeRepo.GetMessages(ctx, disputeID)
+	if err != nil {
+		s.logger.Error("failed to get dispute messages", "error", err, "dispute_id", disputeID)
+		return nil, apperrors.WrapInternal(err, "failed to get dispute messages")
+	}
+	return &models.DisputeDetails{Dispute: *d, Messages: messages}, nil
+}
+
+// AddMessage appends a message to the thread of an open dispute
+func (s *DisputeService) AddMessage(ctx context.Context, userID uuid.UUID, isAdmin bool, disputeID uuid.UUID, body string) (*models.DisputeMessage, error) {
+	d, err := s.getParticipantDispute(ctx, userID, isAdmin, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != models.DisputeOpen {
+		return nil, apperrors.NewValidationError("dispute is already resolved")
+	}
+
+	message := &models.DisputeMessage{
+		ID:        uuid.New(),
+		DisputeID: disputeID,
+		AuthorID:  userID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := s.disputeRepo.AddMessage(ctx, message); err != nil {
+		s.logger.Error("failed to add dispute message", "error", err, "dispute_id", disputeID)
+		return nil, apperrors.WrapInternal(err, "failed to add dispute message")
+	}
+	return message, nil
+}
+
+// ListUserDisputes returns the disputes the user is a buyer or seller in
+func (s *DisputeService) ListUserDisputes(ctx context.Context, userID uuid.UUID) ([]*models.Dispute, error) {
+	disputes, err := s.disputeRepo.ListForUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list user disputes", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to list disputes")
+	}
+	return disputes, nil
+}
+
+// ListDisputes returns disputes by status for the admin queue, open ones by default
+func (s *DisputeService) ListDisputes(ctx context.Context, status models.DisputeStatus) ([]*models.Dispute, error) {
+	if status == "" {
+		status = models.DisputeOpen
+	}
+	if !status.Valid() {
+		return nil, apperrors.NewValidationError("invalid dispute status")
+	}
+	disputes, err := s.disputeRepo.ListByStatus(ctx, status)
+	if err != nil {
+		s.logger.Error("failed to list disputes", "error", err, "status", status)
+		return nil, apperrors.WrapInternal(err, "failed to list disputes")
+	}
+	return disputes, nil
+}
+
+func (s *DisputeService) getParticipantDispute(ctx context.Context, userID uuid.UUID, isAdmin bool, disputeID uuid.UUID) (*models.Dispute, error) {
+	d, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		s.logger.Error("failed to get dispute", "error", err, "dispute_id", disputeID)
+		return nil, apperrors.WrapInternal(err, "failed to get dispute")
+	}
+	if d == nil {
+		return nil, apperrors.NewNotFoundError("dispute not found")
+	}
+	isParty := d.BuyerID == userID || (d.SellerID != nil && *d.SellerID == userID)
+	if !isParty && !isAdmin {
+		return nil, apperrors.NewForbiddenError("you are not a party to this dispute")
+	}
+	return d, nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDisputeRepository is a mock implementation of dispute.Repository
+type MockDisputeRepository struct {
+	mock.Mock
+}
+
+func (m *MockDisputeRepository) Create(ctx context.Context, tx *sqlx.Tx, d *models.Dispute) error {
+	args := m.Called(ctx, tx, d)
+	return args.Error(0)
+}
+
+func (m *MockDisputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Dispute, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) GetByOrderID(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*models.Dispute, error) {
+	args := m.Called(ctx, tx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Dispute, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) ListByStatus(ctx context.Context, status models.DisputeStatus) ([]*models.Dispute, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) Resolve(ctx context.Context, tx *sqlx.Tx, d *models.Dispute) error {
+	args := m.Called(ctx, tx, d)
+	return args.Error(0)
+}
+
+func (m *MockDisputeRepository) AddMessage(ctx context.Context, message *models.DisputeMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MockDisputeRepository) GetMessages(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeMessage, error) {
+	args := m.Called(ctx, disputeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DisputeMessage), args.Error(1)
+}
+
+func newTestDisputeService(repo *MockDisputeRepository) *DisputeService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewDisputeService(repo, nil, nil, nil, nil, nil, realtime.NopPublisher{}, 7*24*time.Hour, nil, logger)
+}
+
+func TestDisputeService_AddMessage(t *testing.T) {
+	buyerID := uuid.New()
+	sellerID := uuid.New()
+	disputeID := uuid.New()
+	open := &models.Dispute{ID: disputeID, BuyerID: buyerID, SellerID: &sellerID, Status: models.DisputeOpen}
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		isAdmin       bool
+		dispute       *models.Dispute
+		expectAdd     bool
+		expectedError error
+	}{
+		{
+			name:      "buyer can post",
+			userID:    buyerID,
+			dispute:   open,
+			expectAdd: true,
+		},
+		{
+			name:      "seller can post",
+			userID:    sellerID,
+			dispute:   open,
+			expectAdd: true,
+		},
+		{
+			name:      "admin can post",
+			userID:    uuid.New(),
+			isAdmin:   true,
+			dispute:   open,
+			expectAdd: true,
+		},
+		{
+			name:          "outsider is rejected",
+			userID:        uuid.New(),
+			dispute:       open,
+			expectedError: apperrors.NewForbiddenError("you are not a party to this dispute"),
+		},
+		{
+			name:          "resolved dispute is read-only",
+			userID:        buyerID,
+			dispute:       &models.Dispute{ID: disputeID, BuyerID: buyerID, Status: models.DisputeRejected},
+			expectedError: apperrors.NewValidationError("dispute is already resolved"),
+		},
+		{
+			name:          "dispute not found",
+			userID:        buyerID,
+			expectedError: apperrors.NewNotFoundError("dispute not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockDisputeRepository)
+			if tt.dispute != nil {
+				repo.On("GetByID", mock.Anything, disputeID).Return(tt.dispute, nil)
+			} else {
+				repo.On("GetByID", mock.Anything, disputeID).Return(nil, nil)
+			}
+			if tt.expectAdd {
+				repo.On("AddMessage", mock.Anything, mock.MatchedBy(func(m *models.DisputeMessage) bool {
+					return m.DisputeID == disputeID && m.AuthorID == tt.userID && m.Body == "hello"
+				})).Return(nil)
+			}
+
+			service := newTestDisputeService(repo)
+			message, err := service.AddMessage(context.Background(), tt.userID, tt.isAdmin, disputeID, "hello")
+
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				assert.Nil(t, message)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, message)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDisputeService_ListDisputes(t *testing.T) {
+	t.Run("defaults to open disputes", func(t *testing.T) {
+		repo := new(MockDisputeRepository)
+		repo.On("ListByStatus", mock.Anything, models.DisputeOpen).Return([]*models.Dispute{}, nil)
+
+		disputes, err := newTestDisputeService(repo).ListDisputes(context.Background(), "")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, disputes)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		repo := new(MockDisputeRepository)
+
+		disputes, err := newTestDisputeService(repo).ListDisputes(context.Background(), "closed")
+
+		assert.Equal(t, apperrors.NewValidationError("invalid dispute status"), err)
+		assert.Nil(t, disputes)
+	})
+}
+
+// MockTaxLedgerRepository is a mock implementation of taxledger.Repository
+type MockTaxLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxLedgerRepository) Create(ctx context.Context, tx *sqlx.Tx, entry *models.TaxEntry) error {
+	args := m.Called(ctx, tx, entry)
+	return args.Error(0)
+}
+
+func (m *MockTaxLedgerRepository) GetReport(ctx context.Context, period models.ReportPeriod, from, to time.Time) ([]*models.TaxReportRow, error) {
+	args := m.Called(ctx, period, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TaxReportRow), args.Error(1)
+}
+
+func TestDisputeService_PartialRefund_ReversesTaxShare(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	country := "DE"
+	ord := &models.Order{ID: uuid.New(), TotalAmount: 119, TaxAmount: 19, TaxRate: 0.19, TaxCountry: &country}
+	buyer := &models.User{ID: uuid.New(), Balance: 10}
+	seller := &models.User{ID: uuid.New(), Balance: 100}
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("UpdateBalance", mock.Anything, mock.Anything, buyer.ID, 69.5).Return(nil)
+	userRepo.On("UpdateBalances", mock.Anything, mock.Anything, seller).Return(nil)
+	var sellerDebit float64
+	transactionRepo := new(MockTransactionRepository)
+	transactionRepo.On("Create", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		if txn := args.Get(2).(*models.Transaction); txn.UserID == seller.ID {
+			sellerDebit += txn.Amount
+		}
+	}).Return(nil)
+	taxLedger := new(MockTaxLedgerRepository)
+	taxLedger.On("Create", mock.Anything, mock.Anything, mock.MatchedBy(func(e *models.TaxEntry) bool {
+		return e.OrderID == ord.ID && e.NetAmount == -50 && e.TaxAmount == -9.5
+	})).Return(nil)
+
+	orderRepo := new(MockOrderRepository)
+	orderRepo.On("AddRefundedAmount", mock.Anything, mock.Anything, ord.ID, 59.5).Return(nil)
+
+	marketplace := &MarketplaceService{orderRepo: orderRepo, taxLedger: taxLedger, logger: logger}
+	svc := NewDisputeService(nil, nil, userRepo, transactionRepo, nil, marketplace, realtime.NopPublisher{}, 7*24*time.Hour, nil, logger)
+
+	txn, err := svc.partialRefund(context.Background(), nil, ord, nil, buyer, seller, 59.5, "scratched")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 59.5, txn.Amount)
+	assert.Equal(t, 59.5, ord.RefundedAmount)
+	// The seller pays the refund without its VAT share, the platform the 9.50 VAT
+	assert.Equal(t, -50.0, sellerDebit)
+	assert.Equal(t, 50.0, seller.Balance)
+	taxLedger.AssertExpectations(t)
+	orderRepo.AssertExpectations(t)
+}
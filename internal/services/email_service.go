@@ -2,9 +2,12 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/mailgun/mailgun-go/v4"
 )
 
@@ -18,7 +21,9 @@ func NewEmailService(mg mailgun.Mailgun, domain string, logger *slog.Logger) *Em
 	return &EmailService{mg: mg, domain: domain, logger: logger}
 }
 
-func (s *EmailService) SendInvoice(to string, pdf []byte) error {
+// SendInvoice emails the invoice PDF, with the UBL XML alongside when xml is
+// not nil
+func (s *EmailService) SendInvoice(to string, pdf, xml []byte) error {
 	s.logger.Info("attempting to send invoice email", "to", to, "domain", s.domain, "pdf_size", len(pdf))
 
 	msg := mailgun.NewMessage(
@@ -29,6 +34,9 @@ func (s *EmailService) SendInvoice(to string, pdf []byte) error {
 	)
 
 	msg.AddBufferAttachment("invoice.pdf", pdf)
+	if xml != nil {
+		msg.AddBufferAttachment("invoice.xml", xml)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -44,3 +52,83 @@ func (s *EmailService) SendInvoice(to string, pdf []byte) error {
 	s.logger.Info("email sent successfully", "to", to)
 	return nil
 }
+
+// SendCreditNote emails the buyer the credit note of a refund
+func (s *EmailService) SendCreditNote(to, number string, pdf []byte) error {
+	msg := mailgun.NewMessage(
+		"noreply@"+s.domain,
+		"Your Credit Note "+number,
+		"Hello, your order has been refunded. Please find attached the credit note.",
+		to,
+	)
+	msg.AddBufferAttachment(number+".pdf", pdf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, _, err := s.mg.Send(ctx, msg); err != nil {
+		s.logger.Error("failed to send credit note email", "to", to, "number", number, "err", err)
+		return err
+	}
+	s.logger.Info("credit note email sent", "to", to, "number", number)
+	return nil
+}
+
+// SendAlert sends a plain-text operational alert, e.g. to an admin
+func (s *EmailService) SendAlert(to, subject, body string) error {
+	msg := mailgun.NewMessage("noreply@"+s.domain, subject, body, to)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, _, err := s.mg.Send(ctx, msg); err != nil {
+		s.logger.Error("failed to send alert email", "to", to, "subject", subject, "err", err)
+		return err
+	}
+	return nil
+}
+
+// SendMonthlyStatement sends a user their statement of a month with the
+// month's transactions as CSV
+func (s *EmailService) SendMonthlyStatement(to string, month time.Time, pdf, csv []byte) error {
+	period := month.Format("January 2006")
+	msg := mailgun.NewMessage(
+		"noreply@"+s.domain,
+		"Your statement for "+period,
+		"Hello, please find attached your account statement and transactions for "+period+".",
+		to,
+	)
+	msg.AddBufferAttachment("statement-"+month.Format("2006-01")+".pdf", pdf)
+	msg.AddBufferAttachment("transactions-"+month.Format("2006-01")+".csv", csv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, _, err := s.mg.Send(ctx, msg); err != nil {
+		s.logger.Error("failed to send statement email", "to", to, "err", err)
+		return err
+	}
+	return nil
+}
+
+// SendListingsExpired tells a seller which of their listings ran out and
+// were taken off the marketplace
+func (s *EmailService) SendListingsExpired(to string, skins []*models.Skin) error {
+	var body strings.Builder
+	body.WriteString("Hello, these listings of yours expired and are no longer on the marketplace:\n\n")
+	for _, sk := range skins {
+		fmt.Fprintf(&body, "- %s | %s (%s) at %.2f\n", sk.Gun, sk.Name, sk.Wear, sk.Price)
+	}
+	body.WriteString("\nYou can relist them in one click from your inventory.")
+
+	msg := mailgun.NewMessage("noreply@"+s.domain, "Your listings expired", body.String(), to)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, _, err := s.mg.Send(ctx, msg); err != nil {
+		s.logger.Error("failed to send listings expired email", "to", to, "count", len(skins), "err", err)
+		return err
+	}
+	return nil
+}
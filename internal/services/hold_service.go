@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/hold"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Number of holds released per database transaction
+const holdReleaseBatchSize = 100
+
+type HoldService struct {
+	holdRepo        hold.Repository
+	userRepo        user.Repository
+	transactionRepo transaction.Repository
+	publisher       realtime.Publisher
+	db              *sqlx.DB
+	logger          *slog.Logger
+}
+
+func NewHoldService(holdRepo hold.Repository, userRepo user.Repository, transactionRepo transaction.Repository, publisher realtime.Publisher, db *sqlx.DB, logger *slog.Logger) *HoldService {
+	return &HoldService{
+		holdRepo:        holdRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		publisher:       publisher,
+		db:              db,
+		logger:          logger,
+	}
+}
+
+// ReleaseMatured moves the proceeds of every hold past its release time from
+// the seller's pending to the available balance. It returns how many holds
+// were released.
+func (s *HoldService) ReleaseMatured(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		released, err := s.releaseBatch(ctx, time.Now())
+		total += released
+		if err != nil {
+			return total, err
+		}
+		if released < holdReleaseBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("released matured balance holds", "count", total)
+	}
+	return total, nil
+}
+
+func (s *HoldService) releaseBatch(ctx context.Context, now time.Time) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return 0, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	holds, err := s.holdRepo.GetMatured(ctx, tx, now, holdReleaseBatchSize)
+	if err != nil {
+		s.logger.Error("failed to get matured holds", "error", err)
+		return 0, apperrors.WrapInternal(err, "failed to get matured holds")
+	}
+	if len(holds) == 0 {
+		return 0, nil
+	}
+
+	accounts := make(map[uuid.UUID]*models.User)
+	for _, h := range holds {
+		u, ok := accounts[h.UserID]
+		if !ok {
+			u, err = s.userRepo.GetUserByIdForUpdate(ctx, tx, h.UserID)
+			if err != nil {
+				s.logger.Error("failed to get user for update", "error", err, "user_id", h.UserID)
+				return 0, apperrors.WrapInternal(err, "failed to get user for update")
+			}
+			accounts[h.UserID] = u
+		}
+
+		before := u.Balance
+		u.PendingBalance = roundCents(max(0, u.PendingBalance-h.Amount))
+		u.Balance = roundCents(u.Balance + h.Amount)
+
+		orderID := h.OrderID
+		description := "trade hold released"
+		if err := s.transactionRepo.Create(ctx, tx, &models.Transaction{
+			ID:            uuid.New(),
+			UserID:        u.ID,
+			Amount:        h.Amount,
+			Type:          models.HoldRelease,
+			BalanceBefore: before,
+			BalanceAfter:  u.Balance,
+			BalanceType:   models.BalanceAvailable,
+			OrderID:       &orderID,
+			Description:   &description,
+			CreatedAt:     now,
+		}); err != nil {
+			s.logger.Error("failed to create transaction record", "error", err, "hold_id", h.ID)
+			return 0, apperrors.WrapInternal(err, "failed to create transaction record")
+		}
+
+		h.Status = models.HoldReleased
+		h.ReleasedAt = &now
+		if err := s.holdRepo.Update(ctx, tx, h); err != nil {
+			s.logger.Error("failed to mark hold released", "error", err, "hold_id", h.ID)
+			return 0, apperrors.WrapInternal(err, "failed to mark hold released")
+		}
+	}
+
+	for _, u := range accounts {
+		if err := s.userRepo.UpdateBalances(ctx, tx, u); err != nil {
+			s.logger.Error("failed to update balances", "error", err, "user_id", u.ID)
+			return 0, apperrors.WrapInternal(err, "failed to update balances")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err)
+		return 0, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	for _, u := range accounts {
+		if err := s.publisher.PublishUser(ctx, u.ID, realtime.NewBalancesEvent(u.Balance, u.PendingBalance)); err != nil {
+			s.logger.Warn("failed to publish balance event", "user_id", u.ID, "err", err)
+		}
+	}
+	return len(holds), nil
+}
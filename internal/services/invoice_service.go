@@ -3,70 +3,803 @@ package services
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/pdfdoc"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/invoice"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/order"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/internal/storage"
+	"github.com/Uranury/RBK_finalProject/internal/ubl"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jmoiron/sqlx"
 	"github.com/jung-kurt/gofpdf"
-	"log/slog"
+)
+
+const (
+	// Refunds younger than this still have their own credit note task in flight
+	missingCreditNoteAge = 15 * time.Minute
+	// Number of refunds whose credit note is queued per sweep
+	missingCreditNoteBatchSize = 100
 )
 
 type InvoiceService struct {
-	orderRepo order.Repository
-	logger    *slog.Logger
+	invoiceRepo invoice.Repository
+	orderRepo   order.Repository
+	userRepo    user.Repository
+	blobs       storage.BlobStore
+	queue       TaskQueue
+	inspector   JobInspector
+	company     config.CompanyConfig
+	cfg         config.InvoiceConfig
+	db          *sqlx.DB
+	logger      *slog.Logger
+}
+
+func NewInvoiceService(invoiceRepo invoice.Repository, orderRepo order.Repository, userRepo user.Repository, blobs storage.BlobStore, queue TaskQueue, inspector JobInspector, company config.CompanyConfig, cfg config.InvoiceConfig, db *sqlx.DB, logger *slog.Logger) *InvoiceService {
+	return &InvoiceService{
+		invoiceRepo: invoiceRepo,
+		orderRepo:   orderRepo,
+		userRepo:    userRepo,
+		blobs:       blobs,
+		queue:       queue,
+		inspector:   inspector,
+		company:     company,
+		cfg:         cfg,
+		db:          db,
+		logger:      logger,
+	}
+}
+
+// invoiceNumber formats a number of the series, e.g. INV-2025-000042
+func invoiceNumber(series string, year, sequence int) string {
+	return fmt.Sprintf("%s-%d-%06d", series, year, sequence)
+}
+
+// invoiceable reports whether money moved for an order in this status
+func invoiceable(status models.OrderStatus) bool {
+	switch status {
+	case models.OrderStatusPaid, models.OrderStatusCompleted, models.OrderStatusDisputed, models.OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// Issue returns the order's invoice, numbering a new one the first time.
+// The order row is locked while numbering so an order never gets two numbers.
+func (s *InvoiceService) Issue(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	inv, err := s.invoiceRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get invoice", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get invoice")
+	}
+	if inv != nil {
+		return inv, nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	ord, err := s.orderRepo.GetOrderByIDForUpdate(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order for update", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order")
+	}
+	if ord == nil {
+		return nil, apperrors.NewNotFoundError("order not found")
+	}
+	if !invoiceable(ord.Status) {
+		return nil, apperrors.NewValidationError("order has not been paid")
+	}
+
+	// Another worker may have issued it while we waited for the lock
+	if inv, err = s.invoiceRepo.GetByOrderIDTx(ctx, tx, orderID); err != nil {
+		s.logger.Error("failed to get invoice", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get invoice")
+	}
+	if inv != nil {
+		return inv, nil
+	}
+
+	now := time.Now().UTC()
+	sequence, err := s.invoiceRepo.NextNumber(ctx, tx, models.InvoiceSeries, now.Year())
+	if err != nil {
+		s.logger.Error("failed to allocate invoice number", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to allocate invoice number")
+	}
+	inv = &models.Invoice{
+		ID:       uuid.New(),
+		OrderID:  orderID,
+		Number:   invoiceNumber(models.InvoiceSeries, now.Year(), sequence),
+		Year:     now.Year(),
+		Sequence: sequence,
+		IssuedAt: now,
+	}
+	if err := s.create(ctx, tx, inv, ord); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.logger.Info("invoice issued", "order_id", orderID, "number", inv.Number)
+	return inv, nil
 }
 
-func NewInvoiceService(orderRepo order.Repository, logger *slog.Logger) *InvoiceService {
-	return &InvoiceService{orderRepo: orderRepo, logger: logger}
+// create saves a new invoice with a copy of the buyer's details and the order's
+// lines, so it prints the same however they change later
+func (s *InvoiceService) create(ctx context.Context, tx *sqlx.Tx, inv *models.Invoice, ord *models.Order) error {
+	buyer, err := s.userRepo.FindByID(ctx, ord.UserID)
+	if err != nil {
+		s.logger.Error("failed to get buyer", "error", err, "user_id", ord.UserID)
+		return apperrors.WrapInternal(err, "failed to get buyer")
+	}
+	if buyer == nil {
+		return apperrors.ErrUserNotFound
+	}
+	inv.BuyerName = buyer.Name
+	inv.BuyerEmail = buyer.Email
+
+	if err := s.invoiceRepo.Create(ctx, tx, inv); err != nil {
+		s.logger.Error("failed to create invoice", "error", err, "order_id", ord.ID)
+		return apperrors.WrapInternal(err, "failed to create invoice")
+	}
+	if err := s.invoiceRepo.CreateLines(ctx, tx, inv.ID, ord.ID); err != nil {
+		s.logger.Error("failed to create invoice lines", "error", err, "order_id", ord.ID)
+		return apperrors.WrapInternal(err, "failed to create invoice lines")
+	}
+	return nil
 }
 
-func (s *InvoiceService) GenerateInvoicePDF(ctx context.Context, orderID, orderItemID uuid.UUID) ([]byte, error) {
+// Document gathers everything printed on the order's invoice, issuing the
+// invoice if needed
+func (s *InvoiceService) Document(ctx context.Context, orderID uuid.UUID) (*models.InvoiceDocument, error) {
+	inv, err := s.Issue(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
 	ord, err := s.orderRepo.GetOrderByID(ctx, orderID)
 	if err != nil {
-		s.logger.Warn("failed to retrieve order", "order_id", orderID, "err", err)
-		return nil, apperrors.NewInternalError("failed to get order by id", err)
+		s.logger.Error("failed to get order", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order")
 	}
 	if ord == nil {
 		return nil, apperrors.NewNotFoundError("order not found")
 	}
+	lines, err := s.invoiceRepo.ListLines(ctx, inv.ID)
+	if err != nil {
+		s.logger.Error("failed to list invoice lines", "error", err, "invoice_id", inv.ID)
+		return nil, apperrors.WrapInternal(err, "failed to list invoice lines")
+	}
+
+	doc := &models.InvoiceDocument{
+		Invoice:    inv,
+		Order:      ord,
+		BuyerName:  inv.BuyerName,
+		BuyerEmail: inv.BuyerEmail,
+		Lines:      lines,
+	}
+	for _, line := range lines {
+		doc.Subtotal += line.Price
+		doc.Fees += line.Fee
+		doc.Tax += line.Tax
+	}
+	doc.Subtotal = roundCents(doc.Subtotal)
+	doc.Fees = roundCents(doc.Fees)
+	doc.Tax = roundCents(doc.Tax)
+	return doc, nil
+}
+
+// GenerateInvoicePDF renders the order's invoice. Rendering the same invoice
+// again gives the same bytes.
+func (s *InvoiceService) GenerateInvoicePDF(ctx context.Context, orderID uuid.UUID) (*models.Invoice, []byte, error) {
+	doc, err := s.Document(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf, err := renderInvoice(doc, s.company)
+	if err != nil {
+		s.logger.Error("failed to render invoice", "error", err, "order_id", orderID)
+		return nil, nil, apperrors.NewInternalError("failed to generate PDF", err)
+	}
+	return doc.Invoice, pdf, nil
+}
+
+// invoiceFileKey is where an invoice's PDF is archived
+func invoiceFileKey(inv *models.Invoice) string {
+	return fmt.Sprintf("invoices/%d/%s.pdf", inv.Year, inv.Number)
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// InvoicePDF returns the order's archived invoice PDF. An invoice that was
+// never archived, or whose file is missing or damaged, is rendered again and
+// archived.
+func (s *InvoiceService) InvoicePDF(ctx context.Context, orderID uuid.UUID) (*models.Invoice, []byte, error) {
+	inv, err := s.Issue(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf, err := s.readArchived(ctx, inv.FileKey, inv.FileSHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pdf != nil {
+		return inv, pdf, nil
+	}
+
+	inv, pdf, err = s.GenerateInvoicePDF(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := invoiceFileKey(inv)
+	if err := s.archive(ctx, key, pdf, func(size int, sum string, storedAt time.Time) error {
+		if err := s.invoiceRepo.SetFile(ctx, inv.ID, key, size, sum, storedAt); err != nil {
+			return err
+		}
+		inv.FileKey, inv.FileSize, inv.FileSHA256, inv.StoredAt = &key, &size, &sum, &storedAt
+		return nil
+	}); err != nil {
+		// The invoice can still be served, the next request archives it
+		s.logger.Error("failed to archive invoice", "error", err, "order_id", orderID)
+	}
+	return inv, pdf, nil
+}
+
+// readArchived returns the archived document, or nil when it has to be
+// rendered again because it was never archived or its file is missing or
+// damaged
+func (s *InvoiceService) readArchived(ctx context.Context, key, sum *string) ([]byte, error) {
+	if key == nil {
+		return nil, nil
+	}
+	pdf, err := s.blobs.Get(ctx, *key)
+	switch {
+	case err == nil && sum != nil && checksum(pdf) == *sum:
+		return pdf, nil
+	case err == nil:
+		s.logger.Warn("archived document does not match its checksum, regenerating", "key", *key)
+	case errors.Is(err, storage.ErrNotFound):
+		s.logger.Warn("archived document is missing, regenerating", "key", *key)
+	default:
+		s.logger.Error("failed to read archived document", "error", err, "key", *key)
+		return nil, apperrors.WrapInternal(err, "failed to read archived document")
+	}
+	return nil, nil
+}
+
+// archive stores the document under key and records the file with record
+func (s *InvoiceService) archive(ctx context.Context, key string, pdf []byte, record func(size int, sum string, storedAt time.Time) error) error {
+	if err := s.blobs.Put(ctx, key, pdf); err != nil {
+		return err
+	}
+	return record(len(pdf), checksum(pdf), time.Now().UTC())
+}
+
+// InvoiceXML renders the order's invoice as UBL 2.1 XML
+func (s *InvoiceService) InvoiceXML(ctx context.Context, orderID uuid.UUID) (*models.Invoice, []byte, error) {
+	doc, err := s.Document(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := ubl.NewInvoice(doc, s.company, s.cfg.Currency).Marshal()
+	if err != nil {
+		s.logger.Error("failed to render UBL invoice", "error", err, "order_id", orderID)
+		return nil, nil, apperrors.NewInternalError("failed to generate XML", err)
+	}
+	return doc.Invoice, data, nil
+}
+
+// AttachXML reports whether invoice emails carry the UBL invoice
+func (s *InvoiceService) AttachXML() bool {
+	return s.cfg.AttachXML
+}
+
+// Download returns the invoice of one of the user's orders as PDF, or as UBL
+// XML when xml is set
+func (s *InvoiceService) Download(ctx context.Context, userID, orderID uuid.UUID, xml bool) (*models.Invoice, []byte, error) {
+	ord, err := s.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order", "error", err, "order_id", orderID)
+		return nil, nil, apperrors.WrapInternal(err, "failed to get order")
+	}
+	if ord == nil {
+		return nil, nil, apperrors.NewNotFoundError("order not found")
+	}
+	if ord.UserID != userID {
+		return nil, nil, apperrors.NewForbiddenError("you are not authorized to access this order")
+	}
+	if xml {
+		return s.InvoiceXML(ctx, orderID)
+	}
+	return s.InvoicePDF(ctx, orderID)
+}
+
+// MarkSent records that the invoice was emailed to the buyer
+func (s *InvoiceService) MarkSent(ctx context.Context, inv *models.Invoice) error {
+	if err := s.invoiceRepo.MarkSent(ctx, inv.ID, time.Now().UTC()); err != nil {
+		s.logger.Error("failed to mark invoice sent", "error", err, "invoice_id", inv.ID)
+		return apperrors.WrapInternal(err, "failed to mark invoice sent")
+	}
+	return nil
+}
+
+// Resend queues the order's invoice email to the buyer again, unless the
+// last one is still waiting to be sent
+func (s *InvoiceService) Resend(ctx context.Context, orderID uuid.UUID) error {
+	ord, err := s.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order", "error", err, "order_id", orderID)
+		return apperrors.WrapInternal(err, "failed to get order")
+	}
+	if ord == nil {
+		return apperrors.NewNotFoundError("order not found")
+	}
+	if !invoiceable(ord.Status) {
+		return apperrors.NewValidationError("order has not been paid")
+	}
+	buyer, err := s.userRepo.FindByID(ctx, ord.UserID)
+	if err != nil {
+		s.logger.Error("failed to get buyer", "error", err, "user_id", ord.UserID)
+		return apperrors.WrapInternal(err, "failed to get buyer")
+	}
+	if buyer == nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	task, err := jobs.NewSendInvoiceTask(orderID, buyer.Email)
+	if err != nil {
+		return apperrors.WrapInternal(err, "failed to create send-invoice task")
+	}
+	// The last send keeps the order's task ID for its retention, a finished
+	// one has to go before the new one is queued
+	queue, taskID := jobs.PolicyFor(jobs.SendInvoice).Queue, jobs.InvoiceTaskID(orderID)
+	info, err := s.inspector.GetTaskInfo(queue, taskID)
+	switch {
+	case errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, asynq.ErrQueueNotFound):
+	case err != nil:
+		s.logger.Error("failed to get send-invoice task", "error", err, "order_id", orderID)
+		return apperrors.WrapInternal(err, "failed to get send-invoice task")
+	case info.State == asynq.TaskStateArchived || info.State == asynq.TaskStateCompleted:
+		if err := s.inspector.DeleteTask(queue, info.ID); err != nil {
+			s.logger.Error("failed to delete send-invoice task", "error", err, "order_id", orderID)
+			return apperrors.WrapInternal(err, "failed to delete send-invoice task")
+		}
+	default:
+		return apperrors.NewAlreadyExistsError("the invoice email is already queued")
+	}
+
+	if _, err := s.queue.EnqueueContext(ctx, task, asynq.TaskID(taskID)); errors.Is(err, asynq.ErrTaskIDConflict) {
+		return apperrors.NewAlreadyExistsError("the invoice email is already queued")
+	} else if err != nil {
+		s.logger.Error("failed to enqueue send-invoice task", "error", err, "order_id", orderID)
+		return apperrors.WrapInternal(err, "failed to enqueue send-invoice task")
+	}
+	s.logger.Info("invoice resend queued", "order_id", orderID, "to", buyer.Email)
+	return nil
+}
+
+// creditNoteFileKey is where a credit note's PDF is archived
+func creditNoteFileKey(note *models.CreditNote) string {
+	return fmt.Sprintf("credit-notes/%d/%s.pdf", note.Year, note.Number)
+}
+
+// QueueMissingCreditNotes queues the credit note task of refunds that have no
+// credit note, e.g. because queuing it failed after the refund committed.
+// Refunds whose task is still queued, retrying or archived are left alone.
+// It returns how many tasks were queued.
+func (s *InvoiceService) QueueMissingCreditNotes(ctx context.Context) (int, error) {
+	refunds, err := s.invoiceRepo.ListRefundsWithoutCreditNote(ctx, time.Now().Add(-missingCreditNoteAge), missingCreditNoteBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list refunds without credit note", "error", err)
+		return 0, apperrors.WrapInternal(err, "failed to list refunds without credit note")
+	}
+
+	queued := 0
+	for _, refund := range refunds {
+		task, err := jobs.NewSendCreditNoteTask(*refund.OrderID, refund.ID, refund.Amount, stringValue(refund.Description))
+		if err != nil {
+			return queued, apperrors.WrapInternal(err, "failed to create credit note task")
+		}
+		_, err = s.queue.EnqueueContext(ctx, task, asynq.TaskID(jobs.CreditNoteTaskID(refund.ID)))
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			continue
+		}
+		if err != nil {
+			s.logger.Error("failed to enqueue credit note task", "error", err, "transaction_id", refund.ID)
+			return queued, apperrors.WrapInternal(err, "failed to enqueue credit note task")
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		s.logger.Warn("queued missing credit notes", "count", queued)
+	}
+	return queued, nil
+}
+
+// IssueCreditNote returns the credit note documenting a refund of amount to
+// the buyer, numbering a new one the first time. The order's invoice is
+// issued first if needed, since the credit note refers to it.
+func (s *InvoiceService) IssueCreditNote(ctx context.Context, orderID, transactionID uuid.UUID, amount float64, reason string) (*models.CreditNote, error) {
+	if amount <= 0 {
+		return nil, apperrors.NewValidationError("refund amount must be positive")
+	}
+	note, err := s.invoiceRepo.GetCreditNoteByTransaction(ctx, transactionID)
+	if err != nil {
+		s.logger.Error("failed to get credit note", "error", err, "transaction_id", transactionID)
+		return nil, apperrors.WrapInternal(err, "failed to get credit note")
+	}
+	if note != nil {
+		return note, nil
+	}
+
+	inv, err := s.Issue(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	// Serializes credit notes of the same order like invoices
+	if _, err := s.orderRepo.GetOrderByIDForUpdate(ctx, tx, orderID); err != nil {
+		s.logger.Error("failed to get order for update", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order")
+	}
+	if note, err = s.invoiceRepo.GetCreditNoteByTransactionTx(ctx, tx, transactionID); err != nil {
+		s.logger.Error("failed to get credit note", "error", err, "transaction_id", transactionID)
+		return nil, apperrors.WrapInternal(err, "failed to get credit note")
+	}
+	if note != nil {
+		return note, nil
+	}
 
-	ordItem, err := s.orderRepo.GetOrderItemByID(ctx, orderItemID)
+	now := time.Now().UTC()
+	sequence, err := s.invoiceRepo.NextNumber(ctx, tx, models.CreditNoteSeries, now.Year())
 	if err != nil {
-		s.logger.Warn("failed to retrieve order item", "order_item_id", orderItemID, "err", err)
-		return nil, apperrors.NewInternalError("failed to get order item by id", err)
+		s.logger.Error("failed to allocate credit note number", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to allocate credit note number")
+	}
+	note = &models.CreditNote{
+		ID:            uuid.New(),
+		InvoiceID:     inv.ID,
+		OrderID:       orderID,
+		TransactionID: transactionID,
+		Number:        invoiceNumber(models.CreditNoteSeries, now.Year(), sequence),
+		Year:          now.Year(),
+		Sequence:      sequence,
+		Amount:        -roundCents(amount),
+		IssuedAt:      now,
 	}
-	if ordItem == nil {
-		return nil, apperrors.NewNotFoundError("order item not found")
+	if reason != "" {
+		note.Reason = &reason
+	}
+	if err := s.invoiceRepo.CreateCreditNote(ctx, tx, note); err != nil {
+		s.logger.Error("failed to create credit note", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to create credit note")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
 	}
 
-	// --- PDF generation ---
-	pdf := gofpdf.New("P", "mm", "A4", "")
+	s.logger.Info("credit note issued", "order_id", orderID, "number", note.Number, "invoice", inv.Number, "amount", note.Amount)
+	return note, nil
+}
+
+// creditNoteLines negates the invoice lines when the note credits the whole
+// invoice. A partial refund has no lines.
+func creditNoteLines(note *models.CreditNote, invoice *models.InvoiceDocument) []*models.InvoiceLine {
+	if roundCents(-note.Amount) != roundCents(invoice.Order.TotalAmount) {
+		return nil
+	}
+	lines := make([]*models.InvoiceLine, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		credited := *line
+		credited.Price = -line.Price
+		credited.Fee = -line.Fee
+		credited.Tax = -line.Tax
+		lines = append(lines, &credited)
+	}
+	return lines
+}
+
+// CreditNotePDF returns the credit note's archived PDF, rendering and
+// archiving it the first time, along with what is printed on it
+func (s *InvoiceService) CreditNotePDF(ctx context.Context, note *models.CreditNote) (*models.CreditNoteDocument, []byte, error) {
+	invoice, err := s.Document(ctx, note.OrderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc := &models.CreditNoteDocument{
+		CreditNote: note,
+		Invoice:    invoice.Invoice,
+		Order:      invoice.Order,
+		BuyerName:  invoice.BuyerName,
+		BuyerEmail: invoice.BuyerEmail,
+		Lines:      creditNoteLines(note, invoice),
+	}
+
+	pdf, err := s.readArchived(ctx, note.FileKey, note.FileSHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pdf != nil {
+		return doc, pdf, nil
+	}
+
+	if pdf, err = renderCreditNote(doc, s.company); err != nil {
+		s.logger.Error("failed to render credit note", "error", err, "credit_note_id", note.ID)
+		return nil, nil, apperrors.NewInternalError("failed to generate PDF", err)
+	}
+	key := creditNoteFileKey(note)
+	if err := s.archive(ctx, key, pdf, func(size int, sum string, storedAt time.Time) error {
+		if err := s.invoiceRepo.SetCreditNoteFile(ctx, note.ID, key, size, sum, storedAt); err != nil {
+			return err
+		}
+		note.FileKey, note.FileSize, note.FileSHA256, note.StoredAt = &key, &size, &sum, &storedAt
+		return nil
+	}); err != nil {
+		s.logger.Error("failed to archive credit note", "error", err, "credit_note_id", note.ID)
+	}
+	return doc, pdf, nil
+}
+
+// MarkCreditNoteSent records that the credit note was emailed to the buyer
+func (s *InvoiceService) MarkCreditNoteSent(ctx context.Context, note *models.CreditNote) error {
+	if err := s.invoiceRepo.MarkCreditNoteSent(ctx, note.ID, time.Now().UTC()); err != nil {
+		s.logger.Error("failed to mark credit note sent", "error", err, "credit_note_id", note.ID)
+		return apperrors.WrapInternal(err, "failed to mark credit note sent")
+	}
+	return nil
+}
+
+// newBrandedPDF starts a document with the company block on the left and
+// the title and references on the right. It is dated by the document rather
+// than the clock, so a regenerated document is identical to the archived one.
+func newBrandedPDF(company config.CompanyConfig, issuedAt time.Time, title string, refs []string) *gofpdf.Fpdf {
+	pdf := pdfdoc.New()
+	pdf.SetCreationDate(issuedAt)
+	pdf.SetModificationDate(issuedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetAutoPageBreak(true, 15)
 	pdf.AddPage()
 
-	pdf.SetFont("Arial", "B", 18)
-	pdf.Cell(40, 10, "Invoice")
-	pdf.Ln(20)
-
-	pdf.SetFont("Arial", "", 12)
-	pdf.MultiCell(0, 10,
-		fmt.Sprintf("Order ID: %s\nUser ID: %s\nTotal Amount: %.2f\nStatus: %s\nCreated At: %s\n\nOrder Item ID: %s\nSkin ID: %s\nPrice: %.2f\nCreated At: %s",
-			ord.ID.String(),
-			ord.UserID.String(),
-			ord.TotalAmount,
-			string(ord.Status),
-			ord.CreatedAt.Format("2006-01-02 15:04:05"),
-			ordItem.ID.String(),
-			ordItem.SkinID.String(),
-			ordItem.Price,
-			ordItem.CreatedAt.Format("2006-01-02 15:04:05"),
-		),
-		"", "", false,
-	)
+	if company.LogoPath != "" {
+		pdf.ImageOptions(company.LogoPath, 15, 12, 0, 16, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		pdf.SetY(30)
+	}
+	top := pdf.GetY()
+	pdf.SetFont(pdfdoc.Font, "B", 14)
+	pdf.CellFormat(100, 7, company.Name, "", 1, "", false, 0, "")
+	pdf.SetFont(pdfdoc.Font, "", 9)
+	lines := []string{company.Address, company.Email, company.Website}
+	if company.VATID != "" {
+		lines = append(lines, "VAT ID "+company.VATID)
+	}
+	for _, line := range lines {
+		if line != "" {
+			pdf.MultiCell(100, 5, line, "", "", false)
+		}
+	}
+	companyBottom := pdf.GetY()
 
+	pdf.SetXY(120, top)
+	pdf.SetFont(pdfdoc.Font, "B", 20)
+	pdf.CellFormat(75, 9, title, "", 2, "R", false, 0, "")
+	pdf.SetFont(pdfdoc.Font, "", 10)
+	for _, ref := range refs {
+		pdf.CellFormat(75, 6, ref, "", 2, "R", false, 0, "")
+	}
+	if pdf.GetY() < companyBottom {
+		pdf.SetY(companyBottom)
+	}
+	pdf.Ln(8)
+	return pdf
+}
+
+// writeParties prints the buyer and the sellers of the lines
+func writeParties(pdf *gofpdf.Fpdf, buyerName, buyerEmail string, buyerVATID *string, sellers string) {
+	pdf.SetX(15)
+	pdf.SetFont(pdfdoc.Font, "B", 10)
+	pdf.CellFormat(90, 6, "Billed to", "", 0, "", false, 0, "")
+	pdf.CellFormat(90, 6, "Sold by", "", 1, "", false, 0, "")
+	pdf.SetFont(pdfdoc.Font, "", 10)
+	pdf.CellFormat(90, 5, buyerName, "", 0, "", false, 0, "")
+	pdf.CellFormat(90, 5, sellers, "", 1, "", false, 0, "")
+	pdf.CellFormat(90, 5, buyerEmail, "", 1, "", false, 0, "")
+	if buyerVATID != nil {
+		pdf.CellFormat(90, 5, "VAT ID "+*buyerVATID, "", 1, "", false, 0, "")
+	}
+	pdf.Ln(8)
+}
+
+// writeLineItems prints one row per order item with its skin details
+func writeLineItems(pdf *gofpdf.Fpdf, lines []*models.InvoiceLine, marketplace string) {
+	widths := []float64{8, 62, 32, 28, 18, 32}
+	pdf.SetFont(pdfdoc.Font, "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	for i, title := range []string{"#", "Item", "Wear", "Seller", "Float", "Price"} {
+		align := "L"
+		if i >= 4 {
+			align = "R"
+		}
+		pdf.CellFormat(widths[i], 7, title, "1", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(pdfdoc.Font, "", 9)
+	for i, line := range lines {
+		seller := marketplace
+		if line.SellerName != nil {
+			seller = *line.SellerName
+		}
+		pdf.CellFormat(widths[0], 6, fmt.Sprintf("%d", i+1), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, fmt.Sprintf("%s | %s", line.Gun, line.SkinName), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, string(line.Wear), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, seller, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], 6, fmt.Sprintf("%.4f", line.Condition), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, fmt.Sprintf("%.2f", line.Price), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+func writeTotal(pdf *gofpdf.Fpdf, label string, amount float64, style string) {
+	pdf.SetX(110)
+	pdf.SetFont(pdfdoc.Font, style, 10)
+	pdf.CellFormat(55, 6, label, "", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 6, fmt.Sprintf("%.2f", amount), "", 1, "R", false, 0, "")
+}
+
+// writeTaxTotal prints the order's VAT line. Orders outside the tax rules
+// have none.
+func writeTaxTotal(pdf *gofpdf.Fpdf, ord *models.Order, amount float64) {
+	switch {
+	case ord.TaxCountry == nil:
+	case ord.ReverseCharge:
+		writeTotal(pdf, "VAT (reverse charge)", 0, "")
+	default:
+		writeTotal(pdf, fmt.Sprintf("VAT %s%% (%s)", strconv.FormatFloat(ord.TaxRate, 'f', -1, 64), *ord.TaxCountry), amount, "")
+	}
+}
+
+// reverseChargeNote is printed on documents of reverse-charged orders
+func reverseChargeNote(ord *models.Order) string {
+	if !ord.ReverseCharge || ord.BuyerVATID == nil {
+		return ""
+	}
+	return fmt.Sprintf("Reverse charge: VAT is to be accounted for by the recipient, VAT ID %s.", *ord.BuyerVATID)
+}
+
+func outputPDF(pdf *gofpdf.Fpdf) ([]byte, error) {
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
-		return nil, apperrors.NewInternalError("failed to generate PDF", err)
+		return nil, err
 	}
-
 	return buf.Bytes(), nil
 }
+
+func renderInvoice(doc *models.InvoiceDocument, company config.CompanyConfig) ([]byte, error) {
+	pdf := newBrandedPDF(company, doc.Invoice.IssuedAt, "INVOICE", []string{
+		"No. " + doc.Invoice.Number,
+		"Issued " + doc.Invoice.IssuedAt.Format("2006-01-02"),
+		"Order date " + doc.Order.CreatedAt.UTC().Format("2006-01-02"),
+		"Order " + doc.Order.ID.String(),
+	})
+	writeParties(pdf, doc.BuyerName, doc.BuyerEmail, doc.Order.BuyerVATID, sellerNames(doc.Lines, company.Name))
+	writeLineItems(pdf, doc.Lines, company.Name)
+
+	writeTotal(pdf, "Subtotal", doc.Subtotal, "")
+	writeTaxTotal(pdf, doc.Order, doc.Tax)
+	writeTotal(pdf, "Total paid", doc.Order.TotalAmount, "B")
+	pdf.Ln(4)
+	pdf.SetFont(pdfdoc.Font, "", 8)
+	if note := reverseChargeNote(doc.Order); note != "" {
+		pdf.MultiCell(0, 4, note, "", "", false)
+	}
+	if doc.Fees > 0 {
+		pdf.MultiCell(0, 4, fmt.Sprintf(
+			"Marketplace fees of %.2f are paid by the sellers out of the item prices; sellers receive %.2f.",
+			doc.Fees, doc.Subtotal-doc.Fees), "", "", false)
+	}
+	return outputPDF(pdf)
+}
+
+func renderCreditNote(doc *models.CreditNoteDocument, company config.CompanyConfig) ([]byte, error) {
+	note := doc.CreditNote
+	pdf := newBrandedPDF(company, note.IssuedAt, "CREDIT NOTE", []string{
+		"No. " + note.Number,
+		"Issued " + note.IssuedAt.Format("2006-01-02"),
+		"For invoice " + doc.Invoice.Number,
+		"Order " + doc.Order.ID.String(),
+	})
+	writeParties(pdf, doc.BuyerName, doc.BuyerEmail, doc.Order.BuyerVATID, sellerNames(doc.Lines, company.Name))
+
+	if len(doc.Lines) > 0 {
+		writeLineItems(pdf, doc.Lines, company.Name)
+		var subtotal, tax float64
+		for _, line := range doc.Lines {
+			subtotal += line.Price
+			tax += line.Tax
+		}
+		writeTotal(pdf, "Subtotal", roundCents(subtotal), "")
+		writeTaxTotal(pdf, doc.Order, roundCents(tax))
+	} else {
+		// A partial refund is not tied to single items
+		pdf.SetFont(pdfdoc.Font, "B", 9)
+		pdf.SetFillColor(235, 235, 235)
+		pdf.CellFormat(148, 7, "Description", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(32, 7, "Amount", "1", 1, "R", true, 0, "")
+		pdf.SetFont(pdfdoc.Font, "", 9)
+		pdf.CellFormat(148, 6, "Partial refund of invoice "+doc.Invoice.Number, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(32, 6, fmt.Sprintf("%.2f", note.Amount), "1", 1, "R", false, 0, "")
+		pdf.Ln(4)
+	}
+
+	writeTotal(pdf, "Total credited", note.Amount, "B")
+	if note.Reason != nil && *note.Reason != "" {
+		pdf.Ln(4)
+		pdf.SetFont(pdfdoc.Font, "", 9)
+		pdf.MultiCell(0, 5, "Reason: "+*note.Reason, "", "", false)
+	}
+	pdf.Ln(2)
+	pdf.SetFont(pdfdoc.Font, "", 8)
+	pdf.MultiCell(0, 4, fmt.Sprintf(
+		"This credit note reduces invoice %s by %.2f. The amount has been credited to your account balance.",
+		doc.Invoice.Number, -note.Amount), "", "", false)
+	return outputPDF(pdf)
+}
+
+// sellerNames lists the distinct sellers of the lines; items without a
+// seller were sold by the marketplace itself
+func sellerNames(lines []*models.InvoiceLine, marketplace string) string {
+	seen := map[string]bool{}
+	var names []string
+	for _, line := range lines {
+		name := marketplace
+		if line.SellerName != nil {
+			name = *line.SellerName
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/storage"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockInvoiceRepository is a mock implementation of invoice.Repository
+type MockInvoiceRepository struct {
+	mock.Mock
+}
+
+func (m *MockInvoiceRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) GetByOrderIDTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*models.Invoice, error) {
+	args := m.Called(ctx, tx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) NextNumber(ctx context.Context, tx *sqlx.Tx, series string, year int) (int, error) {
+	args := m.Called(ctx, tx, series, year)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) Create(ctx context.Context, tx *sqlx.Tx, invoice *models.Invoice) error {
+	args := m.Called(ctx, tx, invoice)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) SetFile(ctx context.Context, id uuid.UUID, key string, size int, sha256 string, storedAt time.Time) error {
+	args := m.Called(ctx, id, key, size, sha256, storedAt)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	args := m.Called(ctx, id, sentAt)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) GetCreditNoteByTransaction(ctx context.Context, transactionID uuid.UUID) (*models.CreditNote, error) {
+	args := m.Called(ctx, transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreditNote), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) GetCreditNoteByTransactionTx(ctx context.Context, tx *sqlx.Tx, transactionID uuid.UUID) (*models.CreditNote, error) {
+	args := m.Called(ctx, tx, transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreditNote), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) CreateCreditNote(ctx context.Context, tx *sqlx.Tx, note *models.CreditNote) error {
+	args := m.Called(ctx, tx, note)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) SetCreditNoteFile(ctx context.Context, id uuid.UUID, key string, size int, sha256 string, storedAt time.Time) error {
+	args := m.Called(ctx, id, key, size, sha256, storedAt)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) MarkCreditNoteSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	args := m.Called(ctx, id, sentAt)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) ListRefundsWithoutCreditNote(ctx context.Context, before time.Time, limit int) ([]*models.Transaction, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Transaction), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) CreateLines(ctx context.Context, tx *sqlx.Tx, invoiceID, orderID uuid.UUID) error {
+	args := m.Called(ctx, tx, invoiceID, orderID)
+	return args.Error(0)
+}
+
+func (m *MockInvoiceRepository) ListLines(ctx context.Context, invoiceID uuid.UUID) ([]*models.InvoiceLine, error) {
+	args := m.Called(ctx, invoiceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.InvoiceLine), args.Error(1)
+}
+
+func TestInvoiceNumber(t *testing.T) {
+	assert.Equal(t, "INV-2025-000042", invoiceNumber(models.InvoiceSeries, 2025, 42))
+	assert.Equal(t, "INV-2026-1234567", invoiceNumber(models.InvoiceSeries, 2026, 1234567))
+	assert.Equal(t, "CN-2025-000003", invoiceNumber(models.CreditNoteSeries, 2025, 3))
+}
+
+func TestInvoiceService_Issue_ExistingInvoice(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInvoiceService(repo, nil, nil, nil, nil, nil, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+	ctx := context.Background()
+	orderID := uuid.New()
+	existing := &models.Invoice{ID: uuid.New(), OrderID: orderID, Number: "INV-2025-000007"}
+
+	repo.On("GetByOrderID", ctx, orderID).Return(existing, nil)
+
+	inv, err := svc.Issue(ctx, orderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, inv)
+	repo.AssertNotCalled(t, "NextNumber", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInvoiceService_Create_SnapshotsBuyerAndLines(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	users := new(MockUserRepository)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInvoiceService(repo, nil, users, nil, nil, nil, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+	ctx := context.Background()
+	ord := &models.Order{ID: uuid.New(), UserID: uuid.New()}
+	inv := &models.Invoice{ID: uuid.New(), OrderID: ord.ID, Number: "INV-2025-000008"}
+
+	users.On("FindByID", ctx, ord.UserID).Return(&models.User{ID: ord.UserID, Name: "Alice", Email: "alice@example.com"}, nil)
+	repo.On("Create", ctx, (*sqlx.Tx)(nil), inv).Return(nil)
+	repo.On("CreateLines", ctx, (*sqlx.Tx)(nil), inv.ID, ord.ID).Return(nil)
+
+	err := svc.create(ctx, nil, inv, ord)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", inv.BuyerName)
+	assert.Equal(t, "alice@example.com", inv.BuyerEmail)
+	repo.AssertExpectations(t)
+}
+
+func TestInvoiceService_QueueMissingCreditNotes(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	queue := new(MockTaskQueue)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInvoiceService(repo, nil, nil, nil, queue, nil, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+	ctx := context.Background()
+	orderID := uuid.New()
+	description := "refund: wrong item"
+	lost := &models.Transaction{ID: uuid.New(), OrderID: &orderID, Amount: 50, Type: models.Refund, Description: &description}
+	pending := &models.Transaction{ID: uuid.New(), OrderID: &orderID, Amount: 20, Type: models.Refund}
+
+	repo.On("ListRefundsWithoutCreditNote", ctx, mock.Anything, missingCreditNoteBatchSize).Return([]*models.Transaction{lost, pending}, nil)
+	queue.On("EnqueueContext", ctx, mock.MatchedBy(func(task *asynq.Task) bool {
+		var p jobs.SendCreditNotePayload
+		return json.Unmarshal(task.Payload(), &p) == nil && p.TransactionID == lost.ID
+	}), mock.Anything).Return(&asynq.TaskInfo{}, nil)
+	queue.On("EnqueueContext", ctx, mock.MatchedBy(func(task *asynq.Task) bool {
+		var p jobs.SendCreditNotePayload
+		return json.Unmarshal(task.Payload(), &p) == nil && p.TransactionID == pending.ID
+	}), mock.Anything).Return(nil, asynq.ErrTaskIDConflict)
+
+	queued, err := svc.QueueMissingCreditNotes(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	queue.AssertNumberOfCalls(t, "EnqueueContext", 2)
+}
+
+func TestInvoiceService_Resend(t *testing.T) {
+	ord := &models.Order{ID: uuid.New(), UserID: uuid.New(), Status: models.OrderStatusCompleted}
+	queueName, taskID := jobs.PolicyFor(jobs.SendInvoice).Queue, jobs.InvoiceTaskID(ord.ID)
+
+	newService := func() (*InvoiceService, *MockTaskQueue, *MockJobInspector) {
+		orders := new(MockOrderRepository)
+		users := new(MockUserRepository)
+		queue := new(MockTaskQueue)
+		inspector := new(MockJobInspector)
+		orders.On("GetOrderByID", mock.Anything, ord.ID).Return(ord, nil)
+		users.On("FindByID", mock.Anything, ord.UserID).Return(&models.User{ID: ord.UserID, Email: "alice@example.com"}, nil)
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		svc := NewInvoiceService(nil, orders, users, nil, queue, inspector, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+		return svc, queue, inspector
+	}
+
+	t.Run("a sent invoice makes room for the new email", func(t *testing.T) {
+		svc, queue, inspector := newService()
+		inspector.On("GetTaskInfo", queueName, taskID).Return(&asynq.TaskInfo{ID: taskID, State: asynq.TaskStateCompleted}, nil)
+		inspector.On("DeleteTask", queueName, taskID).Return(nil)
+		queue.On("EnqueueContext", mock.Anything, mock.Anything, mock.Anything).Return(&asynq.TaskInfo{ID: taskID}, nil)
+
+		assert.NoError(t, svc.Resend(context.Background(), ord.ID))
+		inspector.AssertExpectations(t)
+		queue.AssertExpectations(t)
+	})
+
+	t.Run("an email still queued is not queued twice", func(t *testing.T) {
+		svc, queue, inspector := newService()
+		inspector.On("GetTaskInfo", queueName, taskID).Return(&asynq.TaskInfo{ID: taskID, State: asynq.TaskStateRetry}, nil)
+
+		err := svc.Resend(context.Background(), ord.ID)
+		assertAppErrorCode(t, err, apperrors.CodeAlreadyExists)
+		inspector.AssertNotCalled(t, "DeleteTask", mock.Anything, mock.Anything)
+		queue.AssertNotCalled(t, "EnqueueContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestInvoiceService_InvoicePDF_Archived(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInvoiceService(repo, nil, nil, blobs, nil, nil, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+	ctx := context.Background()
+	orderID := uuid.New()
+
+	pdf := []byte("%PDF-1.3 archived")
+	key, sum := "invoices/2025/INV-2025-000007.pdf", checksum(pdf)
+	assert.NoError(t, blobs.Put(ctx, key, pdf))
+	existing := &models.Invoice{ID: uuid.New(), OrderID: orderID, Number: "INV-2025-000007", Year: 2025, FileKey: &key, FileSHA256: &sum}
+	repo.On("GetByOrderID", ctx, orderID).Return(existing, nil)
+
+	inv, got, err := svc.InvoicePDF(ctx, orderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, inv)
+	assert.Equal(t, pdf, got)
+	repo.AssertNotCalled(t, "SetFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInvoiceFileKey(t *testing.T) {
+	inv := &models.Invoice{Number: "INV-2025-000042", Year: 2025}
+	assert.Equal(t, "invoices/2025/INV-2025-000042.pdf", invoiceFileKey(inv))
+}
+
+func testInvoiceDocument() *models.InvoiceDocument {
+	sellerName := "Иван Петров"
+	return &models.InvoiceDocument{
+		Invoice: &models.Invoice{Number: "INV-2025-000001", IssuedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+		Order: &models.Order{
+			ID:          uuid.MustParse("6f1c2a3e-8d4b-4a7e-9c11-2b5d7e9f0a13"),
+			TotalAmount: 150,
+			Status:      models.OrderStatusCompleted,
+			CreatedAt:   time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+		},
+		BuyerName:  "Zoë Ağaoğlu",
+		BuyerEmail: "buyer@example.com",
+		Lines: []*models.InvoiceLine{
+			{SkinName: "Asiimov", Gun: "AWP", Wear: "Field-Tested", Condition: 0.25, SellerName: &sellerName, Price: 100, Fee: 5},
+			{SkinName: "Redline", Gun: "AK-47", Wear: "Minimal Wear", Condition: 0.1, Price: 50},
+		},
+		Subtotal: 150,
+		Fees:     5,
+	}
+}
+
+func TestRenderInvoice(t *testing.T) {
+	company := config.CompanyConfig{Name: "Test Market", Address: "1 Main St"}
+
+	pdf, err := renderInvoice(testInvoiceDocument(), company)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+
+	// A regenerated invoice is byte for byte the archived one
+	again, err := renderInvoice(testInvoiceDocument(), company)
+	assert.NoError(t, err)
+	assert.Equal(t, pdf, again)
+}
+
+func TestRenderInvoice_VAT(t *testing.T) {
+	company := config.CompanyConfig{Name: "Test Market", VATID: "DE999999999"}
+	country, vatID := "FR", "FR12345678901"
+
+	taxed := testInvoiceDocument()
+	taxed.Order.TaxCountry, taxed.Order.TaxRate, taxed.Order.TaxAmount = &country, 20, 30
+	taxed.Order.TotalAmount = 180
+	taxed.Tax = 30
+
+	reverseCharged := testInvoiceDocument()
+	reverseCharged.Order.TaxCountry, reverseCharged.Order.ReverseCharge, reverseCharged.Order.BuyerVATID = &country, true, &vatID
+
+	for _, doc := range []*models.InvoiceDocument{taxed, reverseCharged} {
+		pdf, err := renderInvoice(doc, company)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+
+		again, err := renderInvoice(doc, company)
+		assert.NoError(t, err)
+		assert.Equal(t, pdf, again)
+	}
+	assert.Contains(t, reverseChargeNote(reverseCharged.Order), "FR12345678901")
+	assert.Empty(t, reverseChargeNote(taxed.Order))
+}
+
+func TestInvoiceService_IssueCreditNote_ExistingNote(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInvoiceService(repo, nil, nil, nil, nil, nil, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+	ctx := context.Background()
+	orderID, transactionID := uuid.New(), uuid.New()
+	existing := &models.CreditNote{ID: uuid.New(), OrderID: orderID, TransactionID: transactionID, Number: "CN-2025-000001", Amount: -150}
+
+	repo.On("GetCreditNoteByTransaction", ctx, transactionID).Return(existing, nil)
+
+	note, err := svc.IssueCreditNote(ctx, orderID, transactionID, 150, "dispute: item not received")
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, note)
+	repo.AssertNotCalled(t, "GetByOrderID", mock.Anything, mock.Anything)
+}
+
+func TestInvoiceService_IssueCreditNote_InvalidAmount(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInvoiceService(repo, nil, nil, nil, nil, nil, config.CompanyConfig{}, config.InvoiceConfig{}, nil, logger)
+
+	_, err := svc.IssueCreditNote(context.Background(), uuid.New(), uuid.New(), 0, "")
+
+	assertValidationError(t, err)
+}
+
+func TestCreditNoteLines(t *testing.T) {
+	invoice := testInvoiceDocument()
+
+	invoice.Lines[0].Tax = 20
+	full := creditNoteLines(&models.CreditNote{Amount: -150}, invoice)
+	if assert.Len(t, full, 2) {
+		assert.Equal(t, -100.0, full[0].Price)
+		assert.Equal(t, -5.0, full[0].Fee)
+		assert.Equal(t, -20.0, full[0].Tax)
+		assert.Equal(t, -50.0, full[1].Price)
+	}
+	// The invoice itself is left alone
+	assert.Equal(t, 100.0, invoice.Lines[0].Price)
+
+	assert.Empty(t, creditNoteLines(&models.CreditNote{Amount: -20}, invoice))
+}
+
+func TestRenderCreditNote(t *testing.T) {
+	invoice := testInvoiceDocument()
+	reason := "dispute: skin was not as described"
+	company := config.CompanyConfig{Name: "Test Market"}
+
+	for _, amount := range []float64{-150, -20} {
+		note := &models.CreditNote{
+			Number:   "CN-2025-000001",
+			Amount:   amount,
+			Reason:   &reason,
+			IssuedAt: time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC),
+		}
+		doc := &models.CreditNoteDocument{
+			CreditNote: note,
+			Invoice:    invoice.Invoice,
+			Order:      invoice.Order,
+			BuyerName:  invoice.BuyerName,
+			BuyerEmail: invoice.BuyerEmail,
+			Lines:      creditNoteLines(note, invoice),
+		}
+
+		pdf, err := renderCreditNote(doc, company)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+
+		again, err := renderCreditNote(doc, company)
+		assert.NoError(t, err)
+		assert.Equal(t, pdf, again)
+	}
+}
+
+func TestSellerNames(t *testing.T) {
+	alice, bob := "Alice", "Bob"
+	lines := []*models.InvoiceLine{{SellerName: &alice}, {SellerName: &bob}, {SellerName: &alice}, {}}
+
+	assert.Equal(t, "Alice, Bob, Market", sellerNames(lines, "Market"))
+}
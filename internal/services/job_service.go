@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	defaultJobPageSize = 20
+	maxJobPageSize     = 100
+)
+
+// JobInspector reads and manages the background job queues, it is
+// implemented by *asynq.Inspector
+type JobInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+	GetTaskInfo(queue, id string) (*asynq.TaskInfo, error)
+	ListPendingTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListActiveTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListScheduledTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListRetryTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListArchivedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListCompletedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	RunTask(queue, id string) error
+	DeleteTask(queue, id string) error
+	PauseQueue(queue string) error
+	UnpauseQueue(queue string) error
+}
+
+type JobService struct {
+	inspector       JobInspector
+	transactionRepo transaction.Repository
+	logger          *slog.Logger
+}
+
+func NewJobService(inspector JobInspector, transactionRepo transaction.Repository, logger *slog.Logger) *JobService {
+	return &JobService{inspector: inspector, transactionRepo: transactionRepo, logger: logger}
+}
+
+// ListQueues returns the size of every queue that has ever had a job
+func (s *JobService) ListQueues(ctx context.Context) ([]*models.QueueStats, error) {
+	queues, err := s.inspector.Queues()
+	if err != nil {
+		s.logger.Error("failed to list queues", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list queues")
+	}
+	slices.Sort(queues)
+
+	stats := make([]*models.QueueStats, 0, len(queues))
+	for _, queue := range queues {
+		info, err := s.inspector.GetQueueInfo(queue)
+		if err != nil {
+			s.logger.Error("failed to get queue info", "queue", queue, "error", err)
+			return nil, apperrors.WrapInternal(err, "failed to get queue info")
+		}
+		stats = append(stats, queueStats(info))
+	}
+	return stats, nil
+}
+
+// ListTasks returns a page of a queue's jobs in the given state, page is
+// 1-based
+func (s *JobService) ListTasks(ctx context.Context, queue string, state models.JobState, page, pageSize int) ([]*models.JobInfo, error) {
+	if !state.Valid() {
+		return nil, apperrors.NewValidationError("invalid state")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultJobPageSize
+	}
+	if pageSize > maxJobPageSize {
+		pageSize = maxJobPageSize
+	}
+
+	tasks, err := s.list(queue, state, page, pageSize)
+	if err != nil {
+		return nil, s.taskError(err, "failed to list tasks", queue)
+	}
+
+	jobs := make([]*models.JobInfo, 0, len(tasks))
+	for _, t := range tasks {
+		jobs = append(jobs, jobInfo(t))
+	}
+	return jobs, nil
+}
+
+// RetryTask runs an archived job again right away
+func (s *JobService) RetryTask(ctx context.Context, queue, id string) error {
+	if err := s.requireArchived(queue, id); err != nil {
+		return err
+	}
+	if err := s.inspector.RunTask(queue, id); err != nil {
+		return s.taskError(err, "failed to retry task", queue)
+	}
+	s.logger.Info("archived task retried", "queue", queue, "task_id", id)
+	return nil
+}
+
+// DeleteTask drops an archived job for good
+func (s *JobService) DeleteTask(ctx context.Context, queue, id string) error {
+	if err := s.requireArchived(queue, id); err != nil {
+		return err
+	}
+	if err := s.inspector.DeleteTask(queue, id); err != nil {
+		return s.taskError(err, "failed to delete task", queue)
+	}
+	s.logger.Info("archived task deleted", "queue", queue, "task_id", id)
+	return nil
+}
+
+// PauseQueue stops the workers from picking up the queue's jobs; jobs keep
+// being queued meanwhile
+func (s *JobService) PauseQueue(ctx context.Context, queue string) error {
+	info, err := s.queueInfo(queue)
+	if err != nil {
+		return err
+	}
+	if info.Paused {
+		return apperrors.NewValidationError("queue is already paused")
+	}
+	if err := s.inspector.PauseQueue(queue); err != nil {
+		s.logger.Error("failed to pause queue", "queue", queue, "error", err)
+		return apperrors.WrapInternal(err, "failed to pause queue")
+	}
+	s.logger.Info("queue paused", "queue", queue)
+	return nil
+}
+
+// ResumeQueue lets the workers process a paused queue again
+func (s *JobService) ResumeQueue(ctx context.Context, queue string) error {
+	info, err := s.queueInfo(queue)
+	if err != nil {
+		return err
+	}
+	if !info.Paused {
+		return apperrors.NewValidationError("queue is not paused")
+	}
+	if err := s.inspector.UnpauseQueue(queue); err != nil {
+		s.logger.Error("failed to resume queue", "queue", queue, "error", err)
+		return apperrors.WrapInternal(err, "failed to resume queue")
+	}
+	s.logger.Info("queue resumed", "queue", queue)
+	return nil
+}
+
+// ListOrderJobs returns the jobs of an order still known to the queues: its
+// invoice, market data and the credit note of each refund. They are queued
+// under IDs derived from the order and its refunds, so each one is looked up
+// directly. Completed jobs are kept for the retention of their policy.
+func (s *JobService) ListOrderJobs(ctx context.Context, orderID uuid.UUID) ([]*models.JobInfo, error) {
+	refunds, err := s.transactionRepo.ListOrderRefunds(ctx, orderID)
+	if err != nil {
+		s.logger.Error("failed to list order refunds", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to list order refunds")
+	}
+
+	type orderJob struct{ taskType, id string }
+	candidates := []orderJob{
+		{jobs.SendInvoice, jobs.InvoiceTaskID(orderID)},
+		{jobs.RecordMarketSales, jobs.MarketSalesTaskID(orderID)},
+	}
+	for _, refund := range refunds {
+		candidates = append(candidates, orderJob{jobs.SendCreditNote, jobs.CreditNoteTaskID(refund.ID)})
+	}
+
+	found := []*models.JobInfo{}
+	for _, c := range candidates {
+		queue := jobs.PolicyFor(c.taskType).Queue
+		info, err := s.inspector.GetTaskInfo(queue, c.id)
+		// Never queued, past its retention or its queue never had a task
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			s.logger.Error("failed to get task", "queue", queue, "id", c.id, "error", err)
+			return nil, apperrors.WrapInternal(err, "failed to get task")
+		}
+		found = append(found, jobInfo(info))
+	}
+	return found, nil
+}
+
+func (s *JobService) list(queue string, state models.JobState, page, pageSize int) ([]*asynq.TaskInfo, error) {
+	opts := []asynq.ListOption{asynq.Page(page), asynq.PageSize(pageSize)}
+	switch state {
+	case models.JobPending:
+		return s.inspector.ListPendingTasks(queue, opts...)
+	case models.JobActive:
+		return s.inspector.ListActiveTasks(queue, opts...)
+	case models.JobScheduled:
+		return s.inspector.ListScheduledTasks(queue, opts...)
+	case models.JobRetry:
+		return s.inspector.ListRetryTasks(queue, opts...)
+	case models.JobArchived:
+		return s.inspector.ListArchivedTasks(queue, opts...)
+	default:
+		return s.inspector.ListCompletedTasks(queue, opts...)
+	}
+}
+
+// queueInfo returns a queue's info, asynq only reports a missing queue as
+// such from the task methods so it is checked against the queue list first
+func (s *JobService) queueInfo(queue string) (*asynq.QueueInfo, error) {
+	queues, err := s.inspector.Queues()
+	if err != nil {
+		s.logger.Error("failed to list queues", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list queues")
+	}
+	if !slices.Contains(queues, queue) {
+		return nil, apperrors.NewNotFoundError("queue not found")
+	}
+	info, err := s.inspector.GetQueueInfo(queue)
+	if err != nil {
+		s.logger.Error("failed to get queue info", "queue", queue, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get queue info")
+	}
+	return info, nil
+}
+
+// requireArchived makes sure only dead jobs are retried or deleted, the
+// others are still owned by the workers
+func (s *JobService) requireArchived(queue, id string) error {
+	info, err := s.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return s.taskError(err, "failed to get task", queue)
+	}
+	if info.State != asynq.TaskStateArchived {
+		return apperrors.NewValidationError("only archived tasks can be retried or deleted")
+	}
+	return nil
+}
+
+func (s *JobService) taskError(err error, message, queue string) error {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound):
+		return apperrors.NewNotFoundError("queue not found")
+	case errors.Is(err, asynq.ErrTaskNotFound):
+		return apperrors.NewNotFoundError("task not found")
+	}
+	s.logger.Error(message, "queue", queue, "error", err)
+	return apperrors.WrapInternal(err, message)
+}
+
+func queueStats(info *asynq.QueueInfo) *models.QueueStats {
+	return &models.QueueStats{
+		Queue:          info.Queue,
+		Size:           info.Size,
+		Pending:        info.Pending,
+		Active:         info.Active,
+		Scheduled:      info.Scheduled,
+		Retry:          info.Retry,
+		Archived:       info.Archived,
+		Completed:      info.Completed,
+		Processed:      info.Processed,
+		Failed:         info.Failed,
+		Paused:         info.Paused,
+		LatencySeconds: info.Latency.Seconds(),
+	}
+}
+
+func jobInfo(t *asynq.TaskInfo) *models.JobInfo {
+	job := &models.JobInfo{
+		ID:            t.ID,
+		Queue:         t.Queue,
+		Type:          t.Type,
+		State:         models.JobState(t.State.String()),
+		MaxRetry:      t.MaxRetry,
+		Retried:       t.Retried,
+		LastError:     t.LastErr,
+		LastFailedAt:  optionalTime(t.LastFailedAt),
+		NextProcessAt: optionalTime(t.NextProcessAt),
+		CompletedAt:   optionalTime(t.CompletedAt),
+	}
+	// Every payload we queue is JSON, anything else would break the response
+	if json.Valid(t.Payload) {
+		job.Payload = t.Payload
+	}
+	return job
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockJobInspector is a mock implementation of JobInspector
+type MockJobInspector struct {
+	mock.Mock
+}
+
+func (m *MockJobInspector) Queues() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockJobInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	args := m.Called(queue)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*asynq.QueueInfo), args.Error(1)
+}
+
+func (m *MockJobInspector) GetTaskInfo(queue, id string) (*asynq.TaskInfo, error) {
+	args := m.Called(queue, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*asynq.TaskInfo), args.Error(1)
+}
+
+func (m *MockJobInspector) listTasks(method, queue string, opts []asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	args := m.MethodCalled(method, queue, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*asynq.TaskInfo), args.Error(1)
+}
+
+func (m *MockJobInspector) ListPendingTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return m.listTasks("ListPendingTasks", queue, opts)
+}
+
+func (m *MockJobInspector) ListActiveTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return m.listTasks("ListActiveTasks", queue, opts)
+}
+
+func (m *MockJobInspector) ListScheduledTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return m.listTasks("ListScheduledTasks", queue, opts)
+}
+
+func (m *MockJobInspector) ListRetryTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return m.listTasks("ListRetryTasks", queue, opts)
+}
+
+func (m *MockJobInspector) ListArchivedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return m.listTasks("ListArchivedTasks", queue, opts)
+}
+
+func (m *MockJobInspector) ListCompletedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return m.listTasks("ListCompletedTasks", queue, opts)
+}
+
+func (m *MockJobInspector) RunTask(queue, id string) error {
+	args := m.Called(queue, id)
+	return args.Error(0)
+}
+
+func (m *MockJobInspector) DeleteTask(queue, id string) error {
+	args := m.Called(queue, id)
+	return args.Error(0)
+}
+
+func (m *MockJobInspector) PauseQueue(queue string) error {
+	args := m.Called(queue)
+	return args.Error(0)
+}
+
+func (m *MockJobInspector) UnpauseQueue(queue string) error {
+	args := m.Called(queue)
+	return args.Error(0)
+}
+
+func newTestJobService(inspector *MockJobInspector) *JobService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewJobService(inspector, nil, logger)
+}
+
+func pageOpts(page, size int) []asynq.ListOption {
+	return []asynq.ListOption{asynq.Page(page), asynq.PageSize(size)}
+}
+
+func assertAppErrorCode(t *testing.T, err error, code apperrors.ErrorCode) {
+	t.Helper()
+	var appErr *apperrors.AppError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, code, appErr.Code)
+	}
+}
+
+func TestJobService_ListQueues(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("Queues").Return([]string{"low", "critical"}, nil)
+	inspector.On("GetQueueInfo", "critical").Return(&asynq.QueueInfo{Queue: "critical", Size: 3, Pending: 2, Archived: 1, Latency: 1500 * time.Millisecond}, nil)
+	inspector.On("GetQueueInfo", "low").Return(&asynq.QueueInfo{Queue: "low", Paused: true}, nil)
+
+	queues, err := svc.ListQueues(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.QueueStats{
+		{Queue: "critical", Size: 3, Pending: 2, Archived: 1, LatencySeconds: 1.5},
+		{Queue: "low", Paused: true},
+	}, queues)
+
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_ListTasks(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+	failedAt := time.Now()
+
+	inspector.On("ListArchivedTasks", "critical", pageOpts(1, defaultJobPageSize)).Return([]*asynq.TaskInfo{{
+		ID:           "task-1",
+		Queue:        "critical",
+		Type:         "invoice:send",
+		State:        asynq.TaskStateArchived,
+		Payload:      []byte(`{"order_id":"x"}`),
+		MaxRetry:     25,
+		Retried:      25,
+		LastErr:      "mailgun: 500",
+		LastFailedAt: failedAt,
+	}}, nil)
+
+	jobs, err := svc.ListTasks(context.Background(), "critical", models.JobArchived, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, models.JobArchived, jobs[0].State)
+	assert.JSONEq(t, `{"order_id":"x"}`, string(jobs[0].Payload))
+	assert.Equal(t, "mailgun: 500", jobs[0].LastError)
+	assert.Equal(t, &failedAt, jobs[0].LastFailedAt)
+	assert.Nil(t, jobs[0].CompletedAt)
+
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_ListTasks_PageSizeCapped(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("ListRetryTasks", "default", pageOpts(2, maxJobPageSize)).Return([]*asynq.TaskInfo{}, nil)
+
+	_, err := svc.ListTasks(context.Background(), "default", models.JobRetry, 2, 1000)
+	assert.NoError(t, err)
+
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_ListTasks_InvalidState(t *testing.T) {
+	svc := newTestJobService(new(MockJobInspector))
+
+	_, err := svc.ListTasks(context.Background(), "default", models.JobState("dead"), 1, 20)
+	assertAppErrorCode(t, err, apperrors.CodeValidation)
+}
+
+func TestJobService_ListTasks_QueueNotFound(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("ListPendingTasks", "nope", pageOpts(1, defaultJobPageSize)).Return(nil, fmt.Errorf("asynq: %w", asynq.ErrQueueNotFound))
+
+	_, err := svc.ListTasks(context.Background(), "nope", models.JobPending, 0, 0)
+	assertAppErrorCode(t, err, apperrors.CodeNotFound)
+}
+
+func TestJobService_RetryTask(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("GetTaskInfo", "critical", "task-1").Return(&asynq.TaskInfo{ID: "task-1", State: asynq.TaskStateArchived}, nil)
+	inspector.On("RunTask", "critical", "task-1").Return(nil)
+
+	assert.NoError(t, svc.RetryTask(context.Background(), "critical", "task-1"))
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_RetryTask_NotArchived(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("GetTaskInfo", "critical", "task-1").Return(&asynq.TaskInfo{ID: "task-1", State: asynq.TaskStateRetry}, nil)
+
+	err := svc.RetryTask(context.Background(), "critical", "task-1")
+	assertAppErrorCode(t, err, apperrors.CodeValidation)
+	inspector.AssertNotCalled(t, "RunTask", mock.Anything, mock.Anything)
+}
+
+func TestJobService_DeleteTask(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("GetTaskInfo", "critical", "task-1").Return(&asynq.TaskInfo{ID: "task-1", State: asynq.TaskStateArchived}, nil)
+	inspector.On("DeleteTask", "critical", "task-1").Return(nil)
+
+	assert.NoError(t, svc.DeleteTask(context.Background(), "critical", "task-1"))
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_DeleteTask_NotFound(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("GetTaskInfo", "critical", "gone").Return(nil, fmt.Errorf("asynq: %w", asynq.ErrTaskNotFound))
+
+	err := svc.DeleteTask(context.Background(), "critical", "gone")
+	assertAppErrorCode(t, err, apperrors.CodeNotFound)
+	inspector.AssertNotCalled(t, "DeleteTask", mock.Anything, mock.Anything)
+}
+
+func TestJobService_PauseQueue(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("Queues").Return([]string{"critical", "default"}, nil)
+	inspector.On("GetQueueInfo", "default").Return(&asynq.QueueInfo{Queue: "default"}, nil)
+	inspector.On("PauseQueue", "default").Return(nil)
+
+	assert.NoError(t, svc.PauseQueue(context.Background(), "default"))
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_PauseQueue_AlreadyPaused(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("Queues").Return([]string{"default"}, nil)
+	inspector.On("GetQueueInfo", "default").Return(&asynq.QueueInfo{Queue: "default", Paused: true}, nil)
+
+	err := svc.PauseQueue(context.Background(), "default")
+	assertAppErrorCode(t, err, apperrors.CodeValidation)
+	inspector.AssertNotCalled(t, "PauseQueue", mock.Anything)
+}
+
+func TestJobService_ResumeQueue(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("Queues").Return([]string{"default"}, nil)
+	inspector.On("GetQueueInfo", "default").Return(&asynq.QueueInfo{Queue: "default", Paused: true}, nil)
+	inspector.On("UnpauseQueue", "default").Return(nil)
+
+	assert.NoError(t, svc.ResumeQueue(context.Background(), "default"))
+	inspector.AssertExpectations(t)
+}
+
+func TestJobService_ResumeQueue_UnknownQueue(t *testing.T) {
+	inspector := new(MockJobInspector)
+	svc := newTestJobService(inspector)
+
+	inspector.On("Queues").Return([]string{"default"}, nil)
+
+	err := svc.ResumeQueue(context.Background(), "typo")
+	assertAppErrorCode(t, err, apperrors.CodeNotFound)
+	inspector.AssertNotCalled(t, "GetQueueInfo", mock.Anything)
+}
+
+func TestJobService_ListOrderJobs(t *testing.T) {
+	inspector := new(MockJobInspector)
+	transactions := new(MockTransactionRepository)
+	svc := NewJobService(inspector, transactions, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	orderID := uuid.New()
+	refunded, unqueued := uuid.New(), uuid.New()
+
+	transactions.On("ListOrderRefunds", mock.Anything, orderID).Return([]*models.Transaction{{ID: refunded}, {ID: unqueued}}, nil)
+	inspector.On("GetTaskInfo", "default", jobs.InvoiceTaskID(orderID)).Return(&asynq.TaskInfo{
+		ID: jobs.InvoiceTaskID(orderID), State: asynq.TaskStateArchived, LastErr: "mailgun: 500",
+	}, nil)
+	// Completed long enough ago to be past its retention
+	inspector.On("GetTaskInfo", "low", jobs.MarketSalesTaskID(orderID)).Return(nil, asynq.ErrTaskNotFound)
+	inspector.On("GetTaskInfo", "default", jobs.CreditNoteTaskID(refunded)).Return(&asynq.TaskInfo{
+		ID: jobs.CreditNoteTaskID(refunded), State: asynq.TaskStateRetry,
+	}, nil)
+	inspector.On("GetTaskInfo", "default", jobs.CreditNoteTaskID(unqueued)).Return(nil, asynq.ErrTaskNotFound)
+
+	found, err := svc.ListOrderJobs(context.Background(), orderID)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 2) {
+		assert.Equal(t, jobs.InvoiceTaskID(orderID), found[0].ID)
+		assert.Equal(t, "mailgun: 500", found[0].LastError)
+		assert.Equal(t, jobs.CreditNoteTaskID(refunded), found[1].ID)
+		assert.Equal(t, models.JobRetry, found[1].State)
+	}
+
+	inspector.AssertExpectations(t)
+	inspector.AssertNotCalled(t, "ListCompletedTasks", mock.Anything, mock.Anything)
+}
+
+func TestJobService_ListOrderJobs_InspectorError(t *testing.T) {
+	inspector := new(MockJobInspector)
+	transactions := new(MockTransactionRepository)
+	svc := NewJobService(inspector, transactions, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	orderID := uuid.New()
+
+	transactions.On("ListOrderRefunds", mock.Anything, orderID).Return([]*models.Transaction{}, nil)
+	inspector.On("GetTaskInfo", "default", jobs.InvoiceTaskID(orderID)).Return(nil, errors.New("redis down"))
+
+	_, err := svc.ListOrderJobs(context.Background(), orderID)
+	assertAppErrorCode(t, err, apperrors.CodeInternal)
+}
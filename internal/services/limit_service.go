@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/limits"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/limit"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LimitService struct {
+	limitRepo limit.Repository
+	userRepo  user.Repository
+	policy    *limits.Policy
+	db        *sqlx.DB
+	logger    *slog.Logger
+}
+
+func NewLimitService(limitRepo limit.Repository, userRepo user.Repository, policy *limits.Policy, db *sqlx.DB, logger *slog.Logger) *LimitService {
+	return &LimitService{limitRepo: limitRepo, userRepo: userRepo, policy: policy, db: db, logger: logger}
+}
+
+// Enforce checks amount against the user's limits for kind. It must run in
+// the transaction that moves the money, after the user row is locked, so
+// concurrent requests can't both fit into the same remaining limit.
+func (s *LimitService) Enforce(ctx context.Context, tx *sqlx.Tx, u *models.User, kind models.LimitKind, amount float64) error {
+	rule, usage, _, err := s.load(ctx, tx, u, kind, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := limits.Check(kind, rule, amount, usage); err != nil {
+		s.logger.Warn("limit exceeded", "user_id", u.ID, "kind", kind, "amount", amount,
+			"daily_used", usage.Daily, "monthly_used", usage.Monthly)
+		return err
+	}
+	return nil
+}
+
+func (s *LimitService) load(ctx context.Context, tx *sqlx.Tx, u *models.User, kind models.LimitKind, now time.Time) (rule config.LimitRule, usage limits.Usage, overridden bool, err error) {
+	override, err := s.limitRepo.GetOverride(ctx, tx, u.ID, kind)
+	if err != nil {
+		s.logger.Error("failed to get limit override", "error", err, "user_id", u.ID, "kind", kind)
+		return rule, usage, false, apperrors.WrapInternal(err, "failed to get limit override")
+	}
+	rule = s.policy.Rule(u.Tier, kind, override)
+
+	if usage.Daily, err = s.limitRepo.GetUsage(ctx, tx, u.ID, kind, now.Add(-limits.DailyWindow)); err != nil {
+		s.logger.Error("failed to get limit usage", "error", err, "user_id", u.ID, "kind", kind)
+		return rule, usage, false, apperrors.WrapInternal(err, "failed to get limit usage")
+	}
+	if usage.Monthly, err = s.limitRepo.GetUsage(ctx, tx, u.ID, kind, now.Add(-limits.MonthlyWindow)); err != nil {
+		s.logger.Error("failed to get limit usage", "error", err, "user_id", u.ID, "kind", kind)
+		return rule, usage, false, apperrors.WrapInternal(err, "failed to get limit usage")
+	}
+	return rule, usage, override != nil, nil
+}
+
+// GetLimits returns the user's effective limits and what is left of them
+func (s *LimitService) GetLimits(ctx context.Context, userID uuid.UUID) ([]models.LimitStatus, error) {
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get user", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to get user")
+	}
+	if u == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	now := time.Now()
+	statuses := make([]models.LimitStatus, 0, len(models.LimitKinds))
+	for _, kind := range models.LimitKinds {
+		rule, usage, overridden, err := s.load(ctx, tx, u, kind, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, limits.Status(kind, rule, usage, overridden))
+	}
+	return statuses, nil
+}
+
+// GetUserLimits returns a user's effective limits together with the
+// overrides an admin has set for them
+func (s *LimitService) GetUserLimits(ctx context.Context, userID uuid.UUID) (*models.UserLimits, error) {
+	statuses, err := s.GetLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.limitRepo.ListOverrides(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list limit overrides", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to list limit overrides")
+	}
+	return &models.UserLimits{UserID: userID, Limits: statuses, Overrides: overrides}, nil
+}
+
+// SetOverride replaces the user's override for kind. Omitted fields keep the
+// tier's value, zero lifts the limit.
+func (s *LimitService) SetOverride(ctx context.Context, adminID, userID uuid.UUID, kind models.LimitKind, req models.SetLimitOverrideRequest) (*models.LimitOverride, error) {
+	if !kind.Valid() {
+		return nil, apperrors.NewValidationError("invalid limit kind")
+	}
+	if req.PerTransaction == nil && req.Daily == nil && req.Monthly == nil {
+		return nil, apperrors.NewValidationError("at least one of per_transaction, daily or monthly is required")
+	}
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get user", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to get user")
+	}
+	if u == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	now := time.Now()
+	override := &models.LimitOverride{
+		UserID:         userID,
+		Kind:           kind,
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		Monthly:        req.Monthly,
+		UpdatedBy:      &adminID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.limitRepo.UpsertOverride(ctx, override); err != nil {
+		s.logger.Error("failed to save limit override", "error", err, "user_id", userID, "kind", kind)
+		return nil, apperrors.WrapInternal(err, "failed to save limit override")
+	}
+
+	s.logger.Info("limit override set", "user_id", userID, "kind", kind, "admin_id", adminID)
+	return override, nil
+}
+
+// DeleteOverride puts the user back on their tier's limits for kind
+func (s *LimitService) DeleteOverride(ctx context.Context, userID uuid.UUID, kind models.LimitKind) error {
+	if !kind.Valid() {
+		return apperrors.NewValidationError("invalid limit kind")
+	}
+	deleted, err := s.limitRepo.DeleteOverride(ctx, userID, kind)
+	if err != nil {
+		s.logger.Error("failed to delete limit override", "error", err, "user_id", userID, "kind", kind)
+		return apperrors.WrapInternal(err, "failed to delete limit override")
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError("limit override not found")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/limits"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLimitRepository is a mock implementation of limit.Repository
+type MockLimitRepository struct {
+	mock.Mock
+}
+
+func (m *MockLimitRepository) GetOverride(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.LimitKind) (*models.LimitOverride, error) {
+	args := m.Called(ctx, tx, userID, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LimitOverride), args.Error(1)
+}
+
+func (m *MockLimitRepository) ListOverrides(ctx context.Context, userID uuid.UUID) ([]*models.LimitOverride, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.LimitOverride), args.Error(1)
+}
+
+func (m *MockLimitRepository) UpsertOverride(ctx context.Context, override *models.LimitOverride) error {
+	args := m.Called(ctx, override)
+	return args.Error(0)
+}
+
+func (m *MockLimitRepository) DeleteOverride(ctx context.Context, userID uuid.UUID, kind models.LimitKind) (bool, error) {
+	args := m.Called(ctx, userID, kind)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLimitRepository) GetUsage(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.LimitKind, since time.Time) (float64, error) {
+	args := m.Called(ctx, tx, userID, kind, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func newTestLimitService(repo *MockLimitRepository, userRepo *MockUserRepository) *LimitService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewLimitService(repo, userRepo, limits.NewPolicy(config.DefaultLimits()), nil, logger)
+}
+
+func TestLimitService_Enforce(t *testing.T) {
+	buyer := &models.User{ID: uuid.New(), Tier: models.TierStandard}
+	daily := 100.0
+
+	tests := []struct {
+		name     string
+		override *models.LimitOverride
+		used     float64
+		amount   float64
+		wantErr  bool
+	}{
+		{name: "within override", override: &models.LimitOverride{Daily: &daily}, used: 50, amount: 50},
+		{name: "over override", override: &models.LimitOverride{Daily: &daily}, used: 50, amount: 50.01, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockLimitRepository)
+			repo.On("GetOverride", mock.Anything, mock.Anything, buyer.ID, models.LimitPurchase).Return(tt.override, nil)
+			repo.On("GetUsage", mock.Anything, mock.Anything, buyer.ID, models.LimitPurchase, mock.Anything).Return(tt.used, nil)
+
+			err := newTestLimitService(repo, nil).Enforce(context.Background(), nil, buyer, models.LimitPurchase, tt.amount)
+
+			if tt.wantErr {
+				var appErr *apperrors.AppError
+				assert.ErrorAs(t, err, &appErr)
+				assert.Equal(t, apperrors.CodeLimitExceeded, appErr.Code)
+			} else {
+				assert.NoError(t, err)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLimitService_SetOverride(t *testing.T) {
+	adminID, userID := uuid.New(), uuid.New()
+	daily := 500.0
+
+	t.Run("invalid kind", func(t *testing.T) {
+		repo := new(MockLimitRepository)
+
+		override, err := newTestLimitService(repo, nil).SetOverride(context.Background(), adminID, userID, "transfer", models.SetLimitOverrideRequest{Daily: &daily})
+
+		assert.Equal(t, apperrors.NewValidationError("invalid limit kind"), err)
+		assert.Nil(t, override)
+	})
+
+	t.Run("empty override", func(t *testing.T) {
+		repo := new(MockLimitRepository)
+
+		override, err := newTestLimitService(repo, nil).SetOverride(context.Background(), adminID, userID, models.LimitDeposit, models.SetLimitOverrideRequest{})
+
+		assert.Equal(t, apperrors.NewValidationError("at least one of per_transaction, daily or monthly is required"), err)
+		assert.Nil(t, override)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		repo := new(MockLimitRepository)
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(nil, nil)
+
+		override, err := newTestLimitService(repo, userRepo).SetOverride(context.Background(), adminID, userID, models.LimitDeposit, models.SetLimitOverrideRequest{Daily: &daily})
+
+		assert.Equal(t, apperrors.ErrUserNotFound, err)
+		assert.Nil(t, override)
+		repo.AssertNotCalled(t, "UpsertOverride", mock.Anything, mock.Anything)
+	})
+
+	t.Run("saves the override", func(t *testing.T) {
+		repo := new(MockLimitRepository)
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(&models.User{ID: userID}, nil)
+		repo.On("UpsertOverride", mock.Anything, mock.MatchedBy(func(o *models.LimitOverride) bool {
+			return o.UserID == userID && o.Kind == models.LimitDeposit && *o.Daily == daily && o.Monthly == nil && *o.UpdatedBy == adminID
+		})).Return(nil)
+
+		override, err := newTestLimitService(repo, userRepo).SetOverride(context.Background(), adminID, userID, models.LimitDeposit, models.SetLimitOverrideRequest{Daily: &daily})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, override)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestLimitService_DeleteOverride_NotFound(t *testing.T) {
+	userID := uuid.New()
+	repo := new(MockLimitRepository)
+	repo.On("DeleteOverride", mock.Anything, userID, models.LimitWithdraw).Return(false, nil)
+
+	err := newTestLimitService(repo, nil).DeleteOverride(context.Background(), userID, models.LimitWithdraw)
+
+	assert.Equal(t, apperrors.NewNotFoundError("limit override not found"), err)
+}
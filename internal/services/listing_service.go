@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/skin"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Number of expired listings delisted per database transaction
+const listingExpiryBatchSize = 100
+
+type ListingService struct {
+	skinRepo  skin.Repository
+	userRepo  user.Repository
+	email     *EmailService
+	publisher realtime.Publisher
+	db        *sqlx.DB
+	logger    *slog.Logger
+}
+
+func NewListingService(skinRepo skin.Repository, userRepo user.Repository, email *EmailService, publisher realtime.Publisher, db *sqlx.DB, logger *slog.Logger) *ListingService {
+	return &ListingService{
+		skinRepo:  skinRepo,
+		userRepo:  userRepo,
+		email:     email,
+		publisher: publisher,
+		db:        db,
+		logger:    logger,
+	}
+}
+
+// ExpireListings takes every listing past its expiry off the marketplace and
+// emails each owner once per batch about their expired listings. It returns
+// how many listings expired.
+func (s *ListingService) ExpireListings(ctx context.Context) (int, error) {
+	now := time.Now()
+	count := 0
+	for {
+		batch, err := s.expireBatch(ctx, now)
+		if err != nil {
+			return count, err
+		}
+		// Each batch is committed on its own, so it is announced before the
+		// next one can fail
+		s.notifyExpired(ctx, batch)
+		count += len(batch)
+		if len(batch) < listingExpiryBatchSize {
+			break
+		}
+	}
+
+	if count > 0 {
+		s.logger.Info("expired listings", "count", count)
+	}
+	return count, nil
+}
+
+// notifyExpired announces a committed batch of expired listings
+func (s *ListingService) notifyExpired(ctx context.Context, expired []*models.Skin) {
+	for _, sk := range expired {
+		event := realtime.NewSkinEvent(realtime.EventListingRemoved, sk)
+		if err := s.publisher.PublishMarket(ctx, event); err != nil {
+			s.logger.Warn("failed to publish market event", "type", event.Type, "err", err)
+		}
+	}
+	// Emails go out after the commit, a failed one doesn't put the listing back
+	for ownerID, skins := range groupByOwner(expired) {
+		s.notifyOwner(ctx, ownerID, skins)
+	}
+}
+
+func (s *ListingService) expireBatch(ctx context.Context, now time.Time) ([]*models.Skin, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	skins, err := s.skinRepo.GetExpiredListings(ctx, tx, now, listingExpiryBatchSize)
+	if err != nil {
+		s.logger.Error("failed to get expired listings", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get expired listings")
+	}
+	if len(skins) == 0 {
+		return nil, nil
+	}
+
+	for _, sk := range skins {
+		// expires_at stays so the owner can relist it
+		if err := s.skinRepo.UpdateAvailability(ctx, tx, sk.ID, false); err != nil {
+			s.logger.Error("failed to update skin availability", "error", err, "skin_id", sk.ID)
+			return nil, apperrors.WrapInternal(err, "failed to update skin availability")
+		}
+		sk.Available = false
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+	return skins, nil
+}
+
+// notifyOwner pushes a listing.expired event per skin and sends the owner one
+// email. Both are best-effort, the job isn't retried for them.
+func (s *ListingService) notifyOwner(ctx context.Context, ownerID uuid.UUID, skins []*models.Skin) {
+	for _, sk := range skins {
+		event := realtime.NewSkinEvent(realtime.EventListingExpired, sk)
+		if err := s.publisher.PublishUser(ctx, ownerID, event); err != nil {
+			s.logger.Warn("failed to publish user event", "type", event.Type, "user_id", ownerID, "err", err)
+		}
+	}
+
+	owner, err := s.userRepo.FindByID(ctx, ownerID)
+	if err != nil {
+		s.logger.Warn("failed to get listing owner", "user_id", ownerID, "err", err)
+		return
+	}
+	if owner == nil {
+		return
+	}
+	if err := s.email.SendListingsExpired(owner.Email, skins); err != nil {
+		s.logger.Warn("failed to email expired listings", "user_id", ownerID, "count", len(skins), "err", err)
+	}
+}
+
+// groupByOwner groups skins by their owner, skins without one are left out
+func groupByOwner(skins []*models.Skin) map[uuid.UUID][]*models.Skin {
+	owners := make(map[uuid.UUID][]*models.Skin)
+	for _, sk := range skins {
+		if sk.OwnerID == nil {
+			continue
+		}
+		owners[*sk.OwnerID] = append(owners[*sk.OwnerID], sk)
+	}
+	return owners
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkin_ListingExpired(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.True(t, (&models.Skin{ExpiresAt: &past}).ListingExpired(now))
+	assert.False(t, (&models.Skin{ExpiresAt: &future}).ListingExpired(now), "not expired yet")
+	assert.True(t, (&models.Skin{Available: true, ExpiresAt: &past}).ListingExpired(now), "not delisted by the job yet")
+	assert.False(t, (&models.Skin{}).ListingExpired(now), "removed by its owner")
+}
+
+func TestGroupByOwner(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	skins := []*models.Skin{
+		{ID: uuid.New(), OwnerID: &alice},
+		{ID: uuid.New(), OwnerID: &bob},
+		{ID: uuid.New(), OwnerID: &alice},
+		{ID: uuid.New()},
+	}
+
+	owners := groupByOwner(skins)
+
+	assert.Len(t, owners, 2)
+	assert.Equal(t, []*models.Skin{skins[0], skins[2]}, owners[alice])
+	assert.Equal(t, []*models.Skin{skins[1]}, owners[bob])
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/marketdata"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	backfillBatchSize      = 500
+	defaultCandleCount     = 100
+	maxCandleCount         = 1000
+	defaultPriceWindowDays = 30
+)
+
+type MarketDataService struct {
+	repo   marketdata.Repository
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewMarketDataService(repo marketdata.Repository, db *sqlx.DB, logger *slog.Logger) *MarketDataService {
+	return &MarketDataService{repo: repo, db: db, logger: logger}
+}
+
+// RecordOrderSales folds the sales of a completed order into the price
+// aggregates. It is idempotent: sales that were already recorded are skipped.
+func (s *MarketDataService) RecordOrderSales(ctx context.Context, orderID uuid.UUID) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	sales, err := s.repo.GetOrderSales(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order sales", "order_id", orderID, "error", err)
+		return apperrors.WrapInternal(err, "failed to get order sales")
+	}
+
+	recorded, err := s.recordSales(ctx, tx, sales)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.logger.Info("market sales recorded", "order_id", orderID, "recorded", recorded)
+	return nil
+}
+
+// Backfill records every completed sale that is missing from the aggregates
+func (s *MarketDataService) Backfill(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		tx, err := s.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return total, apperrors.WrapInternal(err, "failed to begin transaction")
+		}
+
+		sales, err := s.repo.GetUnrecordedSales(ctx, tx, backfillBatchSize)
+		if err != nil {
+			_ = tx.Rollback()
+			return total, apperrors.WrapInternal(err, "failed to get unrecorded sales")
+		}
+
+		recorded, err := s.recordSales(ctx, tx, sales)
+		if err != nil {
+			_ = tx.Rollback()
+			return total, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return total, apperrors.WrapInternal(err, "failed to commit transaction")
+		}
+
+		total += recorded
+		if len(sales) < backfillBatchSize {
+			break
+		}
+	}
+
+	s.logger.Info("market data backfill completed", "recorded", total)
+	return total, nil
+}
+
+func (s *MarketDataService) recordSales(ctx context.Context, tx *sqlx.Tx, sales []*models.MarketSale) (int, error) {
+	recorded := 0
+	for _, sale := range sales {
+		inserted, err := s.repo.InsertSale(ctx, tx, sale)
+		if err != nil {
+			s.logger.Error("failed to insert market sale", "order_item_id", sale.OrderItemID, "error", err)
+			return recorded, apperrors.WrapInternal(err, "failed to insert market sale")
+		}
+		if !inserted {
+			continue
+		}
+		recorded++
+		if sale.Excluded {
+			continue
+		}
+
+		for _, interval := range models.CandleIntervals {
+			if err := s.repo.UpsertCandle(ctx, tx, interval, sale); err != nil {
+				s.logger.Error("failed to update candle", "order_item_id", sale.OrderItemID, "interval", interval, "error", err)
+				return recorded, apperrors.WrapInternal(err, "failed to update candle")
+			}
+		}
+	}
+	return recorded, nil
+}
+
+// ExcludeOrderSales takes the sales of a refunded order out of the price
+// statistics
+func (s *MarketDataService) ExcludeOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) error {
+	sales, err := s.repo.ExcludeOrderSales(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to exclude order sales", "order_id", orderID, "error", err)
+		return apperrors.WrapInternal(err, "failed to exclude order sales")
+	}
+	return s.RebuildCandles(ctx, tx, sales)
+}
+
+// RebuildCandles recomputes every candle the sales fall into, after they were
+// excluded from or returned to the price statistics
+func (s *MarketDataService) RebuildCandles(ctx context.Context, tx *sqlx.Tx, sales []*models.MarketSale) error {
+	type bucket struct {
+		skinType models.SkinType
+		interval models.CandleInterval
+		start    time.Time
+	}
+	seen := make(map[bucket]bool)
+	for _, sale := range sales {
+		skinType := models.SkinType{Name: sale.Name, Gun: sale.Gun, Wear: sale.Wear}
+		for _, interval := range models.CandleIntervals {
+			b := bucket{skinType: skinType, interval: interval, start: interval.BucketStart(sale.SoldAt)}
+			if seen[b] {
+				continue
+			}
+			seen[b] = true
+			if err := s.repo.RebuildCandle(ctx, tx, skinType, interval, b.start); err != nil {
+				s.logger.Error("failed to rebuild candle", "skin_type", skinType, "interval", interval, "bucket_start", b.start, "error", err)
+				return apperrors.WrapInternal(err, "failed to rebuild candle")
+			}
+		}
+	}
+	return nil
+}
+
+// GetCandles returns OHLC candles for a skin type. When from is zero the last
+// 100 buckets before to are returned.
+func (s *MarketDataService) GetCandles(ctx context.Context, skinType models.SkinType, interval models.CandleInterval, from, to time.Time) ([]*models.Candle, error) {
+	if !interval.Valid() {
+		return nil, apperrors.NewValidationError("interval must be one of 1h, 1d, 1w")
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultCandleCount * interval.Duration())
+	}
+	if !from.Before(to) {
+		return nil, apperrors.NewValidationError("from must be before to")
+	}
+	if to.Sub(from) > maxCandleCount*interval.Duration() {
+		return nil, apperrors.NewValidationError("requested range is too large for this interval")
+	}
+
+	candles, err := s.repo.GetCandles(ctx, skinType, interval, interval.BucketStart(from), to)
+	if err != nil {
+		s.logger.Error("failed to get candles", "skin_type", skinType, "interval", interval, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get candles")
+	}
+	return candles, nil
+}
+
+// GetPriceSummary returns the last sale and the median price over the window
+func (s *MarketDataService) GetPriceSummary(ctx context.Context, skinType models.SkinType, windowDays int) (*models.PriceSummary, error) {
+	if windowDays <= 0 {
+		windowDays = defaultPriceWindowDays
+	}
+	if windowDays > 365 {
+		return nil, apperrors.NewValidationError("days cannot exceed 365")
+	}
+
+	summary := &models.PriceSummary{
+		Name:       skinType.Name,
+		Gun:        skinType.Gun,
+		Wear:       skinType.Wear,
+		WindowDays: windowDays,
+	}
+
+	last, err := s.repo.GetLastSale(ctx, skinType)
+	if err != nil {
+		s.logger.Error("failed to get last sale", "skin_type", skinType, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get last sale")
+	}
+	if last != nil {
+		summary.LastSalePrice = &last.Price
+		summary.LastSaleAt = &last.SoldAt
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -windowDays)
+	median, count, err := s.repo.GetMedianPrice(ctx, skinType, since)
+	if err != nil {
+		s.logger.Error("failed to get median price", "skin_type", skinType, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get median price")
+	}
+	summary.MedianPrice = median
+	summary.SalesCount = count
+
+	return summary, nil
+}
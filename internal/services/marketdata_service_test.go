@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMarketDataRepository is a mock implementation of marketdata.Repository
+type MockMarketDataRepository struct {
+	mock.Mock
+}
+
+func (m *MockMarketDataRepository) GetOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.MarketSale, error) {
+	args := m.Called(ctx, tx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MarketSale), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetUnrecordedSales(ctx context.Context, tx *sqlx.Tx, limit int) ([]*models.MarketSale, error) {
+	args := m.Called(ctx, tx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MarketSale), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) InsertSale(ctx context.Context, tx *sqlx.Tx, sale *models.MarketSale) (bool, error) {
+	args := m.Called(ctx, tx, sale)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) ExcludeOrderSales(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.MarketSale, error) {
+	args := m.Called(ctx, tx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MarketSale), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) UpsertCandle(ctx context.Context, tx *sqlx.Tx, interval models.CandleInterval, sale *models.MarketSale) error {
+	args := m.Called(ctx, tx, interval, sale)
+	return args.Error(0)
+}
+
+func (m *MockMarketDataRepository) RebuildCandle(ctx context.Context, tx *sqlx.Tx, skinType models.SkinType, interval models.CandleInterval, bucketStart time.Time) error {
+	args := m.Called(ctx, tx, skinType, interval, bucketStart)
+	return args.Error(0)
+}
+
+func (m *MockMarketDataRepository) GetCandles(ctx context.Context, skinType models.SkinType, interval models.CandleInterval, from, to time.Time) ([]*models.Candle, error) {
+	args := m.Called(ctx, skinType, interval, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Candle), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetLastSale(ctx context.Context, skinType models.SkinType) (*models.MarketSale, error) {
+	args := m.Called(ctx, skinType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MarketSale), args.Error(1)
+}
+
+func (m *MockMarketDataRepository) GetMedianPrice(ctx context.Context, skinType models.SkinType, since time.Time) (*float64, int, error) {
+	args := m.Called(ctx, skinType, since)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).(*float64), args.Int(1), args.Error(2)
+}
+
+func TestCandleInterval_BucketStart(t *testing.T) {
+	// Wednesday
+	ts := time.Date(2025, 3, 12, 17, 45, 30, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 12, 17, 0, 0, 0, time.UTC), models.Interval1h.BucketStart(ts))
+	assert.Equal(t, time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), models.Interval1d.BucketStart(ts))
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), models.Interval1w.BucketStart(ts))
+
+	// Sunday belongs to the week that started on the previous Monday
+	sunday := time.Date(2025, 3, 16, 23, 59, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), models.Interval1w.BucketStart(sunday))
+}
+
+func TestMarketDataService_GetCandles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	skinType := models.SkinType{Name: "Redline", Gun: models.AK47, Wear: models.FieldTested}
+	to := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		interval      models.CandleInterval
+		from          time.Time
+		mockSetup     func(*MockMarketDataRepository)
+		expectedError error
+	}{
+		{
+			name:     "defaults to the last 100 buckets",
+			interval: models.Interval1h,
+			mockSetup: func(repo *MockMarketDataRepository) {
+				repo.On("GetCandles", mock.Anything, skinType, models.Interval1h, to.Add(-100*time.Hour), to).
+					Return([]*models.Candle{}, nil)
+			},
+		},
+		{
+			name:          "invalid interval",
+			interval:      "5m",
+			mockSetup:     func(repo *MockMarketDataRepository) {},
+			expectedError: apperrors.NewValidationError("interval must be one of 1h, 1d, 1w"),
+		},
+		{
+			name:          "from after to",
+			interval:      models.Interval1d,
+			from:          to.Add(time.Hour),
+			mockSetup:     func(repo *MockMarketDataRepository) {},
+			expectedError: apperrors.NewValidationError("from must be before to"),
+		},
+		{
+			name:          "range too large",
+			interval:      models.Interval1h,
+			from:          to.Add(-2000 * time.Hour),
+			mockSetup:     func(repo *MockMarketDataRepository) {},
+			expectedError: apperrors.NewValidationError("requested range is too large for this interval"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMarketDataRepository)
+			tt.mockSetup(repo)
+
+			service := NewMarketDataService(repo, nil, logger)
+			candles, err := service.GetCandles(context.Background(), skinType, tt.interval, tt.from, to)
+
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				assert.Nil(t, candles)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, candles)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMarketDataService_GetPriceSummary(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	skinType := models.SkinType{Name: "Asiimov", Gun: models.AWP, Wear: models.FieldTested}
+	soldAt := time.Now().Add(-time.Hour)
+	median := 95.5
+
+	repo := new(MockMarketDataRepository)
+	repo.On("GetLastSale", mock.Anything, skinType).Return(&models.MarketSale{Price: 99, SoldAt: soldAt}, nil)
+	repo.On("GetMedianPrice", mock.Anything, skinType, mock.AnythingOfType("time.Time")).Return(&median, 12, nil)
+
+	service := NewMarketDataService(repo, nil, logger)
+	summary, err := service.GetPriceSummary(context.Background(), skinType, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30, summary.WindowDays)
+	assert.Equal(t, 99.0, *summary.LastSalePrice)
+	assert.Equal(t, soldAt, *summary.LastSaleAt)
+	assert.Equal(t, median, *summary.MedianPrice)
+	assert.Equal(t, 12, summary.SalesCount)
+	repo.AssertExpectations(t)
+}
+
+func TestMarketDataService_RebuildCandles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Two sales in the same hour and one the next day, all in the same week
+	sales := []*models.MarketSale{
+		{Name: "Redline", Gun: models.AK47, Wear: models.FieldTested, Price: 10, SoldAt: time.Date(2025, 3, 12, 17, 5, 0, 0, time.UTC)},
+		{Name: "Redline", Gun: models.AK47, Wear: models.FieldTested, Price: 12, SoldAt: time.Date(2025, 3, 12, 17, 40, 0, 0, time.UTC)},
+		{Name: "Redline", Gun: models.AK47, Wear: models.FieldTested, Price: 11, SoldAt: time.Date(2025, 3, 13, 9, 0, 0, 0, time.UTC)},
+	}
+
+	repo := new(MockMarketDataRepository)
+	repo.On("RebuildCandle", mock.Anything, (*sqlx.Tx)(nil), mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := NewMarketDataService(repo, nil, logger).RebuildCandles(context.Background(), nil, sales)
+
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "RebuildCandle", 5)
+	repo.AssertCalled(t, "RebuildCandle", mock.Anything, (*sqlx.Tx)(nil), mock.Anything, models.Interval1w, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+}
@@ -6,12 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
+	"github.com/Uranury/RBK_finalProject/internal/fees"
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
 	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/taxledger"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/tax"
 
+	"github.com/Uranury/RBK_finalProject/internal/metrics"
 	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/hold"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/ledger"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/order"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/skin"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
@@ -23,12 +32,25 @@ import (
 
 // TODO: Add asynq background workers later
 
+const maxListingPrice = 1000000.0
+
 type MarketplaceService struct {
 	skinRepo        skin.Repository
 	orderRepo       order.Repository
 	userRepo        user.Repository
 	transactionRepo transaction.Repository
+	ledgerRepo      ledger.Repository
+	holdRepo        hold.Repository
+	taxLedger       taxledger.Repository
 	emailQueue      *asynq.Client
+	publisher       realtime.Publisher
+	pricing         *PricingService
+	marketData      *MarketDataService
+	fees            *fees.Schedule
+	taxes           *tax.Rules
+	limits          *LimitService
+	risk            *RiskService
+	tradeHold       time.Duration
 	db              *sqlx.DB
 	logger          *slog.Logger
 }
@@ -37,10 +59,55 @@ func NewMarketplaceService(skinRepo skin.Repository,
 	orderRepo order.Repository,
 	userRepo user.Repository,
 	transactionRepo transaction.Repository,
+	ledgerRepo ledger.Repository,
+	holdRepo hold.Repository,
+	taxLedger taxledger.Repository,
 	emailQueue *asynq.Client,
+	publisher realtime.Publisher,
+	pricing *PricingService,
+	marketData *MarketDataService,
+	feeSchedule *fees.Schedule,
+	taxRules *tax.Rules,
+	limits *LimitService,
+	risk *RiskService,
+	tradeHold time.Duration,
 	db *sqlx.DB,
 	logger *slog.Logger) *MarketplaceService {
-	return &MarketplaceService{skinRepo, orderRepo, userRepo, transactionRepo, emailQueue, db, logger}
+	return &MarketplaceService{
+		skinRepo:        skinRepo,
+		orderRepo:       orderRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		ledgerRepo:      ledgerRepo,
+		holdRepo:        holdRepo,
+		taxLedger:       taxLedger,
+		emailQueue:      emailQueue,
+		publisher:       publisher,
+		pricing:         pricing,
+		marketData:      marketData,
+		fees:            feeSchedule,
+		taxes:           taxRules,
+		limits:          limits,
+		risk:            risk,
+		tradeHold:       tradeHold,
+		db:              db,
+		logger:          logger,
+	}
+}
+
+// publishMarket pushes a public marketplace event. Streaming is best-effort,
+// so failures are only logged.
+func (s *MarketplaceService) publishMarket(ctx context.Context, event realtime.Event) {
+	if err := s.publisher.PublishMarket(ctx, event); err != nil {
+		s.logger.Warn("failed to publish market event", "type", event.Type, "err", err)
+	}
+}
+
+// publishUser pushes a private event to a single user's stream
+func (s *MarketplaceService) publishUser(ctx context.Context, userID uuid.UUID, event realtime.Event) {
+	if err := s.publisher.PublishUser(ctx, userID, event); err != nil {
+		s.logger.Warn("failed to publish user event", "type", event.Type, "user_id", userID, "err", err)
+	}
 }
 
 func (s *MarketplaceService) logTransaction(ctx context.Context, tx *sqlx.Tx, txn *models.Transaction) {
@@ -58,7 +125,33 @@ func (s *MarketplaceService) logTransaction(ctx context.Context, tx *sqlx.Tx, tx
 	}
 }
 
+// transitionOrder moves the order through the status state machine and keeps
+// the in-memory order in sync
+func (s *MarketplaceService) transitionOrder(ctx context.Context, tx *sqlx.Tx, ord *models.Order, status models.OrderStatus, changedBy *uuid.UUID, reason string) error {
+	if err := s.orderRepo.UpdateStatus(ctx, tx, ord.ID, status, changedBy, reason); err != nil {
+		if errors.Is(err, order.ErrInvalidTransition) {
+			s.logger.Warn("invalid order status transition", "order_id", ord.ID, "from", ord.Status, "to", status)
+			return apperrors.NewValidationError(fmt.Sprintf("order in status %s cannot be moved to %s", ord.Status, status))
+		}
+		s.logger.Error("failed to update order status", "error", err, "order_id", ord.ID, "status", status)
+		return apperrors.WrapInternal(err, "failed to update order status")
+	}
+	ord.Status = status
+	ord.UpdatedAt = time.Now()
+	return nil
+}
+
 func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID, skinID uuid.UUID) (*models.Order, error) {
+	ord, err := s.purchaseSkin(ctx, userID, skinID)
+	if err != nil {
+		metrics.PurchaseFailed(err)
+		return nil, err
+	}
+	metrics.PurchaseCompleted(ord.TotalAmount)
+	return ord, nil
+}
+
+func (s *MarketplaceService) purchaseSkin(ctx context.Context, userID uuid.UUID, skinID uuid.UUID) (*models.Order, error) {
 	s.logger.Info("starting skin purchase", "user_id", userID, "skin_id", skinID)
 
 	// Start database transaction
@@ -106,10 +199,38 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 		s.logger.Error("failed to get buyer for update", "error", err, "user_id", userID)
 		return nil, apperrors.WrapInternal(err, "failed to get buyer for update")
 	}
-	if buyer.Balance < skinToPurchase.Price {
-		s.logger.Warn("insufficient funds", "user_id", userID, "balance", buyer.Balance, "required", skinToPurchase.Price)
+
+	// VAT is charged on top of the price, by the buyer's billing details
+	vat := s.taxes.For(stringValue(buyer.BillingCountry), stringValue(buyer.VATID))
+	taxAmount := vat.Amount(skinToPurchase.Price)
+	total := roundCents(skinToPurchase.Price + taxAmount)
+
+	if buyer.Balance < total {
+		s.logger.Warn("insufficient funds", "user_id", userID, "balance", buyer.Balance, "required", total)
 		return nil, apperrors.NewValidationError("insufficient funds")
 	}
+	if err := s.limits.Enforce(ctx, tx, buyer, models.LimitPurchase, total); err != nil {
+		return nil, err
+	}
+
+	orderID := uuid.New()
+	flag, err := s.risk.Assess(ctx, tx, fraud.Event{
+		Kind:             models.RiskPurchase,
+		UserID:           userID,
+		Amount:           total,
+		CounterpartyID:   skinToPurchase.OwnerID,
+		Category:         models.GetGunCategory(skinToPurchase.Gun),
+		AccountCreatedAt: buyer.CreatedAt,
+		At:               time.Now(),
+	}, &orderID)
+	if err != nil {
+		return nil, err
+	}
+	// A risk hold keeps the seller's proceeds pending until the flag is cleared
+	var riskFlagID *uuid.UUID
+	if flag != nil && flag.Action == models.RiskActionHold {
+		riskFlagID = &flag.ID
+	}
 
 	// Capture original buyer balance before any changes
 	originalBuyerBalance := buyer.Balance
@@ -117,6 +238,7 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 	// Step 3: If skin has an owner, get and lock the owner for balance update
 	var owner *models.User
 	var originalOwnerBalance float64
+	var fee fees.Fee
 	if skinToPurchase.OwnerID != nil {
 		owner, err = s.userRepo.GetUserByIdForUpdate(ctx, tx, *skinToPurchase.OwnerID)
 		if err != nil {
@@ -125,17 +247,28 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 		}
 		originalOwnerBalance = owner.Balance
 		s.logger.Info("skin owner locked for payment", "owner_id", owner.ID, "current_balance", owner.Balance)
+
+		// The platform fee is deducted from the seller's proceeds
+		fee = s.fees.Calculate(skinToPurchase.Price, skinToPurchase.Gun, owner.Tier)
+		s.logger.Info("marketplace fee calculated", "skin_id", skinID, "fee", fee.Amount, "percent", fee.Percent, "rule", fee.Rule)
 	}
 
 	// Step 4: Create order record
 	now := time.Now()
 	ord := &models.Order{
-		ID:          uuid.New(),
-		UserID:      userID,
-		TotalAmount: skinToPurchase.Price,
-		Status:      models.OrderStatusPending,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:            orderID,
+		UserID:        userID,
+		TotalAmount:   total,
+		TaxAmount:     taxAmount,
+		TaxRate:       vat.Rate,
+		ReverseCharge: vat.ReverseCharge,
+		BuyerVATID:    buyer.VATID,
+		Status:        models.OrderStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if vat.Country != "" {
+		ord.TaxCountry = &vat.Country
 	}
 
 	if err := s.orderRepo.Create(ctx, tx, ord); err != nil {
@@ -148,7 +281,10 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 		ID:        uuid.New(),
 		OrderID:   ord.ID,
 		SkinID:    skinID,
+		SellerID:  skinToPurchase.OwnerID,
 		Price:     skinToPurchase.Price,
+		Fee:       fee.Amount,
+		Tax:       taxAmount,
 		CreatedAt: now,
 	}
 
@@ -157,27 +293,83 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 		return nil, apperrors.WrapInternal(err, "failed to create order item")
 	}
 
+	if err := s.bookTax(ctx, tx, ord, now); err != nil {
+		return nil, err
+	}
+
 	// Step 6: Update buyer balance (deduct payment)
-	newBuyerBalance := buyer.Balance - skinToPurchase.Price
+	newBuyerBalance := roundCents(buyer.Balance - total)
 	if err := s.userRepo.UpdateBalance(ctx, tx, userID, newBuyerBalance); err != nil {
 		s.logger.Error("failed to update buyer balance", "error", err, "user_id", userID)
 		return nil, apperrors.WrapInternal(err, "failed to update buyer balance")
 	}
 	s.logger.Info("buyer balance updated", "user_id", userID, "old_balance", buyer.Balance, "new_balance", newBuyerBalance)
 
-	// Step 7: If skin has an owner, credit them with the sale amount
+	// Step 7: If skin has an owner, credit them with the sale amount minus the platform fee.
+	// With a trade hold or a risk hold the proceeds land in the pending balance and
+	// only become withdrawable once the hold is released.
 	var newOwnerBalance float64
+	sellerBalanceType := models.BalanceAvailable
 	if owner != nil {
-		newOwnerBalance = owner.Balance + skinToPurchase.Price
-		if err := s.userRepo.UpdateBalance(ctx, tx, owner.ID, newOwnerBalance); err != nil {
+		proceeds := roundCents(skinToPurchase.Price - fee.Amount)
+		if s.tradeHold > 0 || riskFlagID != nil {
+			sellerBalanceType = models.BalancePending
+			originalOwnerBalance = owner.PendingBalance
+			owner.PendingBalance = roundCents(owner.PendingBalance + proceeds)
+			newOwnerBalance = owner.PendingBalance
+
+			balanceHold := &models.BalanceHold{
+				ID:          uuid.New(),
+				UserID:      owner.ID,
+				OrderID:     ord.ID,
+				OrderItemID: &orderItem.ID,
+				Amount:      proceeds,
+				Status:      models.HoldHeld,
+				ReleaseAt:   now.Add(s.tradeHold),
+				RiskFlagID:  riskFlagID,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			if err := s.holdRepo.Create(ctx, tx, balanceHold); err != nil {
+				s.logger.Error("failed to create balance hold", "error", err, "owner_id", owner.ID)
+				return nil, apperrors.WrapInternal(err, "failed to create balance hold")
+			}
+		} else {
+			owner.Balance = roundCents(owner.Balance + proceeds)
+			newOwnerBalance = owner.Balance
+		}
+
+		if err := s.userRepo.UpdateBalances(ctx, tx, owner); err != nil {
 			s.logger.Error("failed to update owner balance", "error", err, "owner_id", owner.ID)
 			return nil, apperrors.WrapInternal(err, "failed to update owner balance")
 		}
-		s.logger.Info("owner credited with sale", "owner_id", owner.ID, "old_balance", owner.Balance, "new_balance", newOwnerBalance, "amount", skinToPurchase.Price)
+		s.logger.Info("owner credited with sale", "owner_id", owner.ID, "balance_type", sellerBalanceType, "old_balance", originalOwnerBalance, "new_balance", newOwnerBalance, "amount", skinToPurchase.Price, "fee", fee.Amount)
+
+		if fee.Amount > 0 {
+			description := fmt.Sprintf("marketplace fee (%s, %.2f%%)", fee.Rule, fee.Percent)
+			entry := &models.LedgerEntry{
+				ID:          uuid.New(),
+				OrderID:     &ord.ID,
+				OrderItemID: &orderItem.ID,
+				Type:        models.LedgerFee,
+				Amount:      fee.Amount,
+				Description: &description,
+				CreatedAt:   now,
+			}
+			if err := s.ledgerRepo.Create(ctx, tx, entry); err != nil {
+				s.logger.Error("failed to record platform fee", "error", err, "order_id", ord.ID)
+				return nil, apperrors.WrapInternal(err, "failed to record platform fee")
+			}
+		}
 	} else {
 		s.logger.Info("no owner to credit - market-created skin", "skin_id", skinID)
 	}
 
+	// Payment has moved, the order is paid
+	if err := s.transitionOrder(ctx, tx, ord, models.OrderStatusPaid, nil, ""); err != nil {
+		return nil, err
+	}
+
 	// Step 8: Mark skin as not available (remove from listing) and transfer ownership
 	if err := s.skinRepo.UpdateAvailability(ctx, tx, skinID, false); err != nil {
 		s.logger.Error("failed to update skin availability", "error", err, "skin_id", skinID)
@@ -189,11 +381,9 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 		return nil, apperrors.WrapInternal(err, "failed to update skin ownership")
 	}
 
-	// Step 9: Update order status to completed
-	ord.Status = models.OrderStatusCompleted
-	if err := s.orderRepo.UpdateStatus(ctx, tx, ord.ID, models.OrderStatusCompleted); err != nil {
-		s.logger.Error("failed to update order status", "error", err, "order_id", ord.ID)
-		return nil, apperrors.WrapInternal(err, "failed to update order status")
+	// Step 9: Skin delivered, the order is complete
+	if err := s.transitionOrder(ctx, tx, ord, models.OrderStatusCompleted, nil, ""); err != nil {
+		return nil, err
 	}
 
 	transactionTime := time.Now()
@@ -202,7 +392,7 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 	buyerTransaction := &models.Transaction{
 		ID:             uuid.New(),
 		UserID:         userID,
-		Amount:         -skinToPurchase.Price, // negative for debit
+		Amount:         -total, // negative for debit, tax included
 		Type:           models.Purchase,
 		BalanceBefore:  originalBuyerBalance,
 		BalanceAfter:   newBuyerBalance,
@@ -219,15 +409,17 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 
 	s.logTransaction(ctx, tx, buyerTransaction)
 
-	// Create seller transaction record (credit) if owner exists
+	// Create seller transaction records (sale credit, then fee debit) if owner exists
 	if owner != nil {
+		balanceAfterSale := roundCents(originalOwnerBalance + skinToPurchase.Price)
 		sellerTransaction := &models.Transaction{
 			ID:             uuid.New(),
 			UserID:         owner.ID,
 			Amount:         skinToPurchase.Price, // positive for credit
 			Type:           models.Sale,
 			BalanceBefore:  originalOwnerBalance,
-			BalanceAfter:   newOwnerBalance,
+			BalanceAfter:   balanceAfterSale,
+			BalanceType:    sellerBalanceType,
 			SkinID:         &skinID,
 			OrderID:        &ord.ID,
 			CounterpartyID: &userID,
@@ -235,6 +427,23 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 		}
 
 		s.logTransaction(ctx, tx, sellerTransaction)
+
+		if fee.Amount > 0 {
+			feeTransaction := &models.Transaction{
+				ID:            uuid.New(),
+				UserID:        owner.ID,
+				Amount:        -fee.Amount, // negative for debit
+				Type:          models.Fee,
+				BalanceBefore: balanceAfterSale,
+				BalanceAfter:  newOwnerBalance,
+				BalanceType:   sellerBalanceType,
+				SkinID:        &skinID,
+				OrderID:       &ord.ID,
+				CreatedAt:     transactionTime,
+			}
+
+			s.logTransaction(ctx, tx, feeTransaction)
+		}
 	}
 
 	// Commit transaction
@@ -244,50 +453,80 @@ func (s *MarketplaceService) PurchaseSkin(ctx context.Context, userID uuid.UUID,
 	}
 
 	// Enqueue send-invoice task after commit
-	task, err := jobs.NewSendInvoiceTask(ord.ID, orderItem.ID, buyer.Email)
+	task, err := jobs.NewSendInvoiceTask(ord.ID, buyer.Email)
 	if err != nil {
 		s.logger.Warn("failed to create send-invoice task", "err", err)
 	} else {
-		if _, err := s.emailQueue.Enqueue(task, asynq.Queue("default")); err != nil {
+		if _, err := s.emailQueue.Enqueue(task, asynq.TaskID(jobs.InvoiceTaskID(ord.ID))); err != nil {
 			s.logger.Warn("failed to enqueue send-invoice task", "err", err)
 		} else {
 			s.logger.Info("send-invoice task enqueued", "order_id", ord.ID, "order_item_id", orderItem.ID, "to", buyer.Email)
 		}
 	}
 
+	// Feed the sale into the price history aggregates
+	salesTask, err := jobs.NewRecordMarketSalesTask(ord.ID)
+	if err != nil {
+		s.logger.Warn("failed to create record-market-sales task", "err", err)
+	} else if _, err := s.emailQueue.Enqueue(salesTask, asynq.TaskID(jobs.MarketSalesTaskID(ord.ID))); err != nil {
+		s.logger.Warn("failed to enqueue record-market-sales task", "err", err)
+	}
+
+	skinToPurchase.OwnerID = &userID
+	skinToPurchase.Available = false
+	s.publishMarket(ctx, realtime.NewSkinEvent(realtime.EventSkinSold, skinToPurchase))
+	s.publishUser(ctx, userID, realtime.NewBalanceEvent(newBuyerBalance))
+	s.publishUser(ctx, userID, realtime.NewOrderEvent(ord))
+	if owner != nil {
+		s.publishUser(ctx, owner.ID, realtime.NewBalancesEvent(owner.Balance, owner.PendingBalance))
+	}
+
 	s.logger.Info("skin purchase completed successfully",
 		"user_id", userID,
 		"skin_id", skinID,
 		"order_id", ord.ID,
 		"amount", skinToPurchase.Price,
+		"tax", taxAmount,
+		"fee", fee.Amount,
 		"owner_credited", owner != nil)
 
 	return ord, nil
 }
 
-// SellSkin allows users to list their owned skins for sale on the marketplace
-func (s *MarketplaceService) SellSkin(ctx context.Context, userID uuid.UUID, skinID uuid.UUID, price float64) error {
+// SellSkin allows users to list their owned skins for sale on the marketplace.
+// With autoPrice the price argument is ignored and the suggested market price
+// is used instead; otherwise the result warns when the price looks like a typo.
+// A listing with durationDays, one of models.ListingDurations, expires after
+// that many days, 0 keeps it listed until it is sold or removed.
+func (s *MarketplaceService) SellSkin(ctx context.Context, userID uuid.UUID, skinID uuid.UUID, price float64, autoPrice bool, durationDays int) (*models.ListingResult, error) {
 	s.logger.Info("starting skin listing for sale",
 		"user_id", userID,
 		"skin_id", skinID,
-		"price", price)
+		"price", price,
+		"auto_price", autoPrice,
+		"duration_days", durationDays)
 
-	// Validate price
-	if price <= 0 {
-		s.logger.Warn("invalid price for skin listing", "user_id", userID, "skin_id", skinID, "price", price)
-		return apperrors.NewValidationError("price must be greater than 0")
+	if durationDays != 0 && !slices.Contains(models.ListingDurations, durationDays) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("duration_days must be one of %v", models.ListingDurations))
 	}
 
-	const maxPrice = 1000000.0
-	if price > maxPrice {
-		s.logger.Warn("price exceeds maximum allowed", "user_id", userID, "skin_id", skinID, "price", price)
-		return apperrors.NewValidationError(fmt.Sprintf("price cannot exceed %.2f", maxPrice))
+	// Validate price
+	if !autoPrice {
+		if price <= 0 {
+			s.logger.Warn("invalid price for skin listing", "user_id", userID, "skin_id", skinID, "price", price)
+			return nil, apperrors.NewValidationError("price must be greater than 0")
+		}
+
+		if price > maxListingPrice {
+			s.logger.Warn("price exceeds maximum allowed", "user_id", userID, "skin_id", skinID, "price", price)
+			return nil, apperrors.NewValidationError(fmt.Sprintf("price cannot exceed %.2f", maxListingPrice))
+		}
 	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		s.logger.Error("failed to begin transaction", "error", err)
-		return apperrors.WrapInternal(err, "failed to begin transaction")
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
 	}
 
 	defer func(tx *sqlx.Tx) {
@@ -299,12 +538,12 @@ func (s *MarketplaceService) SellSkin(ctx context.Context, userID uuid.UUID, ski
 	skins, err := s.skinRepo.GetSkinsForSellUpdate(ctx, tx, []uuid.UUID{skinID})
 	if err != nil {
 		s.logger.Error("failed to get skin for update", "error", err, "skin_id", skinID)
-		return apperrors.WrapInternal(err, "failed to get skin for update")
+		return nil, apperrors.WrapInternal(err, "failed to get skin for update")
 	}
 
 	if len(skins) == 0 {
 		s.logger.Warn("skin not found", "skin_id", skinID)
-		return apperrors.NewNotFoundError("skin not found")
+		return nil, apperrors.NewNotFoundError("skin not found")
 	}
 
 	skinToSell := skins[0]
@@ -314,15 +553,17 @@ func (s *MarketplaceService) SellSkin(ctx context.Context, userID uuid.UUID, ski
 			"user_id", userID,
 			"skin_id", skinID,
 			"actual_owner", skinToSell.OwnerID)
-		return apperrors.NewForbiddenError("you can only sell skins you own")
+		return nil, apperrors.NewForbiddenError("you can only sell skins you own")
 	}
 
-	if skinToSell.Available {
+	// A listing that ran out counts as delisted even before the expire-listings
+	// job gets to it
+	if skinToSell.Available && !skinToSell.ListingExpired(time.Now()) {
 		s.logger.Warn("skin is already listed for sale",
 			"user_id", userID,
 			"skin_id", skinID,
 			"current_price", skinToSell.Price)
-		return apperrors.NewValidationError("skin is already listed for sale")
+		return nil, apperrors.NewValidationError("skin is already listed for sale")
 	}
 
 	s.logger.Info("skin ownership verified",
@@ -331,26 +572,183 @@ func (s *MarketplaceService) SellSkin(ctx context.Context, userID uuid.UUID, ski
 		"current_price", skinToSell.Price,
 		"new_price", price)
 
+	result := &models.ListingResult{SkinID: skinID}
+
+	suggestion, err := s.pricing.SuggestPrice(ctx, skinToSell)
+	if err != nil {
+		if autoPrice {
+			return nil, err
+		}
+		// The suggestion only feeds a warning for manual prices, so don't block the listing
+		s.logger.Warn("failed to compute price suggestion", "skin_id", skinID, "error", err)
+	}
+	result.Suggestion = suggestion
+
+	if autoPrice {
+		if suggestion == nil {
+			s.logger.Warn("no market data for auto price", "skin_id", skinID)
+			return nil, apperrors.NewValidationError("not enough market data to auto price this skin, please set a price")
+		}
+		price = suggestion.SuggestedPrice
+		s.logger.Info("using suggested price", "skin_id", skinID, "price", price, "confidence", suggestion.Confidence)
+	} else if warning := priceDeviationWarning(price, suggestion); warning != "" {
+		s.logger.Warn("listing price deviates from market", "skin_id", skinID, "price", price, "suggested", suggestion.SuggestedPrice)
+		result.Warnings = append(result.Warnings, warning)
+	}
+	result.Price = price
+
+	seller, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get seller", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to get seller")
+	}
+	if seller == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	// Listings move no money yet, so a hold is reviewed like a flag
+	if _, err := s.risk.Assess(ctx, tx, fraud.Event{
+		Kind:             models.RiskSell,
+		UserID:           userID,
+		Amount:           price,
+		Category:         models.GetGunCategory(skinToSell.Gun),
+		AccountCreatedAt: seller.CreatedAt,
+		At:               time.Now(),
+	}, &skinID); err != nil {
+		return nil, err
+	}
+
 	if err := s.skinRepo.UpdatePrice(ctx, tx, skinID, price); err != nil {
 		s.logger.Error("failed to update skin price", "error", err, "skin_id", skinID)
-		return apperrors.WrapInternal(err, "failed to update skin price")
+		return nil, apperrors.WrapInternal(err, "failed to update skin price")
 	}
 
 	if err := s.skinRepo.UpdateAvailability(ctx, tx, skinID, true); err != nil {
 		s.logger.Error("failed to update skin availability", "error", err, "skin_id", skinID)
-		return apperrors.WrapInternal(err, "failed to update skin availability")
+		return nil, apperrors.WrapInternal(err, "failed to update skin availability")
+	}
+
+	// Always written so an earlier listing's expiry doesn't carry over
+	var days *int
+	var expiresAt *time.Time
+	if durationDays != 0 {
+		end := time.Now().AddDate(0, 0, durationDays)
+		days, expiresAt = &durationDays, &end
+	}
+	if err := s.skinRepo.SetListingExpiry(ctx, tx, skinID, days, expiresAt); err != nil {
+		s.logger.Error("failed to set listing expiry", "error", err, "skin_id", skinID)
+		return nil, apperrors.WrapInternal(err, "failed to set listing expiry")
+	}
+	result.ExpiresAt = expiresAt
+
+	// Kept for the sell risk rules, the skin only shows the current listing
+	listing := &models.Listing{ID: uuid.New(), UserID: userID, SkinID: skinID, Price: price, CreatedAt: time.Now()}
+	if err := s.skinRepo.RecordListing(ctx, tx, listing); err != nil {
+		s.logger.Error("failed to record listing", "error", err, "skin_id", skinID)
+		return nil, apperrors.WrapInternal(err, "failed to record listing")
 	}
 
 	if err := tx.Commit(); err != nil {
 		s.logger.Error("failed to commit transaction", "error", err, "skin_id", skinID)
-		return apperrors.WrapInternal(err, "failed to commit transaction")
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
 	}
 
+	skinToSell.Price = price
+	skinToSell.Available = true
+	skinToSell.ListingDays = days
+	skinToSell.ExpiresAt = expiresAt
+	s.publishMarket(ctx, realtime.NewSkinEvent(realtime.EventListingCreated, skinToSell))
+
 	s.logger.Info("skin listed for sale successfully",
 		"user_id", userID,
 		"skin_id", skinID,
 		"price", price)
 
+	return result, nil
+}
+
+// RelistSkin lists a skin whose listing expired again in one go, at its
+// previous price or with autoPrice at a refreshed market suggestion. Without
+// durationDays the previous listing's duration is used.
+func (s *MarketplaceService) RelistSkin(ctx context.Context, userID uuid.UUID, skinID uuid.UUID, autoPrice bool, durationDays *int) (*models.ListingResult, error) {
+	expired, err := s.skinRepo.GetSkin(ctx, skinID)
+	if err != nil {
+		s.logger.Error("failed to get skin", "error", err, "skin_id", skinID)
+		return nil, apperrors.WrapInternal(err, "failed to get skin")
+	}
+	if expired == nil {
+		return nil, apperrors.NewNotFoundError("skin not found")
+	}
+	if expired.OwnerID == nil || *expired.OwnerID != userID {
+		return nil, apperrors.NewForbiddenError("you can only relist your own skins")
+	}
+	if !expired.ListingExpired(time.Now()) {
+		return nil, apperrors.NewValidationError("only expired listings can be relisted")
+	}
+
+	days := 0
+	if durationDays != nil {
+		days = *durationDays
+	} else if expired.ListingDays != nil {
+		days = *expired.ListingDays
+	}
+	// SellSkin checks ownership and availability again under the row lock
+	return s.SellSkin(ctx, userID, skinID, expired.Price, autoPrice, days)
+}
+
+// UpdateListingPrice changes the price of a skin that is currently listed for sale
+func (s *MarketplaceService) UpdateListingPrice(ctx context.Context, userID uuid.UUID, skinID uuid.UUID, price float64) error {
+	s.logger.Info("starting listing price update", "user_id", userID, "skin_id", skinID, "price", price)
+
+	if price <= 0 {
+		return apperrors.NewValidationError("price must be greater than 0")
+	}
+	if price > maxListingPrice {
+		return apperrors.NewValidationError(fmt.Sprintf("price cannot exceed %.2f", maxListingPrice))
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	skins, err := s.skinRepo.GetSkinsForUpdate(ctx, tx, []uuid.UUID{skinID})
+	if err != nil {
+		s.logger.Error("failed to get skin for update", "error", err, "skin_id", skinID)
+		return apperrors.WrapInternal(err, "failed to get skin for update")
+	}
+	if len(skins) == 0 {
+		s.logger.Warn("listed skin not found", "skin_id", skinID)
+		return apperrors.NewNotFoundError("skin not found or not listed")
+	}
+
+	listed := skins[0]
+	if listed.OwnerID == nil || *listed.OwnerID != userID {
+		s.logger.Warn("user doesn't own this skin", "user_id", userID, "skin_id", skinID, "actual_owner", listed.OwnerID)
+		return apperrors.NewForbiddenError("you can only reprice your own listings")
+	}
+
+	if err := s.skinRepo.UpdatePrice(ctx, tx, skinID, price); err != nil {
+		s.logger.Error("failed to update skin price", "error", err, "skin_id", skinID)
+		return apperrors.WrapInternal(err, "failed to update skin price")
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "skin_id", skinID)
+		return apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	oldPrice := listed.Price
+	listed.Price = price
+	s.publishMarket(ctx, realtime.NewPriceChangedEvent(listed, oldPrice))
+
+	s.logger.Info("listing price updated successfully", "user_id", userID, "skin_id", skinID, "old_price", oldPrice, "new_price", price)
 	return nil
 }
 
@@ -369,7 +767,7 @@ func (s *MarketplaceService) RemoveSkinFromListing(ctx context.Context, userID u
 		}
 	}(tx)
 
-	skins, err := s.skinRepo.GetSkinsForUpdate(ctx, tx, []uuid.UUID{skinID})
+	skins, err := s.skinRepo.GetSkinsForSellUpdate(ctx, tx, []uuid.UUID{skinID})
 	if err != nil {
 		s.logger.Error("failed to get skin for update", "error", err, "skin_id", skinID)
 		return apperrors.WrapInternal(err, "failed to get skin for update")
@@ -386,7 +784,7 @@ func (s *MarketplaceService) RemoveSkinFromListing(ctx context.Context, userID u
 		return apperrors.NewForbiddenError("you can only remove your own skins from listing")
 	}
 
-	if !skinToRemove.Available {
+	if !skinToRemove.Available || skinToRemove.ListingExpired(time.Now()) {
 		s.logger.Warn("skin is not currently listed", "skin_id", skinID)
 		return apperrors.NewValidationError("skin is not currently listed for sale")
 	}
@@ -395,12 +793,20 @@ func (s *MarketplaceService) RemoveSkinFromListing(ctx context.Context, userID u
 		s.logger.Error("failed to update skin availability", "error", err, "skin_id", skinID)
 		return apperrors.WrapInternal(err, "failed to update skin availability")
 	}
+	// A listing taken down by its owner didn't expire
+	if err := s.skinRepo.SetListingExpiry(ctx, tx, skinToRemove.ID, nil, nil); err != nil {
+		s.logger.Error("failed to clear listing expiry", "error", err, "skin_id", skinID)
+		return apperrors.WrapInternal(err, "failed to clear listing expiry")
+	}
 
 	if err := tx.Commit(); err != nil {
 		s.logger.Error("failed to commit transaction", "error", err, "skin_id", skinID)
 		return apperrors.WrapInternal(err, "failed to commit transaction")
 	}
 
+	skinToRemove.Available = false
+	s.publishMarket(ctx, realtime.NewSkinEvent(realtime.EventListingRemoved, skinToRemove))
+
 	s.logger.Info("skin removed from listing successfully", "user_id", userID, "skin_id", skinID)
 	return nil
 }
@@ -434,3 +840,385 @@ func (s *MarketplaceService) GetOrder(ctx context.Context, orderID uuid.UUID) (*
 	}
 	return ord, nil
 }
+
+// GetOrderHistory returns the status changes of an order, oldest first
+func (s *MarketplaceService) GetOrderHistory(ctx context.Context, orderID uuid.UUID) ([]*models.OrderStatusChange, error) {
+	ord, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.orderRepo.GetStatusHistory(ctx, ord.ID)
+	if err != nil {
+		s.logger.Error("failed to get order status history", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order status history")
+	}
+	return history, nil
+}
+
+// RefundOrder undoes a purchase: the buyer gets the full amount back, each
+// seller is debited what they were credited (price minus fee), the platform
+// fee is reversed and the skins go back to their sellers (or back on the
+// market for skins that had no seller).
+func (s *MarketplaceService) RefundOrder(ctx context.Context, adminID uuid.UUID, orderID uuid.UUID, reason string) (*models.Order, error) {
+	s.logger.Info("starting order refund", "admin_id", adminID, "order_id", orderID)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	ord, err := s.orderRepo.GetOrderByIDForUpdate(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order for update", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order for update")
+	}
+	if ord == nil {
+		return nil, apperrors.NewNotFoundError("order not found")
+	}
+
+	res, err := s.refundLocked(ctx, tx, ord, adminID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.publishRefund(ctx, ord, res)
+	s.queueCreditNote(ctx, res.refund, reason)
+
+	s.logger.Info("order refunded successfully",
+		"admin_id", adminID,
+		"order_id", orderID,
+		"amount", ord.TotalAmount)
+
+	return ord, nil
+}
+
+// refundResult carries what changed during a refund so events can be
+// published once the transaction is committed
+type refundResult struct {
+	accounts map[uuid.UUID]*models.User
+	released []*models.Skin
+	// refund is the buyer's refund transaction
+	refund *models.Transaction
+}
+
+// refundLocked performs the refund of an order locked by the caller inside tx
+func (s *MarketplaceService) refundLocked(ctx context.Context, tx *sqlx.Tx, ord *models.Order, adminID uuid.UUID, reason string) (*refundResult, error) {
+	orderID := ord.ID
+	if !ord.Status.CanTransitionTo(models.OrderStatusRefunded) {
+		s.logger.Warn("order cannot be refunded", "order_id", orderID, "status", ord.Status)
+		return nil, apperrors.NewValidationError(fmt.Sprintf("order in status %s cannot be refunded", ord.Status))
+	}
+
+	items, err := s.orderRepo.GetOrderItems(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get order items", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get order items")
+	}
+
+	// Proceeds still in their trade hold are taken back from the pending balance.
+	// Holds are locked before the accounts, like the hold release job does.
+	activeHolds, err := s.holdRepo.GetActiveByOrder(ctx, tx, orderID)
+	if err != nil {
+		s.logger.Error("failed to get balance holds", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get balance holds")
+	}
+	holdsByItem := make(map[uuid.UUID]*models.BalanceHold, len(activeHolds))
+	for _, h := range activeHolds {
+		if h.OrderItemID != nil {
+			holdsByItem[*h.OrderItemID] = h
+		}
+	}
+
+	// The skins must still be with the buyer, otherwise there is nothing to return
+	skinIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		skinIDs = append(skinIDs, item.SkinID)
+	}
+	skins, err := s.skinRepo.GetSkinsForSellUpdate(ctx, tx, skinIDs)
+	if err != nil {
+		s.logger.Error("failed to get skins for update", "error", err, "order_id", orderID)
+		return nil, apperrors.WrapInternal(err, "failed to get skins for update")
+	}
+	skinsByID := make(map[uuid.UUID]*models.Skin, len(skins))
+	for _, sk := range skins {
+		if sk.OwnerID == nil || *sk.OwnerID != ord.UserID {
+			s.logger.Warn("refunded skin no longer owned by buyer", "order_id", orderID, "skin_id", sk.ID)
+			return nil, apperrors.NewValidationError("a skin from this order is no longer owned by the buyer")
+		}
+		skinsByID[sk.ID] = sk
+	}
+
+	// Lock every affected account once, buyer first like in PurchaseSkin
+	accounts := make(map[uuid.UUID]*models.User)
+	lockUser := func(id uuid.UUID) (*models.User, error) {
+		if u, ok := accounts[id]; ok {
+			return u, nil
+		}
+		u, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, id)
+		if err != nil {
+			s.logger.Error("failed to get user for update", "error", err, "user_id", id)
+			return nil, apperrors.WrapInternal(err, "failed to get user for update")
+		}
+		accounts[id] = u
+		return u, nil
+	}
+
+	buyer, err := lockUser(ord.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	refundDescription := "refund: " + reason
+	var transactions []*models.Transaction
+	var released []*models.Skin
+	// Partial refunds are paid by the order's seller, except for their VAT
+	// share which the platform gives back
+	sellerPaid := roundCents(ord.RefundedAmount - refundTax(ord, ord.RefundedAmount))
+
+	for _, item := range items {
+		itemID := item.ID
+		skinID := item.SkinID
+
+		if item.SellerID != nil {
+			seller, err := lockUser(*item.SellerID)
+			if err != nil {
+				return nil, err
+			}
+
+			newReversal := func() *models.Transaction {
+				return &models.Transaction{
+					ID:             uuid.New(),
+					UserID:         seller.ID,
+					Type:           models.Reversal,
+					SkinID:         &skinID,
+					OrderID:        &ord.ID,
+					CounterpartyID: &buyer.ID,
+					Description:    &refundDescription,
+					CreatedAt:      now,
+				}
+			}
+
+			// The seller paid the partial refunds, out of the hold when it was
+			// large enough and out of the available balance otherwise, so only
+			// the rest of the proceeds is taken back. More can have been paid than
+			// the hold still covers, the difference is then returned.
+			charge := roundCents(item.Price - item.Fee - sellerPaid)
+			sellerPaid = 0
+			if h, ok := holdsByItem[item.ID]; ok {
+				reversal := newReversal()
+				reversal.Amount = -h.Amount
+				reversal.BalanceType = models.BalancePending
+				reversal.BalanceBefore = seller.PendingBalance
+				seller.PendingBalance = roundCents(max(0, seller.PendingBalance-h.Amount))
+				reversal.BalanceAfter = seller.PendingBalance
+				transactions = append(transactions, reversal)
+				charge = roundCents(charge - h.Amount)
+
+				h.Status = models.HoldCancelled
+				if err := s.holdRepo.Update(ctx, tx, h); err != nil {
+					s.logger.Error("failed to cancel balance hold", "error", err, "hold_id", h.ID)
+					return nil, apperrors.WrapInternal(err, "failed to cancel balance hold")
+				}
+			}
+			if charge != 0 {
+				if seller.Balance < charge {
+					s.logger.Warn("seller cannot cover refund", "order_id", orderID, "seller_id", seller.ID, "balance", seller.Balance, "required", charge)
+					return nil, apperrors.NewValidationError("seller balance is too low to reverse the sale")
+				}
+				reversal := newReversal()
+				reversal.Amount = -charge
+				reversal.BalanceType = models.BalanceAvailable
+				reversal.BalanceBefore = seller.Balance
+				seller.Balance = roundCents(seller.Balance - charge)
+				reversal.BalanceAfter = seller.Balance
+				transactions = append(transactions, reversal)
+			}
+
+			if item.Fee > 0 {
+				description := "marketplace fee reversal (order refunded)"
+				entry := &models.LedgerEntry{
+					ID:          uuid.New(),
+					OrderID:     &ord.ID,
+					OrderItemID: &itemID,
+					Type:        models.LedgerFee,
+					Amount:      -item.Fee,
+					Description: &description,
+					CreatedAt:   now,
+				}
+				if err := s.ledgerRepo.Create(ctx, tx, entry); err != nil {
+					s.logger.Error("failed to reverse platform fee", "error", err, "order_id", orderID)
+					return nil, apperrors.WrapInternal(err, "failed to reverse platform fee")
+				}
+			}
+
+			if err := s.skinRepo.UpdateOwnership(ctx, tx, []uuid.UUID{skinID}, seller.ID); err != nil {
+				s.logger.Error("failed to return skin to seller", "error", err, "skin_id", skinID)
+				return nil, apperrors.WrapInternal(err, "failed to return skin to seller")
+			}
+		} else {
+			if err := s.skinRepo.ReleaseToMarket(ctx, tx, skinID); err != nil {
+				s.logger.Error("failed to release skin to market", "error", err, "skin_id", skinID)
+				return nil, apperrors.WrapInternal(err, "failed to release skin to market")
+			}
+			if sk, ok := skinsByID[skinID]; ok {
+				sk.OwnerID = nil
+				sk.Available = true
+				released = append(released, sk)
+			}
+		}
+	}
+
+	// Partial refunds already paid back part of the order
+	amount := roundCents(ord.TotalAmount - ord.RefundedAmount)
+	before := buyer.Balance
+	buyer.Balance = roundCents(buyer.Balance + amount)
+	buyerTransaction := &models.Transaction{
+		ID:            uuid.New(),
+		UserID:        buyer.ID,
+		Amount:        amount,
+		Type:          models.Refund,
+		BalanceBefore: before,
+		BalanceAfter:  buyer.Balance,
+		OrderID:       &ord.ID,
+		Description:   &refundDescription,
+		CreatedAt:     now,
+	}
+	if len(items) == 1 {
+		buyerTransaction.SkinID = &items[0].SkinID
+		buyerTransaction.CounterpartyID = items[0].SellerID
+	}
+	transactions = append([]*models.Transaction{buyerTransaction}, transactions...)
+
+	for _, u := range accounts {
+		if err := s.userRepo.UpdateBalances(ctx, tx, u); err != nil {
+			s.logger.Error("failed to update balance", "error", err, "user_id", u.ID)
+			return nil, apperrors.WrapInternal(err, "failed to update balance")
+		}
+	}
+	for _, txn := range transactions {
+		s.logTransaction(ctx, tx, txn)
+	}
+
+	if err := s.recordRefund(ctx, tx, ord, amount, now); err != nil {
+		return nil, err
+	}
+	// A refunded sale didn't happen as far as prices are concerned
+	if err := s.marketData.ExcludeOrderSales(ctx, tx, orderID); err != nil {
+		return nil, err
+	}
+
+	if err := s.transitionOrder(ctx, tx, ord, models.OrderStatusRefunded, &adminID, reason); err != nil {
+		return nil, err
+	}
+
+	return &refundResult{accounts: accounts, released: released, refund: buyerTransaction}, nil
+}
+
+// bookTax records the order's VAT in the tax ledger. Orders outside the tax
+// rules are not booked.
+func (s *MarketplaceService) bookTax(ctx context.Context, tx *sqlx.Tx, ord *models.Order, at time.Time) error {
+	if ord.TaxCountry == nil {
+		return nil
+	}
+	return s.createTaxEntry(ctx, tx, ord, roundCents(ord.NetAmount()), ord.TaxAmount, at)
+}
+
+// recordRefund adds a refund of amount, tax included, to the order's refunded
+// amount and reverses its VAT share
+func (s *MarketplaceService) recordRefund(ctx context.Context, tx *sqlx.Tx, ord *models.Order, amount float64, at time.Time) error {
+	if err := s.reverseTax(ctx, tx, ord, amount, at); err != nil {
+		return err
+	}
+	if err := s.orderRepo.AddRefundedAmount(ctx, tx, ord.ID, amount); err != nil {
+		s.logger.Error("failed to record refunded amount", "error", err, "order_id", ord.ID)
+		return apperrors.WrapInternal(err, "failed to record refunded amount")
+	}
+	ord.RefundedAmount = roundCents(ord.RefundedAmount + amount)
+	return nil
+}
+
+// reverseTax books the VAT share of a refund of amount, tax included, with
+// negative amounts. The tax is reversed in proportion to the order total, on
+// top of what earlier refunds reversed, so partial refunds followed by a
+// full one reverse exactly the VAT that was booked.
+func (s *MarketplaceService) reverseTax(ctx context.Context, tx *sqlx.Tx, ord *models.Order, amount float64, at time.Time) error {
+	if ord.TaxCountry == nil || ord.TotalAmount <= 0 {
+		return nil
+	}
+	taxAmount := refundTaxShare(ord, amount)
+	return s.createTaxEntry(ctx, tx, ord, -roundCents(amount-taxAmount), -taxAmount, at)
+}
+
+// refundTax is the VAT included in refunds of amount in total
+func refundTax(ord *models.Order, amount float64) float64 {
+	if ord.TaxCountry == nil || ord.TotalAmount <= 0 {
+		return 0
+	}
+	return roundCents(ord.TaxAmount * amount / ord.TotalAmount)
+}
+
+// refundTaxShare is the VAT included in a new refund of amount, on top of
+// what the order's earlier refunds included
+func refundTaxShare(ord *models.Order, amount float64) float64 {
+	return roundCents(refundTax(ord, ord.RefundedAmount+amount) - refundTax(ord, ord.RefundedAmount))
+}
+
+func (s *MarketplaceService) createTaxEntry(ctx context.Context, tx *sqlx.Tx, ord *models.Order, netAmount, taxAmount float64, at time.Time) error {
+	entry := &models.TaxEntry{
+		ID:            uuid.New(),
+		OrderID:       ord.ID,
+		Country:       *ord.TaxCountry,
+		Rate:          ord.TaxRate,
+		ReverseCharge: ord.ReverseCharge,
+		NetAmount:     netAmount,
+		TaxAmount:     taxAmount,
+		CreatedAt:     at,
+	}
+	if err := s.taxLedger.Create(ctx, tx, entry); err != nil {
+		s.logger.Error("failed to book tax", "error", err, "order_id", ord.ID)
+		return apperrors.WrapInternal(err, "failed to book tax")
+	}
+	return nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// queueCreditNote queues the credit note of a committed refund. Each refund
+// transaction gets one credit note.
+func (s *MarketplaceService) queueCreditNote(ctx context.Context, refund *models.Transaction, reason string) {
+	task, err := jobs.NewSendCreditNoteTask(*refund.OrderID, refund.ID, refund.Amount, reason)
+	if err != nil {
+		s.logger.Warn("failed to create credit note task", "err", err, "transaction_id", refund.ID)
+		return
+	}
+	if _, err := s.emailQueue.EnqueueContext(ctx, task, asynq.TaskID(jobs.CreditNoteTaskID(refund.ID))); err != nil {
+		s.logger.Warn("failed to enqueue credit note task", "err", err, "order_id", *refund.OrderID)
+	}
+}
+
+func (s *MarketplaceService) publishRefund(ctx context.Context, ord *models.Order, res *refundResult) {
+	for _, u := range res.accounts {
+		s.publishUser(ctx, u.ID, realtime.NewBalancesEvent(u.Balance, u.PendingBalance))
+	}
+	s.publishUser(ctx, ord.UserID, realtime.NewOrderEvent(ord))
+	for _, sk := range res.released {
+		s.publishMarket(ctx, realtime.NewSkinEvent(realtime.EventListingCreated, sk))
+	}
+}
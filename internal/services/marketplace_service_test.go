@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrderStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    models.OrderStatus
+		to      models.OrderStatus
+		allowed bool
+	}{
+		{models.OrderStatusPending, models.OrderStatusPaid, true},
+		{models.OrderStatusPending, models.OrderStatusCompleted, false},
+		{models.OrderStatusPaid, models.OrderStatusCompleted, true},
+		{models.OrderStatusPaid, models.OrderStatusRefunded, true},
+		{models.OrderStatusCompleted, models.OrderStatusRefunded, true},
+		{models.OrderStatusCompleted, models.OrderStatusDisputed, true},
+		{models.OrderStatusCompleted, models.OrderStatusPending, false},
+		{models.OrderStatusDisputed, models.OrderStatusCompleted, true},
+		{models.OrderStatusDisputed, models.OrderStatusRefunded, true},
+		{models.OrderStatusRefunded, models.OrderStatusCompleted, false},
+		{models.OrderStatusRefunded, models.OrderStatusDisputed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestMarketplaceService_SellSkin_InvalidDuration(t *testing.T) {
+	repo := new(MockSkinRepository)
+	svc := &MarketplaceService{skinRepo: repo, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	_, err := svc.SellSkin(context.Background(), uuid.New(), uuid.New(), 10, false, 3)
+
+	assertAppErrorCode(t, err, apperrors.CodeValidation)
+	repo.AssertNotCalled(t, "GetSkinsForSellUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMarketplaceService_RelistSkin(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	expiredAt := time.Now().Add(-time.Hour)
+	endsAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		skin *models.Skin
+		code apperrors.ErrorCode
+	}{
+		{"skin not found", nil, apperrors.CodeNotFound},
+		{"someone else's skin", &models.Skin{OwnerID: &otherID, ExpiresAt: &expiredAt}, apperrors.CodeForbidden},
+		{"still listed", &models.Skin{OwnerID: &userID, Available: true, ExpiresAt: &endsAt}, apperrors.CodeValidation},
+		{"removed by its owner", &models.Skin{OwnerID: &userID}, apperrors.CodeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockSkinRepository)
+			skinID := uuid.New()
+			repo.On("GetSkin", mock.Anything, skinID).Return(tt.skin, nil)
+			svc := &MarketplaceService{skinRepo: repo, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			_, err := svc.RelistSkin(context.Background(), userID, skinID, false, nil)
+
+			assertAppErrorCode(t, err, tt.code)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+// MockOrderRepository is a mock implementation of order.Repository
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) Create(ctx context.Context, tx *sqlx.Tx, order *models.Order) error {
+	args := m.Called(ctx, tx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) CreateOrderItem(ctx context.Context, tx *sqlx.Tx, orderItem *models.OrderItem) error {
+	args := m.Called(ctx, tx, orderItem)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrderByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderItemByID(ctx context.Context, id uuid.UUID) (*models.OrderItem, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrderItem), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderItems(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.OrderItem, error) {
+	args := m.Called(ctx, tx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OrderItem), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Order, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status models.OrderStatus, changedBy *uuid.UUID, reason string) error {
+	args := m.Called(ctx, tx, id, status, changedBy, reason)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*models.OrderStatusChange, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OrderStatusChange), args.Error(1)
+}
+
+func (m *MockOrderRepository) AddRefundedAmount(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, amount float64) error {
+	args := m.Called(ctx, tx, id, amount)
+	return args.Error(0)
+}
+
+// MockHoldRepository is a mock implementation of hold.Repository
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepository) Create(ctx context.Context, tx *sqlx.Tx, hold *models.BalanceHold) error {
+	args := m.Called(ctx, tx, hold)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) GetActiveByOrder(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) ([]*models.BalanceHold, error) {
+	args := m.Called(ctx, tx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BalanceHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetMatured(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) ([]*models.BalanceHold, error) {
+	args := m.Called(ctx, tx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BalanceHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) Update(ctx context.Context, tx *sqlx.Tx, hold *models.BalanceHold) error {
+	args := m.Called(ctx, tx, hold)
+	return args.Error(0)
+}
+
+// MockLedgerRepository is a mock implementation of ledger.Repository
+type MockLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepository) Create(ctx context.Context, tx *sqlx.Tx, entry *models.LedgerEntry) error {
+	args := m.Called(ctx, tx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepository) GetRevenueByPeriod(ctx context.Context, entryType models.LedgerEntryType, period models.ReportPeriod, from, to time.Time) ([]*models.RevenueRow, error) {
+	args := m.Called(ctx, entryType, period, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RevenueRow), args.Error(1)
+}
+
+// A full refund after a partial one pays back only the rest: the buyer gets
+// the order total once, the seller gives up the proceeds once and the tax
+// ledger reverses the VAT once
+func TestMarketplaceService_RefundAfterPartialRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		hold    bool
+		partial float64
+	}{
+		{"proceeds available", false, 40},
+		{"partial refund paid from the hold", true, 40},
+		{"partial refund larger than the hold", true, 97},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			country := "DE"
+			buyer := &models.User{ID: uuid.New(), Balance: 0}
+			seller := &models.User{ID: uuid.New(), Balance: 200}
+			ord := &models.Order{ID: uuid.New(), UserID: buyer.ID, TotalAmount: 119, TaxAmount: 19, TaxRate: 0.19, TaxCountry: &country, Status: models.OrderStatusCompleted}
+			item := &models.OrderItem{ID: uuid.New(), OrderID: ord.ID, SkinID: uuid.New(), SellerID: &seller.ID, Price: 100, Fee: 5}
+			var holds []*models.BalanceHold
+			if tt.hold {
+				seller.PendingBalance = 95
+				holds = []*models.BalanceHold{{ID: uuid.New(), UserID: seller.ID, OrderID: ord.ID, OrderItemID: &item.ID, Amount: 95, Status: models.HoldHeld}}
+			}
+			startBalance, startPending := seller.Balance, seller.PendingBalance
+
+			userRepo := new(MockUserRepository)
+			userRepo.On("UpdateBalance", mock.Anything, mock.Anything, buyer.ID, mock.Anything).Return(nil)
+			userRepo.On("UpdateBalances", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			userRepo.On("GetUserByIdForUpdate", mock.Anything, mock.Anything, buyer.ID).Return(buyer, nil)
+			userRepo.On("GetUserByIdForUpdate", mock.Anything, mock.Anything, seller.ID).Return(seller, nil)
+			credited := 0.0
+			transactionRepo := new(MockTransactionRepository)
+			transactionRepo.On("Create", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				if txn := args.Get(2).(*models.Transaction); txn.UserID == buyer.ID {
+					credited += txn.Amount
+				}
+			}).Return(nil)
+			var netReversed, taxReversed float64
+			taxLedger := new(MockTaxLedgerRepository)
+			taxLedger.On("Create", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				entry := args.Get(2).(*models.TaxEntry)
+				netReversed += entry.NetAmount
+				taxReversed += entry.TaxAmount
+			}).Return(nil)
+			orderRepo := new(MockOrderRepository)
+			orderRepo.On("AddRefundedAmount", mock.Anything, mock.Anything, ord.ID, mock.Anything).Return(nil)
+			orderRepo.On("GetOrderItems", mock.Anything, mock.Anything, ord.ID).Return([]*models.OrderItem{item}, nil)
+			orderRepo.On("UpdateStatus", mock.Anything, mock.Anything, ord.ID, models.OrderStatusRefunded, mock.Anything, mock.Anything).Return(nil)
+			holdRepo := new(MockHoldRepository)
+			holdRepo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			holdRepo.On("GetActiveByOrder", mock.Anything, mock.Anything, ord.ID).Return(holds, nil)
+			ledgerRepo := new(MockLedgerRepository)
+			ledgerRepo.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			skinRepo := new(MockSkinRepository)
+			skinRepo.On("GetSkinsForSellUpdate", mock.Anything, mock.Anything, []uuid.UUID{item.SkinID}).Return([]*models.Skin{{ID: item.SkinID, OwnerID: &buyer.ID}}, nil)
+			skinRepo.On("UpdateOwnership", mock.Anything, mock.Anything, []uuid.UUID{item.SkinID}, seller.ID).Return(nil)
+
+			sale := &models.MarketSale{OrderItemID: item.ID, Name: "Redline", Gun: models.AK47, Wear: models.FieldTested, Price: 100, SoldAt: time.Now()}
+			marketDataRepo := new(MockMarketDataRepository)
+			marketDataRepo.On("ExcludeOrderSales", mock.Anything, mock.Anything, ord.ID).Return([]*models.MarketSale{sale}, nil)
+			marketDataRepo.On("RebuildCandle", mock.Anything, mock.Anything, models.SkinType{Name: sale.Name, Gun: sale.Gun, Wear: sale.Wear}, mock.Anything, mock.Anything).Return(nil)
+
+			marketplace := &MarketplaceService{skinRepo: skinRepo, orderRepo: orderRepo, userRepo: userRepo, transactionRepo: transactionRepo,
+				ledgerRepo: ledgerRepo, holdRepo: holdRepo, taxLedger: taxLedger, marketData: NewMarketDataService(marketDataRepo, nil, logger), logger: logger}
+			disputes := NewDisputeService(nil, orderRepo, userRepo, transactionRepo, holdRepo, marketplace, realtime.NopPublisher{}, 7*24*time.Hour, nil, logger)
+
+			_, err := disputes.partialRefund(context.Background(), nil, ord, holds, buyer, seller, tt.partial, "scratched")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.partial, ord.RefundedAmount)
+
+			res, err := marketplace.refundLocked(context.Background(), nil, ord, uuid.New(), "not as described")
+			assert.NoError(t, err)
+			assert.InDelta(t, 119-tt.partial, res.refund.Amount, 0.001)
+			assert.Equal(t, 119.0, ord.RefundedAmount)
+
+			assert.InDelta(t, 119, credited, 0.001)
+			assert.InDelta(t, 119, buyer.Balance, 0.001)
+			assert.InDelta(t, 95, startBalance+startPending-seller.Balance-seller.PendingBalance, 0.001)
+			assert.InDelta(t, -100, netReversed, 0.001)
+			assert.InDelta(t, -19, taxReversed, 0.001)
+			// The refunded sale leaves the price statistics
+			marketDataRepo.AssertNumberOfCalls(t, "RebuildCandle", len(models.CandleIntervals))
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
+	"github.com/Uranury/RBK_finalProject/internal/metrics"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/payments"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/payment"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// How often and how far apart saving the provider's reference of a new
+// intent is tried
+const (
+	providerIntentSaveAttempts = 3
+	providerIntentRetryDelay   = 100 * time.Millisecond
+)
+
+type PaymentService struct {
+	paymentRepo     payment.Repository
+	userRepo        user.Repository
+	transactionRepo transaction.Repository
+	limits          *LimitService
+	risk            *RiskService
+	provider        payments.PaymentProvider
+	publisher       realtime.Publisher
+	db              *sqlx.DB
+	logger          *slog.Logger
+}
+
+func NewPaymentService(paymentRepo payment.Repository,
+	userRepo user.Repository,
+	transactionRepo transaction.Repository,
+	limits *LimitService,
+	risk *RiskService,
+	provider payments.PaymentProvider,
+	publisher realtime.Publisher,
+	db *sqlx.DB,
+	logger *slog.Logger) *PaymentService {
+	return &PaymentService{
+		paymentRepo:     paymentRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		limits:          limits,
+		risk:            risk,
+		provider:        provider,
+		publisher:       publisher,
+		db:              db,
+		logger:          logger,
+	}
+}
+
+// CreateDeposit opens a payment intent at the provider. Nothing is credited
+// until the provider confirms the payment through a webhook, but the amount
+// counts towards the user's deposit limits from now on.
+func (s *PaymentService) CreateDeposit(ctx context.Context, userID uuid.UUID, amount float64) (*models.PaymentIntent, error) {
+	amount = roundCents(amount)
+	if amount <= 0 {
+		return nil, apperrors.NewValidationError("Deposit amount must be greater than zero")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	usr, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, userID)
+	if err != nil {
+		s.logger.Error("failed to get user for update", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to get user for update")
+	}
+	if usr == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if err := s.limits.Enforce(ctx, tx, usr, models.LimitDeposit, amount); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	now := time.Now()
+	// Deposits are only credited once the provider confirms them, so a hold
+	// is reviewed like a flag
+	if _, err := s.risk.Assess(ctx, tx, fraud.Event{
+		Kind:             models.RiskDeposit,
+		UserID:           userID,
+		Amount:           amount,
+		AccountCreatedAt: usr.CreatedAt,
+		At:               now,
+	}, &id); err != nil {
+		return nil, err
+	}
+
+	// The provider is called after the commit so the user row isn't locked
+	// while waiting on it; the saved intent already counts towards the limits
+	pi := &models.PaymentIntent{
+		ID:        id,
+		UserID:    userID,
+		Amount:    amount,
+		Status:    models.PaymentPending,
+		Provider:  s.provider.Name(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.paymentRepo.CreateIntent(ctx, tx, pi); err != nil {
+		s.logger.Error("failed to save payment intent", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to save payment intent")
+	}
+	if err := s.commit(tx); err != nil {
+		return nil, err
+	}
+
+	if err := s.openAtProvider(ctx, pi); err != nil {
+		return nil, err
+	}
+	s.logger.Info("deposit intent created", "intent_id", pi.ID, "user_id", userID, "amount", amount, "reference", *pi.ProviderReference)
+	return pi, nil
+}
+
+// openAtProvider creates the provider side of a saved intent. If the provider
+// can't be reached the intent is failed so it stops counting towards the
+// user's deposit limits.
+func (s *PaymentService) openAtProvider(ctx context.Context, pi *models.PaymentIntent) error {
+	intent, err := s.provider.CreateIntent(ctx, payments.IntentRequest{
+		IdempotencyKey: pi.ID.String(),
+		UserID:         pi.UserID,
+		Amount:         pi.Amount,
+	})
+	if err != nil {
+		s.logger.Error("failed to create payment intent", "error", err, "intent_id", pi.ID)
+		// The request may have been cancelled, the intent still has to be failed
+		if err := s.paymentRepo.FailIntent(context.WithoutCancel(ctx), pi.ID, "payment provider unavailable"); err != nil {
+			s.logger.Error("failed to fail payment intent", "error", err, "intent_id", pi.ID)
+		}
+		return apperrors.WrapInternal(err, "failed to create payment intent")
+	}
+
+	if err := s.saveProviderIntent(context.WithoutCancel(ctx), pi.ID, intent); err != nil {
+		// The webhook still finds the intent by its idempotency key
+		s.logger.Error("failed to save provider intent", "error", err, "intent_id", pi.ID, "reference", intent.Reference)
+		return apperrors.WrapInternal(err, "failed to save provider intent")
+	}
+	pi.ProviderReference = &intent.Reference
+	pi.CheckoutURL = &intent.CheckoutURL
+	return nil
+}
+
+// saveProviderIntent stores the provider's side of an intent, retrying a few
+// times because the payment can't be matched by reference without it
+func (s *PaymentService) saveProviderIntent(ctx context.Context, id uuid.UUID, intent *payments.Intent) error {
+	var err error
+	for attempt := 1; attempt <= providerIntentSaveAttempts; attempt++ {
+		if err = s.paymentRepo.SetProviderIntent(ctx, id, intent.Reference, intent.CheckoutURL); err == nil {
+			return nil
+		}
+		s.logger.Warn("failed to save provider intent, retrying", "intent_id", id, "attempt", attempt, "err", err)
+		if attempt < providerIntentSaveAttempts {
+			time.Sleep(time.Duration(attempt) * providerIntentRetryDelay)
+		}
+	}
+	return err
+}
+
+// GetDeposit returns one of the user's payment intents
+func (s *PaymentService) GetDeposit(ctx context.Context, userID, intentID uuid.UUID) (*models.PaymentIntent, error) {
+	pi, err := s.paymentRepo.GetIntent(ctx, intentID)
+	if err != nil {
+		s.logger.Error("failed to get payment intent", "error", err, "intent_id", intentID)
+		return nil, apperrors.WrapInternal(err, "failed to get payment intent")
+	}
+	if pi == nil || pi.UserID != userID {
+		return nil, apperrors.NewNotFoundError("deposit not found")
+	}
+	return pi, nil
+}
+
+// HandleWebhook verifies a provider notification and settles the payment
+// intent it refers to. Redelivered events are acknowledged without being
+// applied again, and an intent is only credited once.
+func (s *PaymentService) HandleWebhook(ctx context.Context, payload []byte, header http.Header) error {
+	event, err := s.provider.ParseWebhook(payload, header)
+	if err != nil {
+		s.logger.Warn("rejected payment webhook", "err", err)
+		return apperrors.NewUnauthorizedError("invalid webhook signature")
+	}
+	if event.Type != payments.EventPaymentSucceeded && event.Type != payments.EventPaymentFailed {
+		s.logger.Info("ignoring payment webhook", "event_id", event.ID, "type", event.Type)
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	fresh, err := s.paymentRepo.RecordWebhookEvent(ctx, tx, s.provider.Name(), event.ID, string(event.Type), event.Reference)
+	if err != nil {
+		s.logger.Error("failed to record webhook event", "error", err, "event_id", event.ID)
+		return apperrors.WrapInternal(err, "failed to record webhook event")
+	}
+	if !fresh {
+		s.logger.Info("duplicate payment webhook", "event_id", event.ID)
+		return nil
+	}
+
+	pi, err := s.findIntent(ctx, tx, event)
+	if err != nil {
+		return err
+	}
+	if pi == nil {
+		// Acknowledge so the provider stops redelivering an event we can't use
+		s.logger.Warn("payment webhook for unknown intent", "event_id", event.ID, "reference", event.Reference)
+		return s.commit(tx)
+	}
+	if pi.Status != models.PaymentPending {
+		s.logger.Info("payment intent already settled", "intent_id", pi.ID, "status", pi.Status, "event_id", event.ID)
+		return s.commit(tx)
+	}
+
+	now := time.Now()
+	pi.CompletedAt = &now
+
+	var usr *models.User
+	switch {
+	case event.Type == payments.EventPaymentFailed:
+		reason := event.FailureReason
+		if reason == "" {
+			reason = "payment failed"
+		}
+		pi.Status = models.PaymentFailed
+		pi.FailureReason = &reason
+	case roundCents(event.Amount) != pi.Amount:
+		reason := fmt.Sprintf("paid amount %.2f does not match deposit amount %.2f", event.Amount, pi.Amount)
+		s.logger.Error("payment amount mismatch", "intent_id", pi.ID, "event_id", event.ID, "paid", event.Amount, "expected", pi.Amount)
+		pi.Status = models.PaymentFailed
+		pi.FailureReason = &reason
+	default:
+		if usr, err = s.credit(ctx, tx, pi); err != nil {
+			return err
+		}
+		pi.Status = models.PaymentSucceeded
+	}
+
+	if err := s.paymentRepo.UpdateIntent(ctx, tx, pi); err != nil {
+		s.logger.Error("failed to update payment intent", "error", err, "intent_id", pi.ID)
+		return apperrors.WrapInternal(err, "failed to update payment intent")
+	}
+	if err := s.commit(tx); err != nil {
+		return err
+	}
+	metrics.DepositSettled(string(pi.Status), pi.Status == models.PaymentSucceeded, pi.Amount)
+
+	if usr != nil {
+		if err := s.publisher.PublishUser(ctx, usr.ID, realtime.NewBalancesEvent(usr.Balance, usr.PendingBalance)); err != nil {
+			s.logger.Warn("failed to publish balance event", "user_id", usr.ID, "err", err)
+		}
+	}
+	s.logger.Info("payment intent settled", "intent_id", pi.ID, "status", pi.Status, "event_id", event.ID)
+	return nil
+}
+
+// findIntent locks the intent a webhook event refers to. An intent whose
+// provider reference was never saved is found by the idempotency key it was
+// created with, which is its ID, and gets the event's reference.
+func (s *PaymentService) findIntent(ctx context.Context, tx *sqlx.Tx, event *payments.WebhookEvent) (*models.PaymentIntent, error) {
+	pi, err := s.paymentRepo.GetIntentByReferenceForUpdate(ctx, tx, s.provider.Name(), event.Reference)
+	if err != nil {
+		s.logger.Error("failed to get payment intent", "error", err, "reference", event.Reference)
+		return nil, apperrors.WrapInternal(err, "failed to get payment intent")
+	}
+	if pi != nil {
+		return pi, nil
+	}
+
+	id, err := uuid.Parse(event.IdempotencyKey)
+	if err != nil {
+		return nil, nil
+	}
+	pi, err = s.paymentRepo.GetIntentForUpdate(ctx, tx, id)
+	if err != nil {
+		s.logger.Error("failed to get payment intent", "error", err, "intent_id", id)
+		return nil, apperrors.WrapInternal(err, "failed to get payment intent")
+	}
+	if pi == nil || pi.Provider != s.provider.Name() || pi.ProviderReference != nil {
+		return nil, nil
+	}
+	s.logger.Warn("payment intent matched by idempotency key", "intent_id", pi.ID, "reference", event.Reference)
+	pi.ProviderReference = &event.Reference
+	return pi, nil
+}
+
+// credit adds a confirmed deposit to the user's available balance
+func (s *PaymentService) credit(ctx context.Context, tx *sqlx.Tx, pi *models.PaymentIntent) (*models.User, error) {
+	usr, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, pi.UserID)
+	if err != nil {
+		s.logger.Error("failed to get user for update", "error", err, "user_id", pi.UserID)
+		return nil, apperrors.WrapInternal(err, "failed to get user for update")
+	}
+	if usr == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	before := usr.Balance
+	usr.Balance = roundCents(usr.Balance + pi.Amount)
+	if err := s.userRepo.UpdateBalances(ctx, tx, usr); err != nil {
+		s.logger.Error("failed to update balances", "error", err, "user_id", usr.ID)
+		return nil, apperrors.WrapInternal(err, "failed to update balances")
+	}
+
+	description := fmt.Sprintf("deposit %s", *pi.ProviderReference)
+	if err := s.transactionRepo.Create(ctx, tx, &models.Transaction{
+		ID:            uuid.New(),
+		UserID:        usr.ID,
+		Amount:        pi.Amount,
+		Type:          models.Deposit,
+		BalanceBefore: before,
+		BalanceAfter:  usr.Balance,
+		Description:   &description,
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		s.logger.Error("failed to create transaction record", "error", err, "user_id", usr.ID)
+		return nil, apperrors.WrapInternal(err, "failed to create transaction record")
+	}
+	return usr, nil
+}
+
+func (s *PaymentService) commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err)
+		return apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/payments"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPaymentRepository is a mock implementation of payment.Repository
+type MockPaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepository) CreateIntent(ctx context.Context, tx *sqlx.Tx, intent *models.PaymentIntent) error {
+	args := m.Called(ctx, tx, intent)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) GetIntent(ctx context.Context, id uuid.UUID) (*models.PaymentIntent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentIntent), args.Error(1)
+}
+
+func (m *MockPaymentRepository) GetIntentForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.PaymentIntent, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentIntent), args.Error(1)
+}
+
+func (m *MockPaymentRepository) GetIntentByReferenceForUpdate(ctx context.Context, tx *sqlx.Tx, provider, reference string) (*models.PaymentIntent, error) {
+	args := m.Called(ctx, tx, provider, reference)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentIntent), args.Error(1)
+}
+
+func (m *MockPaymentRepository) UpdateIntent(ctx context.Context, tx *sqlx.Tx, intent *models.PaymentIntent) error {
+	args := m.Called(ctx, tx, intent)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) SetProviderIntent(ctx context.Context, id uuid.UUID, reference, checkoutURL string) error {
+	args := m.Called(ctx, id, reference, checkoutURL)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) FailIntent(ctx context.Context, id uuid.UUID, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) RecordWebhookEvent(ctx context.Context, tx *sqlx.Tx, provider, eventID, eventType, reference string) (bool, error) {
+	args := m.Called(ctx, tx, provider, eventID, eventType, reference)
+	return args.Bool(0), args.Error(1)
+}
+
+const testWebhookSecret = "whsec_test"
+
+func newTestPaymentService(repo *MockPaymentRepository) *PaymentService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	provider := payments.NewFakeProvider(testWebhookSecret, logger)
+	return NewPaymentService(repo, nil, nil, nil, nil, provider, realtime.NopPublisher{}, nil, logger)
+}
+
+func TestPaymentService_CreateDeposit_InvalidAmount(t *testing.T) {
+	repo := new(MockPaymentRepository)
+
+	intent, err := newTestPaymentService(repo).CreateDeposit(context.Background(), uuid.New(), 0)
+
+	assert.Equal(t, apperrors.NewValidationError("Deposit amount must be greater than zero"), err)
+	assert.Nil(t, intent)
+	repo.AssertNotCalled(t, "CreateIntent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPaymentService_OpenAtProvider(t *testing.T) {
+	pi := &models.PaymentIntent{ID: uuid.New(), UserID: uuid.New(), Amount: 25, Status: models.PaymentPending}
+	repo := new(MockPaymentRepository)
+	repo.On("SetProviderIntent", mock.Anything, pi.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+
+	err := newTestPaymentService(repo).openAtProvider(context.Background(), pi)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, pi.ProviderReference) && assert.NotNil(t, pi.CheckoutURL) {
+		assert.Equal(t, "fake://checkout/"+*pi.ProviderReference, *pi.CheckoutURL)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestPaymentService_OpenAtProvider_ProviderError(t *testing.T) {
+	pi := &models.PaymentIntent{ID: uuid.New(), UserID: uuid.New(), Amount: 25, Status: models.PaymentPending}
+	repo := new(MockPaymentRepository)
+	repo.On("FailIntent", mock.Anything, pi.ID, "payment provider unavailable").Return(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := newTestPaymentService(repo).openAtProvider(ctx, pi)
+
+	assert.Error(t, err)
+	assert.Nil(t, pi.ProviderReference)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "SetProviderIntent", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPaymentService_OpenAtProvider_RetriesSave(t *testing.T) {
+	pi := &models.PaymentIntent{ID: uuid.New(), UserID: uuid.New(), Amount: 25, Status: models.PaymentPending}
+	repo := new(MockPaymentRepository)
+	repo.On("SetProviderIntent", mock.Anything, pi.ID, mock.Anything, mock.Anything).Return(errors.New("connection reset")).Once()
+	repo.On("SetProviderIntent", mock.Anything, pi.ID, mock.Anything, mock.Anything).Return(nil).Once()
+
+	err := newTestPaymentService(repo).openAtProvider(context.Background(), pi)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, pi.ProviderReference)
+	repo.AssertNumberOfCalls(t, "SetProviderIntent", 2)
+}
+
+func TestPaymentService_FindIntent_ByIdempotencyKey(t *testing.T) {
+	unsaved := &models.PaymentIntent{ID: uuid.New(), Provider: payments.FakeProviderName, Status: models.PaymentPending}
+	saved := "fake_pi_other"
+	tests := []struct {
+		name   string
+		key    string
+		intent *models.PaymentIntent
+		found  bool
+	}{
+		{"reference never saved", unsaved.ID.String(), unsaved, true},
+		{"intent has another reference", unsaved.ID.String(), &models.PaymentIntent{ID: unsaved.ID, Provider: payments.FakeProviderName, ProviderReference: &saved}, false},
+		{"no idempotency key", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &payments.WebhookEvent{ID: "evt_1", Reference: "fake_pi_1", IdempotencyKey: tt.key}
+			repo := new(MockPaymentRepository)
+			repo.On("GetIntentByReferenceForUpdate", mock.Anything, mock.Anything, payments.FakeProviderName, event.Reference).Return(nil, nil)
+			repo.On("GetIntentForUpdate", mock.Anything, mock.Anything, unsaved.ID).Return(tt.intent, nil)
+
+			pi, err := newTestPaymentService(repo).findIntent(context.Background(), nil, event)
+
+			assert.NoError(t, err)
+			if !tt.found {
+				assert.Nil(t, pi)
+				return
+			}
+			if assert.NotNil(t, pi) && assert.NotNil(t, pi.ProviderReference) {
+				assert.Equal(t, event.Reference, *pi.ProviderReference)
+			}
+		})
+	}
+}
+
+func TestPaymentService_GetDeposit(t *testing.T) {
+	owner := uuid.New()
+	intentID := uuid.New()
+	repo := new(MockPaymentRepository)
+	repo.On("GetIntent", mock.Anything, intentID).Return(&models.PaymentIntent{ID: intentID, UserID: owner}, nil)
+	service := newTestPaymentService(repo)
+
+	intent, err := service.GetDeposit(context.Background(), owner, intentID)
+	assert.NoError(t, err)
+	assert.Equal(t, intentID, intent.ID)
+
+	intent, err = service.GetDeposit(context.Background(), uuid.New(), intentID)
+	assert.Equal(t, apperrors.NewNotFoundError("deposit not found"), err)
+	assert.Nil(t, intent)
+}
+
+func TestPaymentService_HandleWebhook_Rejected(t *testing.T) {
+	event := payments.WebhookEvent{ID: "evt_1", Type: payments.EventPaymentSucceeded, Reference: "fake_pi_1", Amount: 10}
+
+	tests := []struct {
+		name   string
+		secret string
+		signAt time.Time
+	}{
+		{name: "wrong secret", secret: "other", signAt: time.Now()},
+		{name: "replayed old event", secret: testWebhookSecret, signAt: time.Now().Add(-time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockPaymentRepository)
+			payload, signature, err := payments.NewSignedWebhook([]byte(tt.secret), event, tt.signAt)
+			assert.NoError(t, err)
+			header := http.Header{}
+			header.Set(payments.SignatureHeader, signature)
+
+			err = newTestPaymentService(repo).HandleWebhook(context.Background(), payload, header)
+
+			assert.Equal(t, apperrors.NewUnauthorizedError("invalid webhook signature"), err)
+			repo.AssertNotCalled(t, "RecordWebhookEvent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
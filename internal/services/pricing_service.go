@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/marketdata"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/skin"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+)
+
+const (
+	suggestionWindowDays = 30
+	// Below this many recent sales the median is too noisy, so the last sale is used instead
+	minSalesForMedian = 3
+	// Sales count from which we trust the suggestion
+	highConfidenceSales = 10
+	// Weight of the sales reference when blended with the cheapest competing listing
+	salesWeight = 0.7
+	// A skin at the best end of its wear band is worth up to this much more (and the worst end this much less)
+	maxConditionAdjustment = 0.10
+	// Listing prices outside [suggested/factor, suggested*factor] trigger a typo warning
+	priceDeviationFactor = 2.0
+)
+
+type PricingService struct {
+	marketDataRepo marketdata.Repository
+	skinRepo       skin.Repository
+	logger         *slog.Logger
+}
+
+func NewPricingService(marketDataRepo marketdata.Repository, skinRepo skin.Repository, logger *slog.Logger) *PricingService {
+	return &PricingService{marketDataRepo: marketDataRepo, skinRepo: skinRepo, logger: logger}
+}
+
+// SuggestPriceForSkin loads the skin and returns its suggested listing price
+func (s *PricingService) SuggestPriceForSkin(ctx context.Context, skinID uuid.UUID) (*models.PriceSuggestion, error) {
+	sk, err := s.skinRepo.GetSkin(ctx, skinID)
+	if err != nil {
+		s.logger.Error("failed to get skin", "skin_id", skinID, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get skin")
+	}
+	if sk == nil {
+		return nil, apperrors.NewNotFoundError("skin not found")
+	}
+
+	suggestion, err := s.SuggestPrice(ctx, sk)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion == nil {
+		return nil, apperrors.NewNotFoundError("not enough market data to suggest a price")
+	}
+	return suggestion, nil
+}
+
+// SuggestPrice computes a recommended price from recent sales and the cheapest
+// competing listings of the same name/gun/wear. It returns nil when there is
+// no market data at all.
+func (s *PricingService) SuggestPrice(ctx context.Context, sk *models.Skin) (*models.PriceSuggestion, error) {
+	skinType := models.SkinType{Name: sk.Name, Gun: sk.Gun, Wear: sk.Wear}
+
+	median, salesCount, err := s.marketDataRepo.GetMedianPrice(ctx, skinType, time.Now().UTC().AddDate(0, 0, -suggestionWindowDays))
+	if err != nil {
+		s.logger.Error("failed to get median price", "skin_id", sk.ID, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get median price")
+	}
+
+	lastSale, err := s.marketDataRepo.GetLastSale(ctx, skinType)
+	if err != nil {
+		s.logger.Error("failed to get last sale", "skin_id", sk.ID, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get last sale")
+	}
+
+	listings, err := s.skinRepo.GetLowestListings(ctx, skinType, sk.ID, 1)
+	if err != nil {
+		s.logger.Error("failed to get lowest listings", "skin_id", sk.ID, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get lowest listings")
+	}
+
+	in := priceInputs{
+		median:     median,
+		salesCount: salesCount,
+		condition:  sk.Condition,
+		wear:       sk.Wear,
+	}
+	if lastSale != nil {
+		in.lastSale = &lastSale.Price
+	}
+	if len(listings) > 0 {
+		in.lowestListing = &listings[0].Price
+	}
+
+	suggestion := suggestPrice(in)
+	if suggestion != nil {
+		suggestion.SkinID = sk.ID
+	}
+	return suggestion, nil
+}
+
+type priceInputs struct {
+	median        *float64
+	salesCount    int
+	lastSale      *float64
+	lowestListing *float64
+	condition     float64
+	wear          models.Wear
+}
+
+func suggestPrice(in priceInputs) *models.PriceSuggestion {
+	var salesRef *float64
+	switch {
+	case in.median != nil && in.salesCount >= minSalesForMedian:
+		salesRef = in.median
+	case in.lastSale != nil:
+		salesRef = in.lastSale
+	}
+
+	var base float64
+	var confidence models.PriceConfidence
+	switch {
+	case salesRef != nil && in.lowestListing != nil:
+		base = salesWeight*(*salesRef) + (1-salesWeight)*(*in.lowestListing)
+	case salesRef != nil:
+		base = *salesRef
+	case in.lowestListing != nil:
+		base = *in.lowestListing
+	default:
+		return nil
+	}
+
+	switch {
+	case in.salesCount >= highConfidenceSales:
+		confidence = models.ConfidenceHigh
+	case salesRef != nil:
+		confidence = models.ConfidenceMedium
+	default:
+		confidence = models.ConfidenceLow
+	}
+
+	adjustment := conditionAdjustment(in.condition, in.wear)
+	price := roundCents(base * (1 + adjustment))
+	price = math.Max(0.01, math.Min(price, maxListingPrice))
+
+	return &models.PriceSuggestion{
+		SuggestedPrice:      price,
+		MedianPrice:         in.median,
+		LastSalePrice:       in.lastSale,
+		LowestListing:       in.lowestListing,
+		SalesCount:          in.salesCount,
+		ConditionAdjustment: math.Round(adjustment*10000) / 10000,
+		Confidence:          confidence,
+	}
+}
+
+// conditionAdjustment returns the relative premium (positive) or discount
+// (negative) of a condition compared to the middle of its wear band. Lower
+// float values look better and are worth more.
+func conditionAdjustment(condition float64, wear models.Wear) float64 {
+	lo, hi := models.GetWearRange(wear)
+	if hi <= lo {
+		return 0
+	}
+	position := (condition - lo) / (hi - lo)
+	position = math.Max(0, math.Min(1, position))
+	return (0.5 - position) * 2 * maxConditionAdjustment
+}
+
+// priceDeviationWarning returns a warning when the price looks like a typo
+// compared to the suggestion, or an empty string otherwise.
+func priceDeviationWarning(price float64, suggestion *models.PriceSuggestion) string {
+	if suggestion == nil || suggestion.SuggestedPrice <= 0 {
+		return ""
+	}
+	if price > suggestion.SuggestedPrice*priceDeviationFactor || price < suggestion.SuggestedPrice/priceDeviationFactor {
+		return fmt.Sprintf("price %.2f deviates strongly from the suggested market price %.2f, please double-check it",
+			price, suggestion.SuggestedPrice)
+	}
+	return ""
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
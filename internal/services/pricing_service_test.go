@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestConditionAdjustment(t *testing.T) {
+	// Middle of the Field-Tested band is neutral
+	assert.InDelta(t, 0.0, conditionAdjustment(0.265, models.FieldTested), 1e-9)
+	// Best end of the band earns the full premium, worst end the full discount
+	assert.InDelta(t, maxConditionAdjustment, conditionAdjustment(0.15, models.FieldTested), 1e-9)
+	assert.InDelta(t, -maxConditionAdjustment, conditionAdjustment(0.38, models.FieldTested), 1e-9)
+	// Values outside the band are clamped
+	assert.InDelta(t, maxConditionAdjustment, conditionAdjustment(0.01, models.MinimalWear), 1e-9)
+}
+
+func TestSuggestPrice(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             priceInputs
+		wantNil        bool
+		wantPrice      float64
+		wantConfidence models.PriceConfidence
+	}{
+		{
+			name:    "no market data",
+			in:      priceInputs{condition: 0.265, wear: models.FieldTested},
+			wantNil: true,
+		},
+		{
+			name:           "median blended with lowest listing",
+			in:             priceInputs{median: floatPtr(100), salesCount: 12, lowestListing: floatPtr(90), condition: 0.265, wear: models.FieldTested},
+			wantPrice:      97,
+			wantConfidence: models.ConfidenceHigh,
+		},
+		{
+			name:           "too few sales falls back to last sale",
+			in:             priceInputs{median: floatPtr(100), salesCount: 2, lastSale: floatPtr(80), condition: 0.265, wear: models.FieldTested},
+			wantPrice:      80,
+			wantConfidence: models.ConfidenceMedium,
+		},
+		{
+			name:           "listings only",
+			in:             priceInputs{lowestListing: floatPtr(50), condition: 0.265, wear: models.FieldTested},
+			wantPrice:      50,
+			wantConfidence: models.ConfidenceLow,
+		},
+		{
+			name:           "best condition in band gets a premium",
+			in:             priceInputs{lastSale: floatPtr(100), salesCount: 1, condition: 0.15, wear: models.FieldTested},
+			wantPrice:      110,
+			wantConfidence: models.ConfidenceMedium,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestPrice(tt.in)
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.NotNil(t, got)
+			assert.InDelta(t, tt.wantPrice, got.SuggestedPrice, 0.001)
+			assert.Equal(t, tt.wantConfidence, got.Confidence)
+		})
+	}
+}
+
+func TestPriceDeviationWarning(t *testing.T) {
+	suggestion := &models.PriceSuggestion{SuggestedPrice: 100}
+
+	assert.Empty(t, priceDeviationWarning(150, suggestion))
+	assert.Empty(t, priceDeviationWarning(60, suggestion))
+	assert.NotEmpty(t, priceDeviationWarning(1000, suggestion))
+	assert.NotEmpty(t, priceDeviationWarning(10, suggestion))
+	assert.Empty(t, priceDeviationWarning(10, nil))
+}
+
+func TestPricingService_SuggestPriceForSkin(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	skinID := uuid.New()
+	sk := &models.Skin{ID: skinID, Name: "Redline", Gun: models.AK47, Wear: models.FieldTested, Condition: 0.265}
+	skinType := models.SkinType{Name: sk.Name, Gun: sk.Gun, Wear: sk.Wear}
+
+	t.Run("skin not found", func(t *testing.T) {
+		skinRepo := new(MockSkinRepository)
+		skinRepo.On("GetSkin", mock.Anything, skinID).Return(nil, nil)
+
+		service := NewPricingService(new(MockMarketDataRepository), skinRepo, logger)
+		suggestion, err := service.SuggestPriceForSkin(context.Background(), skinID)
+
+		assert.Equal(t, apperrors.NewNotFoundError("skin not found"), err)
+		assert.Nil(t, suggestion)
+	})
+
+	t.Run("no market data", func(t *testing.T) {
+		skinRepo := new(MockSkinRepository)
+		skinRepo.On("GetSkin", mock.Anything, skinID).Return(sk, nil)
+		skinRepo.On("GetLowestListings", mock.Anything, skinType, skinID, 1).Return([]*models.Skin{}, nil)
+		marketRepo := new(MockMarketDataRepository)
+		marketRepo.On("GetMedianPrice", mock.Anything, skinType, mock.AnythingOfType("time.Time")).Return(nil, 0, nil)
+		marketRepo.On("GetLastSale", mock.Anything, skinType).Return(nil, nil)
+
+		service := NewPricingService(marketRepo, skinRepo, logger)
+		suggestion, err := service.SuggestPriceForSkin(context.Background(), skinID)
+
+		assert.Equal(t, apperrors.NewNotFoundError("not enough market data to suggest a price"), err)
+		assert.Nil(t, suggestion)
+	})
+
+	t.Run("suggestion from sales and listings", func(t *testing.T) {
+		skinRepo := new(MockSkinRepository)
+		skinRepo.On("GetSkin", mock.Anything, skinID).Return(sk, nil)
+		skinRepo.On("GetLowestListings", mock.Anything, skinType, skinID, 1).Return([]*models.Skin{{Price: 90}}, nil)
+		marketRepo := new(MockMarketDataRepository)
+		marketRepo.On("GetMedianPrice", mock.Anything, skinType, mock.AnythingOfType("time.Time")).Return(floatPtr(100), 5, nil)
+		marketRepo.On("GetLastSale", mock.Anything, skinType).Return(&models.MarketSale{Price: 101}, nil)
+
+		service := NewPricingService(marketRepo, skinRepo, logger)
+		suggestion, err := service.SuggestPriceForSkin(context.Background(), skinID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, skinID, suggestion.SkinID)
+		assert.InDelta(t, 97.0, suggestion.SuggestedPrice, 0.001)
+		assert.Equal(t, 101.0, *suggestion.LastSalePrice)
+		assert.Equal(t, 90.0, *suggestion.LowestListing)
+		skinRepo.AssertExpectations(t)
+		marketRepo.AssertExpectations(t)
+	})
+}
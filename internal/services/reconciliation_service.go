@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/reconcile"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/reconciliation"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultReconciliationReports = 20
+	maxReconciliationReports     = 100
+	// reconciliationBatch is how many users are checked per query round
+	reconciliationBatch = 500
+	// alertedDiscrepancies is how many discrepancies an alert email lists
+	alertedDiscrepancies = 20
+)
+
+type ReconciliationService struct {
+	reconRepo reconciliation.Repository
+	queue     *asynq.Client
+	// alerts is only set in the worker, which is where reconciliations run
+	alerts *EmailService
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewReconciliationService(reconRepo reconciliation.Repository, queue *asynq.Client, alerts *EmailService, db *sqlx.DB, logger *slog.Logger) *ReconciliationService {
+	return &ReconciliationService{reconRepo: reconRepo, queue: queue, alerts: alerts, db: db, logger: logger}
+}
+
+// Reconcile checks every user's balances against their transaction history
+// and every paid order for its transactions, stores a report and alerts the
+// admins when something is off. Everything is read from one snapshot so
+// purchases made meanwhile don't show up as drift.
+func (s *ReconciliationService) Reconcile(ctx context.Context) (*models.ReconciliationReport, error) {
+	report := &models.ReconciliationReport{ID: uuid.New(), StartedAt: time.Now()}
+
+	found, err := s.check(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+	report.DiscrepancyCount = len(found)
+	for _, d := range found {
+		d.ID = uuid.New()
+		d.ReportID = report.ID
+		d.CreatedAt = report.FinishedAt
+	}
+	report.Discrepancies = found
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	if err := s.reconRepo.CreateReport(ctx, tx, report); err != nil {
+		s.logger.Error("failed to create reconciliation report", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to create reconciliation report")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	if report.DiscrepancyCount > 0 {
+		s.logger.Error("balance reconciliation found discrepancies", "report_id", report.ID,
+			"discrepancies", report.DiscrepancyCount, "users", report.UsersChecked)
+		s.alert(ctx, report)
+	} else {
+		s.logger.Info("balance reconciliation completed", "report_id", report.ID,
+			"users", report.UsersChecked, "transactions", report.TransactionsChecked, "orders", report.OrdersChecked)
+	}
+	return report, nil
+}
+
+// check runs the checks in a read-only repeatable-read transaction, filling
+// in the report's counters
+func (s *ReconciliationService) check(ctx context.Context, report *models.ReconciliationReport) ([]*models.Discrepancy, error) {
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	found := []*models.Discrepancy{}
+	after := uuid.Nil
+	for {
+		accounts, err := s.reconRepo.ListAccounts(ctx, tx, after, reconciliationBatch)
+		if err != nil {
+			s.logger.Error("failed to list accounts", "error", err, "after", after)
+			return nil, apperrors.WrapInternal(err, "failed to list accounts")
+		}
+		if len(accounts) == 0 {
+			break
+		}
+
+		userIDs := make([]uuid.UUID, 0, len(accounts))
+		for _, a := range accounts {
+			userIDs = append(userIDs, a.UserID)
+		}
+		txns, err := s.reconRepo.ListTransactions(ctx, tx, userIDs)
+		if err != nil {
+			s.logger.Error("failed to list transactions", "error", err)
+			return nil, apperrors.WrapInternal(err, "failed to list transactions")
+		}
+
+		history := historyByUser(txns)
+		for _, a := range accounts {
+			found = append(found, reconcile.CheckAccount(a, history[a.UserID])...)
+		}
+		report.UsersChecked += len(accounts)
+		report.TransactionsChecked += len(txns)
+
+		if len(accounts) < reconciliationBatch {
+			break
+		}
+		after = accounts[len(accounts)-1].UserID
+	}
+
+	if report.OrdersChecked, err = s.reconRepo.CountPaidOrders(ctx, tx); err != nil {
+		s.logger.Error("failed to count paid orders", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to count paid orders")
+	}
+	orphans, err := s.reconRepo.ListOrphanOrders(ctx, tx)
+	if err != nil {
+		s.logger.Error("failed to list orphan orders", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list orphan orders")
+	}
+	found = append(found, reconcile.CheckOrders(orphans)...)
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+	return found, nil
+}
+
+// historyByUser splits transactions ordered by user and time into each
+// user's history
+func historyByUser(txns []*models.Transaction) map[uuid.UUID][]*models.Transaction {
+	history := make(map[uuid.UUID][]*models.Transaction)
+	for _, txn := range txns {
+		history[txn.UserID] = append(history[txn.UserID], txn)
+	}
+	return history
+}
+
+// alert emails every admin a summary of the report. A failed alert is only
+// logged; the report is stored either way.
+func (s *ReconciliationService) alert(ctx context.Context, report *models.ReconciliationReport) {
+	if s.alerts == nil {
+		return
+	}
+	emails, err := s.reconRepo.ListAdminEmails(ctx)
+	if err != nil {
+		s.logger.Error("failed to list admin emails", "error", err)
+		return
+	}
+
+	subject := fmt.Sprintf("Balance reconciliation found %d discrepancies", report.DiscrepancyCount)
+	body := alertBody(report)
+	for _, to := range emails {
+		if err := s.alerts.SendAlert(to, subject, body); err != nil {
+			s.logger.Error("failed to send reconciliation alert", "error", err, "to", to, "report_id", report.ID)
+		}
+	}
+}
+
+func alertBody(report *models.ReconciliationReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reconciliation report %s checked %d users, %d transactions and %d orders and found %d discrepancies.\n\n",
+		report.ID, report.UsersChecked, report.TransactionsChecked, report.OrdersChecked, report.DiscrepancyCount)
+
+	for i, d := range report.Discrepancies {
+		if i == alertedDiscrepancies {
+			fmt.Fprintf(&b, "... and %d more\n", len(report.Discrepancies)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s", d.Kind)
+		if d.UserID != nil {
+			fmt.Fprintf(&b, " user %s", d.UserID)
+		}
+		if d.OrderID != nil {
+			fmt.Fprintf(&b, " order %s", d.OrderID)
+		}
+		fmt.Fprintf(&b, ": %s\n", d.Detail)
+	}
+	return b.String()
+}
+
+// Run queues a reconciliation now instead of waiting for the schedule
+func (s *ReconciliationService) Run(ctx context.Context) error {
+	_, err := s.queue.EnqueueContext(ctx, jobs.NewReconcileBalancesTask(), asynq.Unique(10*time.Minute))
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		return apperrors.NewAlreadyExistsError("a reconciliation is already queued")
+	}
+	if err != nil {
+		s.logger.Error("failed to enqueue reconciliation", "error", err)
+		return apperrors.WrapInternal(err, "failed to enqueue reconciliation")
+	}
+	return nil
+}
+
+// ListReports returns the most recent reconciliation reports, without their
+// discrepancies
+func (s *ReconciliationService) ListReports(ctx context.Context, limit int) ([]*models.ReconciliationReport, error) {
+	if limit <= 0 {
+		limit = defaultReconciliationReports
+	}
+	if limit > maxReconciliationReports {
+		return nil, apperrors.NewValidationError("limit cannot exceed 100")
+	}
+
+	reports, err := s.reconRepo.ListReports(ctx, limit)
+	if err != nil {
+		s.logger.Error("failed to list reconciliation reports", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list reconciliation reports")
+	}
+	return reports, nil
+}
+
+// GetReport returns a report with its discrepancies, only those of kind
+// unless it is empty
+func (s *ReconciliationService) GetReport(ctx context.Context, reportID uuid.UUID, kind models.DiscrepancyKind) (*models.ReconciliationReport, error) {
+	if kind != "" && !kind.Valid() {
+		return nil, apperrors.NewValidationError("invalid discrepancy kind")
+	}
+
+	report, err := s.reconRepo.GetReport(ctx, reportID)
+	if err != nil {
+		s.logger.Error("failed to get reconciliation report", "error", err, "report_id", reportID)
+		return nil, apperrors.WrapInternal(err, "failed to get reconciliation report")
+	}
+	if report == nil {
+		return nil, apperrors.NewNotFoundError("report not found")
+	}
+
+	if report.Discrepancies, err = s.reconRepo.ListDiscrepancies(ctx, reportID, kind); err != nil {
+		s.logger.Error("failed to list discrepancies", "error", err, "report_id", reportID)
+		return nil, apperrors.WrapInternal(err, "failed to list discrepancies")
+	}
+	return report, nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockReconciliationRepository is a mock implementation of reconciliation.Repository
+type MockReconciliationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationRepository) ListAccounts(ctx context.Context, tx *sqlx.Tx, afterID uuid.UUID, limit int) ([]models.AccountSnapshot, error) {
+	args := m.Called(ctx, tx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AccountSnapshot), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListTransactions(ctx context.Context, tx *sqlx.Tx, userIDs []uuid.UUID) ([]*models.Transaction, error) {
+	args := m.Called(ctx, tx, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Transaction), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) CountPaidOrders(ctx context.Context, tx *sqlx.Tx) (int, error) {
+	args := m.Called(ctx, tx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListOrphanOrders(ctx context.Context, tx *sqlx.Tx) ([]models.OrphanOrder, error) {
+	args := m.Called(ctx, tx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrphanOrder), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) CreateReport(ctx context.Context, tx *sqlx.Tx, report *models.ReconciliationReport) error {
+	args := m.Called(ctx, tx, report)
+	return args.Error(0)
+}
+
+func (m *MockReconciliationRepository) GetReport(ctx context.Context, id uuid.UUID) (*models.ReconciliationReport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReconciliationReport), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListReports(ctx context.Context, limit int) ([]*models.ReconciliationReport, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ReconciliationReport), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListDiscrepancies(ctx context.Context, reportID uuid.UUID, kind models.DiscrepancyKind) ([]*models.Discrepancy, error) {
+	args := m.Called(ctx, reportID, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Discrepancy), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListAdminEmails(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func newTestReconciliationService(repo *MockReconciliationRepository) *ReconciliationService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewReconciliationService(repo, nil, nil, nil, logger)
+}
+
+func TestHistoryByUser(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	txns := []*models.Transaction{
+		{ID: uuid.New(), UserID: alice},
+		{ID: uuid.New(), UserID: alice},
+		{ID: uuid.New(), UserID: bob},
+	}
+
+	history := historyByUser(txns)
+
+	assert.Equal(t, []*models.Transaction{txns[0], txns[1]}, history[alice])
+	assert.Equal(t, []*models.Transaction{txns[2]}, history[bob])
+	assert.Empty(t, history[uuid.New()])
+}
+
+func TestAlertBody(t *testing.T) {
+	userID := uuid.New()
+	report := &models.ReconciliationReport{ID: uuid.New(), UsersChecked: 3, DiscrepancyCount: 25}
+	for i := 0; i < 25; i++ {
+		report.Discrepancies = append(report.Discrepancies, &models.Discrepancy{
+			Kind:   models.DiscrepancyBalance,
+			UserID: &userID,
+			Detail: "balance differs from where the transaction history ends",
+		})
+	}
+
+	body := alertBody(report)
+
+	assert.Contains(t, body, "checked 3 users")
+	assert.Contains(t, body, "- balance_mismatch user "+userID.String()+": balance differs")
+	assert.Equal(t, alertedDiscrepancies, strings.Count(body, "- balance_mismatch"))
+	assert.Contains(t, body, "... and 5 more")
+}
+
+func TestReconciliationService_ListReports(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	svc := newTestReconciliationService(repo)
+	ctx := context.Background()
+
+	repo.On("ListReports", ctx, defaultReconciliationReports).Return([]*models.ReconciliationReport{}, nil)
+
+	reports, err := svc.ListReports(ctx, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+
+	_, err = svc.ListReports(ctx, maxReconciliationReports+1)
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+
+	repo.AssertExpectations(t)
+}
+
+func TestReconciliationService_GetReport(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	svc := newTestReconciliationService(repo)
+	ctx := context.Background()
+	reportID := uuid.New()
+	discrepancies := []*models.Discrepancy{{ID: uuid.New(), ReportID: reportID, Kind: models.DiscrepancyGap}}
+
+	repo.On("GetReport", ctx, reportID).Return(&models.ReconciliationReport{ID: reportID}, nil)
+	repo.On("ListDiscrepancies", ctx, reportID, models.DiscrepancyGap).Return(discrepancies, nil)
+
+	report, err := svc.GetReport(ctx, reportID, models.DiscrepancyGap)
+	assert.NoError(t, err)
+	assert.Equal(t, discrepancies, report.Discrepancies)
+	repo.AssertExpectations(t)
+}
+
+func TestReconciliationService_GetReport_InvalidKind(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	svc := newTestReconciliationService(repo)
+
+	_, err := svc.GetReport(context.Background(), uuid.New(), "bogus")
+
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+	repo.AssertNotCalled(t, "GetReport", mock.Anything, mock.Anything)
+}
+
+func TestReconciliationService_GetReport_NotFound(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	svc := newTestReconciliationService(repo)
+	ctx := context.Background()
+	reportID := uuid.New()
+
+	repo.On("GetReport", ctx, reportID).Return(nil, nil)
+
+	_, err := svc.GetReport(ctx, reportID, "")
+
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.CodeNotFound, appErr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestReconciliationService_Alert_SkippedWithoutEmail(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	svc := newTestReconciliationService(repo)
+
+	svc.alert(context.Background(), &models.ReconciliationReport{DiscrepancyCount: 1})
+
+	repo.AssertNotCalled(t, "ListAdminEmails", mock.Anything)
+}
+
+func TestReconciliationService_GetReport_RepoError(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	svc := newTestReconciliationService(repo)
+	ctx := context.Background()
+	reportID := uuid.New()
+
+	repo.On("GetReport", ctx, reportID).Return(nil, errors.New("db down"))
+
+	_, err := svc.GetReport(ctx, reportID, "")
+
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.CodeInternal, appErr.Code)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/ledger"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/taxledger"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+)
+
+const defaultReportDays = 30
+
+type ReportService struct {
+	ledgerRepo ledger.Repository
+	taxLedger  taxledger.Repository
+	logger     *slog.Logger
+}
+
+func NewReportService(ledgerRepo ledger.Repository, taxLedger taxledger.Repository, logger *slog.Logger) *ReportService {
+	return &ReportService{ledgerRepo: ledgerRepo, taxLedger: taxLedger, logger: logger}
+}
+
+// reportRange applies the report defaults: daily periods over the last 30
+// days
+func reportRange(period models.ReportPeriod, from, to time.Time) (models.ReportPeriod, time.Time, time.Time, error) {
+	if period == "" {
+		period = models.PeriodDay
+	}
+	if !period.Valid() {
+		return "", from, to, apperrors.NewValidationError("period must be one of day, week, month")
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultReportDays)
+	}
+	if !from.Before(to) {
+		return "", from, to, apperrors.NewValidationError("from must be before to")
+	}
+	return period, from, to, nil
+}
+
+// GetFeeRevenue aggregates the platform fee revenue in [from, to) per period.
+// Zero bounds default to the last 30 days.
+func (s *ReportService) GetFeeRevenue(ctx context.Context, period models.ReportPeriod, from, to time.Time) (*models.RevenueReport, error) {
+	period, from, to, err := reportRange(period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.ledgerRepo.GetRevenueByPeriod(ctx, models.LedgerFee, period, from, to)
+	if err != nil {
+		s.logger.Error("failed to get fee revenue", "period", period, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get fee revenue")
+	}
+
+	report := &models.RevenueReport{
+		Type:   models.LedgerFee,
+		Period: period,
+		From:   from,
+		To:     to,
+		Rows:   rows,
+	}
+	for _, row := range rows {
+		report.Total += row.Total
+	}
+	report.Total = roundCents(report.Total)
+	return report, nil
+}
+
+// GetTaxReport sums the VAT booked in [from, to) per period, country and
+// rate. Refunds count in the period they happened, with negative amounts.
+// Zero bounds default to the last 30 days.
+func (s *ReportService) GetTaxReport(ctx context.Context, period models.ReportPeriod, from, to time.Time) (*models.TaxReport, error) {
+	period, from, to, err := reportRange(period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.taxLedger.GetReport(ctx, period, from, to)
+	if err != nil {
+		s.logger.Error("failed to get tax report", "period", period, "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get tax report")
+	}
+
+	report := &models.TaxReport{
+		Period: period,
+		From:   from,
+		To:     to,
+		Rows:   rows,
+	}
+	for _, row := range rows {
+		report.NetTotal += row.NetAmount
+		report.TaxTotal += row.TaxAmount
+	}
+	report.NetTotal = roundCents(report.NetTotal)
+	report.TaxTotal = roundCents(report.TaxTotal)
+	return report, nil
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/risk"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxReplayRange bounds how much history a single replay loads
+	maxReplayRange = 31 * 24 * time.Hour
+	// maxReplayHits is how many individual hits a replay report lists
+	maxReplayHits = 500
+	// defaultHitsLookback is how far back a user's rule hits are listed by default
+	defaultHitsLookback = 30 * 24 * time.Hour
+)
+
+type RiskService struct {
+	riskRepo risk.Repository
+	engine   *fraud.Engine
+	db       *sqlx.DB
+	logger   *slog.Logger
+}
+
+func NewRiskService(riskRepo risk.Repository, engine *fraud.Engine, db *sqlx.DB, logger *slog.Logger) *RiskService {
+	return &RiskService{riskRepo: riskRepo, engine: engine, db: db, logger: logger}
+}
+
+// txHistory answers the engine's questions about a user's history inside the
+// transaction that is about to move money
+type txHistory struct {
+	repo risk.Repository
+	tx   *sqlx.Tx
+}
+
+func (h txHistory) Sum(ctx context.Context, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (float64, error) {
+	return h.repo.Sum(ctx, h.tx, userID, kind, since)
+}
+
+func (h txHistory) Count(ctx context.Context, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (int, error) {
+	return h.repo.Count(ctx, h.tx, userID, kind, since)
+}
+
+func (h txHistory) CountWith(ctx context.Context, userID uuid.UUID, kind models.RiskEventKind, counterpartyID uuid.UUID, since time.Time) (int, error) {
+	return h.repo.CountWith(ctx, h.tx, userID, kind, counterpartyID, since)
+}
+
+// Assess checks a money movement against the risk rules. It must run in the
+// transaction that moves the money, after the user is locked and before
+// anything has been written. Rule hits are recorded in that transaction and
+// movements that need review get a flag, which is returned; nil means no rule
+// asked for one.
+//
+// A blocked movement gets a forbidden error for the caller to pass on, and its
+// flag and hits are recorded in a transaction of their own so they survive the
+// caller rolling tx back.
+func (s *RiskService) Assess(ctx context.Context, tx *sqlx.Tx, ev fraud.Event, referenceID *uuid.UUID) (*models.RiskFlag, error) {
+	decision, err := s.engine.Evaluate(ctx, ev, txHistory{repo: s.riskRepo, tx: tx})
+	if err != nil {
+		s.logger.Error("failed to evaluate risk rules", "error", err, "user_id", ev.UserID, "kind", ev.Kind)
+		return nil, apperrors.WrapInternal(err, "failed to evaluate risk rules")
+	}
+	if len(decision.Hits) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var flag *models.RiskFlag
+	if decision.Action != models.RiskActionAllow {
+		flag = &models.RiskFlag{
+			ID:          uuid.New(),
+			UserID:      ev.UserID,
+			Kind:        ev.Kind,
+			Action:      decision.Action,
+			Amount:      ev.Amount,
+			ReferenceID: referenceID,
+			Status:      models.RiskFlagOpen,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+	}
+
+	hits := make([]*models.RiskRuleHit, 0, len(decision.Hits))
+	for _, h := range decision.Hits {
+		hit := &models.RiskRuleHit{
+			ID:          uuid.New(),
+			UserID:      ev.UserID,
+			Kind:        ev.Kind,
+			Rule:        h.Rule,
+			RuleType:    h.Type,
+			Action:      h.Action,
+			Amount:      ev.Amount,
+			ReferenceID: referenceID,
+			Detail:      h.Detail,
+			CreatedAt:   now,
+		}
+		if flag != nil {
+			hit.FlagID = &flag.ID
+		}
+		hits = append(hits, hit)
+	}
+
+	s.logger.Warn("risk rules matched", "user_id", ev.UserID, "kind", ev.Kind, "amount", ev.Amount,
+		"action", decision.Action, "hits", len(hits))
+
+	if decision.Action == models.RiskActionBlock {
+		// The caller still holds its lock on the user row, which the inserts
+		// wait on through their foreign keys, so the block is written in the
+		// background once the caller has rolled back
+		go s.recordBlock(context.WithoutCancel(ctx), flag, hits)
+		return nil, apperrors.NewForbiddenError("this operation was blocked by our risk checks, please contact support")
+	}
+	if err := s.record(ctx, tx, flag, hits); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// record stores a flag, if there is one, and its rule hits in tx
+func (s *RiskService) record(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag, hits []*models.RiskRuleHit) error {
+	if flag != nil {
+		if err := s.riskRepo.CreateFlag(ctx, tx, flag); err != nil {
+			s.logger.Error("failed to create risk flag", "error", err, "user_id", flag.UserID)
+			return apperrors.WrapInternal(err, "failed to create risk flag")
+		}
+	}
+	if err := s.riskRepo.CreateHits(ctx, tx, hits); err != nil {
+		s.logger.Error("failed to record risk rule hits", "error", err, "user_id", hits[0].UserID)
+		return apperrors.WrapInternal(err, "failed to record risk rule hits")
+	}
+	return nil
+}
+
+// recordBlock stores a blocked movement's flag and hits in their own
+// transaction, leaving the blocked operation's transaction to its caller
+func (s *RiskService) recordBlock(ctx context.Context, flag *models.RiskFlag, hits []*models.RiskRuleHit) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err, "user_id", flag.UserID)
+		return
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	if err := s.record(ctx, tx, flag, hits); err != nil {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "user_id", flag.UserID)
+	}
+}
+
+// ListFlags returns flags by status, the open ones by default
+func (s *RiskService) ListFlags(ctx context.Context, status models.RiskFlagStatus) ([]*models.RiskFlag, error) {
+	if status == "" {
+		status = models.RiskFlagOpen
+	}
+	if !status.Valid() {
+		return nil, apperrors.NewValidationError("invalid flag status")
+	}
+
+	flags, err := s.riskRepo.ListFlags(ctx, status)
+	if err != nil {
+		s.logger.Error("failed to list risk flags", "error", err, "status", status)
+		return nil, apperrors.WrapInternal(err, "failed to list risk flags")
+	}
+	return flags, nil
+}
+
+// GetFlag returns a flag with the rule hits that raised it
+func (s *RiskService) GetFlag(ctx context.Context, flagID uuid.UUID) (*models.RiskFlag, error) {
+	flag, err := s.riskRepo.GetFlag(ctx, flagID)
+	if err != nil {
+		s.logger.Error("failed to get risk flag", "error", err, "flag_id", flagID)
+		return nil, apperrors.WrapInternal(err, "failed to get risk flag")
+	}
+	if flag == nil {
+		return nil, apperrors.NewNotFoundError("flag not found")
+	}
+
+	if flag.Hits, err = s.riskRepo.ListHitsForFlag(ctx, flagID); err != nil {
+		s.logger.Error("failed to list risk rule hits", "error", err, "flag_id", flagID)
+		return nil, apperrors.WrapInternal(err, "failed to list risk rule hits")
+	}
+	return flag, nil
+}
+
+// ReviewFlag closes an open flag. Clearing a held purchase lets the seller's
+// proceeds be released with the next hold release run; held withdrawals are
+// still approved or rejected in the withdrawal queue.
+func (s *RiskService) ReviewFlag(ctx context.Context, adminID, flagID uuid.UUID, status models.RiskFlagStatus, note string) (*models.RiskFlag, error) {
+	if status != models.RiskFlagCleared && status != models.RiskFlagConfirmed {
+		return nil, apperrors.NewValidationError("status must be cleared or confirmed")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	flag, err := s.riskRepo.GetFlagForUpdate(ctx, tx, flagID)
+	if err != nil {
+		s.logger.Error("failed to get risk flag for update", "error", err, "flag_id", flagID)
+		return nil, apperrors.WrapInternal(err, "failed to get risk flag for update")
+	}
+	if flag == nil {
+		return nil, apperrors.NewNotFoundError("flag not found")
+	}
+	if flag.Status != models.RiskFlagOpen {
+		return nil, apperrors.NewValidationError("flag has already been reviewed")
+	}
+
+	now := time.Now()
+	flag.Status = status
+	flag.ReviewedBy = &adminID
+	flag.ReviewedAt = &now
+	flag.UpdatedAt = now
+	if note != "" {
+		flag.ReviewNote = &note
+	}
+	if err := s.riskRepo.UpdateFlag(ctx, tx, flag); err != nil {
+		s.logger.Error("failed to update risk flag", "error", err, "flag_id", flagID)
+		return nil, apperrors.WrapInternal(err, "failed to update risk flag")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "flag_id", flagID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.logger.Info("risk flag reviewed", "flag_id", flagID, "status", status, "admin_id", adminID)
+	return flag, nil
+}
+
+// ListUserHits returns the rule hits of a user since the given time, the last
+// 30 days by default
+func (s *RiskService) ListUserHits(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.RiskRuleHit, error) {
+	if since.IsZero() {
+		since = time.Now().Add(-defaultHitsLookback)
+	}
+	hits, err := s.riskRepo.ListHitsForUser(ctx, userID, since)
+	if err != nil {
+		s.logger.Error("failed to list risk rule hits", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to list risk rule hits")
+	}
+	return hits, nil
+}
+
+// Replay runs rules against the transaction history between from and to and
+// reports what they would have done, without recording anything. Without
+// rules the configured ones are used. The range defaults to the last 7 days.
+func (s *RiskService) Replay(ctx context.Context, from, to time.Time, rules []config.RiskRule) (*models.RiskReplayReport, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-7 * 24 * time.Hour)
+	}
+	if !from.Before(to) {
+		return nil, apperrors.NewValidationError("from must be before to")
+	}
+	if to.Sub(from) > maxReplayRange {
+		return nil, apperrors.NewValidationError("replay range cannot exceed 31 days")
+	}
+
+	engine := s.engine
+	if len(rules) > 0 {
+		var err error
+		if engine, err = fraud.NewEngine(rules); err != nil {
+			return nil, apperrors.NewValidationError(err.Error())
+		}
+	}
+
+	activity, err := s.riskRepo.ListActivity(ctx, from.Add(-engine.MaxWindow()), to)
+	if err != nil {
+		s.logger.Error("failed to load activity for replay", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to load activity for replay")
+	}
+
+	report, err := fraud.Replay(ctx, engine, activity, from, to, maxReplayHits)
+	if err != nil {
+		s.logger.Error("failed to replay risk rules", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to replay risk rules")
+	}
+	return report, nil
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRiskRepository is a mock implementation of risk.Repository
+type MockRiskRepository struct {
+	mock.Mock
+}
+
+func (m *MockRiskRepository) CreateFlag(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag) error {
+	args := m.Called(ctx, tx, flag)
+	return args.Error(0)
+}
+
+func (m *MockRiskRepository) GetFlag(ctx context.Context, id uuid.UUID) (*models.RiskFlag, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RiskFlag), args.Error(1)
+}
+
+func (m *MockRiskRepository) GetFlagForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.RiskFlag, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RiskFlag), args.Error(1)
+}
+
+func (m *MockRiskRepository) ListFlags(ctx context.Context, status models.RiskFlagStatus) ([]*models.RiskFlag, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RiskFlag), args.Error(1)
+}
+
+func (m *MockRiskRepository) UpdateFlag(ctx context.Context, tx *sqlx.Tx, flag *models.RiskFlag) error {
+	args := m.Called(ctx, tx, flag)
+	return args.Error(0)
+}
+
+func (m *MockRiskRepository) CreateHits(ctx context.Context, tx *sqlx.Tx, hits []*models.RiskRuleHit) error {
+	args := m.Called(ctx, tx, hits)
+	return args.Error(0)
+}
+
+func (m *MockRiskRepository) ListHitsForFlag(ctx context.Context, flagID uuid.UUID) ([]*models.RiskRuleHit, error) {
+	args := m.Called(ctx, flagID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RiskRuleHit), args.Error(1)
+}
+
+func (m *MockRiskRepository) ListHitsForUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.RiskRuleHit, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RiskRuleHit), args.Error(1)
+}
+
+func (m *MockRiskRepository) Sum(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (float64, error) {
+	args := m.Called(ctx, tx, userID, kind, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockRiskRepository) Count(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, since time.Time) (int, error) {
+	args := m.Called(ctx, tx, userID, kind, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRiskRepository) CountWith(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, kind models.RiskEventKind, counterpartyID uuid.UUID, since time.Time) (int, error) {
+	args := m.Called(ctx, tx, userID, kind, counterpartyID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRiskRepository) ListActivity(ctx context.Context, from, to time.Time) ([]*models.RiskActivity, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RiskActivity), args.Error(1)
+}
+
+func newTestRiskService(t *testing.T, repo *MockRiskRepository, rules []config.RiskRule) *RiskService {
+	engine, err := fraud.NewEngine(rules)
+	assert.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRiskService(repo, engine, nil, logger)
+}
+
+func TestRiskService_Assess(t *testing.T) {
+	userID := uuid.New()
+	refID := uuid.New()
+	rules := []config.RiskRule{
+		{Name: "big_deposit", Type: fraud.TypeAmount, Kind: "deposit", Threshold: 1000, Action: "flag"},
+		{Name: "any_deposit", Type: fraud.TypeAmount, Kind: "deposit", Threshold: 1, Action: "allow"},
+	}
+
+	t.Run("allow-only hits are recorded without a flag", func(t *testing.T) {
+		repo := new(MockRiskRepository)
+		repo.On("CreateHits", mock.Anything, mock.Anything, mock.MatchedBy(func(hits []*models.RiskRuleHit) bool {
+			return len(hits) == 1 && hits[0].Rule == "any_deposit" && hits[0].FlagID == nil && *hits[0].ReferenceID == refID
+		})).Return(nil)
+
+		flag, err := newTestRiskService(t, repo, rules).Assess(context.Background(), nil,
+			fraud.Event{Kind: models.RiskDeposit, UserID: userID, Amount: 50, At: time.Now()}, &refID)
+
+		assert.NoError(t, err)
+		assert.Nil(t, flag)
+		repo.AssertNotCalled(t, "CreateFlag", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("flagged movements open a flag", func(t *testing.T) {
+		repo := new(MockRiskRepository)
+		repo.On("CreateFlag", mock.Anything, mock.Anything, mock.MatchedBy(func(f *models.RiskFlag) bool {
+			return f.UserID == userID && f.Action == models.RiskActionFlag && f.Status == models.RiskFlagOpen
+		})).Return(nil)
+		repo.On("CreateHits", mock.Anything, mock.Anything, mock.MatchedBy(func(hits []*models.RiskRuleHit) bool {
+			return len(hits) == 2 && hits[0].FlagID != nil && hits[1].FlagID != nil
+		})).Return(nil)
+
+		flag, err := newTestRiskService(t, repo, rules).Assess(context.Background(), nil,
+			fraud.Event{Kind: models.RiskDeposit, UserID: userID, Amount: 5000, At: time.Now()}, &refID)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, flag) {
+			assert.Equal(t, models.RiskActionFlag, flag.Action)
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rules of other kinds are ignored", func(t *testing.T) {
+		repo := new(MockRiskRepository)
+
+		flag, err := newTestRiskService(t, repo, rules).Assess(context.Background(), nil,
+			fraud.Event{Kind: models.RiskWithdraw, UserID: userID, Amount: 5000, At: time.Now()}, &refID)
+
+		assert.NoError(t, err)
+		assert.Nil(t, flag)
+		repo.AssertNotCalled(t, "CreateHits", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRiskService_ListFlags(t *testing.T) {
+	t.Run("defaults to open flags", func(t *testing.T) {
+		repo := new(MockRiskRepository)
+		repo.On("ListFlags", mock.Anything, models.RiskFlagOpen).Return([]*models.RiskFlag{}, nil)
+
+		flags, err := newTestRiskService(t, repo, nil).ListFlags(context.Background(), "")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, flags)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		flags, err := newTestRiskService(t, new(MockRiskRepository), nil).ListFlags(context.Background(), "closed")
+
+		assert.Equal(t, apperrors.NewValidationError("invalid flag status"), err)
+		assert.Nil(t, flags)
+	})
+}
+
+func TestRiskService_ReviewFlag_InvalidStatus(t *testing.T) {
+	flag, err := newTestRiskService(t, new(MockRiskRepository), nil).ReviewFlag(context.Background(), uuid.New(), uuid.New(), models.RiskFlagOpen, "")
+
+	assert.Equal(t, apperrors.NewValidationError("status must be cleared or confirmed"), err)
+	assert.Nil(t, flag)
+}
+
+func TestRiskService_Replay(t *testing.T) {
+	to := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-48 * time.Hour)
+	window := config.Duration(24 * time.Hour)
+	rules := []config.RiskRule{{Name: "busy", Type: fraud.TypeVelocity, Kind: "purchase", Window: window, Threshold: 1, Action: "flag"}}
+
+	t.Run("range too long", func(t *testing.T) {
+		report, err := newTestRiskService(t, new(MockRiskRepository), rules).Replay(context.Background(), to.AddDate(0, -2, 0), to, nil)
+
+		assert.Equal(t, apperrors.NewValidationError("replay range cannot exceed 31 days"), err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("invalid candidate rules", func(t *testing.T) {
+		candidate := []config.RiskRule{{Name: "bad", Type: "magic", Kind: "purchase", Threshold: 1, Action: "flag"}}
+
+		report, err := newTestRiskService(t, new(MockRiskRepository), rules).Replay(context.Background(), from, to, candidate)
+
+		assert.Equal(t, apperrors.NewValidationError(`risk rule "bad": unknown type "magic"`), err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("loads history a window before the range", func(t *testing.T) {
+		userID := uuid.New()
+		repo := new(MockRiskRepository)
+		repo.On("ListActivity", mock.Anything, from.Add(-24*time.Hour), to).Return([]*models.RiskActivity{
+			{ID: uuid.New(), UserID: userID, Kind: models.RiskPurchase, Amount: 10, CreatedAt: from.Add(-time.Hour)},
+			{ID: uuid.New(), UserID: userID, Kind: models.RiskPurchase, Amount: 10, CreatedAt: from.Add(time.Hour)},
+		}, nil)
+
+		report, err := newTestRiskService(t, repo, rules).Replay(context.Background(), from, to, nil)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, report) {
+			assert.Equal(t, 1, report.Events)
+			assert.Equal(t, map[string]int{"busy": 1}, report.RuleHits)
+		}
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/scheduler"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+)
+
+// ScheduleStatus is what the worker's scheduler shares about its runs, it is
+// implemented by *scheduler.Store
+type ScheduleStatus interface {
+	Runs(ctx context.Context) (map[string]models.ScheduleRun, error)
+	Leader(ctx context.Context) (string, error)
+}
+
+type ScheduleService struct {
+	entries []scheduler.Entry
+	status  ScheduleStatus
+	logger  *slog.Logger
+}
+
+func NewScheduleService(entries []scheduler.Entry, status ScheduleStatus, logger *slog.Logger) *ScheduleService {
+	return &ScheduleService{entries: entries, status: status, logger: logger}
+}
+
+// ListSchedules returns every periodic job with its last and next run. The
+// API reads the same config as the worker, so the schedules are the
+// worker's as long as both are deployed with the same settings.
+func (s *ScheduleService) ListSchedules(ctx context.Context) (*models.Schedules, error) {
+	runs, err := s.status.Runs(ctx)
+	if err != nil {
+		s.logger.Error("failed to get schedule runs", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get schedule runs")
+	}
+	leader, err := s.status.Leader(ctx)
+	if err != nil {
+		s.logger.Error("failed to get scheduler leader", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to get scheduler leader")
+	}
+
+	now := time.Now()
+	list := &models.Schedules{Leader: leader, Schedules: make([]*models.Schedule, 0, len(s.entries))}
+	for _, e := range s.entries {
+		schedule := &models.Schedule{
+			Name:     e.Name,
+			TaskType: e.Task.Type(),
+			Queue:    jobs.PolicyFor(e.Task.Type()).Queue,
+			Spec:     e.Spec,
+			Enabled:  e.Enabled(),
+		}
+		if run, ok := runs[e.Name]; ok {
+			schedule.LastRun = &run
+		}
+		// Nobody queues the job without a leader, so there is no next run
+		if e.Enabled() && leader != "" {
+			next, err := e.Next(now)
+			if err != nil {
+				s.logger.Error("invalid schedule", "name", e.Name, "spec", e.Spec, "error", err)
+				return nil, apperrors.WrapInternal(err, "invalid schedule")
+			}
+			schedule.NextRunAt = &next
+		}
+		list.Schedules = append(list.Schedules, schedule)
+	}
+	return list, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/scheduler"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockScheduleStatus is a mock implementation of ScheduleStatus
+type MockScheduleStatus struct {
+	mock.Mock
+}
+
+func (m *MockScheduleStatus) Runs(ctx context.Context) (map[string]models.ScheduleRun, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]models.ScheduleRun), args.Error(1)
+}
+
+func (m *MockScheduleStatus) Leader(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func newTestScheduleService(status *MockScheduleStatus) *ScheduleService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	entries := []scheduler.Entry{
+		{Name: "release-holds", Spec: "@every 5m", Task: jobs.NewReleaseHoldsTask()},
+		{Name: "backfill-market-data", Spec: "", Task: jobs.NewBackfillMarketDataTask()},
+	}
+	return NewScheduleService(entries, status, logger)
+}
+
+func TestScheduleService_ListSchedules(t *testing.T) {
+	status := new(MockScheduleStatus)
+	svc := newTestScheduleService(status)
+	ctx := context.Background()
+	lastRun := models.ScheduleRun{At: time.Now().Add(-time.Minute), TaskID: "task-1"}
+
+	status.On("Runs", ctx).Return(map[string]models.ScheduleRun{"release-holds": lastRun}, nil)
+	status.On("Leader", ctx).Return("worker-1-42", nil)
+
+	list, err := svc.ListSchedules(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "worker-1-42", list.Leader)
+	if assert.Len(t, list.Schedules, 2) {
+		holds := list.Schedules[0]
+		assert.Equal(t, jobs.ReleaseHolds, holds.TaskType)
+		assert.Equal(t, "default", holds.Queue)
+		assert.True(t, holds.Enabled)
+		assert.Equal(t, &lastRun, holds.LastRun)
+		if assert.NotNil(t, holds.NextRunAt) {
+			assert.WithinDuration(t, time.Now().Add(5*time.Minute), *holds.NextRunAt, 5*time.Second)
+		}
+
+		backfill := list.Schedules[1]
+		assert.False(t, backfill.Enabled)
+		assert.Nil(t, backfill.LastRun)
+		assert.Nil(t, backfill.NextRunAt)
+	}
+
+	status.AssertExpectations(t)
+}
+
+func TestScheduleService_ListSchedules_NoLeader(t *testing.T) {
+	status := new(MockScheduleStatus)
+	svc := newTestScheduleService(status)
+	ctx := context.Background()
+
+	status.On("Runs", ctx).Return(map[string]models.ScheduleRun{}, nil)
+	status.On("Leader", ctx).Return("", nil)
+
+	list, err := svc.ListSchedules(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Leader)
+	assert.Nil(t, list.Schedules[0].NextRunAt)
+}
+
+func TestScheduleService_ListSchedules_StatusError(t *testing.T) {
+	status := new(MockScheduleStatus)
+	svc := newTestScheduleService(status)
+	ctx := context.Background()
+
+	status.On("Runs", ctx).Return(nil, errors.New("redis down"))
+
+	_, err := svc.ListSchedules(ctx)
+	assertAppErrorCode(t, err, apperrors.CodeInternal)
+}
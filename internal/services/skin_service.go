@@ -6,18 +6,20 @@ import (
 	"time"
 
 	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/skin"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"github.com/google/uuid"
 )
 
 type Skin struct {
-	repo   skin.Repository
-	logger *slog.Logger
+	repo      skin.Repository
+	publisher realtime.Publisher
+	logger    *slog.Logger
 }
 
-func NewSkin(repo skin.Repository, logger *slog.Logger) *Skin {
-	return &Skin{repo: repo, logger: logger}
+func NewSkin(repo skin.Repository, publisher realtime.Publisher, logger *slog.Logger) *Skin {
+	return &Skin{repo: repo, publisher: publisher, logger: logger}
 }
 
 // GetAllGuns returns all available guns in the system
@@ -84,6 +86,11 @@ func (s *Skin) CreateSkin(ctx context.Context, skin *models.Skin) (*models.Skin,
 		return nil, apperrors.NewInternalError("Failed to create skin", err)
 	}
 
+	// New skins are listed straight away, so streaming clients see them as new listings
+	if err := s.publisher.PublishMarket(ctx, realtime.NewSkinEvent(realtime.EventListingCreated, skin)); err != nil {
+		s.logger.Warn("failed to publish market event", "skin_id", skin.ID, "err", err)
+	}
+
 	s.logger.Info("skin created successfully", "skin_id", skin.ID)
 	return skin, nil
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"io"
 	"testing"
+	"time"
 
 	"log/slog"
 
 	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -64,6 +66,14 @@ func (m *MockSkinRepository) GetUserSkins(ctx context.Context, userID uuid.UUID)
 	return args.Get(0).([]*models.Skin), args.Error(1)
 }
 
+func (m *MockSkinRepository) GetLowestListings(ctx context.Context, skinType models.SkinType, excludeID uuid.UUID, limit int) ([]*models.Skin, error) {
+	args := m.Called(ctx, skinType, excludeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Skin), args.Error(1)
+}
+
 // Add missing methods to satisfy the interface
 func (m *MockSkinRepository) GetSkinsForUpdate(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID) ([]*models.Skin, error) {
 	args := m.Called(ctx, tx, skinIDs)
@@ -73,6 +83,11 @@ func (m *MockSkinRepository) GetSkinsForUpdate(ctx context.Context, tx *sqlx.Tx,
 	return args.Get(0).([]*models.Skin), args.Error(1)
 }
 
+func (m *MockSkinRepository) ReleaseToMarket(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID) error {
+	args := m.Called(ctx, tx, skinID)
+	return args.Error(0)
+}
+
 func (m *MockSkinRepository) UpdateOwnership(ctx context.Context, tx *sqlx.Tx, skinIDs []uuid.UUID, newOwnerID uuid.UUID) error {
 	args := m.Called(ctx, tx, skinIDs, newOwnerID)
 	return args.Error(0)
@@ -88,10 +103,28 @@ func (m *MockSkinRepository) UpdateAvailability(ctx context.Context, tx *sqlx.Tx
 	return args.Error(0)
 }
 
+func (m *MockSkinRepository) SetListingExpiry(ctx context.Context, tx *sqlx.Tx, skinID uuid.UUID, days *int, expiresAt *time.Time) error {
+	args := m.Called(ctx, tx, skinID, days, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSkinRepository) GetExpiredListings(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) ([]*models.Skin, error) {
+	args := m.Called(ctx, tx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Skin), args.Error(1)
+}
+
+func (m *MockSkinRepository) RecordListing(ctx context.Context, tx *sqlx.Tx, listing *models.Listing) error {
+	args := m.Called(ctx, tx, listing)
+	return args.Error(0)
+}
+
 func TestSkinService_GetAllGuns(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	mockRepo := new(MockSkinRepository)
-	service := NewSkin(mockRepo, logger)
+	service := NewSkin(mockRepo, realtime.NopPublisher{}, logger)
 
 	guns := service.GetAllGuns()
 
@@ -117,7 +150,7 @@ func TestSkinService_GetAllGuns(t *testing.T) {
 func TestSkinService_GetAllWears(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	mockRepo := new(MockSkinRepository)
-	service := NewSkin(mockRepo, logger)
+	service := NewSkin(mockRepo, realtime.NopPublisher{}, logger)
 
 	wears := service.GetAllWears()
 
@@ -307,7 +340,7 @@ func TestSkinService_CreateSkin(t *testing.T) {
 			mockRepo := new(MockSkinRepository)
 			tt.mockSetup(mockRepo)
 
-			service := NewSkin(mockRepo, logger)
+			service := NewSkin(mockRepo, realtime.NopPublisher{}, logger)
 			result, err := service.CreateSkin(context.Background(), tt.skin)
 
 			if tt.expectedError != nil {
@@ -386,7 +419,7 @@ func TestSkinService_GetSkinByID(t *testing.T) {
 			mockRepo := new(MockSkinRepository)
 			tt.mockSetup(mockRepo)
 
-			service := NewSkin(mockRepo, logger)
+			service := NewSkin(mockRepo, realtime.NopPublisher{}, logger)
 			skin, err := service.GetSkinByID(context.Background(), tt.skinID)
 
 			if tt.expectedError != nil {
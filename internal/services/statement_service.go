@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/pdfdoc"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/statement"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+)
+
+type StatementService struct {
+	transactionRepo transaction.Repository
+	logger          *slog.Logger
+}
+
+func NewStatementService(transactionRepo transaction.Repository, logger *slog.Logger) *StatementService {
+	return &StatementService{transactionRepo: transactionRepo, logger: logger}
+}
+
+// Generate returns the user's statement for the month. The current month's
+// statement runs up to now.
+func (s *StatementService) Generate(ctx context.Context, userID uuid.UUID, month time.Time) (*models.Statement, error) {
+	start, end := monthRange(month)
+	now := time.Now().UTC()
+	if start.After(now) {
+		return nil, apperrors.NewValidationError("statement month has not started yet")
+	}
+	if end.After(now) {
+		end = now
+	}
+
+	opening, err := s.transactionRepo.GetBalancesAt(ctx, userID, start)
+	if err != nil {
+		s.logger.Error("failed to get opening balances", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to get opening balances")
+	}
+
+	// Streamed newest first, the statement lists them oldest first
+	var txns []*models.Transaction
+	err = s.transactionRepo.StreamUserTransactions(ctx, userID, models.TransactionFilter{From: start, To: end},
+		func(txn *models.Transaction) error {
+			txns = append(txns, txn)
+			return nil
+		})
+	if err != nil {
+		s.logger.Error("failed to list statement transactions", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to list statement transactions")
+	}
+	for i, j := 0, len(txns)-1; i < j; i, j = i+1, j-1 {
+		txns[i], txns[j] = txns[j], txns[i]
+	}
+
+	return statement.Build(userID, start, end, opening, txns), nil
+}
+
+// GeneratePDF renders the user's statement for the month as a PDF
+func (s *StatementService) GeneratePDF(ctx context.Context, userID uuid.UUID, month time.Time) ([]byte, error) {
+	st, err := s.Generate(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := renderStatement(st)
+	if err != nil {
+		s.logger.Error("failed to render statement", "error", err, "user_id", userID)
+		return nil, apperrors.NewInternalError("failed to generate PDF", err)
+	}
+	return pdf, nil
+}
+
+func renderStatement(st *models.Statement) ([]byte, error) {
+	pdf := pdfdoc.New()
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont(pdfdoc.Font, "B", 18)
+	pdf.Cell(0, 10, "Account Statement")
+	pdf.Ln(12)
+
+	pdf.SetFont(pdfdoc.Font, "", 11)
+	pdf.Cell(0, 6, "Account: "+st.UserID.String())
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Period: %s to %s (UTC)",
+		st.PeriodStart.Format("2006-01-02"), st.PeriodEnd.Add(-time.Second).Format("2006-01-02")))
+	pdf.Ln(10)
+
+	balances := func(label string, b models.StatementBalances) {
+		pdf.SetFont(pdfdoc.Font, "B", 11)
+		pdf.CellFormat(45, 7, label, "", 0, "", false, 0, "")
+		pdf.SetFont(pdfdoc.Font, "", 11)
+		pdf.CellFormat(60, 7, fmt.Sprintf("Available: %.2f", b.Available), "", 0, "", false, 0, "")
+		pdf.CellFormat(60, 7, fmt.Sprintf("Pending: %.2f", b.Pending), "", 1, "", false, 0, "")
+	}
+	balances("Opening balance", st.Opening)
+	pdf.Ln(4)
+
+	// Transactions with the balances after each of them
+	widths := []float64{32, 30, 22, 26, 26, 26}
+	header := func() {
+		pdf.SetFont(pdfdoc.Font, "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, title := range []string{"Date", "Type", "Balance", "Amount", "Available", "Pending"} {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, title, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(pdfdoc.Font, "", 9)
+	}
+	header()
+	_, pageHeight := pdf.GetPageSize()
+	for _, line := range st.Lines {
+		if pdf.GetY() > pageHeight-25 {
+			pdf.AddPage()
+			header()
+		}
+		txn := line.Transaction
+		pdf.CellFormat(widths[0], 6, txn.CreatedAt.UTC().Format("2006-01-02 15:04"), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, string(txn.Type), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, string(txn.BalanceType), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, fmt.Sprintf("%.2f", txn.BalanceAfter-txn.BalanceBefore), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, fmt.Sprintf("%.2f", line.Available), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, fmt.Sprintf("%.2f", line.Pending), "1", 1, "R", false, 0, "")
+	}
+	if len(st.Lines) == 0 {
+		pdf.CellFormat(162, 6, "No transactions in this period", "1", 1, "C", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	balances("Closing balance", st.Closing)
+	pdf.Ln(4)
+
+	pdf.SetFont(pdfdoc.Font, "B", 11)
+	pdf.Cell(0, 7, "Totals by type")
+	pdf.Ln(8)
+	pdf.SetFont(pdfdoc.Font, "", 10)
+	for _, total := range st.Totals {
+		pdf.CellFormat(45, 6, string(total.Type), "", 0, "", false, 0, "")
+		pdf.CellFormat(30, 6, fmt.Sprintf("%d", total.Count), "", 0, "R", false, 0, "")
+		pdf.CellFormat(35, 6, fmt.Sprintf("%.2f", total.Net), "", 1, "R", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
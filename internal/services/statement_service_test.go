@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestStatementService(repo *MockTransactionRepository) *StatementService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewStatementService(repo, logger)
+}
+
+func TestStatementService_Generate(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestStatementService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+	start := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	// newest first, as the repository streams them
+	txns := []*models.Transaction{
+		{ID: uuid.New(), Type: models.Purchase, BalanceType: models.BalanceAvailable, Amount: -30, BalanceBefore: 110, BalanceAfter: 80, CreatedAt: start.Add(48 * time.Hour)},
+		{ID: uuid.New(), Type: models.Deposit, BalanceType: models.BalanceAvailable, Amount: 100, BalanceBefore: 10, BalanceAfter: 110, CreatedAt: start.Add(24 * time.Hour)},
+	}
+	repo.On("GetBalancesAt", ctx, userID, start).Return(models.StatementBalances{Available: 10}, nil)
+	repo.On("StreamUserTransactions", ctx, userID, models.TransactionFilter{From: start, To: end}, mock.Anything).Return(txns, nil)
+
+	st, err := svc.Generate(ctx, userID, time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, st.Opening.Available)
+	assert.Equal(t, 80.0, st.Closing.Available)
+	if assert.Len(t, st.Lines, 2) {
+		assert.Equal(t, models.Deposit, st.Lines[0].Transaction.Type)
+		assert.Equal(t, 110.0, st.Lines[0].Available)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestStatementService_Generate_FutureMonth(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestStatementService(repo)
+
+	_, err := svc.Generate(context.Background(), uuid.New(), time.Now().AddDate(0, 2, 0))
+
+	assertValidationError(t, err)
+	repo.AssertNotCalled(t, "GetBalancesAt", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRenderStatement(t *testing.T) {
+	start := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	st := &models.Statement{
+		UserID:      uuid.New(),
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		Closing:     models.StatementBalances{Available: 100},
+		Totals:      []models.StatementTotal{{Type: models.Deposit, Count: 1, Net: 100}},
+	}
+	// enough rows to spill onto a second page
+	for i := 0; i < 60; i++ {
+		st.Lines = append(st.Lines, models.StatementLine{
+			Transaction: &models.Transaction{Type: models.Deposit, BalanceType: models.BalanceAvailable, CreatedAt: start},
+		})
+	}
+
+	pdf, err := renderStatement(st)
+
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+}
@@ -1,186 +1,195 @@
 package services
 
 import (
+	"bytes"
 	"context"
-	"database/sql"
+	"encoding/base64"
 	"errors"
+	"io"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/Uranury/RBK_finalProject/internal/export"
 	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
-	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"github.com/google/uuid"
-	"github.com/jmoiron/sqlx"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	defaultTransactionPage = 50
+	maxTransactionPage     = 200
 )
 
 type TransactionService struct {
 	transactionRepo transaction.Repository
-	userRepo        user.Repository
-	db              *sqlx.DB
+	queue           *asynq.Client
 	logger          *slog.Logger
 }
 
-func NewTransactionService(transactionRepo transaction.Repository, userRepo user.Repository, db *sqlx.DB, logger *slog.Logger) *TransactionService {
+func NewTransactionService(transactionRepo transaction.Repository, queue *asynq.Client, logger *slog.Logger) *TransactionService {
 	return &TransactionService{
 		transactionRepo: transactionRepo,
-		userRepo:        userRepo,
-		db:              db,
+		queue:           queue,
 		logger:          logger,
 	}
 }
 
-// Withdraw handles withdrawing money from user's balance
-func (s *TransactionService) Withdraw(ctx context.Context, userID uuid.UUID, amount float64) (*models.Transaction, error) {
-	s.logger.Info("starting withdrawal", "user_id", userID, "amount", amount)
-
-	// Validate amount
-	if amount <= 0 {
-		return nil, apperrors.NewValidationError("Withdrawal amount must be greater than zero")
+func validateTransactionFilter(filter models.TransactionFilter) error {
+	for _, t := range filter.Types {
+		if !t.Valid() {
+			return apperrors.NewValidationError("invalid transaction type " + string(t))
+		}
 	}
-
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		s.logger.Error("failed to start transaction", "error", err)
-		return nil, apperrors.NewInternalError("Failed to process withdrawal", err)
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return apperrors.NewValidationError("from must be before to")
 	}
-	defer func(tx *sqlx.Tx) {
-		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			s.logger.Error("failed to rollback transaction", "error", err)
-		}
-	}(tx)
-
-	// Get current user balance
-	usr, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, userID)
-	if err != nil {
-		s.logger.Error("failed to get user for update", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process withdrawal", err)
+	if filter.MinAmount != nil && *filter.MinAmount < 0 {
+		return apperrors.NewValidationError("min_amount cannot be negative")
 	}
-	if usr == nil {
-		return nil, apperrors.NewNotFoundError("User not found")
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return apperrors.NewValidationError("min_amount cannot exceed max_amount")
 	}
+	return nil
+}
 
-	balanceBefore := usr.Balance
-	if balanceBefore < amount {
-		s.logger.Warn("insufficient funds for withdrawal", "user_id", userID, "balance", balanceBefore, "requested", amount)
-		return nil, apperrors.NewValidationError("Insufficient funds for withdrawal")
-	}
+// encodeCursor turns the position of a page's last transaction into an
+// opaque cursor
+func encodeCursor(c models.TransactionCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
 
-	// Update user balance
-	newBalance := balanceBefore - amount
-	if err := s.userRepo.UpdateBalance(ctx, tx, userID, newBalance); err != nil {
-		s.logger.Error("failed to update user balance", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process withdrawal", err)
+func decodeCursor(s string) (*models.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, apperrors.NewValidationError("invalid cursor")
 	}
-
-	// Create transaction record
-	now := time.Now()
-	trnsc := &models.Transaction{
-		ID:            uuid.New(),
-		UserID:        userID,
-		Amount:        amount,
-		Type:          models.Withdraw,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  newBalance,
-		CreatedAt:     now,
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, apperrors.NewValidationError("invalid cursor")
 	}
 
-	if err := s.transactionRepo.Create(ctx, tx, trnsc); err != nil {
-		s.logger.Error("failed to create transaction record", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process withdrawal", err)
+	c := &models.TransactionCursor{}
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, apperrors.NewValidationError("invalid cursor")
 	}
-
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		s.logger.Error("failed to commit transaction", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process withdrawal", err)
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return nil, apperrors.NewValidationError("invalid cursor")
 	}
-
-	s.logger.Info("withdrawal completed successfully",
-		"user_id", userID,
-		"amount", amount,
-		"balance_before", balanceBefore,
-		"balance_after", newBalance)
-
-	return trnsc, nil
+	return c, nil
 }
 
-// Deposit handles depositing money to user's balance
-func (s *TransactionService) Deposit(ctx context.Context, userID uuid.UUID, amount float64) (*models.Transaction, error) {
-	s.logger.Info("starting deposit", "user_id", userID, "amount", amount)
-
-	// Validate amount
-	if amount <= 0 {
-		return nil, apperrors.NewValidationError("Deposit amount must be greater than zero")
+// ListTransactions returns a page of the user's transactions matching the
+// filter, newest first. An empty cursor starts at the newest transaction.
+func (s *TransactionService) ListTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error) {
+	if limit <= 0 {
+		limit = defaultTransactionPage
 	}
-
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		s.logger.Error("failed to start transaction", "error", err)
-		return nil, apperrors.NewInternalError("Failed to process deposit", err)
+	if limit > maxTransactionPage {
+		return nil, apperrors.NewValidationError("limit cannot exceed 200")
+	}
+	if err := validateTransactionFilter(filter); err != nil {
+		return nil, err
 	}
-	defer func(tx *sqlx.Tx) {
-		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			s.logger.Error("failed to rollback transaction", "error", err)
+
+	var after *models.TransactionCursor
+	if cursor != "" {
+		var err error
+		if after, err = decodeCursor(cursor); err != nil {
+			return nil, err
 		}
-	}(tx)
+	}
 
-	// Get current user balance
-	usr, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, userID)
+	// One extra row tells whether there is a next page
+	transactions, err := s.transactionRepo.ListUserTransactions(ctx, userID, filter, after, limit+1)
 	if err != nil {
-		s.logger.Error("failed to get user for update", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process deposit", err)
-	}
-	if usr == nil {
-		return nil, apperrors.NewNotFoundError("User not found")
+		s.logger.Error("failed to list transactions", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to retrieve transaction history")
 	}
 
-	balanceBefore := usr.Balance
-	newBalance := balanceBefore + amount
-
-	// Update user balance
-	if err := s.userRepo.UpdateBalance(ctx, tx, userID, newBalance); err != nil {
-		s.logger.Error("failed to update user balance", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process deposit", err)
+	page := &models.TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = encodeCursor(models.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID})
 	}
+	return page, nil
+}
 
-	// Create transaction record
-	now := time.Now()
-	trnsc := &models.Transaction{
-		ID:            uuid.New(),
-		UserID:        userID,
-		Amount:        amount,
-		Type:          models.Deposit,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  newBalance,
-		CreatedAt:     now,
+// ExportTransactions writes every transaction of the user matching the filter
+// to w, newest first. Nothing is written when the filter or format is invalid.
+func (s *TransactionService) ExportTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, format export.Format, w io.Writer) error {
+	if !format.Valid() {
+		return apperrors.NewValidationError("format must be csv or ndjson")
 	}
-
-	if err := s.transactionRepo.Create(ctx, tx, trnsc); err != nil {
-		s.logger.Warn("failed to create transaction record", "error", err, "user_id", userID)
+	if err := validateTransactionFilter(filter); err != nil {
+		return err
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		s.logger.Error("failed to commit transaction", "error", err, "user_id", userID)
-		return nil, apperrors.NewInternalError("Failed to process deposit", err)
+	writer, err := export.NewTransactionWriter(format, w)
+	if err != nil {
+		return apperrors.WrapInternal(err, "failed to create export writer")
+	}
+	if err := s.transactionRepo.StreamUserTransactions(ctx, userID, filter, writer.Write); err != nil {
+		s.logger.Error("failed to export transactions", "error", err, "user_id", userID)
+		return apperrors.WrapInternal(err, "failed to export transactions")
 	}
+	if err := writer.Close(); err != nil {
+		s.logger.Error("failed to flush transaction export", "error", err, "user_id", userID)
+		return apperrors.WrapInternal(err, "failed to flush transaction export")
+	}
+	return nil
+}
 
-	s.logger.Info("deposit completed successfully",
-		"user_id", userID,
-		"amount", amount,
-		"balance_before", balanceBefore,
-		"balance_after", newBalance)
+// monthRange returns the first instant of the month and of the next one, in UTC
+func monthRange(month time.Time) (time.Time, time.Time) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
 
-	return trnsc, nil
+// MonthlyExport returns the user's transactions of the month as CSV
+func (s *TransactionService) MonthlyExport(ctx context.Context, userID uuid.UUID, month time.Time) ([]byte, error) {
+	from, to := monthRange(month)
+	var buf bytes.Buffer
+	if err := s.ExportTransactions(ctx, userID, models.TransactionFilter{From: from, To: to}, export.FormatCSV, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-// GetUserTransactions returns transaction history for a user
-func (s *TransactionService) GetUserTransactions(ctx context.Context, userID uuid.UUID) ([]*models.Transaction, error) {
-	transactions, err := s.transactionRepo.GetUserTransactions(ctx, userID)
+// QueueMonthlyExports queues an email with the month's statement and
+// transactions for every user who had any. Each user's email is queued at most
+// once per month.
+func (s *TransactionService) QueueMonthlyExports(ctx context.Context, month time.Time) (int, error) {
+	from, to := monthRange(month)
+	recipients, err := s.transactionRepo.ListActiveUsers(ctx, from, to)
 	if err != nil {
-		return nil, apperrors.NewInternalError("Failed to retrieve transaction history", err)
+		s.logger.Error("failed to list users with transactions", "error", err, "month", from.Format("2006-01"))
+		return 0, apperrors.WrapInternal(err, "failed to list users with transactions")
 	}
-	return transactions, nil
+
+	queued := 0
+	for _, r := range recipients {
+		task, err := jobs.NewSendTransactionExportTask(r.UserID, r.Email, from)
+		if err != nil {
+			s.logger.Error("failed to create transaction export task", "error", err, "user_id", r.UserID)
+			continue
+		}
+		taskID := "transaction-export:" + r.UserID.String() + ":" + from.Format("2006-01")
+		if _, err := s.queue.EnqueueContext(ctx, task, asynq.TaskID(taskID)); err != nil {
+			if errors.Is(err, asynq.ErrTaskIDConflict) {
+				continue
+			}
+			s.logger.Error("failed to enqueue transaction export", "error", err, "user_id", r.UserID)
+			continue
+		}
+		queued++
+	}
+
+	s.logger.Info("monthly transaction exports queued", "month", from.Format("2006-01"), "users", len(recipients), "queued", queued)
+	return queued, nil
 }
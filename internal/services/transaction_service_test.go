@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/export"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransactionRepository is a mock implementation of transaction.Repository
+type MockTransactionRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransactionRepository) Create(ctx context.Context, tx *sqlx.Tx, transaction *models.Transaction) error {
+	args := m.Called(ctx, tx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockTransactionRepository) ListUserTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, after *models.TransactionCursor, limit int) ([]*models.Transaction, error) {
+	args := m.Called(ctx, userID, filter, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionRepository) StreamUserTransactions(ctx context.Context, userID uuid.UUID, filter models.TransactionFilter, fn func(*models.Transaction) error) error {
+	args := m.Called(ctx, userID, filter, fn)
+	if txns, ok := args.Get(0).([]*models.Transaction); ok {
+		for _, txn := range txns {
+			if err := fn(txn); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetBalancesAt(ctx context.Context, userID uuid.UUID, at time.Time) (models.StatementBalances, error) {
+	args := m.Called(ctx, userID, at)
+	return args.Get(0).(models.StatementBalances), args.Error(1)
+}
+
+func (m *MockTransactionRepository) ListActiveUsers(ctx context.Context, from, to time.Time) ([]models.ExportRecipient, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ExportRecipient), args.Error(1)
+}
+
+func (m *MockTransactionRepository) ListOrderRefunds(ctx context.Context, orderID uuid.UUID) ([]*models.Transaction, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Transaction), args.Error(1)
+}
+
+func newTestTransactionService(repo *MockTransactionRepository) *TransactionService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewTransactionService(repo, nil, logger)
+}
+
+func assertValidationError(t *testing.T, err error) {
+	t.Helper()
+	var appErr *apperrors.AppError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+	}
+}
+
+func TestTransactionCursor_RoundTrip(t *testing.T) {
+	c := models.TransactionCursor{
+		CreatedAt: time.Date(2025, 3, 10, 12, 0, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := decodeCursor(encodeCursor(c))
+
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, decoded.ID)
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+
+	_, err = decodeCursor("not-a-cursor")
+	assertValidationError(t, err)
+}
+
+func TestTransactionService_ListTransactions_Pages(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestTransactionService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+	filter := models.TransactionFilter{Types: []models.TransactionType{models.Sale}}
+
+	now := time.Now()
+	txns := []*models.Transaction{
+		{ID: uuid.New(), CreatedAt: now},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Minute)},
+	}
+	repo.On("ListUserTransactions", ctx, userID, filter, (*models.TransactionCursor)(nil), 3).Return(txns, nil)
+
+	page, err := svc.ListTransactions(ctx, userID, filter, "", 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, txns[:2], page.Transactions)
+	if assert.NotEmpty(t, page.NextCursor) {
+		next, err := decodeCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, txns[1].ID, next.ID)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestTransactionService_ListTransactions_LastPage(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestTransactionService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+	after := &models.TransactionCursor{CreatedAt: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC), ID: uuid.New()}
+	txns := []*models.Transaction{{ID: uuid.New()}}
+
+	repo.On("ListUserTransactions", ctx, userID, models.TransactionFilter{}, after, defaultTransactionPage+1).Return(txns, nil)
+
+	page, err := svc.ListTransactions(ctx, userID, models.TransactionFilter{}, encodeCursor(*after), 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, txns, page.Transactions)
+	assert.Empty(t, page.NextCursor)
+	repo.AssertExpectations(t)
+}
+
+func TestTransactionService_ListTransactions_InvalidFilter(t *testing.T) {
+	minAmount, maxAmount := 50.0, 10.0
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		filter models.TransactionFilter
+	}{
+		{"unknown type", models.TransactionFilter{Types: []models.TransactionType{"gift"}}},
+		{"empty range", models.TransactionFilter{From: now, To: now}},
+		{"amount range", models.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTransactionRepository)
+			svc := newTestTransactionService(repo)
+
+			_, err := svc.ListTransactions(context.Background(), uuid.New(), tt.filter, "", 0)
+
+			assertValidationError(t, err)
+			repo.AssertNotCalled(t, "ListUserTransactions", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTransactionService_ExportTransactions(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestTransactionService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+	txns := []*models.Transaction{
+		{ID: uuid.New(), Type: models.Deposit, BalanceType: models.BalanceAvailable, Amount: 100, BalanceAfter: 100},
+		{ID: uuid.New(), Type: models.Purchase, BalanceType: models.BalanceAvailable, Amount: -30, BalanceBefore: 100, BalanceAfter: 70},
+	}
+	repo.On("StreamUserTransactions", ctx, userID, models.TransactionFilter{}, mock.Anything).Return(txns, nil)
+
+	var buf bytes.Buffer
+	err := svc.ExportTransactions(ctx, userID, models.TransactionFilter{}, export.FormatNDJSON, &buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+	repo.AssertExpectations(t)
+}
+
+func TestTransactionService_ExportTransactions_InvalidFormat(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestTransactionService(repo)
+
+	var buf bytes.Buffer
+	err := svc.ExportTransactions(context.Background(), uuid.New(), models.TransactionFilter{}, "xlsx", &buf)
+
+	assertValidationError(t, err)
+	assert.Zero(t, buf.Len())
+}
+
+func TestTransactionService_MonthlyExport(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	svc := newTestTransactionService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+	month := time.Date(2025, 2, 17, 9, 30, 0, 0, time.UTC)
+	filter := models.TransactionFilter{
+		From: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	repo.On("StreamUserTransactions", ctx, userID, filter, mock.Anything).Return([]*models.Transaction{}, nil)
+
+	csv, err := svc.MonthlyExport(ctx, userID, month)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(csv), "id,created_at,type"))
+	repo.AssertExpectations(t)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Uranury/RBK_finalProject/internal/auth"
 	"github.com/Uranury/RBK_finalProject/internal/models"
 	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/internal/tax"
 	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -41,6 +42,7 @@ func (s *User) CreateUser(ctx context.Context, user *models.User) error {
 	user.ID = uuid.New()
 	user.Balance = 0.0
 	user.Role = auth.User
+	user.Tier = models.TierStandard
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -106,3 +108,51 @@ func (s *User) GetUserProfile(ctx context.Context, id uuid.UUID) (*models.UserPr
 
 	return usr, nil
 }
+
+// UpdateTier changes the seller tier of a user, which selects their fee rule
+func (s *User) UpdateTier(ctx context.Context, userID uuid.UUID, tier models.UserTier) error {
+	if !tier.Valid() {
+		return apperrors.NewValidationError("invalid tier")
+	}
+
+	found, err := s.repo.UpdateTier(ctx, userID, tier)
+	if err != nil {
+		s.logger.Error("failed to update user tier", "user_id", userID, "tier", tier, "error", err)
+		return apperrors.WrapInternal(err, "failed to update user tier")
+	}
+	if !found {
+		return apperrors.ErrUserNotFound
+	}
+
+	s.logger.Info("user tier updated", "user_id", userID, "tier", tier)
+	return nil
+}
+
+// UpdateBilling sets the country the user is taxed in and, for business
+// buyers, their VAT ID. An empty VAT ID clears it.
+func (s *User) UpdateBilling(ctx context.Context, userID uuid.UUID, country, vatID string) error {
+	country, ok := tax.NormalizeCountry(country)
+	if !ok {
+		return apperrors.NewValidationError("country must be a two-letter ISO 3166-1 code")
+	}
+	var vatIDArg *string
+	if strings.TrimSpace(vatID) != "" {
+		normalized, ok := tax.NormalizeVATID(vatID)
+		if !ok {
+			return apperrors.NewValidationError("invalid vat_id")
+		}
+		vatIDArg = &normalized
+	}
+
+	found, err := s.repo.UpdateBilling(ctx, userID, country, vatIDArg)
+	if err != nil {
+		s.logger.Error("failed to update billing details", "user_id", userID, "error", err)
+		return apperrors.WrapInternal(err, "failed to update billing details")
+	}
+	if !found {
+		return apperrors.ErrUserNotFound
+	}
+
+	s.logger.Info("billing details updated", "user_id", userID, "country", country, "business", vatIDArg != nil)
+	return nil
+}
@@ -64,6 +64,21 @@ func (m *MockUserRepository) UpdateBalance(ctx context.Context, tx *sqlx.Tx, use
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdateBalances(ctx context.Context, tx *sqlx.Tx, user *models.User) error {
+	args := m.Called(ctx, tx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateTier(ctx context.Context, userID uuid.UUID, tier models.UserTier) (bool, error) {
+	args := m.Called(ctx, userID, tier)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateBilling(ctx context.Context, userID uuid.UUID, country string, vatID *string) (bool, error) {
+	args := m.Called(ctx, userID, country, vatID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
@@ -331,3 +346,124 @@ func TestUserService_GetUserProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_UpdateTier(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	authService := auth.NewService("test-secret")
+	testUserID := uuid.New()
+
+	tests := []struct {
+		name          string
+		tier          models.UserTier
+		mockSetup     func(*MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "successful tier update",
+			tier: models.TierPro,
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("UpdateTier", mock.Anything, testUserID, models.TierPro).Return(true, nil)
+			},
+		},
+		{
+			name:          "invalid tier",
+			tier:          "gold",
+			mockSetup:     func(repo *MockUserRepository) {},
+			expectedError: apperrors.NewValidationError("invalid tier"),
+		},
+		{
+			name: "user not found",
+			tier: models.TierVerified,
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("UpdateTier", mock.Anything, testUserID, models.TierVerified).Return(false, nil)
+			},
+			expectedError: apperrors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			tt.mockSetup(mockRepo)
+
+			service := NewUser(mockRepo, authService, logger)
+			err := service.UpdateTier(context.Background(), testUserID, tt.tier)
+
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_UpdateBilling(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	authService := auth.NewService("test-secret")
+	testUserID := uuid.New()
+	vatID := "DE123456789"
+
+	tests := []struct {
+		name          string
+		country       string
+		vatID         string
+		mockSetup     func(*MockUserRepository)
+		expectedError error
+	}{
+		{
+			name:    "consumer",
+			country: "fr",
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("UpdateBilling", mock.Anything, testUserID, "FR", (*string)(nil)).Return(true, nil)
+			},
+		},
+		{
+			name:    "business buyer",
+			country: "DE",
+			vatID:   "de 123 456 789",
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("UpdateBilling", mock.Anything, testUserID, "DE", &vatID).Return(true, nil)
+			},
+		},
+		{
+			name:          "invalid country",
+			country:       "Germany",
+			mockSetup:     func(repo *MockUserRepository) {},
+			expectedError: apperrors.NewValidationError("country must be a two-letter ISO 3166-1 code"),
+		},
+		{
+			name:          "invalid VAT ID",
+			country:       "DE",
+			vatID:         "123",
+			mockSetup:     func(repo *MockUserRepository) {},
+			expectedError: apperrors.NewValidationError("invalid vat_id"),
+		},
+		{
+			name:    "user not found",
+			country: "DE",
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("UpdateBilling", mock.Anything, testUserID, "DE", (*string)(nil)).Return(false, nil)
+			},
+			expectedError: apperrors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			tt.mockSetup(mockRepo)
+
+			service := NewUser(mockRepo, authService, logger)
+			err := service.UpdateBilling(context.Background(), testUserID, tt.country, tt.vatID)
+
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
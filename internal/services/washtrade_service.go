@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/washtrade"
+	"github.com/Uranury/RBK_finalProject/internal/surveillance"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultWashTradeReports = 20
+	maxWashTradeReports     = 100
+)
+
+type WashTradeService struct {
+	washRepo   washtrade.Repository
+	marketData *MarketDataService
+	cfg        config.WashTradeConfig
+	db         *sqlx.DB
+	logger     *slog.Logger
+}
+
+func NewWashTradeService(washRepo washtrade.Repository, marketData *MarketDataService, cfg config.WashTradeConfig, db *sqlx.DB, logger *slog.Logger) *WashTradeService {
+	return &WashTradeService{washRepo: washRepo, marketData: marketData, cfg: cfg, db: db, logger: logger}
+}
+
+// Detect analyses the completed sales of the configured window for wash
+// trading and stores a report. Only clusters with at least one sale that no
+// earlier run flagged are reported, so a cluster shows up again only when it
+// keeps trading. Sales of reported clusters are excluded from price statistics
+// until an admin clears the cluster.
+func (s *WashTradeService) Detect(ctx context.Context) (*models.WashTradeReport, error) {
+	now := time.Now()
+	since := now.Add(-s.cfg.Window)
+
+	trades, err := s.washRepo.ListTrades(ctx, since, now)
+	if err != nil {
+		s.logger.Error("failed to list trades", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list trades")
+	}
+	known, err := s.washRepo.ListFlaggedOrderItems(ctx, since)
+	if err != nil {
+		s.logger.Error("failed to list flagged order items", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list flagged order items")
+	}
+
+	report := &models.WashTradeReport{
+		ID:             uuid.New(),
+		WindowStart:    since,
+		WindowEnd:      now,
+		TradesAnalysed: len(trades),
+		CreatedAt:      now,
+	}
+	report.Clusters = newClusters(report.ID, surveillance.Detect(trades, s.params()), known, now)
+	report.ClustersFound = len(report.Clusters)
+	for _, c := range report.Clusters {
+		report.SalesFlagged += len(c.Sales)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	if err := s.washRepo.CreateReport(ctx, tx, report); err != nil {
+		s.logger.Error("failed to create wash trade report", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to create wash trade report")
+	}
+	for _, c := range report.Clusters {
+		if err := s.washRepo.CreateCluster(ctx, tx, c); err != nil {
+			s.logger.Error("failed to create wash trade cluster", "error", err, "cluster_id", c.ID)
+			return nil, apperrors.WrapInternal(err, "failed to create wash trade cluster")
+		}
+	}
+	// Sync every run, not only when something new was found, so sales that
+	// were recorded while a cluster was being stored are caught up
+	if err := s.syncExcludedSales(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.logger.Info("wash trade detection completed", "report_id", report.ID, "trades", report.TradesAnalysed,
+		"clusters", report.ClustersFound, "sales_flagged", report.SalesFlagged)
+	return report, nil
+}
+
+func (s *WashTradeService) params() surveillance.Params {
+	return surveillance.Params{
+		MinPairTrades:  s.cfg.MinPairTrades,
+		PriceDeviation: s.cfg.PriceDeviation,
+		MinSamples:     s.cfg.MinSamples,
+		MinScore:       s.cfg.MinScore,
+	}
+}
+
+// newClusters turns detected clusters into open clusters of the report,
+// skipping the ones whose sales were all flagged before
+func newClusters(reportID uuid.UUID, detected []surveillance.Cluster, known []uuid.UUID, now time.Time) []*models.WashTradeCluster {
+	flagged := make(map[uuid.UUID]bool, len(known))
+	for _, id := range known {
+		flagged[id] = true
+	}
+
+	clusters := []*models.WashTradeCluster{}
+	for _, d := range detected {
+		fresh := false
+		for _, t := range d.Trades {
+			if !flagged[t.OrderItemID] {
+				fresh = true
+				break
+			}
+		}
+		if !fresh {
+			continue
+		}
+
+		c := &models.WashTradeCluster{
+			ID:         uuid.New(),
+			ReportID:   reportID,
+			Score:      d.Score,
+			TradeCount: len(d.Trades),
+			Volume:     d.Volume,
+			Status:     models.WashTradeOpen,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			UserIDs:    d.UserIDs,
+		}
+		for _, t := range d.Trades {
+			c.Sales = append(c.Sales, &models.WashTradeSale{
+				ClusterID:   c.ID,
+				OrderItemID: t.OrderItemID,
+				SkinID:      t.SkinID,
+				SellerID:    t.SellerID,
+				BuyerID:     t.BuyerID,
+				Price:       t.Price,
+				SoldAt:      t.SoldAt,
+				Reasons:     t.ReasonList(),
+				Score:       t.Score,
+			})
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
+
+// syncExcludedSales brings the excluded flag of recorded sales in line with
+// the clusters they belong to and rebuilds the candles they fall into
+func (s *WashTradeService) syncExcludedSales(ctx context.Context, tx *sqlx.Tx) error {
+	changed, err := s.washRepo.SyncExcludedSales(ctx, tx)
+	if err != nil {
+		s.logger.Error("failed to sync excluded sales", "error", err)
+		return apperrors.WrapInternal(err, "failed to sync excluded sales")
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := s.marketData.RebuildCandles(ctx, tx, changed); err != nil {
+		return err
+	}
+	s.logger.Info("price statistics updated for wash trades", "sales", len(changed))
+	return nil
+}
+
+// ListReports returns the most recent detection reports, without clusters
+func (s *WashTradeService) ListReports(ctx context.Context, limit int) ([]*models.WashTradeReport, error) {
+	if limit <= 0 {
+		limit = defaultWashTradeReports
+	}
+	if limit > maxWashTradeReports {
+		return nil, apperrors.NewValidationError("limit cannot exceed 100")
+	}
+
+	reports, err := s.washRepo.ListReports(ctx, limit)
+	if err != nil {
+		s.logger.Error("failed to list wash trade reports", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to list wash trade reports")
+	}
+	return reports, nil
+}
+
+// GetReport returns a report with its clusters, their users and sales
+func (s *WashTradeService) GetReport(ctx context.Context, reportID uuid.UUID) (*models.WashTradeReport, error) {
+	report, err := s.washRepo.GetReport(ctx, reportID)
+	if err != nil {
+		s.logger.Error("failed to get wash trade report", "error", err, "report_id", reportID)
+		return nil, apperrors.WrapInternal(err, "failed to get wash trade report")
+	}
+	if report == nil {
+		return nil, apperrors.NewNotFoundError("report not found")
+	}
+
+	if report.Clusters, err = s.washRepo.ListClustersForReport(ctx, reportID); err != nil {
+		s.logger.Error("failed to list wash trade clusters", "error", err, "report_id", reportID)
+		return nil, apperrors.WrapInternal(err, "failed to list wash trade clusters")
+	}
+	for _, c := range report.Clusters {
+		if err := s.loadClusterDetails(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// ListClusters returns clusters by status, the open ones by default
+func (s *WashTradeService) ListClusters(ctx context.Context, status models.WashTradeStatus) ([]*models.WashTradeCluster, error) {
+	if status == "" {
+		status = models.WashTradeOpen
+	}
+	if !status.Valid() {
+		return nil, apperrors.NewValidationError("invalid cluster status")
+	}
+
+	clusters, err := s.washRepo.ListClustersByStatus(ctx, status)
+	if err != nil {
+		s.logger.Error("failed to list wash trade clusters", "error", err, "status", status)
+		return nil, apperrors.WrapInternal(err, "failed to list wash trade clusters")
+	}
+	return clusters, nil
+}
+
+// GetCluster returns a cluster with its users and sales
+func (s *WashTradeService) GetCluster(ctx context.Context, clusterID uuid.UUID) (*models.WashTradeCluster, error) {
+	cluster, err := s.washRepo.GetCluster(ctx, clusterID)
+	if err != nil {
+		s.logger.Error("failed to get wash trade cluster", "error", err, "cluster_id", clusterID)
+		return nil, apperrors.WrapInternal(err, "failed to get wash trade cluster")
+	}
+	if cluster == nil {
+		return nil, apperrors.NewNotFoundError("cluster not found")
+	}
+	if err := s.loadClusterDetails(ctx, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+func (s *WashTradeService) loadClusterDetails(ctx context.Context, c *models.WashTradeCluster) error {
+	var err error
+	if c.UserIDs, err = s.washRepo.ListClusterUsers(ctx, c.ID); err != nil {
+		s.logger.Error("failed to list wash trade cluster users", "error", err, "cluster_id", c.ID)
+		return apperrors.WrapInternal(err, "failed to list wash trade cluster users")
+	}
+	if c.Sales, err = s.washRepo.ListClusterSales(ctx, c.ID); err != nil {
+		s.logger.Error("failed to list wash trade cluster sales", "error", err, "cluster_id", c.ID)
+		return apperrors.WrapInternal(err, "failed to list wash trade cluster sales")
+	}
+	return nil
+}
+
+// ReviewCluster closes an open cluster. Clearing it returns its sales to the
+// price statistics unless another open or confirmed cluster holds them too.
+func (s *WashTradeService) ReviewCluster(ctx context.Context, adminID, clusterID uuid.UUID, status models.WashTradeStatus, note string) (*models.WashTradeCluster, error) {
+	if status != models.WashTradeCleared && status != models.WashTradeConfirmed {
+		return nil, apperrors.NewValidationError("status must be cleared or confirmed")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	cluster, err := s.washRepo.GetClusterForUpdate(ctx, tx, clusterID)
+	if err != nil {
+		s.logger.Error("failed to get wash trade cluster for update", "error", err, "cluster_id", clusterID)
+		return nil, apperrors.WrapInternal(err, "failed to get wash trade cluster for update")
+	}
+	if cluster == nil {
+		return nil, apperrors.NewNotFoundError("cluster not found")
+	}
+	if cluster.Status != models.WashTradeOpen {
+		return nil, apperrors.NewValidationError("cluster has already been reviewed")
+	}
+
+	now := time.Now()
+	cluster.Status = status
+	cluster.ReviewedBy = &adminID
+	cluster.ReviewedAt = &now
+	cluster.UpdatedAt = now
+	if note != "" {
+		cluster.ReviewNote = &note
+	}
+	if err := s.washRepo.UpdateCluster(ctx, tx, cluster); err != nil {
+		s.logger.Error("failed to update wash trade cluster", "error", err, "cluster_id", clusterID)
+		return nil, apperrors.WrapInternal(err, "failed to update wash trade cluster")
+	}
+	if status == models.WashTradeCleared {
+		if err := s.syncExcludedSales(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "cluster_id", clusterID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.logger.Info("wash trade cluster reviewed", "cluster_id", clusterID, "status", status, "admin_id", adminID)
+	return cluster, nil
+}
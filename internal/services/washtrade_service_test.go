@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/surveillance"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWashTradeRepository is a mock implementation of washtrade.Repository
+type MockWashTradeRepository struct {
+	mock.Mock
+}
+
+func (m *MockWashTradeRepository) ListTrades(ctx context.Context, from, to time.Time) ([]models.Trade, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Trade), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) ListFlaggedOrderItems(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) CreateReport(ctx context.Context, tx *sqlx.Tx, report *models.WashTradeReport) error {
+	args := m.Called(ctx, tx, report)
+	return args.Error(0)
+}
+
+func (m *MockWashTradeRepository) GetReport(ctx context.Context, id uuid.UUID) (*models.WashTradeReport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WashTradeReport), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) ListReports(ctx context.Context, limit int) ([]*models.WashTradeReport, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WashTradeReport), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) CreateCluster(ctx context.Context, tx *sqlx.Tx, cluster *models.WashTradeCluster) error {
+	args := m.Called(ctx, tx, cluster)
+	return args.Error(0)
+}
+
+func (m *MockWashTradeRepository) GetCluster(ctx context.Context, id uuid.UUID) (*models.WashTradeCluster, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WashTradeCluster), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) GetClusterForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.WashTradeCluster, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WashTradeCluster), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) ListClustersForReport(ctx context.Context, reportID uuid.UUID) ([]*models.WashTradeCluster, error) {
+	args := m.Called(ctx, reportID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WashTradeCluster), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) ListClustersByStatus(ctx context.Context, status models.WashTradeStatus) ([]*models.WashTradeCluster, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WashTradeCluster), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) UpdateCluster(ctx context.Context, tx *sqlx.Tx, cluster *models.WashTradeCluster) error {
+	args := m.Called(ctx, tx, cluster)
+	return args.Error(0)
+}
+
+func (m *MockWashTradeRepository) ListClusterUsers(ctx context.Context, clusterID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, clusterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) ListClusterSales(ctx context.Context, clusterID uuid.UUID) ([]*models.WashTradeSale, error) {
+	args := m.Called(ctx, clusterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WashTradeSale), args.Error(1)
+}
+
+func (m *MockWashTradeRepository) SyncExcludedSales(ctx context.Context, tx *sqlx.Tx) ([]*models.MarketSale, error) {
+	args := m.Called(ctx, tx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MarketSale), args.Error(1)
+}
+
+func newTestWashTradeService(repo *MockWashTradeRepository) *WashTradeService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewWashTradeService(repo, nil, config.WashTradeConfig{}, nil, logger)
+}
+
+func TestNewClusters(t *testing.T) {
+	reportID := uuid.New()
+	now := time.Now()
+	a, b := uuid.New(), uuid.New()
+	seen := surveillance.FlaggedTrade{
+		Trade:   models.Trade{OrderItemID: uuid.New(), SellerID: a, BuyerID: b, Price: 10},
+		Reasons: []string{surveillance.ReasonRepeatedCounterparty},
+		Score:   2,
+	}
+	fresh := surveillance.FlaggedTrade{
+		Trade:   models.Trade{OrderItemID: uuid.New(), SellerID: b, BuyerID: a, Price: 12},
+		Reasons: []string{surveillance.ReasonCircular, surveillance.ReasonRepeatedCounterparty},
+		Score:   5,
+	}
+
+	t.Run("clusters with a new sale are reported with all their sales", func(t *testing.T) {
+		detected := []surveillance.Cluster{{UserIDs: []uuid.UUID{a, b}, Trades: []surveillance.FlaggedTrade{seen, fresh}, Score: 7, Volume: 22}}
+
+		clusters := newClusters(reportID, detected, []uuid.UUID{seen.OrderItemID}, now)
+
+		if assert.Len(t, clusters, 1) {
+			c := clusters[0]
+			assert.Equal(t, reportID, c.ReportID)
+			assert.Equal(t, models.WashTradeOpen, c.Status)
+			assert.Equal(t, 2, c.TradeCount)
+			assert.Equal(t, 7.0, c.Score)
+			if assert.Len(t, c.Sales, 2) {
+				assert.Equal(t, c.ID, c.Sales[1].ClusterID)
+				assert.Equal(t, "circular,repeated_counterparty", c.Sales[1].Reasons)
+			}
+		}
+	})
+
+	t.Run("clusters flagged before are skipped", func(t *testing.T) {
+		detected := []surveillance.Cluster{{UserIDs: []uuid.UUID{a, b}, Trades: []surveillance.FlaggedTrade{seen}, Score: 2, Volume: 10}}
+
+		clusters := newClusters(reportID, detected, []uuid.UUID{seen.OrderItemID}, now)
+
+		assert.Empty(t, clusters)
+	})
+}
+
+func TestWashTradeService_ListReports(t *testing.T) {
+	t.Run("defaults to the last 20", func(t *testing.T) {
+		repo := new(MockWashTradeRepository)
+		repo.On("ListReports", mock.Anything, 20).Return([]*models.WashTradeReport{}, nil)
+
+		reports, err := newTestWashTradeService(repo).ListReports(context.Background(), 0)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, reports)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("limit too large", func(t *testing.T) {
+		repo := new(MockWashTradeRepository)
+
+		reports, err := newTestWashTradeService(repo).ListReports(context.Background(), 500)
+
+		assert.Equal(t, apperrors.NewValidationError("limit cannot exceed 100"), err)
+		assert.Nil(t, reports)
+	})
+}
+
+func TestWashTradeService_ListClusters(t *testing.T) {
+	t.Run("defaults to open clusters", func(t *testing.T) {
+		repo := new(MockWashTradeRepository)
+		repo.On("ListClustersByStatus", mock.Anything, models.WashTradeOpen).Return([]*models.WashTradeCluster{}, nil)
+
+		clusters, err := newTestWashTradeService(repo).ListClusters(context.Background(), "")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, clusters)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		repo := new(MockWashTradeRepository)
+
+		clusters, err := newTestWashTradeService(repo).ListClusters(context.Background(), "ignored")
+
+		assert.Equal(t, apperrors.NewValidationError("invalid cluster status"), err)
+		assert.Nil(t, clusters)
+	})
+}
+
+func TestWashTradeService_GetCluster_NotFound(t *testing.T) {
+	repo := new(MockWashTradeRepository)
+	id := uuid.New()
+	repo.On("GetCluster", mock.Anything, id).Return(nil, nil)
+
+	cluster, err := newTestWashTradeService(repo).GetCluster(context.Background(), id)
+
+	assert.Equal(t, apperrors.NewNotFoundError("cluster not found"), err)
+	assert.Nil(t, cluster)
+}
+
+func TestWashTradeService_ReviewCluster_InvalidStatus(t *testing.T) {
+	repo := new(MockWashTradeRepository)
+
+	cluster, err := newTestWashTradeService(repo).ReviewCluster(context.Background(), uuid.New(), uuid.New(), models.WashTradeOpen, "")
+
+	assert.Equal(t, apperrors.NewValidationError("status must be cleared or confirmed"), err)
+	assert.Nil(t, cluster)
+	repo.AssertNotCalled(t, "GetClusterForUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/fraud"
+	"github.com/Uranury/RBK_finalProject/internal/metrics"
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/payments"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/transaction"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/user"
+	"github.com/Uranury/RBK_finalProject/internal/repositories/withdrawal"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jmoiron/sqlx"
+)
+
+// Payouts that haven't moved for this long are checked by the stale payout sweep
+const stalePayoutAge = 30 * time.Minute
+
+// TaskQueue queues background jobs, it is implemented by *asynq.Client
+type TaskQueue interface {
+	Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+	EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+type WithdrawalService struct {
+	withdrawalRepo  withdrawal.Repository
+	userRepo        user.Repository
+	transactionRepo transaction.Repository
+	limits          *LimitService
+	risk            *RiskService
+	provider        payments.PayoutProvider
+	queue           TaskQueue
+	inspector       JobInspector
+	publisher       realtime.Publisher
+	reviewThreshold float64
+	db              *sqlx.DB
+	logger          *slog.Logger
+}
+
+func NewWithdrawalService(withdrawalRepo withdrawal.Repository,
+	userRepo user.Repository,
+	transactionRepo transaction.Repository,
+	limits *LimitService,
+	risk *RiskService,
+	provider payments.PayoutProvider,
+	queue TaskQueue,
+	inspector JobInspector,
+	publisher realtime.Publisher,
+	reviewThreshold float64,
+	db *sqlx.DB,
+	logger *slog.Logger) *WithdrawalService {
+	return &WithdrawalService{
+		withdrawalRepo:  withdrawalRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		limits:          limits,
+		risk:            risk,
+		provider:        provider,
+		queue:           queue,
+		inspector:       inspector,
+		publisher:       publisher,
+		reviewThreshold: reviewThreshold,
+		db:              db,
+		logger:          logger,
+	}
+}
+
+// requiresReview reports whether a withdrawal is large enough to need admin approval
+func requiresReview(amount, threshold float64) bool {
+	return amount > threshold
+}
+
+func (s *WithdrawalService) publishBalance(ctx context.Context, u *models.User) {
+	if err := s.publisher.PublishUser(ctx, u.ID, realtime.NewBalancesEvent(u.Balance, u.PendingBalance)); err != nil {
+		s.logger.Warn("failed to publish balance event", "user_id", u.ID, "err", err)
+	}
+}
+
+// enqueuePayout schedules the payout job. The task ID is the withdrawal ID so
+// a withdrawal is never queued twice. A payout that couldn't be queued is
+// picked up by RequeueStalePayouts.
+func (s *WithdrawalService) enqueuePayout(w *models.Withdrawal) error {
+	task, err := jobs.NewProcessPayoutTask(w.ID)
+	if err != nil {
+		s.logger.Error("failed to create payout task", "withdrawal_id", w.ID, "err", err)
+		return err
+	}
+	if _, err := s.queue.Enqueue(task, asynq.TaskID(w.ID.String())); err != nil {
+		s.logger.Error("failed to enqueue payout", "withdrawal_id", w.ID, "err", err)
+		return err
+	}
+	return nil
+}
+
+// RequeueStalePayouts queues the payout job again for approved and processing
+// withdrawals that haven't moved in a while: their enqueue failed after the
+// commit, or the job ran out of retries and was archived. The withdrawal ID
+// stays the idempotency key, so a payout the provider already made isn't
+// made again. It returns how many payouts were queued.
+func (s *WithdrawalService) RequeueStalePayouts(ctx context.Context) (int, error) {
+	stale, err := s.withdrawalRepo.ListStalePayouts(ctx, time.Now().Add(-stalePayoutAge))
+	if err != nil {
+		s.logger.Error("failed to list stale payouts", "error", err)
+		return 0, apperrors.WrapInternal(err, "failed to list stale payouts")
+	}
+
+	queue := jobs.PolicyFor(jobs.ProcessPayout).Queue
+	requeued := 0
+	for _, w := range stale {
+		info, err := s.inspector.GetTaskInfo(queue, w.ID.String())
+		switch {
+		case errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, asynq.ErrQueueNotFound):
+		case err != nil:
+			s.logger.Error("failed to get payout task", "error", err, "withdrawal_id", w.ID)
+			return requeued, apperrors.WrapInternal(err, "failed to get payout task")
+		case info.State == asynq.TaskStateArchived || info.State == asynq.TaskStateCompleted:
+			// The old task holds on to the task ID, it has to go before the new one is queued
+			if err := s.inspector.DeleteTask(queue, info.ID); err != nil {
+				s.logger.Error("failed to delete payout task", "error", err, "withdrawal_id", w.ID)
+				return requeued, apperrors.WrapInternal(err, "failed to delete payout task")
+			}
+		default:
+			// Still pending, scheduled for a retry or running
+			continue
+		}
+
+		if err := s.enqueuePayout(w); errors.Is(err, asynq.ErrTaskIDConflict) {
+			// Queued in the meantime, e.g. by an admin retrying the archived task
+			continue
+		} else if err != nil {
+			return requeued, apperrors.WrapInternal(err, "failed to enqueue payout")
+		}
+		requeued++
+		s.logger.Warn("requeued stale payout", "withdrawal_id", w.ID, "status", w.Status)
+	}
+	return requeued, nil
+}
+
+// RequestWithdrawal reserves the amount from the user's available balance and
+// creates a payout request. Withdrawals above the review threshold or held by
+// a risk rule wait for an admin, the others are paid out right away.
+func (s *WithdrawalService) RequestWithdrawal(ctx context.Context, userID uuid.UUID, amount float64) (*models.Withdrawal, error) {
+	s.logger.Info("starting withdrawal", "user_id", userID, "amount", amount)
+
+	amount = roundCents(amount)
+	if amount <= 0 {
+		return nil, apperrors.NewValidationError("Withdrawal amount must be greater than zero")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	usr, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, userID)
+	if err != nil {
+		s.logger.Error("failed to get user for update", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to get user for update")
+	}
+	if usr == nil {
+		return nil, apperrors.NewNotFoundError("User not found")
+	}
+	if usr.Balance < amount {
+		s.logger.Warn("insufficient funds for withdrawal", "user_id", userID, "balance", usr.Balance, "requested", amount)
+		return nil, apperrors.NewValidationError("Insufficient funds for withdrawal")
+	}
+	if err := s.limits.Enforce(ctx, tx, usr, models.LimitWithdraw, amount); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	withdrawalID := uuid.New()
+	flag, err := s.risk.Assess(ctx, tx, fraud.Event{
+		Kind:             models.RiskWithdraw,
+		UserID:           userID,
+		Amount:           amount,
+		AccountCreatedAt: usr.CreatedAt,
+		At:               now,
+	}, &withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &models.Withdrawal{
+		ID:             withdrawalID,
+		UserID:         userID,
+		Amount:         amount,
+		Status:         models.WithdrawalApproved,
+		RequiresReview: requiresReview(amount, s.reviewThreshold),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	// Withdrawals held by a risk rule go through the review queue whatever their amount
+	if flag != nil && flag.Action == models.RiskActionHold {
+		w.RequiresReview = true
+	}
+	if w.RequiresReview {
+		w.Status = models.WithdrawalPendingReview
+	}
+	if err := s.withdrawalRepo.Create(ctx, tx, w); err != nil {
+		s.logger.Error("failed to create withdrawal", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to create withdrawal")
+	}
+
+	before := usr.Balance
+	usr.Balance = roundCents(usr.Balance - amount)
+	usr.ReservedBalance = roundCents(usr.ReservedBalance + amount)
+	if err := s.userRepo.UpdateBalances(ctx, tx, usr); err != nil {
+		s.logger.Error("failed to update balances", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to update balances")
+	}
+
+	description := fmt.Sprintf("withdrawal %s", w.ID)
+	if err := s.transactionRepo.Create(ctx, tx, &models.Transaction{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Amount:        amount,
+		Type:          models.Withdraw,
+		BalanceBefore: before,
+		BalanceAfter:  usr.Balance,
+		Description:   &description,
+		CreatedAt:     now,
+	}); err != nil {
+		s.logger.Error("failed to create transaction record", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to create transaction record")
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+	metrics.WithdrawalRequested(string(w.Status), w.Amount)
+
+	s.publishBalance(ctx, usr)
+	if w.Status == models.WithdrawalApproved {
+		_ = s.enqueuePayout(w)
+	}
+
+	s.logger.Info("withdrawal requested", "withdrawal_id", w.ID, "user_id", userID, "amount", amount, "status", w.Status)
+	return w, nil
+}
+
+// ApproveWithdrawal releases a reviewed withdrawal for payout
+func (s *WithdrawalService) ApproveWithdrawal(ctx context.Context, adminID, withdrawalID uuid.UUID) (*models.Withdrawal, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	w, err := s.getForReview(ctx, tx, withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w.Status = models.WithdrawalApproved
+	w.ReviewedBy = &adminID
+	w.ReviewedAt = &now
+	if err := s.withdrawalRepo.Update(ctx, tx, w); err != nil {
+		s.logger.Error("failed to update withdrawal", "error", err, "withdrawal_id", w.ID)
+		return nil, apperrors.WrapInternal(err, "failed to update withdrawal")
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "withdrawal_id", w.ID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	_ = s.enqueuePayout(w)
+	s.logger.Info("withdrawal approved", "withdrawal_id", w.ID, "admin_id", adminID)
+	return w, nil
+}
+
+// RejectWithdrawal declines a reviewed withdrawal and returns the reserved
+// amount to the user's available balance
+func (s *WithdrawalService) RejectWithdrawal(ctx context.Context, adminID, withdrawalID uuid.UUID, reason string) (*models.Withdrawal, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	w, err := s.getForReview(ctx, tx, withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := s.releaseReservation(ctx, tx, w, "withdrawal rejected")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w.Status = models.WithdrawalRejected
+	w.ReviewedBy = &adminID
+	w.ReviewedAt = &now
+	w.ReviewReason = &reason
+	if err := s.withdrawalRepo.Update(ctx, tx, w); err != nil {
+		s.logger.Error("failed to update withdrawal", "error", err, "withdrawal_id", w.ID)
+		return nil, apperrors.WrapInternal(err, "failed to update withdrawal")
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "withdrawal_id", w.ID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+
+	s.publishBalance(ctx, usr)
+	s.logger.Info("withdrawal rejected", "withdrawal_id", w.ID, "admin_id", adminID)
+	return w, nil
+}
+
+func (s *WithdrawalService) getForReview(ctx context.Context, tx *sqlx.Tx, withdrawalID uuid.UUID) (*models.Withdrawal, error) {
+	w, err := s.withdrawalRepo.GetByIDForUpdate(ctx, tx, withdrawalID)
+	if err != nil {
+		s.logger.Error("failed to get withdrawal", "error", err, "withdrawal_id", withdrawalID)
+		return nil, apperrors.WrapInternal(err, "failed to get withdrawal")
+	}
+	if w == nil {
+		return nil, apperrors.NewNotFoundError("withdrawal not found")
+	}
+	if w.Status != models.WithdrawalPendingReview {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("withdrawal is %s, not pending review", w.Status))
+	}
+	return w, nil
+}
+
+// releaseReservation moves the withdrawal amount from the reserved back to the
+// available balance. The caller saves the withdrawal.
+func (s *WithdrawalService) releaseReservation(ctx context.Context, tx *sqlx.Tx, w *models.Withdrawal, description string) (*models.User, error) {
+	usr, err := s.userRepo.GetUserByIdForUpdate(ctx, tx, w.UserID)
+	if err != nil {
+		s.logger.Error("failed to get user for update", "error", err, "user_id", w.UserID)
+		return nil, apperrors.WrapInternal(err, "failed to get user for update")
+	}
+	if usr == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	before := usr.Balance
+	usr.ReservedBalance = roundCents(max(0, usr.ReservedBalance-w.Amount))
+	usr.Balance = roundCents(usr.Balance + w.Amount)
+	if err := s.userRepo.UpdateBalances(ctx, tx, usr); err != nil {
+		s.logger.Error("failed to update balances", "error", err, "user_id", usr.ID)
+		return nil, apperrors.WrapInternal(err, "failed to update balances")
+	}
+
+	description = fmt.Sprintf("%s %s", description, w.ID)
+	if err := s.transactionRepo.Create(ctx, tx, &models.Transaction{
+		ID:            uuid.New(),
+		UserID:        usr.ID,
+		Amount:        w.Amount,
+		Type:          models.WithdrawRelease,
+		BalanceBefore: before,
+		BalanceAfter:  usr.Balance,
+		Description:   &description,
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		s.logger.Error("failed to create transaction record", "error", err, "user_id", usr.ID)
+		return nil, apperrors.WrapInternal(err, "failed to create transaction record")
+	}
+	return usr, nil
+}
+
+// ProcessPayout sends an approved withdrawal to the payment provider. A
+// declined payout fails the withdrawal and releases the reservation, a
+// successful one settles it and consumes the reservation. Temporary provider
+// errors are returned so the job is retried; the withdrawal ID is the
+// idempotency key, so a retry never pays twice.
+func (s *WithdrawalService) ProcessPayout(ctx context.Context, withdrawalID uuid.UUID) error {
+	w, err := s.startPayout(ctx, withdrawalID)
+	if err != nil || w == nil {
+		return err
+	}
+
+	result, payoutErr := s.provider.Payout(ctx, payments.PayoutRequest{
+		IdempotencyKey: w.ID.String(),
+		UserID:         w.UserID,
+		Amount:         w.Amount,
+	})
+	if payoutErr != nil && !errors.Is(payoutErr, payments.ErrDeclined) {
+		s.logger.Warn("payout attempt failed, will retry", "withdrawal_id", w.ID, "err", payoutErr)
+		return payoutErr
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	w, err = s.withdrawalRepo.GetByIDForUpdate(ctx, tx, withdrawalID)
+	if err != nil {
+		s.logger.Error("failed to get withdrawal", "error", err, "withdrawal_id", withdrawalID)
+		return apperrors.WrapInternal(err, "failed to get withdrawal")
+	}
+	if w == nil || w.Status != models.WithdrawalProcessing {
+		return nil
+	}
+
+	var usr *models.User
+	if payoutErr != nil {
+		if usr, err = s.releaseReservation(ctx, tx, w, "payout failed for withdrawal"); err != nil {
+			return err
+		}
+		reason := payoutErr.Error()
+		w.Status = models.WithdrawalFailed
+		w.FailureReason = &reason
+	} else {
+		usr, err = s.userRepo.GetUserByIdForUpdate(ctx, tx, w.UserID)
+		if err != nil {
+			s.logger.Error("failed to get user for update", "error", err, "user_id", w.UserID)
+			return apperrors.WrapInternal(err, "failed to get user for update")
+		}
+		if usr == nil {
+			return apperrors.ErrUserNotFound
+		}
+		usr.ReservedBalance = roundCents(max(0, usr.ReservedBalance-w.Amount))
+		if err := s.userRepo.UpdateBalances(ctx, tx, usr); err != nil {
+			s.logger.Error("failed to update balances", "error", err, "user_id", usr.ID)
+			return apperrors.WrapInternal(err, "failed to update balances")
+		}
+		w.Status = models.WithdrawalSettled
+		w.ProviderReference = &result.Reference
+	}
+
+	if err := s.withdrawalRepo.Update(ctx, tx, w); err != nil {
+		s.logger.Error("failed to update withdrawal", "error", err, "withdrawal_id", w.ID)
+		return apperrors.WrapInternal(err, "failed to update withdrawal")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "withdrawal_id", w.ID)
+		return apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+	metrics.PayoutFinished(string(w.Status))
+
+	if w.Status == models.WithdrawalFailed {
+		s.publishBalance(ctx, usr)
+	}
+	s.logger.Info("payout finished", "withdrawal_id", w.ID, "status", w.Status)
+	return nil
+}
+
+// startPayout marks an approved withdrawal as processing. It returns nil when
+// there is nothing to pay out, e.g. the withdrawal was already settled.
+func (s *WithdrawalService) startPayout(ctx context.Context, withdrawalID uuid.UUID) (*models.Withdrawal, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		s.logger.Error("failed to begin transaction", "error", err)
+		return nil, apperrors.WrapInternal(err, "failed to begin transaction")
+	}
+	defer func(tx *sqlx.Tx) {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", "error", err)
+		}
+	}(tx)
+
+	w, err := s.withdrawalRepo.GetByIDForUpdate(ctx, tx, withdrawalID)
+	if err != nil {
+		s.logger.Error("failed to get withdrawal", "error", err, "withdrawal_id", withdrawalID)
+		return nil, apperrors.WrapInternal(err, "failed to get withdrawal")
+	}
+	if w == nil {
+		s.logger.Warn("payout for unknown withdrawal", "withdrawal_id", withdrawalID)
+		return nil, nil
+	}
+
+	switch w.Status {
+	case models.WithdrawalProcessing:
+		// A previous attempt didn't finish, try again with the same idempotency key
+		return w, nil
+	case models.WithdrawalApproved:
+	default:
+		s.logger.Info("withdrawal not payable, skipping", "withdrawal_id", w.ID, "status", w.Status)
+		return nil, nil
+	}
+
+	w.Status = models.WithdrawalProcessing
+	if err := s.withdrawalRepo.Update(ctx, tx, w); err != nil {
+		s.logger.Error("failed to update withdrawal", "error", err, "withdrawal_id", w.ID)
+		return nil, apperrors.WrapInternal(err, "failed to update withdrawal")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("failed to commit transaction", "error", err, "withdrawal_id", w.ID)
+		return nil, apperrors.WrapInternal(err, "failed to commit transaction")
+	}
+	return w, nil
+}
+
+func (s *WithdrawalService) ListUserWithdrawals(ctx context.Context, userID uuid.UUID) ([]*models.Withdrawal, error) {
+	withdrawals, err := s.withdrawalRepo.ListForUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list withdrawals", "error", err, "user_id", userID)
+		return nil, apperrors.WrapInternal(err, "failed to list withdrawals")
+	}
+	return withdrawals, nil
+}
+
+// ListWithdrawals returns withdrawals by status, the review queue by default
+func (s *WithdrawalService) ListWithdrawals(ctx context.Context, status models.WithdrawalStatus) ([]*models.Withdrawal, error) {
+	if status == "" {
+		status = models.WithdrawalPendingReview
+	}
+	if !status.Valid() {
+		return nil, apperrors.NewValidationError("invalid withdrawal status")
+	}
+	withdrawals, err := s.withdrawalRepo.ListByStatus(ctx, status)
+	if err != nil {
+		s.logger.Error("failed to list withdrawals", "error", err, "status", status)
+		return nil, apperrors.WrapInternal(err, "failed to list withdrawals")
+	}
+	return withdrawals, nil
+}
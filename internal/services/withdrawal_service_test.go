@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/internal/queue/jobs"
+	"github.com/Uranury/RBK_finalProject/internal/realtime"
+	"github.com/Uranury/RBK_finalProject/pkg/apperrors"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWithdrawalRepository is a mock implementation of withdrawal.Repository
+type MockWithdrawalRepository struct {
+	mock.Mock
+}
+
+func (m *MockWithdrawalRepository) Create(ctx context.Context, tx *sqlx.Tx, w *models.Withdrawal) error {
+	args := m.Called(ctx, tx, w)
+	return args.Error(0)
+}
+
+func (m *MockWithdrawalRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Withdrawal, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Withdrawal), args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*models.Withdrawal, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Withdrawal), args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]*models.Withdrawal, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Withdrawal), args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) ListByStatus(ctx context.Context, status models.WithdrawalStatus) ([]*models.Withdrawal, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Withdrawal), args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) Update(ctx context.Context, tx *sqlx.Tx, w *models.Withdrawal) error {
+	args := m.Called(ctx, tx, w)
+	return args.Error(0)
+}
+
+func (m *MockWithdrawalRepository) ListStalePayouts(ctx context.Context, before time.Time) ([]*models.Withdrawal, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Withdrawal), args.Error(1)
+}
+
+// MockTaskQueue is a mock implementation of TaskQueue
+type MockTaskQueue struct {
+	mock.Mock
+}
+
+func (m *MockTaskQueue) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	args := m.Called(task, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*asynq.TaskInfo), args.Error(1)
+}
+
+func (m *MockTaskQueue) EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	args := m.Called(ctx, task, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*asynq.TaskInfo), args.Error(1)
+}
+
+func newTestWithdrawalService(repo *MockWithdrawalRepository) *WithdrawalService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewWithdrawalService(repo, nil, nil, nil, nil, nil, nil, nil, realtime.NopPublisher{}, 1000, nil, logger)
+}
+
+func TestRequiresReview(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    float64
+		threshold float64
+		want      bool
+	}{
+		{name: "below threshold", amount: 999.99, threshold: 1000, want: false},
+		{name: "at threshold", amount: 1000, threshold: 1000, want: false},
+		{name: "above threshold", amount: 1000.01, threshold: 1000, want: true},
+		{name: "zero threshold reviews everything", amount: 0.01, threshold: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, requiresReview(tt.amount, tt.threshold))
+		})
+	}
+}
+
+func TestWithdrawalService_RequestWithdrawal_InvalidAmount(t *testing.T) {
+	repo := new(MockWithdrawalRepository)
+
+	w, err := newTestWithdrawalService(repo).RequestWithdrawal(context.Background(), uuid.New(), 0.001)
+
+	assert.Equal(t, apperrors.NewValidationError("Withdrawal amount must be greater than zero"), err)
+	assert.Nil(t, w)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWithdrawalService_ListWithdrawals(t *testing.T) {
+	t.Run("defaults to the review queue", func(t *testing.T) {
+		repo := new(MockWithdrawalRepository)
+		repo.On("ListByStatus", mock.Anything, models.WithdrawalPendingReview).Return([]*models.Withdrawal{}, nil)
+
+		withdrawals, err := newTestWithdrawalService(repo).ListWithdrawals(context.Background(), "")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, withdrawals)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		repo := new(MockWithdrawalRepository)
+
+		withdrawals, err := newTestWithdrawalService(repo).ListWithdrawals(context.Background(), "paid")
+
+		assert.Equal(t, apperrors.NewValidationError("invalid withdrawal status"), err)
+		assert.Nil(t, withdrawals)
+	})
+}
+
+func TestWithdrawalService_RequeueStalePayouts(t *testing.T) {
+	payoutTask := mock.MatchedBy(func(task *asynq.Task) bool { return task.Type() == jobs.ProcessPayout })
+
+	t.Run("approved withdrawal whose enqueue failed", func(t *testing.T) {
+		w := &models.Withdrawal{ID: uuid.New(), Status: models.WithdrawalApproved}
+		repo := new(MockWithdrawalRepository)
+		repo.On("ListStalePayouts", mock.Anything, mock.Anything).Return([]*models.Withdrawal{w}, nil)
+		inspector := new(MockJobInspector)
+		inspector.On("GetTaskInfo", "critical", w.ID.String()).Return(nil, asynq.ErrTaskNotFound)
+		queue := new(MockTaskQueue)
+		queue.On("Enqueue", payoutTask, mock.Anything).Return(&asynq.TaskInfo{ID: w.ID.String()}, nil)
+		svc := newTestWithdrawalService(repo)
+		svc.queue, svc.inspector = queue, inspector
+
+		requeued, err := svc.RequeueStalePayouts(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requeued)
+		inspector.AssertNotCalled(t, "DeleteTask", mock.Anything, mock.Anything)
+		queue.AssertExpectations(t)
+	})
+
+	t.Run("processing withdrawal whose job ran out of retries", func(t *testing.T) {
+		w := &models.Withdrawal{ID: uuid.New(), Status: models.WithdrawalProcessing}
+		repo := new(MockWithdrawalRepository)
+		repo.On("ListStalePayouts", mock.Anything, mock.Anything).Return([]*models.Withdrawal{w}, nil)
+		inspector := new(MockJobInspector)
+		inspector.On("GetTaskInfo", "critical", w.ID.String()).
+			Return(&asynq.TaskInfo{ID: w.ID.String(), State: asynq.TaskStateArchived}, nil)
+		inspector.On("DeleteTask", "critical", w.ID.String()).Return(nil)
+		queue := new(MockTaskQueue)
+		queue.On("Enqueue", payoutTask, mock.Anything).Return(&asynq.TaskInfo{ID: w.ID.String()}, nil)
+		svc := newTestWithdrawalService(repo)
+		svc.queue, svc.inspector = queue, inspector
+
+		requeued, err := svc.RequeueStalePayouts(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requeued)
+		inspector.AssertExpectations(t)
+		queue.AssertExpectations(t)
+	})
+
+	t.Run("payout job still retrying", func(t *testing.T) {
+		w := &models.Withdrawal{ID: uuid.New(), Status: models.WithdrawalProcessing}
+		repo := new(MockWithdrawalRepository)
+		repo.On("ListStalePayouts", mock.Anything, mock.Anything).Return([]*models.Withdrawal{w}, nil)
+		inspector := new(MockJobInspector)
+		inspector.On("GetTaskInfo", "critical", w.ID.String()).
+			Return(&asynq.TaskInfo{ID: w.ID.String(), State: asynq.TaskStateRetry}, nil)
+		queue := new(MockTaskQueue)
+		svc := newTestWithdrawalService(repo)
+		svc.queue, svc.inspector = queue, inspector
+
+		requeued, err := svc.RequeueStalePayouts(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, requeued)
+		inspector.AssertNotCalled(t, "DeleteTask", mock.Anything, mock.Anything)
+		queue.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything)
+	})
+}
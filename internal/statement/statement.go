@@ -0,0 +1,61 @@
+// Package statement builds account statements from transaction history.
+package statement
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+)
+
+// Build returns the statement of [start, end) from the balances at start and
+// the period's transactions, oldest first.
+//
+// The running balances are the ones each row recorded, so the statement shows
+// what the ledger says even where it doesn't add up. A hold release is
+// recorded on the available balance only but also empties the pending one.
+func Build(userID uuid.UUID, start, end time.Time, opening models.StatementBalances, txns []*models.Transaction) *models.Statement {
+	st := &models.Statement{
+		UserID:      userID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Opening:     opening,
+		Lines:       make([]models.StatementLine, 0, len(txns)),
+	}
+
+	running := opening
+	totals := map[models.TransactionType]*models.StatementTotal{}
+	for _, txn := range txns {
+		if txn.BalanceType == models.BalancePending {
+			running.Pending = txn.BalanceAfter
+		} else {
+			running.Available = txn.BalanceAfter
+		}
+		if txn.Type == models.HoldRelease {
+			running.Pending = round(running.Pending - math.Abs(txn.Amount))
+		}
+		st.Lines = append(st.Lines, models.StatementLine{Transaction: txn, Available: running.Available, Pending: running.Pending})
+
+		total, ok := totals[txn.Type]
+		if !ok {
+			total = &models.StatementTotal{Type: txn.Type}
+			totals[txn.Type] = total
+		}
+		total.Count++
+		total.Net = round(total.Net + txn.BalanceAfter - txn.BalanceBefore)
+	}
+	st.Closing = running
+
+	st.Totals = make([]models.StatementTotal, 0, len(totals))
+	for _, total := range totals {
+		st.Totals = append(st.Totals, *total)
+	}
+	sort.Slice(st.Totals, func(i, j int) bool { return st.Totals[i].Type < st.Totals[j].Type })
+	return st
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
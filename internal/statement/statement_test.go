@@ -0,0 +1,54 @@
+package statement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+func row(typ models.TransactionType, bt models.BalanceType, amount, before, after float64) *models.Transaction {
+	return &models.Transaction{ID: uuid.New(), Type: typ, BalanceType: bt, Amount: amount, BalanceBefore: before, BalanceAfter: after}
+}
+
+func TestBuild(t *testing.T) {
+	txns := []*models.Transaction{
+		row(models.Deposit, models.BalanceAvailable, 100, 50, 150),
+		row(models.Sale, models.BalancePending, 20, 5, 25),
+		row(models.Fee, models.BalancePending, -1, 25, 24),
+		row(models.HoldRelease, models.BalanceAvailable, 24, 150, 174),
+		row(models.Purchase, models.BalanceAvailable, -30, 174, 144),
+		row(models.Deposit, models.BalanceAvailable, 10, 144, 154),
+	}
+
+	st := Build(uuid.New(), start, start.AddDate(0, 1, 0), models.StatementBalances{Available: 50, Pending: 5}, txns)
+
+	assert.Equal(t, models.StatementBalances{Available: 154, Pending: 0}, st.Closing)
+	if assert.Len(t, st.Lines, 6) {
+		assert.Equal(t, 150.0, st.Lines[0].Available)
+		assert.Equal(t, 24.0, st.Lines[2].Pending)
+		assert.Equal(t, 174.0, st.Lines[3].Available)
+		assert.Equal(t, 0.0, st.Lines[3].Pending)
+	}
+	assert.Equal(t, []models.StatementTotal{
+		{Type: models.Deposit, Count: 2, Net: 110},
+		{Type: models.Fee, Count: 1, Net: -1},
+		{Type: models.HoldRelease, Count: 1, Net: 24},
+		{Type: models.Purchase, Count: 1, Net: -30},
+		{Type: models.Sale, Count: 1, Net: 20},
+	}, st.Totals)
+}
+
+func TestBuild_NoTransactions(t *testing.T) {
+	opening := models.StatementBalances{Available: 42.5}
+
+	st := Build(uuid.New(), start, start.AddDate(0, 1, 0), opening, nil)
+
+	assert.Equal(t, opening, st.Closing)
+	assert.Empty(t, st.Lines)
+	assert.Empty(t, st.Totals)
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore_PutGet(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, store.Put(ctx, "invoices/2025/INV-2025-000001.pdf", []byte("first")))
+	assert.NoError(t, store.Put(ctx, "invoices/2025/INV-2025-000001.pdf", []byte("second")))
+
+	data, err := store.Get(ctx, "invoices/2025/INV-2025-000001.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("second"), data)
+}
+
+func TestLocalStore_GetMissing(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	_, err = store.Get(context.Background(), "invoices/missing.pdf")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b"} {
+		assert.Error(t, store.Put(context.Background(), key, []byte("x")), key)
+	}
+}
@@ -0,0 +1,259 @@
+package surveillance
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+)
+
+// Signals that make a trade look like wash trading
+const (
+	// ReasonCircular means the skin came back to an account that sold it
+	// earlier in the window
+	ReasonCircular = "circular"
+	// ReasonRepeatedCounterparty means buyer and seller traded with each other
+	// at least MinPairTrades times in the window
+	ReasonRepeatedCounterparty = "repeated_counterparty"
+	// ReasonOffMarket means the price is at least PriceDeviation away from the
+	// median price of the skin type
+	ReasonOffMarket = "off_market_price"
+)
+
+// weights is how much each signal adds to a trade's score. A skin travelling
+// in a circle is the strongest sign, an odd price on its own the weakest.
+var weights = map[string]float64{
+	ReasonCircular:             3,
+	ReasonRepeatedCounterparty: 2,
+	ReasonOffMarket:            1,
+}
+
+// Params tune the detection
+type Params struct {
+	// MinPairTrades is how many trades between the same two accounts count
+	// as repeated
+	MinPairTrades int
+	// PriceDeviation is the relative distance from the median price (0.5 =
+	// 50%) from which a price counts as off market
+	PriceDeviation float64
+	// MinSamples is how many trades of a skin type are needed before its
+	// median price is trusted
+	MinSamples int
+	// MinScore is the score from which a cluster is reported
+	MinScore float64
+}
+
+// FlaggedTrade is a trade with at least one signal
+type FlaggedTrade struct {
+	models.Trade
+	Reasons []string
+	Score   float64
+}
+
+// ReasonList returns the trade's signals as a comma separated list
+func (t FlaggedTrade) ReasonList() string {
+	return strings.Join(t.Reasons, ",")
+}
+
+// Cluster is a group of accounts connected by flagged trades
+type Cluster struct {
+	UserIDs []uuid.UUID
+	Trades  []FlaggedTrade
+	Score   float64
+	Volume  float64
+}
+
+// Detect looks for wash trading in trades. Accounts connected by flagged
+// trades form a cluster whose score is the sum of its trades' scores, and
+// clusters scoring at least MinScore are returned, most suspicious first.
+func Detect(trades []models.Trade, p Params) []Cluster {
+	reasons := make([][]string, len(trades))
+	mark := func(i int, reason string) {
+		for _, r := range reasons[i] {
+			if r == reason {
+				return
+			}
+		}
+		reasons[i] = append(reasons[i], reason)
+	}
+
+	markCircular(trades, mark)
+	markRepeatedPairs(trades, p.MinPairTrades, mark)
+	markOffMarket(trades, p.PriceDeviation, p.MinSamples, mark)
+
+	uf := newUnionFind()
+	for i, t := range trades {
+		if len(reasons[i]) > 0 {
+			uf.union(t.SellerID, t.BuyerID)
+		}
+	}
+
+	byRoot := make(map[uuid.UUID]*Cluster)
+	members := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for i, t := range trades {
+		if len(reasons[i]) == 0 {
+			continue
+		}
+		root := uf.find(t.SellerID)
+		c, ok := byRoot[root]
+		if !ok {
+			c = &Cluster{}
+			byRoot[root] = c
+			members[root] = make(map[uuid.UUID]bool)
+		}
+
+		score := 0.0
+		for _, r := range reasons[i] {
+			score += weights[r]
+		}
+		c.Trades = append(c.Trades, FlaggedTrade{Trade: t, Reasons: reasons[i], Score: score})
+		c.Score += score
+		c.Volume += t.Price
+		members[root][t.SellerID] = true
+		members[root][t.BuyerID] = true
+	}
+
+	clusters := make([]Cluster, 0, len(byRoot))
+	for root, c := range byRoot {
+		if c.Score < p.MinScore {
+			continue
+		}
+		for id := range members[root] {
+			c.UserIDs = append(c.UserIDs, id)
+		}
+		sort.Slice(c.UserIDs, func(i, j int) bool { return c.UserIDs[i].String() < c.UserIDs[j].String() })
+		sort.SliceStable(c.Trades, func(i, j int) bool { return c.Trades[i].SoldAt.Before(c.Trades[j].SoldAt) })
+		c.Volume = math.Round(c.Volume*100) / 100
+		clusters = append(clusters, *c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Score != clusters[j].Score {
+			return clusters[i].Score > clusters[j].Score
+		}
+		return clusters[i].UserIDs[0].String() < clusters[j].UserIDs[0].String()
+	})
+	return clusters
+}
+
+// markCircular follows every skin from owner to owner and marks all trades of
+// a loop that brings it back to an earlier seller
+func markCircular(trades []models.Trade, mark func(int, string)) {
+	bySkin := make(map[uuid.UUID][]int)
+	for i, t := range trades {
+		bySkin[t.SkinID] = append(bySkin[t.SkinID], i)
+	}
+	for _, idx := range bySkin {
+		sort.SliceStable(idx, func(a, b int) bool { return trades[idx[a]].SoldAt.Before(trades[idx[b]].SoldAt) })
+
+		// position in idx of the last time each account sold the skin
+		soldAt := make(map[uuid.UUID]int)
+		for pos, i := range idx {
+			t := trades[i]
+			if start, ok := soldAt[t.BuyerID]; ok {
+				for _, j := range idx[start : pos+1] {
+					mark(j, ReasonCircular)
+				}
+			}
+			soldAt[t.SellerID] = pos
+		}
+	}
+}
+
+// markRepeatedPairs marks every trade between two accounts that traded with
+// each other at least minTrades times, in either direction
+func markRepeatedPairs(trades []models.Trade, minTrades int, mark func(int, string)) {
+	if minTrades <= 0 {
+		return
+	}
+	byPair := make(map[[2]uuid.UUID][]int)
+	for i, t := range trades {
+		key := pairKey(t.SellerID, t.BuyerID)
+		byPair[key] = append(byPair[key], i)
+	}
+	for _, idx := range byPair {
+		if len(idx) < minTrades {
+			continue
+		}
+		for _, i := range idx {
+			mark(i, ReasonRepeatedCounterparty)
+		}
+	}
+}
+
+// markOffMarket marks trades whose price is far from the median price of
+// their skin type. Skin types with fewer than minSamples trades are skipped.
+func markOffMarket(trades []models.Trade, deviation float64, minSamples int, mark func(int, string)) {
+	if deviation <= 0 {
+		return
+	}
+	byType := make(map[models.SkinType][]int)
+	for i, t := range trades {
+		byType[t.SkinType()] = append(byType[t.SkinType()], i)
+	}
+	for _, idx := range byType {
+		if len(idx) < minSamples {
+			continue
+		}
+		prices := make([]float64, len(idx))
+		for k, i := range idx {
+			prices[k] = trades[i].Price
+		}
+		m := median(prices)
+		if m <= 0 {
+			continue
+		}
+		for _, i := range idx {
+			if math.Abs(trades[i].Price-m)/m >= deviation {
+				mark(i, ReasonOffMarket)
+			}
+		}
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func pairKey(a, b uuid.UUID) [2]uuid.UUID {
+	if a.String() > b.String() {
+		a, b = b, a
+	}
+	return [2]uuid.UUID{a, b}
+}
+
+type unionFind struct {
+	parent map[uuid.UUID]uuid.UUID
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{parent: make(map[uuid.UUID]uuid.UUID)}
+}
+
+func (u *unionFind) find(id uuid.UUID) uuid.UUID {
+	p, ok := u.parent[id]
+	if !ok {
+		u.parent[id] = id
+		return id
+	}
+	if p == id {
+		return id
+	}
+	root := u.find(p)
+	u.parent[id] = root
+	return root
+}
+
+func (u *unionFind) union(a, b uuid.UUID) {
+	ra, rb := u.find(a), u.find(b)
+	if ra != rb {
+		u.parent[rb] = ra
+	}
+}
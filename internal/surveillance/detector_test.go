@@ -0,0 +1,164 @@
+package surveillance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	start    = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	redline  = models.SkinType{Name: "Redline", Gun: models.AK47, Wear: models.FieldTested}
+	asiimov  = models.SkinType{Name: "Asiimov", Gun: models.AWP, Wear: models.FieldTested}
+	defaults = Params{MinPairTrades: 3, PriceDeviation: 0.5, MinSamples: 5, MinScore: 5}
+)
+
+func trade(skinID, seller, buyer uuid.UUID, st models.SkinType, price float64, hours int) models.Trade {
+	return models.Trade{
+		OrderItemID: uuid.New(),
+		SkinID:      skinID,
+		SellerID:    seller,
+		BuyerID:     buyer,
+		Name:        st.Name,
+		Gun:         st.Gun,
+		Wear:        st.Wear,
+		Price:       price,
+		SoldAt:      start.Add(time.Duration(hours) * time.Hour),
+	}
+}
+
+func TestDetect_CircularTrades(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	skin := uuid.New()
+
+	trades := []models.Trade{
+		// out of order on purpose, the skin goes a -> b -> c -> a
+		trade(skin, c, a, redline, 12, 2),
+		trade(skin, a, b, redline, 10, 0),
+		trade(skin, b, c, redline, 11, 1),
+		// unrelated sale of another skin
+		trade(uuid.New(), uuid.New(), uuid.New(), redline, 10, 3),
+	}
+
+	clusters := Detect(trades, defaults)
+
+	if assert.Len(t, clusters, 1) {
+		assert.Equal(t, 9.0, clusters[0].Score)
+		assert.Equal(t, 33.0, clusters[0].Volume)
+		assert.ElementsMatch(t, []uuid.UUID{a, b, c}, clusters[0].UserIDs)
+		if assert.Len(t, clusters[0].Trades, 3) {
+			assert.Equal(t, a, clusters[0].Trades[0].SellerID)
+			for _, ft := range clusters[0].Trades {
+				assert.Equal(t, []string{ReasonCircular}, ft.Reasons)
+			}
+		}
+	}
+}
+
+func TestDetect_RepeatedCounterparty(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	t.Run("reported once the pair trades often enough", func(t *testing.T) {
+		trades := []models.Trade{
+			trade(uuid.New(), a, b, redline, 10, 0),
+			trade(uuid.New(), b, a, asiimov, 50, 1),
+			trade(uuid.New(), a, b, redline, 10, 2),
+		}
+
+		clusters := Detect(trades, defaults)
+
+		if assert.Len(t, clusters, 1) {
+			assert.Equal(t, 6.0, clusters[0].Score)
+			assert.Equal(t, "repeated_counterparty", clusters[0].Trades[0].ReasonList())
+		}
+	})
+
+	t.Run("two trades are not enough", func(t *testing.T) {
+		trades := []models.Trade{
+			trade(uuid.New(), a, b, redline, 10, 0),
+			trade(uuid.New(), b, a, asiimov, 50, 1),
+		}
+
+		assert.Empty(t, Detect(trades, defaults))
+	})
+}
+
+func TestDetect_OffMarketPrice(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	var trades []models.Trade
+	for i := 0; i < 5; i++ {
+		trades = append(trades, trade(uuid.New(), uuid.New(), uuid.New(), redline, 10, i))
+	}
+	pumped := trade(uuid.New(), a, b, redline, 100, 6)
+	trades = append(trades, pumped)
+
+	t.Run("single odd price scores below the threshold", func(t *testing.T) {
+		assert.Empty(t, Detect(trades, defaults))
+	})
+
+	t.Run("reported with a lower threshold", func(t *testing.T) {
+		p := defaults
+		p.MinScore = 1
+
+		clusters := Detect(trades, p)
+
+		if assert.Len(t, clusters, 1) && assert.Len(t, clusters[0].Trades, 1) {
+			assert.Equal(t, pumped.OrderItemID, clusters[0].Trades[0].OrderItemID)
+			assert.Equal(t, []string{ReasonOffMarket}, clusters[0].Trades[0].Reasons)
+		}
+	})
+
+	t.Run("too few samples to trust the median", func(t *testing.T) {
+		p := defaults
+		p.MinScore = 1
+		p.MinSamples = 10
+
+		assert.Empty(t, Detect(trades, p))
+	})
+}
+
+func TestDetect_SignalsAddUp(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	skin := uuid.New()
+	trades := []models.Trade{
+		trade(skin, a, b, redline, 10, 0),
+		trade(skin, b, a, redline, 10, 1),
+		trade(skin, a, b, redline, 10, 2),
+	}
+
+	clusters := Detect(trades, defaults)
+
+	if assert.Len(t, clusters, 1) {
+		// every trade is both circular and between the same pair
+		assert.Equal(t, 15.0, clusters[0].Score)
+		for _, ft := range clusters[0].Trades {
+			assert.Equal(t, "circular,repeated_counterparty", ft.ReasonList())
+		}
+	}
+}
+
+func TestDetect_ClustersOrderedByScore(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	skin := uuid.New()
+	trades := []models.Trade{
+		// a and b trade often
+		trade(uuid.New(), a, b, redline, 10, 0),
+		trade(uuid.New(), a, b, redline, 10, 1),
+		trade(uuid.New(), a, b, redline, 10, 2),
+		// c and d pass a skin back and forth and trade often
+		trade(skin, c, d, asiimov, 50, 0),
+		trade(skin, d, c, asiimov, 50, 1),
+		trade(skin, c, d, asiimov, 50, 2),
+	}
+
+	clusters := Detect(trades, defaults)
+
+	if assert.Len(t, clusters, 2) {
+		assert.ElementsMatch(t, []uuid.UUID{c, d}, clusters[0].UserIDs)
+		assert.ElementsMatch(t, []uuid.UUID{a, b}, clusters[1].UserIDs)
+		assert.Greater(t, clusters[0].Score, clusters[1].Score)
+	}
+}
@@ -0,0 +1,78 @@
+package tax
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+)
+
+// Tax is the VAT applied to a purchase
+type Tax struct {
+	// Country is the country whose rules applied, empty when none did
+	Country string  `json:"country,omitempty"`
+	Rate    float64 `json:"rate"`
+	// ReverseCharge means the business buyer accounts for the VAT themselves
+	ReverseCharge bool `json:"reverse_charge"`
+}
+
+// Amount returns the tax on a net amount, rounded to cents
+func (t Tax) Amount(net float64) float64 {
+	return math.Round(net*t.Rate) / 100
+}
+
+// Rules resolves which VAT a buyer pays
+type Rules struct {
+	cfg config.TaxConfig
+}
+
+func NewRules(cfg config.TaxConfig) *Rules {
+	return &Rules{cfg: cfg}
+}
+
+// For returns the tax of a buyer with the given billing country and VAT ID.
+// Buyers without a country pay the marketplace's own rate. A business buyer
+// with a VAT ID in another taxed country is reverse charged, and countries
+// without a rate are outside the scope of the rules.
+func (r *Rules) For(country, vatID string) Tax {
+	if len(r.cfg.Rates) == 0 {
+		return Tax{}
+	}
+	if country == "" {
+		country = r.cfg.Country
+	}
+	rate, ok := r.cfg.Rates[country]
+	if !ok {
+		return Tax{}
+	}
+	if vatID != "" && country != r.cfg.Country {
+		return Tax{Country: country, ReverseCharge: true}
+	}
+	return Tax{Country: country, Rate: rate}
+}
+
+var (
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	vatIDPattern   = regexp.MustCompile(`^[A-Z]{2}[0-9A-Z+*]{2,13}$`)
+)
+
+// NormalizeCountry upper-cases a country code and reports whether it is a
+// two-letter ISO 3166-1 code
+func NormalizeCountry(country string) (string, bool) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	return country, countryPattern.MatchString(country)
+}
+
+// NormalizeVATID strips spaces, dots and dashes from a VAT ID, upper-cases it
+// and reports whether it looks like a VAT ID: a two-letter prefix followed by
+// the national number
+func NormalizeVATID(vatID string) (string, bool) {
+	vatID = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '.' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(vatID))
+	return vatID, vatIDPattern.MatchString(vatID)
+}
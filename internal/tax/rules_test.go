@@ -0,0 +1,69 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_For(t *testing.T) {
+	rules := NewRules(config.TaxConfig{
+		Country: "DE",
+		Rates:   map[string]float64{"DE": 19, "FR": 20, "HU": 27},
+	})
+
+	tests := []struct {
+		name    string
+		country string
+		vatID   string
+		want    Tax
+	}{
+		{"domestic consumer", "DE", "", Tax{Country: "DE", Rate: 19}},
+		{"foreign consumer pays their country's rate", "FR", "", Tax{Country: "FR", Rate: 20}},
+		{"domestic business still pays VAT", "DE", "DE123456789", Tax{Country: "DE", Rate: 19}},
+		{"foreign business is reverse charged", "HU", "HU12345678", Tax{Country: "HU", ReverseCharge: true}},
+		{"no country falls back to home", "", "", Tax{Country: "DE", Rate: 19}},
+		{"country without a rate is out of scope", "US", "", Tax{}},
+		{"out of scope with VAT ID too", "US", "US123456", Tax{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules.For(tt.country, tt.vatID))
+		})
+	}
+}
+
+func TestRules_For_NoRates(t *testing.T) {
+	rules := NewRules(config.TaxConfig{Country: "DE"})
+	assert.Equal(t, Tax{}, rules.For("DE", ""))
+}
+
+func TestTax_Amount(t *testing.T) {
+	assert.InDelta(t, 19.0, Tax{Rate: 19}.Amount(100), 1e-9)
+	assert.InDelta(t, 6.33, Tax{Rate: 19}.Amount(33.33), 1e-9)
+	assert.InDelta(t, 0.0, Tax{ReverseCharge: true}.Amount(100), 1e-9)
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	country, ok := NormalizeCountry(" de ")
+	assert.True(t, ok)
+	assert.Equal(t, "DE", country)
+
+	for _, bad := range []string{"", "D", "DEU", "D1"} {
+		_, ok := NormalizeCountry(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestNormalizeVATID(t *testing.T) {
+	vatID, ok := NormalizeVATID("de 123.456-789")
+	assert.True(t, ok)
+	assert.Equal(t, "DE123456789", vatID)
+
+	for _, bad := range []string{"", "123456789", "DE1", "DE12345678901234"} {
+		_, ok := NormalizeVATID(bad)
+		assert.False(t, ok, bad)
+	}
+}
@@ -0,0 +1,265 @@
+// Package ubl renders invoices as OASIS UBL 2.1 XML for bookkeeping software.
+package ubl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+)
+
+const (
+	InvoiceNamespace   = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	AggregateNamespace = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	BasicNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	// ContentType is the media type of the XML
+	ContentType = "application/xml"
+
+	// commercialInvoice is the UNCL 1001 code of a plain invoice
+	commercialInvoice = "380"
+	// unitCode is the UN/ECE rec 20 code for "one", every line is one skin
+	unitCode = "C62"
+	// UNCL 5305 tax categories: standard rate, reverse charge, and amounts
+	// outside the scope of VAT
+	standardRate  = "S"
+	reverseCharge = "AE"
+	outsideScope  = "O"
+)
+
+// Invoice is a UBL 2.1 invoice. Field order is element order, which the
+// schema fixes.
+type Invoice struct {
+	XMLName              xml.Name          `xml:"Invoice"`
+	Xmlns                string            `xml:"xmlns,attr"`
+	XmlnsCac             string            `xml:"xmlns:cac,attr"`
+	XmlnsCbc             string            `xml:"xmlns:cbc,attr"`
+	UBLVersionID         string            `xml:"cbc:UBLVersionID"`
+	ID                   string            `xml:"cbc:ID"`
+	IssueDate            string            `xml:"cbc:IssueDate"`
+	InvoiceTypeCode      string            `xml:"cbc:InvoiceTypeCode"`
+	Note                 []string          `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string            `xml:"cbc:DocumentCurrencyCode"`
+	OrderReference       OrderReference    `xml:"cac:OrderReference"`
+	Supplier             PartyWrapper      `xml:"cac:AccountingSupplierParty"`
+	Customer             PartyWrapper      `xml:"cac:AccountingCustomerParty"`
+	TaxTotal             TaxTotal          `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal   MonetaryTotal     `xml:"cac:LegalMonetaryTotal"`
+	Lines                []InvoiceLineType `xml:"cac:InvoiceLine"`
+}
+
+type OrderReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type PartyWrapper struct {
+	Party Party `xml:"cac:Party"`
+}
+
+type Party struct {
+	WebsiteURI     string          `xml:"cbc:WebsiteURI,omitempty"`
+	PartyName      Name            `xml:"cac:PartyName"`
+	PostalAddress  *Address        `xml:"cac:PostalAddress,omitempty"`
+	PartyTaxScheme *PartyTaxScheme `xml:"cac:PartyTaxScheme,omitempty"`
+	Contact        *Contact        `xml:"cac:Contact,omitempty"`
+}
+
+// PartyTaxScheme carries a party's VAT ID
+type PartyTaxScheme struct {
+	CompanyID string    `xml:"cbc:CompanyID"`
+	TaxScheme TaxScheme `xml:"cac:TaxScheme"`
+}
+
+type Name struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type Address struct {
+	AddressLine []AddressLine `xml:"cac:AddressLine"`
+}
+
+type AddressLine struct {
+	Line string `xml:"cbc:Line"`
+}
+
+type Contact struct {
+	ElectronicMail string `xml:"cbc:ElectronicMail"`
+}
+
+type Amount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type Quantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type TaxTotal struct {
+	TaxAmount   Amount      `xml:"cbc:TaxAmount"`
+	TaxSubtotal TaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type TaxSubtotal struct {
+	TaxableAmount Amount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     Amount      `xml:"cbc:TaxAmount"`
+	TaxCategory   TaxCategory `xml:"cac:TaxCategory"`
+}
+
+type TaxCategory struct {
+	ID                 string    `xml:"cbc:ID"`
+	Percent            string    `xml:"cbc:Percent,omitempty"`
+	TaxExemptionReason string    `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme          TaxScheme `xml:"cac:TaxScheme"`
+}
+
+type TaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type MonetaryTotal struct {
+	LineExtensionAmount Amount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount  Amount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  Amount `xml:"cbc:TaxInclusiveAmount"`
+	PayableAmount       Amount `xml:"cbc:PayableAmount"`
+}
+
+type InvoiceLineType struct {
+	ID                  string   `xml:"cbc:ID"`
+	InvoicedQuantity    Quantity `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount Amount   `xml:"cbc:LineExtensionAmount"`
+	Item                Item     `xml:"cac:Item"`
+	Price               Price    `xml:"cac:Price"`
+}
+
+type Item struct {
+	Name                      string         `xml:"cbc:Name"`
+	SellersItemIdentification ItemID         `xml:"cac:SellersItemIdentification"`
+	ClassifiedTaxCategory     TaxCategory    `xml:"cac:ClassifiedTaxCategory"`
+	AdditionalItemProperty    []ItemProperty `xml:"cac:AdditionalItemProperty"`
+}
+
+type ItemID struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ItemProperty struct {
+	Name  string `xml:"cbc:Name"`
+	Value string `xml:"cbc:Value"`
+}
+
+type Price struct {
+	PriceAmount Amount `xml:"cbc:PriceAmount"`
+}
+
+// NewInvoice maps an invoice document onto UBL
+func NewInvoice(doc *models.InvoiceDocument, company config.CompanyConfig, currency string) *Invoice {
+	amount := func(v float64) Amount {
+		return Amount{CurrencyID: currency, Value: fmt.Sprintf("%.2f", v)}
+	}
+	category := taxCategory(doc.Order)
+
+	supplier := Party{WebsiteURI: company.Website, PartyName: Name{Name: company.Name}}
+	if company.Address != "" {
+		supplier.PostalAddress = &Address{AddressLine: []AddressLine{{Line: company.Address}}}
+	}
+	if company.VATID != "" {
+		supplier.PartyTaxScheme = &PartyTaxScheme{CompanyID: company.VATID, TaxScheme: TaxScheme{ID: "VAT"}}
+	}
+	if company.Email != "" {
+		supplier.Contact = &Contact{ElectronicMail: company.Email}
+	}
+	customer := Party{
+		PartyName: Name{Name: doc.BuyerName},
+		Contact:   &Contact{ElectronicMail: doc.BuyerEmail},
+	}
+	if doc.Order.BuyerVATID != nil {
+		customer.PartyTaxScheme = &PartyTaxScheme{CompanyID: *doc.Order.BuyerVATID, TaxScheme: TaxScheme{ID: "VAT"}}
+	}
+
+	inv := &Invoice{
+		Xmlns:                InvoiceNamespace,
+		XmlnsCac:             AggregateNamespace,
+		XmlnsCbc:             BasicNamespace,
+		UBLVersionID:         "2.1",
+		ID:                   doc.Invoice.Number,
+		IssueDate:            doc.Invoice.IssuedAt.UTC().Format("2006-01-02"),
+		InvoiceTypeCode:      commercialInvoice,
+		DocumentCurrencyCode: currency,
+		OrderReference:       OrderReference{ID: doc.Order.ID.String()},
+		Supplier:             PartyWrapper{Party: supplier},
+		Customer:             PartyWrapper{Party: customer},
+		TaxTotal: TaxTotal{
+			TaxAmount: amount(doc.Tax),
+			TaxSubtotal: TaxSubtotal{
+				TaxableAmount: amount(doc.Subtotal),
+				TaxAmount:     amount(doc.Tax),
+				TaxCategory:   category,
+			},
+		},
+		LegalMonetaryTotal: MonetaryTotal{
+			LineExtensionAmount: amount(doc.Subtotal),
+			TaxExclusiveAmount:  amount(doc.Subtotal),
+			TaxInclusiveAmount:  amount(doc.Order.TotalAmount),
+			PayableAmount:       amount(doc.Order.TotalAmount),
+		},
+	}
+	if doc.Fees > 0 {
+		inv.Note = append(inv.Note, fmt.Sprintf(
+			"Marketplace fees of %.2f are paid by the sellers out of the item prices.", doc.Fees))
+	}
+
+	for i, line := range doc.Lines {
+		seller := company.Name
+		if line.SellerName != nil {
+			seller = *line.SellerName
+		}
+		inv.Lines = append(inv.Lines, InvoiceLineType{
+			ID:                  fmt.Sprintf("%d", i+1),
+			InvoicedQuantity:    Quantity{UnitCode: unitCode, Value: "1"},
+			LineExtensionAmount: amount(line.Price),
+			Item: Item{
+				Name:                      fmt.Sprintf("%s | %s", line.Gun, line.SkinName),
+				SellersItemIdentification: ItemID{ID: line.SkinID.String()},
+				ClassifiedTaxCategory:     TaxCategory{ID: category.ID, Percent: category.Percent, TaxScheme: category.TaxScheme},
+				AdditionalItemProperty: []ItemProperty{
+					{Name: "Wear", Value: string(line.Wear)},
+					{Name: "Float", Value: fmt.Sprintf("%.4f", line.Condition)},
+					{Name: "Seller", Value: seller},
+				},
+			},
+			Price: Price{PriceAmount: amount(line.Price)},
+		})
+	}
+	return inv
+}
+
+// taxCategory is the VAT category of the whole order, every line has the same
+func taxCategory(ord *models.Order) TaxCategory {
+	vat := TaxScheme{ID: "VAT"}
+	switch {
+	case ord.TaxCountry == nil:
+		return TaxCategory{ID: outsideScope, TaxExemptionReason: "Not subject to VAT", TaxScheme: vat}
+	case ord.ReverseCharge:
+		return TaxCategory{ID: reverseCharge, Percent: "0", TaxExemptionReason: "Reverse charge", TaxScheme: vat}
+	default:
+		return TaxCategory{ID: standardRate, Percent: strconv.FormatFloat(ord.TaxRate, 'f', -1, 64), TaxScheme: vat}
+	}
+}
+
+// Marshal renders the invoice as an indented XML document
+func (inv *Invoice) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(inv); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
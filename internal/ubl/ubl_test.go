@@ -0,0 +1,135 @@
+package ubl
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Uranury/RBK_finalProject/internal/models"
+	"github.com/Uranury/RBK_finalProject/pkg/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// invoiceSchema is the official UBL 2.1 invoice schema, `make ubl-schemas`
+// puts the OASIS schema set under testdata/xsd
+var invoiceSchema = filepath.Join("testdata", "xsd", "maindoc", "UBL-Invoice-2.1.xsd")
+
+func testDocument() *models.InvoiceDocument {
+	seller := "Иван Петров"
+	return &models.InvoiceDocument{
+		Invoice: &models.Invoice{Number: "INV-2025-000042", IssuedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+		Order: &models.Order{
+			ID:          uuid.MustParse("6f1c2a3e-8d4b-4a7e-9c11-2b5d7e9f0a13"),
+			TotalAmount: 150,
+			Status:      models.OrderStatusCompleted,
+		},
+		BuyerName:  "Zoë Ağaoğlu",
+		BuyerEmail: "buyer@example.com",
+		Lines: []*models.InvoiceLine{
+			{SkinID: uuid.MustParse("0b7e4c2d-1f3a-4e5b-8c6d-7e8f9a0b1c2d"), SkinName: "Asiimov", Gun: "AWP",
+				Wear: "Field-Tested", Condition: 0.25, SellerName: &seller, Price: 100, Fee: 5},
+			{SkinID: uuid.MustParse("3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f"), SkinName: "Redline", Gun: "AK-47",
+				Wear: "Minimal Wear", Condition: 0.1, Price: 50},
+		},
+		Subtotal: 150,
+		Fees:     5,
+	}
+}
+
+var testCompany = config.CompanyConfig{
+	Name:    "Test Market",
+	Address: "1 Market Street, Almaty",
+	Email:   "billing@example.com",
+	Website: "https://example.com",
+}
+
+func TestInvoice_MatchesFixture(t *testing.T) {
+	got, err := NewInvoice(testDocument(), testCompany, "USD").Marshal()
+	assert.NoError(t, err)
+
+	want, err := os.ReadFile(filepath.Join("testdata", "invoice.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestInvoice_FollowsSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+	if _, err := os.Stat(invoiceSchema); err != nil {
+		t.Skip("UBL 2.1 schemas not found, run make ubl-schemas")
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.xml"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, fixtures)
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			out, err := exec.Command(xmllint, "--noout", "--nonet", "--schema", invoiceSchema, fixture).CombinedOutput()
+			assert.NoError(t, err, string(out))
+		})
+	}
+}
+
+func TestInvoice_MinimalCompany(t *testing.T) {
+	doc := testDocument()
+	doc.Fees = 0
+
+	got, err := NewInvoice(doc, config.CompanyConfig{Name: "Test Market"}, "EUR").Marshal()
+	assert.NoError(t, err)
+
+	want, err := os.ReadFile(filepath.Join("testdata", "invoice_minimal.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+	assert.NotContains(t, string(got), "PostalAddress")
+	assert.NotContains(t, string(got), "<cbc:Note>")
+}
+
+func taxedDocument(reverseCharge bool) *models.InvoiceDocument {
+	doc := testDocument()
+	doc.Lines = doc.Lines[:1]
+	doc.Subtotal = 100
+	doc.Fees = 0
+	if reverseCharge {
+		country, vatID := "FR", "FR12345678901"
+		doc.Order.TaxCountry, doc.Order.BuyerVATID, doc.Order.ReverseCharge = &country, &vatID, true
+		doc.Order.TotalAmount = 100
+	} else {
+		country := "DE"
+		doc.Order.TaxCountry, doc.Order.TaxRate, doc.Order.TaxAmount = &country, 19, 19
+		doc.Order.TotalAmount = 119
+		doc.Lines[0].Tax = 19
+		doc.Tax = 19
+	}
+	return doc
+}
+
+func TestInvoice_VAT(t *testing.T) {
+	company := testCompany
+	company.VATID = "DE999999999"
+
+	tests := []struct {
+		name          string
+		reverseCharge bool
+		fixture       string
+	}{
+		{"standard rate", false, "invoice_vat.xml"},
+		{"reverse charge", true, "invoice_reverse_charge.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewInvoice(taxedDocument(tt.reverseCharge), company, "EUR").Marshal()
+			assert.NoError(t, err)
+
+			want, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS price_candles;
+DROP TABLE IF EXISTS market_sales;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS market_sales (
+    order_item_id UUID PRIMARY KEY REFERENCES order_items(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    gun VARCHAR(50) NOT NULL,
+    wear TEXT NOT NULL,
+    price DECIMAL(12,2) NOT NULL,
+    sold_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_market_sales_type_sold_at ON market_sales(name, gun, wear, sold_at DESC);
+
+CREATE TABLE IF NOT EXISTS price_candles (
+    name VARCHAR(255) NOT NULL,
+    gun VARCHAR(50) NOT NULL,
+    wear TEXT NOT NULL,
+    interval VARCHAR(3) NOT NULL CHECK (interval IN ('1h', '1d', '1w')),
+    bucket_start TIMESTAMP NOT NULL,
+    open DECIMAL(12,2) NOT NULL,
+    high DECIMAL(12,2) NOT NULL,
+    low DECIMAL(12,2) NOT NULL,
+    close DECIMAL(12,2) NOT NULL,
+    volume INTEGER NOT NULL DEFAULT 0,
+    turnover DECIMAL(14,2) NOT NULL DEFAULT 0,
+    first_sale_at TIMESTAMP NOT NULL,
+    last_sale_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (name, gun, wear, interval, bucket_start)
+);
@@ -0,0 +1,16 @@
+DELETE FROM transaction_history WHERE type = 'fee';
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale'));
+
+DROP TABLE IF EXISTS platform_ledger;
+
+ALTER TABLE order_items
+    DROP COLUMN fee,
+    DROP COLUMN seller_id;
+
+ALTER TABLE users
+    DROP CONSTRAINT chk_user_tier,
+    DROP COLUMN tier;
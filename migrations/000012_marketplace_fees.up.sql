@@ -0,0 +1,26 @@
+ALTER TABLE users
+    ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'standard',
+    ADD CONSTRAINT chk_user_tier CHECK (tier IN ('standard', 'verified', 'pro'));
+
+ALTER TABLE order_items
+    ADD COLUMN seller_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN fee DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (fee >= 0);
+
+CREATE INDEX idx_order_items_seller_id ON order_items(seller_id);
+
+CREATE TABLE IF NOT EXISTS platform_ledger (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    order_item_id UUID REFERENCES order_items(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('fee')),
+    amount DECIMAL(12,2) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_platform_ledger_type_created_at ON platform_ledger(type, created_at);
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee'));
@@ -0,0 +1,11 @@
+DELETE FROM transaction_history WHERE type IN ('refund', 'reversal');
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee'));
+
+DROP TABLE IF EXISTS order_status_history;
+
+ALTER TABLE orders
+    DROP CONSTRAINT chk_order_status;
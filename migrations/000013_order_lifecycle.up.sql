@@ -0,0 +1,24 @@
+ALTER TABLE orders
+    ADD CONSTRAINT chk_order_status
+        CHECK (status IN ('pending', 'paid', 'completed', 'cancelled', 'refunded', 'disputed'));
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, created_at);
+
+-- Existing orders start their history at their current status
+INSERT INTO order_status_history (order_id, from_status, to_status, created_at)
+SELECT id, NULL, status, created_at FROM orders;
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal'));
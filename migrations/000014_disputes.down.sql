@@ -0,0 +1,12 @@
+DELETE FROM transaction_history WHERE type IN ('dispute_hold', 'dispute_release');
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal'));
+
+DROP TABLE IF EXISTS dispute_messages;
+DROP TABLE IF EXISTS disputes;
+
+ALTER TABLE users
+    DROP COLUMN frozen_balance;
@@ -0,0 +1,39 @@
+ALTER TABLE users
+    ADD COLUMN frozen_balance FLOAT NOT NULL DEFAULT 0.0;
+
+CREATE TABLE IF NOT EXISTS disputes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    buyer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seller_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(30) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'refunded', 'partially_refunded', 'rejected')),
+    reason TEXT NOT NULL,
+    frozen_amount DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (frozen_amount >= 0),
+    refund_amount DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (refund_amount >= 0),
+    resolution TEXT,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_disputes_status ON disputes(status, created_at);
+CREATE INDEX idx_disputes_buyer_id ON disputes(buyer_id);
+CREATE INDEX idx_disputes_seller_id ON disputes(seller_id);
+
+CREATE TABLE IF NOT EXISTS dispute_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_id UUID NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_dispute_messages_dispute_id ON dispute_messages(dispute_id, created_at);
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal',
+                        'dispute_hold', 'dispute_release'));
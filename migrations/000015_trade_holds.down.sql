@@ -0,0 +1,18 @@
+-- Pending proceeds become available again so no money is lost
+UPDATE users SET balance = balance + pending_balance WHERE pending_balance > 0;
+
+DELETE FROM transaction_history WHERE type = 'hold_release';
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal',
+                        'dispute_hold', 'dispute_release'));
+
+ALTER TABLE transaction_history
+    DROP COLUMN balance_type;
+
+DROP TABLE IF EXISTS balance_holds;
+
+ALTER TABLE users
+    DROP COLUMN pending_balance;
@@ -0,0 +1,31 @@
+ALTER TABLE users
+    ADD COLUMN pending_balance FLOAT NOT NULL DEFAULT 0.0;
+
+CREATE TABLE IF NOT EXISTS balance_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id UUID REFERENCES order_items(id) ON DELETE SET NULL,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'held'
+        CHECK (status IN ('held', 'released', 'cancelled')),
+    release_at TIMESTAMP NOT NULL,
+    released_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_balance_holds_release ON balance_holds(release_at) WHERE status = 'held';
+CREATE INDEX idx_balance_holds_order_id ON balance_holds(order_id);
+CREATE INDEX idx_balance_holds_user_id ON balance_holds(user_id);
+
+-- Which of the user's balances a history row moved
+ALTER TABLE transaction_history
+    ADD COLUMN balance_type VARCHAR(20) NOT NULL DEFAULT 'available'
+        CHECK (balance_type IN ('available', 'pending'));
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal',
+                        'dispute_hold', 'dispute_release', 'hold_release'));
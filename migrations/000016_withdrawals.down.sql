@@ -0,0 +1,15 @@
+-- Funds reserved for unfinished payouts go back to the available balance
+UPDATE users SET balance = balance + reserved_balance WHERE reserved_balance > 0;
+
+DELETE FROM transaction_history WHERE type = 'withdraw_release';
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal',
+                        'dispute_hold', 'dispute_release', 'hold_release'));
+
+DROP TABLE IF EXISTS withdrawals;
+
+ALTER TABLE users
+    DROP COLUMN reserved_balance;
@@ -0,0 +1,27 @@
+ALTER TABLE users
+    ADD COLUMN reserved_balance FLOAT NOT NULL DEFAULT 0.0;
+
+CREATE TABLE IF NOT EXISTS withdrawals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending_review'
+        CHECK (status IN ('pending_review', 'approved', 'processing', 'settled', 'failed', 'rejected')),
+    requires_review BOOLEAN NOT NULL DEFAULT FALSE,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_reason TEXT,
+    provider_reference VARCHAR(255),
+    failure_reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_withdrawals_user_id ON withdrawals(user_id);
+CREATE INDEX idx_withdrawals_status ON withdrawals(status);
+
+ALTER TABLE transaction_history
+    DROP CONSTRAINT transaction_history_type_check,
+    ADD CONSTRAINT transaction_history_type_check
+        CHECK (type IN ('withdraw', 'deposit', 'purchase', 'sale', 'fee', 'refund', 'reversal',
+                        'dispute_hold', 'dispute_release', 'hold_release', 'withdraw_release'));
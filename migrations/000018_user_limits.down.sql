@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payment_intents_user_created;
+DROP INDEX IF EXISTS idx_withdrawals_user_created;
+DROP INDEX IF EXISTS idx_transaction_history_user_type_created;
+
+DROP TABLE IF EXISTS user_limit_overrides;
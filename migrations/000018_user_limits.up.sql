@@ -0,0 +1,18 @@
+-- Per-user overrides of the tier limits, NULL keeps the tier value
+CREATE TABLE IF NOT EXISTS user_limit_overrides (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('deposit', 'withdraw', 'purchase')),
+    per_transaction DECIMAL(12,2) CHECK (per_transaction >= 0),
+    daily DECIMAL(12,2) CHECK (daily >= 0),
+    monthly DECIMAL(12,2) CHECK (monthly >= 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, kind)
+);
+
+-- Rolling window usage lookups
+CREATE INDEX IF NOT EXISTS idx_transaction_history_user_type_created
+    ON transaction_history(user_id, type, created_at);
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user_created ON withdrawals(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_intents_user_created ON payment_intents(user_id, created_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_transaction_history_user_counterparty;
+
+ALTER TABLE balance_holds
+    DROP COLUMN risk_flag_id;
+
+DROP TABLE IF EXISTS risk_rule_hits;
+DROP TABLE IF EXISTS risk_flags;
@@ -0,0 +1,45 @@
+-- Money movements that matched risk rules with a flag, hold or block action
+CREATE TABLE IF NOT EXISTS risk_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('deposit', 'withdraw', 'purchase', 'sell')),
+    action VARCHAR(10) NOT NULL CHECK (action IN ('flag', 'hold', 'block')),
+    amount DECIMAL(12,2) NOT NULL,
+    reference_id UUID,
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'cleared', 'confirmed')),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_flags_status_created ON risk_flags(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_risk_flags_user_id ON risk_flags(user_id);
+
+-- Audit trail of every rule that matched, including allow-only rules
+CREATE TABLE IF NOT EXISTS risk_rule_hits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    flag_id UUID REFERENCES risk_flags(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    rule VARCHAR(100) NOT NULL,
+    rule_type VARCHAR(50) NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('allow', 'flag', 'hold', 'block')),
+    amount DECIMAL(12,2) NOT NULL,
+    reference_id UUID,
+    detail TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_rule_hits_flag_id ON risk_rule_hits(flag_id);
+CREATE INDEX IF NOT EXISTS idx_risk_rule_hits_user_created ON risk_rule_hits(user_id, created_at);
+
+-- Sale proceeds held by a risk rule stay pending until the flag is reviewed
+ALTER TABLE balance_holds
+    ADD COLUMN risk_flag_id UUID REFERENCES risk_flags(id) ON DELETE SET NULL;
+
+-- Counterparty lookups for the velocity rules
+CREATE INDEX IF NOT EXISTS idx_transaction_history_user_counterparty
+    ON transaction_history(user_id, counterparty_id, created_at);
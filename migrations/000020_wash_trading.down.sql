@@ -0,0 +1,7 @@
+ALTER TABLE market_sales
+    DROP COLUMN excluded;
+
+DROP TABLE IF EXISTS wash_trade_sales;
+DROP TABLE IF EXISTS wash_trade_cluster_users;
+DROP TABLE IF EXISTS wash_trade_clusters;
+DROP TABLE IF EXISTS wash_trade_reports;
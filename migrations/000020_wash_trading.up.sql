@@ -0,0 +1,52 @@
+-- One run of the wash-trading detection job
+CREATE TABLE IF NOT EXISTS wash_trade_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    trades_analysed INT NOT NULL,
+    clusters_found INT NOT NULL,
+    sales_flagged INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wash_trade_reports_created_at ON wash_trade_reports(created_at DESC);
+
+-- Group of accounts trading suspiciously with each other
+CREATE TABLE IF NOT EXISTS wash_trade_clusters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id UUID NOT NULL REFERENCES wash_trade_reports(id) ON DELETE CASCADE,
+    score DECIMAL(10,2) NOT NULL,
+    trade_count INT NOT NULL,
+    volume DECIMAL(12,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'cleared', 'confirmed')),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wash_trade_clusters_report_id ON wash_trade_clusters(report_id);
+CREATE INDEX IF NOT EXISTS idx_wash_trade_clusters_status ON wash_trade_clusters(status, created_at);
+
+CREATE TABLE IF NOT EXISTS wash_trade_cluster_users (
+    cluster_id UUID NOT NULL REFERENCES wash_trade_clusters(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (cluster_id, user_id)
+);
+
+-- Sales of a cluster together with the signals that made them suspicious
+CREATE TABLE IF NOT EXISTS wash_trade_sales (
+    cluster_id UUID NOT NULL REFERENCES wash_trade_clusters(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    reasons TEXT NOT NULL,
+    score DECIMAL(10,2) NOT NULL,
+    PRIMARY KEY (cluster_id, order_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wash_trade_sales_order_item_id ON wash_trade_sales(order_item_id);
+
+-- Sales of clusters that are not cleared are left out of price statistics
+ALTER TABLE market_sales
+    ADD COLUMN excluded BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transaction_history_order_id;
+
+DROP TABLE IF EXISTS reconciliation_discrepancies;
+DROP TABLE IF EXISTS reconciliation_reports;
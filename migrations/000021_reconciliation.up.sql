@@ -0,0 +1,33 @@
+-- One run of the balance reconciliation job
+CREATE TABLE IF NOT EXISTS reconciliation_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    users_checked INT NOT NULL,
+    transactions_checked INT NOT NULL,
+    orders_checked INT NOT NULL,
+    discrepancy_count INT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_reports_started_at ON reconciliation_reports(started_at DESC);
+
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id UUID NOT NULL REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL
+        CHECK (kind IN ('balance_mismatch', 'pending_mismatch', 'frozen_mismatch', 'reserved_mismatch',
+                        'chain_gap', 'broken_row', 'orphan_order')),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    transaction_id UUID REFERENCES transaction_history(id) ON DELETE SET NULL,
+    expected DECIMAL(12,2),
+    actual DECIMAL(12,2),
+    detail TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_report_id ON reconciliation_discrepancies(report_id, kind);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_user_id ON reconciliation_discrepancies(user_id);
+
+-- Orphan order lookups
+CREATE INDEX IF NOT EXISTS idx_transaction_history_order_id ON transaction_history(order_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_transaction_history_user_created_id;
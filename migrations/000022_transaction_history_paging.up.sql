@@ -0,0 +1,3 @@
+-- Keyset pagination of a user's history, newest first
+CREATE INDEX IF NOT EXISTS idx_transaction_history_user_created_id
+    ON transaction_history(user_id, created_at DESC, id DESC);
@@ -0,0 +1,16 @@
+-- Last invoice number handed out per calendar year. Incrementing the row
+-- locks it until the issuing transaction ends, so numbers have no gaps.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    year INT PRIMARY KEY,
+    last_number INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    number VARCHAR(20) NOT NULL UNIQUE,
+    year INT NOT NULL,
+    sequence INT NOT NULL,
+    issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (year, sequence)
+);
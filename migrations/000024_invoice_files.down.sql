@@ -0,0 +1,6 @@
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS sent_at,
+    DROP COLUMN IF EXISTS stored_at,
+    DROP COLUMN IF EXISTS file_sha256,
+    DROP COLUMN IF EXISTS file_size,
+    DROP COLUMN IF EXISTS file_key;
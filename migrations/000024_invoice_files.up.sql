@@ -0,0 +1,7 @@
+-- Where each invoice PDF is archived and when it was last emailed
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS file_key VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS file_size INT,
+    ADD COLUMN IF NOT EXISTS file_sha256 CHAR(64),
+    ADD COLUMN IF NOT EXISTS stored_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS sent_at TIMESTAMP;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS credit_notes;
+
+DELETE FROM invoice_sequences WHERE series <> 'INV';
+ALTER TABLE invoice_sequences DROP CONSTRAINT IF EXISTS invoice_sequences_pkey;
+ALTER TABLE invoice_sequences ADD PRIMARY KEY (year);
+ALTER TABLE invoice_sequences DROP COLUMN IF EXISTS series;
@@ -0,0 +1,26 @@
+-- Credit notes are numbered in their own series, next to invoices
+ALTER TABLE invoice_sequences ADD COLUMN IF NOT EXISTS series VARCHAR(10) NOT NULL DEFAULT 'INV';
+ALTER TABLE invoice_sequences DROP CONSTRAINT IF EXISTS invoice_sequences_pkey;
+ALTER TABLE invoice_sequences ADD PRIMARY KEY (series, year);
+
+-- One credit note per refund, referencing the invoice it corrects
+CREATE TABLE IF NOT EXISTS credit_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transaction_history(id) ON DELETE CASCADE,
+    number VARCHAR(20) NOT NULL UNIQUE,
+    year INT NOT NULL,
+    sequence INT NOT NULL,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount < 0),
+    reason TEXT,
+    issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    file_key VARCHAR(255),
+    file_size INT,
+    file_sha256 CHAR(64),
+    stored_at TIMESTAMP,
+    sent_at TIMESTAMP,
+    UNIQUE (year, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_notes_order_id ON credit_notes(order_id);
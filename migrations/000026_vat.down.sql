@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS tax_entries;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS buyer_vat_id,
+    DROP COLUMN IF EXISTS reverse_charge,
+    DROP COLUMN IF EXISTS tax_country,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS vat_id,
+    DROP COLUMN IF EXISTS billing_country;
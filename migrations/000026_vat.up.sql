@@ -0,0 +1,30 @@
+-- Where buyers are billed, and their VAT ID when they buy as a business
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS billing_country CHAR(2),
+    ADD COLUMN IF NOT EXISTS vat_id VARCHAR(20);
+
+-- VAT charged on top of the item prices at purchase time. total_amount is
+-- what the buyer paid, tax included.
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_country CHAR(2),
+    ADD COLUMN IF NOT EXISTS reverse_charge BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS buyer_vat_id VARCHAR(20);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+-- VAT booked per sale, and reversed with negative amounts on refund, for
+-- the tax report. Sales outside the tax rules are not booked.
+CREATE TABLE IF NOT EXISTS tax_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    country CHAR(2) NOT NULL,
+    rate DECIMAL(5,2) NOT NULL,
+    reverse_charge BOOLEAN NOT NULL DEFAULT FALSE,
+    net_amount DECIMAL(12,2) NOT NULL,
+    tax_amount DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_tax_entries_created_at ON tax_entries(created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_skins_listing_expiry;
+
+ALTER TABLE skins
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS listing_days;
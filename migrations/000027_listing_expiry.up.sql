@@ -0,0 +1,7 @@
+-- Listings can run for a fixed number of days. expires_at stays on the skin
+-- once the listing expired so the owner can relist it in one click.
+ALTER TABLE skins
+    ADD COLUMN IF NOT EXISTS listing_days INT,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_skins_listing_expiry ON skins(expires_at) WHERE available = true;
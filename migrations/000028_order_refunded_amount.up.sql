@@ -0,0 +1,16 @@
+-- What partial and full refunds paid back on an order so far, so a full
+-- refund after a partial one only pays back the rest
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0);
+
+UPDATE orders o
+SET refunded_amount = d.refunded
+FROM (
+    SELECT order_id, SUM(refund_amount) AS refunded
+    FROM disputes
+    WHERE status IN ('refunded', 'partially_refunded')
+    GROUP BY order_id
+) d
+WHERE d.order_id = o.id;
+
+UPDATE orders SET refunded_amount = total_amount WHERE status = 'refunded';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transaction_history_user_seq;
+ALTER TABLE transaction_history DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS transaction_history_seq_seq;
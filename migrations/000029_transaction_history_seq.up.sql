@@ -0,0 +1,23 @@
+-- Rows written in one database transaction share created_at (a sale and its
+-- fee), seq keeps the order they were applied in. Existing rows are numbered
+-- by created_at and id, the tie-break they were read with so far.
+CREATE SEQUENCE IF NOT EXISTS transaction_history_seq_seq;
+
+ALTER TABLE transaction_history ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE transaction_history t
+SET seq = o.n
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n
+    FROM transaction_history
+) o
+WHERE o.id = t.id;
+
+SELECT setval('transaction_history_seq_seq', COALESCE((SELECT MAX(seq) FROM transaction_history), 0) + 1, false);
+
+ALTER TABLE transaction_history
+    ALTER COLUMN seq SET DEFAULT nextval('transaction_history_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+ALTER SEQUENCE transaction_history_seq_seq OWNED BY transaction_history.seq;
+
+CREATE INDEX idx_transaction_history_user_seq ON transaction_history(user_id, seq);
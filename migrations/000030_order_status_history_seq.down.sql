@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_order_status_history_order_id;
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, created_at);
+ALTER TABLE order_status_history DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS order_status_history_seq_seq;
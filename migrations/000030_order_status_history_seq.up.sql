@@ -0,0 +1,33 @@
+-- A purchase moves its order through several statuses in one database
+-- transaction, so their created_at is the same. seq keeps the order the
+-- changes were made in. Existing ties are numbered along the state machine.
+CREATE SEQUENCE IF NOT EXISTS order_status_history_seq_seq;
+
+ALTER TABLE order_status_history ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE order_status_history h
+SET seq = o.n
+FROM (
+    SELECT id, ROW_NUMBER() OVER (
+        ORDER BY created_at,
+                 CASE from_status
+                     WHEN 'pending' THEN 1
+                     WHEN 'paid' THEN 2
+                     WHEN 'completed' THEN 3
+                     WHEN 'disputed' THEN 4
+                     ELSE 0
+                 END,
+                 id) AS n
+    FROM order_status_history
+) o
+WHERE o.id = h.id;
+
+SELECT setval('order_status_history_seq_seq', COALESCE((SELECT MAX(seq) FROM order_status_history), 0) + 1, false);
+
+ALTER TABLE order_status_history
+    ALTER COLUMN seq SET DEFAULT nextval('order_status_history_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+ALTER SEQUENCE order_status_history_seq_seq OWNED BY order_status_history.seq;
+
+DROP INDEX IF EXISTS idx_order_status_history_order_id;
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, seq);
@@ -0,0 +1,12 @@
+-- Every time a skin is put up for sale. Listings move no money, so they
+-- leave no transaction_history rows; the sell risk rules look here instead.
+CREATE TABLE IF NOT EXISTS listing_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    skin_id UUID NOT NULL REFERENCES skins(id) ON DELETE CASCADE,
+    price DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_history_user_created ON listing_history(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_listing_history_created_at ON listing_history(created_at);
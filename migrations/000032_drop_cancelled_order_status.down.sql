@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    DROP CONSTRAINT chk_order_status,
+    ADD CONSTRAINT chk_order_status
+        CHECK (status IN ('pending', 'paid', 'completed', 'cancelled', 'refunded', 'disputed'));
@@ -0,0 +1,6 @@
+-- Purchases pay and complete their order in one database transaction, so
+-- no order is ever cancelled; mistaken purchases are refunded
+ALTER TABLE orders
+    DROP CONSTRAINT chk_order_status,
+    ADD CONSTRAINT chk_order_status
+        CHECK (status IN ('pending', 'paid', 'completed', 'refunded', 'disputed'));
@@ -0,0 +1,4 @@
+DELETE FROM payment_intents WHERE provider_reference IS NULL OR checkout_url IS NULL;
+ALTER TABLE payment_intents
+    ALTER COLUMN provider_reference SET NOT NULL,
+    ALTER COLUMN checkout_url SET NOT NULL;
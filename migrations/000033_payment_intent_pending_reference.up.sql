@@ -0,0 +1,5 @@
+-- The intent row is saved before the provider is called, so the provider's
+-- reference and checkout URL are filled in after the transaction commits
+ALTER TABLE payment_intents
+    ALTER COLUMN provider_reference DROP NOT NULL,
+    ALTER COLUMN checkout_url DROP NOT NULL;
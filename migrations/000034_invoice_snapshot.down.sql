@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS invoice_lines;
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS buyer_email,
+    DROP COLUMN IF EXISTS buyer_name;
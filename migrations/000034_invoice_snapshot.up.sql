@@ -0,0 +1,39 @@
+-- What an invoice prints is copied when it is issued, so renaming a user or
+-- a skin later doesn't change an invoice that was already sent
+ALTER TABLE invoices
+    ADD COLUMN buyer_name VARCHAR(255),
+    ADD COLUMN buyer_email VARCHAR(255);
+
+UPDATE invoices i SET buyer_name = u.name, buyer_email = u.email
+FROM orders o JOIN users u ON u.id = o.user_id
+WHERE o.id = i.order_id;
+
+ALTER TABLE invoices
+    ALTER COLUMN buyer_name SET NOT NULL,
+    ALTER COLUMN buyer_email SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    order_item_id UUID NOT NULL,
+    skin_id UUID NOT NULL,
+    skin_name VARCHAR(255) NOT NULL,
+    gun VARCHAR(50) NOT NULL,
+    wear TEXT NOT NULL,
+    condition DECIMAL(10,8) NOT NULL,
+    seller_id UUID,
+    seller_name VARCHAR(255),
+    price DECIMAL(12,2) NOT NULL,
+    fee DECIMAL(12,2) NOT NULL,
+    tax DECIMAL(12,2) NOT NULL,
+    PRIMARY KEY (invoice_id, position)
+);
+
+INSERT INTO invoice_lines (invoice_id, position, order_item_id, skin_id, skin_name, gun, wear, condition,
+                           seller_id, seller_name, price, fee, tax)
+SELECT i.id, ROW_NUMBER() OVER (PARTITION BY i.id ORDER BY oi.created_at, oi.id),
+       oi.id, oi.skin_id, s.name, s.gun, s.wear, s.condition, oi.seller_id, u.name, oi.price, oi.fee, oi.tax
+FROM invoices i
+JOIN order_items oi ON oi.order_id = i.order_id
+JOIN skins s ON s.id = oi.skin_id
+LEFT JOIN users u ON u.id = oi.seller_id;
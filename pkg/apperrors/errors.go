@@ -16,6 +16,7 @@ const (
 	CodeUnauthorized
 	CodeForbidden
 	CodeValidation
+	CodeLimitExceeded
 )
 
 // AppError represents an application error with code and context
@@ -75,6 +76,14 @@ func NewUnauthorizedError(message string) *AppError {
 	}
 }
 
+// NewLimitExceededError reports an amount above one of the user's limits
+func NewLimitExceededError(message string) *AppError {
+	return &AppError{
+		Code:    CodeLimitExceeded,
+		Message: message,
+	}
+}
+
 func NewInternalError(message string, err error) *AppError {
 	return &AppError{
 		Code:    CodeInternal,
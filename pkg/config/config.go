@@ -1,10 +1,14 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +21,180 @@ type Config struct {
 	JWTKey         string
 	MailgunDomain  string
 	MailgunAPIKey  string
+	Fees           FeeConfig
+	DisputeWindow  time.Duration
+	// TradeHold is how long sale proceeds stay pending before they can be withdrawn
+	TradeHold           time.Duration
+	HoldReleaseSchedule string
+	// ReconciliationSchedule is when balances are reconciled, nightly by default
+	ReconciliationSchedule string
+	// TransactionExportSchedule is when users get last month's transactions
+	// by email; empty disables the emails
+	TransactionExportSchedule string
+	// JobSchedules overrides the cron spec of periodic jobs by name, an empty
+	// spec turns a job off
+	JobSchedules map[string]string
+	// WorkerShutdownTimeout is how long a stopping worker waits for running
+	// tasks before putting them back in their queue
+	WorkerShutdownTimeout time.Duration
+	// MetricsAddr is where the API serves its Prometheus metrics, apart from
+	// the public listener
+	MetricsAddr string
+	// WorkerMetricsAddr is where the worker serves its Prometheus metrics
+	WorkerMetricsAddr string
+	// StreamAllowedOrigins are the browser origins allowed to open the
+	// WebSocket stream, empty allows only the API's own origin
+	StreamAllowedOrigins []string
+	// WithdrawalReviewThreshold is the amount from which withdrawals need admin approval
+	WithdrawalReviewThreshold float64
+	// PaymentWebhookSecret signs payment provider webhooks
+	PaymentWebhookSecret string
+	Limits               LimitsConfig
+	// RiskRules are the fraud and velocity rules money movements are checked against
+	RiskRules []RiskRule
+	WashTrade WashTradeConfig
+	Company   CompanyConfig
+	Invoice   InvoiceConfig
+	Tax       TaxConfig
+	// BlobDir is where generated documents such as invoice PDFs are archived
+	BlobDir string
+}
+
+// CompanyConfig is the marketplace's branding printed on invoices
+type CompanyConfig struct {
+	Name    string
+	Address string
+	Email   string
+	Website string
+	// LogoPath is an optional PNG or JPEG shown in the invoice header
+	LogoPath string
+	// VATID is the marketplace's own VAT registration number
+	VATID string
+}
+
+// InvoiceConfig controls the invoices and e-invoices sent to buyers
+type InvoiceConfig struct {
+	// Currency is the ISO 4217 code of all amounts
+	Currency string
+	// AttachXML adds the UBL e-invoice to the invoice email next to the PDF
+	AttachXML bool
+}
+
+// TaxConfig holds the VAT rates buyers pay per billing country. Without
+// rates no tax is charged.
+type TaxConfig struct {
+	// Country is where the marketplace is established. Buyers without a
+	// billing country pay its rate; business buyers with a VAT ID in another
+	// country with a rate are reverse charged.
+	Country string
+	// Rates are VAT percentages by ISO 3166-1 alpha-2 country code
+	Rates map[string]float64
+}
+
+// WashTradeConfig tunes the periodic wash-trading detection job
+type WashTradeConfig struct {
+	Schedule string
+	// Window is how far back each run looks at completed sales
+	Window         time.Duration
+	MinPairTrades  int
+	PriceDeviation float64
+	MinSamples     int
+	MinScore       float64
+}
+
+// FeeRule is a percentage fee with a minimum absolute amount
+type FeeRule struct {
+	Percent float64 `json:"percent"`
+	Min     float64 `json:"min"`
+}
+
+// FeeConfig is the marketplace fee schedule. Seller tier rules take precedence
+// over gun category rules, which take precedence over the default.
+type FeeConfig struct {
+	Default    FeeRule            `json:"default"`
+	Categories map[string]FeeRule `json:"categories"`
+	Tiers      map[string]FeeRule `json:"tiers"`
+}
+
+// LimitRule caps the amount of one kind of money movement. Zero means unlimited.
+type LimitRule struct {
+	PerTransaction float64 `json:"per_transaction"`
+	Daily          float64 `json:"daily"`
+	Monthly        float64 `json:"monthly"`
+}
+
+// LimitsConfig holds limit rules by user tier and then by kind
+// (deposit, withdraw, purchase)
+type LimitsConfig map[string]map[string]LimitRule
+
+// DefaultLimits apply to every tier and kind LIMITS doesn't override
+func DefaultLimits() LimitsConfig {
+	return LimitsConfig{
+		"standard": {
+			"deposit":  {PerTransaction: 1000, Daily: 2500, Monthly: 10000},
+			"withdraw": {PerTransaction: 1000, Daily: 2500, Monthly: 10000},
+			"purchase": {PerTransaction: 5000, Daily: 10000, Monthly: 50000},
+		},
+		"verified": {
+			"deposit":  {PerTransaction: 5000, Daily: 10000, Monthly: 50000},
+			"withdraw": {PerTransaction: 5000, Daily: 10000, Monthly: 50000},
+			"purchase": {PerTransaction: 20000, Daily: 50000, Monthly: 250000},
+		},
+		"pro": {
+			"deposit":  {PerTransaction: 25000, Daily: 50000, Monthly: 250000},
+			"withdraw": {PerTransaction: 25000, Daily: 50000, Monthly: 250000},
+			"purchase": {PerTransaction: 100000, Daily: 250000, Monthly: 1000000},
+		},
+	}
+}
+
+// Duration is a time.Duration written as a Go duration string ("24h") in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string like \"24h\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// RiskRule is one fraud or velocity rule. Which fields matter depends on the
+// rule type, see internal/fraud.
+type RiskRule struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Kind      string   `json:"kind"`
+	Window    Duration `json:"window"`
+	Threshold float64  `json:"threshold"`
+	MinAmount float64  `json:"min_amount,omitempty"`
+	Category  string   `json:"category,omitempty"`
+	Action    string   `json:"action"`
+}
+
+// DefaultRiskRules are used when RISK_RULES is not set
+func DefaultRiskRules() []RiskRule {
+	return []RiskRule{
+		{Name: "deposit_then_withdraw", Type: "deposit_then_withdraw", Kind: "withdraw",
+			Window: Duration(24 * time.Hour), Threshold: 0.8, MinAmount: 100, Action: "hold"},
+		{Name: "withdraw_velocity", Type: "velocity", Kind: "withdraw",
+			Window: Duration(time.Hour), Threshold: 5, Action: "block"},
+		{Name: "repeat_seller", Type: "counterparty", Kind: "purchase",
+			Window: Duration(24 * time.Hour), Threshold: 5, Action: "flag"},
+		{Name: "new_account_knife", Type: "new_account", Kind: "purchase",
+			Window: Duration(7 * 24 * time.Hour), Threshold: 500, Category: "knife", Action: "hold"},
+		{Name: "sell_velocity", Type: "velocity", Kind: "sell",
+			Window: Duration(time.Hour), Threshold: 30, Action: "flag"},
+	}
 }
 
 type DBConfig struct {
@@ -44,21 +222,264 @@ func Load() (*Config, error) {
 		return nil, errors.New("JWT_SECRET not set")
 	}
 
+	fees, err := loadFeeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	disputeWindow, err := getEnvDuration("DISPUTE_WINDOW", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	tradeHold, err := getEnvDuration("TRADE_HOLD", 72*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if tradeHold < 0 {
+		return nil, errors.New("TRADE_HOLD must not be negative")
+	}
+
+	var jobSchedules map[string]string
+	if raw := os.Getenv("JOB_SCHEDULES"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &jobSchedules); err != nil {
+			return nil, fmt.Errorf("invalid JOB_SCHEDULES: %w", err)
+		}
+	}
+
+	shutdownTimeout, err := getEnvDuration("WORKER_SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if shutdownTimeout <= 0 {
+		return nil, errors.New("WORKER_SHUTDOWN_TIMEOUT must be positive")
+	}
+
+	reviewThreshold, err := getEnvFloat("WITHDRAWAL_REVIEW_THRESHOLD", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if reviewThreshold < 0 {
+		return nil, errors.New("WITHDRAWAL_REVIEW_THRESHOLD must not be negative")
+	}
+
+	limits, err := loadLimitsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	riskRules, err := loadRiskRules()
+	if err != nil {
+		return nil, err
+	}
+
+	washTrade, err := loadWashTradeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	attachXML, err := getEnvBool("INVOICE_ATTACH_XML", false)
+	if err != nil {
+		return nil, err
+	}
+
+	tax, err := loadTaxConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	paymentWebhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if paymentWebhookSecret == "" {
+		log.Println("[WARN] PAYMENT_WEBHOOK_SECRET not set – payment webhooks will be rejected and deposits never confirmed")
+	}
+
 	if MailgunDomain == "" || MailgunAPIKey == "" {
 		log.Println("[WARN] Mailgun config not fully set – email features will be disabled")
 	}
 
 	return &Config{
-		ListenAddr:     listenAddr,
-		RedisAddr:      redisAddr,
-		DbURL:          dbURL,
-		MigrationsPath: migrationsPath,
-		JWTKey:         JWTKey,
-		MailgunDomain:  MailgunDomain,
-		MailgunAPIKey:  MailgunAPIKey,
+		ListenAddr:                listenAddr,
+		RedisAddr:                 redisAddr,
+		DbURL:                     dbURL,
+		MigrationsPath:            migrationsPath,
+		JWTKey:                    JWTKey,
+		MailgunDomain:             MailgunDomain,
+		MailgunAPIKey:             MailgunAPIKey,
+		Fees:                      fees,
+		DisputeWindow:             disputeWindow,
+		TradeHold:                 tradeHold,
+		HoldReleaseSchedule:       getEnv("HOLD_RELEASE_SCHEDULE", "@every 5m"),
+		ReconciliationSchedule:    getEnv("RECONCILIATION_SCHEDULE", "0 3 * * *"),
+		TransactionExportSchedule: getEnv("TRANSACTION_EXPORT_SCHEDULE", "0 6 1 * *"),
+		JobSchedules:              jobSchedules,
+		WorkerShutdownTimeout:     shutdownTimeout,
+		MetricsAddr:               getEnv("METRICS_ADDR", ":9090"),
+		WorkerMetricsAddr:         getEnv("WORKER_METRICS_ADDR", ":9091"),
+		StreamAllowedOrigins:      getEnvList("STREAM_ALLOWED_ORIGINS"),
+		WithdrawalReviewThreshold: reviewThreshold,
+		PaymentWebhookSecret:      paymentWebhookSecret,
+		Limits:                    limits,
+		RiskRules:                 riskRules,
+		WashTrade:                 washTrade,
+		Company: CompanyConfig{
+			Name:     getEnv("COMPANY_NAME", "CS:GO Skin Marketplace"),
+			Address:  getEnv("COMPANY_ADDRESS", ""),
+			Email:    getEnv("COMPANY_EMAIL", ""),
+			Website:  getEnv("COMPANY_WEBSITE", ""),
+			LogoPath: getEnv("COMPANY_LOGO_PATH", ""),
+			VATID:    getEnv("COMPANY_VAT_ID", ""),
+		},
+		Invoice: InvoiceConfig{
+			Currency:  getEnv("INVOICE_CURRENCY", "USD"),
+			AttachXML: attachXML,
+		},
+		Tax:     tax,
+		BlobDir: getEnv("BLOB_DIR", "./data/blobs"),
 	}, nil
 }
 
+// loadFeeConfig reads FEE_PERCENT and FEE_MIN for the default rule and an
+// optional FEE_SCHEDULE JSON document with "categories" and "tiers" overrides.
+func loadFeeConfig() (FeeConfig, error) {
+	percent, err := getEnvFloat("FEE_PERCENT", 5)
+	if err != nil {
+		return FeeConfig{}, err
+	}
+	minFee, err := getEnvFloat("FEE_MIN", 0.05)
+	if err != nil {
+		return FeeConfig{}, err
+	}
+
+	fees := FeeConfig{Default: FeeRule{Percent: percent, Min: minFee}}
+	if raw := os.Getenv("FEE_SCHEDULE"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &fees); err != nil {
+			return FeeConfig{}, fmt.Errorf("invalid FEE_SCHEDULE: %w", err)
+		}
+		// The default rule always comes from FEE_PERCENT/FEE_MIN
+		fees.Default = FeeRule{Percent: percent, Min: minFee}
+	}
+
+	rules := []FeeRule{fees.Default}
+	for _, r := range fees.Categories {
+		rules = append(rules, r)
+	}
+	for _, r := range fees.Tiers {
+		rules = append(rules, r)
+	}
+	for _, r := range rules {
+		if r.Percent < 0 || r.Percent > 100 || r.Min < 0 {
+			return FeeConfig{}, errors.New("fee percent must be within 0-100 and minimum fee must not be negative")
+		}
+	}
+	return fees, nil
+}
+
+// loadLimitsConfig starts from DefaultLimits and applies the optional LIMITS
+// JSON document, e.g. {"standard":{"withdraw":{"daily":500}}}. Rules given in
+// LIMITS replace the default rule of that tier and kind as a whole.
+func loadLimitsConfig() (LimitsConfig, error) {
+	limits := DefaultLimits()
+	raw := os.Getenv("LIMITS")
+	if raw == "" {
+		return limits, nil
+	}
+
+	var overrides LimitsConfig
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid LIMITS: %w", err)
+	}
+	for tier, kinds := range overrides {
+		if limits[tier] == nil {
+			limits[tier] = make(map[string]LimitRule)
+		}
+		for kind, rule := range kinds {
+			if rule.PerTransaction < 0 || rule.Daily < 0 || rule.Monthly < 0 {
+				return nil, errors.New("limits must not be negative")
+			}
+			limits[tier][kind] = rule
+		}
+	}
+	return limits, nil
+}
+
+// loadRiskRules reads the optional RISK_RULES JSON array, which replaces the
+// default rules as a whole. Rule types and actions are checked by the engine.
+func loadRiskRules() ([]RiskRule, error) {
+	raw := os.Getenv("RISK_RULES")
+	if raw == "" {
+		return DefaultRiskRules(), nil
+	}
+
+	var rules []RiskRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("invalid RISK_RULES: %w", err)
+	}
+	return rules, nil
+}
+
+// loadTaxConfig reads TAX_COUNTRY and the optional TAX_RATES JSON object of
+// VAT percentages by country, e.g. {"DE":19,"FR":20}
+func loadTaxConfig() (TaxConfig, error) {
+	cfg := TaxConfig{Country: getEnv("TAX_COUNTRY", "")}
+	raw := os.Getenv("TAX_RATES")
+	if raw == "" {
+		return cfg, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &cfg.Rates); err != nil {
+		return cfg, fmt.Errorf("invalid TAX_RATES: %w", err)
+	}
+	for country, rate := range cfg.Rates {
+		if !validCountry(country) {
+			return cfg, fmt.Errorf("invalid TAX_RATES: %q is not a two-letter country code", country)
+		}
+		if rate < 0 || rate > 100 {
+			return cfg, errors.New("tax rates must be within 0-100")
+		}
+	}
+	if _, ok := cfg.Rates[cfg.Country]; !ok {
+		return cfg, errors.New("TAX_COUNTRY must be set to a country in TAX_RATES")
+	}
+	return cfg, nil
+}
+
+func validCountry(code string) bool {
+	return len(code) == 2 && code[0] >= 'A' && code[0] <= 'Z' && code[1] >= 'A' && code[1] <= 'Z'
+}
+
+// loadWashTradeConfig reads the WASH_TRADE_* settings of the detection job
+func loadWashTradeConfig() (WashTradeConfig, error) {
+	cfg := WashTradeConfig{Schedule: getEnv("WASH_TRADE_SCHEDULE", "@every 1h")}
+
+	var err error
+	if cfg.Window, err = getEnvDuration("WASH_TRADE_WINDOW", 30*24*time.Hour); err != nil {
+		return cfg, err
+	}
+	if cfg.MinPairTrades, err = getEnvInt("WASH_TRADE_MIN_PAIR_TRADES", 3); err != nil {
+		return cfg, err
+	}
+	if cfg.PriceDeviation, err = getEnvFloat("WASH_TRADE_PRICE_DEVIATION", 0.5); err != nil {
+		return cfg, err
+	}
+	if cfg.MinSamples, err = getEnvInt("WASH_TRADE_MIN_SAMPLES", 5); err != nil {
+		return cfg, err
+	}
+	if cfg.MinScore, err = getEnvFloat("WASH_TRADE_MIN_SCORE", 5); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Window <= 0 {
+		return cfg, errors.New("WASH_TRADE_WINDOW must be greater than zero")
+	}
+	if cfg.MinPairTrades < 2 {
+		return cfg, errors.New("WASH_TRADE_MIN_PAIR_TRADES must be at least 2")
+	}
+	if cfg.PriceDeviation <= 0 || cfg.MinSamples < 1 || cfg.MinScore <= 0 {
+		return cfg, errors.New("WASH_TRADE_PRICE_DEVIATION, WASH_TRADE_MIN_SAMPLES and WASH_TRADE_MIN_SCORE must be greater than zero")
+	}
+	return cfg, nil
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -66,6 +487,65 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvList splits a comma-separated variable, leaving out empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return i, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
 func loadEnv() error {
 	return godotenv.Load()
 }